@@ -4,6 +4,10 @@ testing and integration tests whenever is possible.
 */
 package mocks // import "github.com/slok/kubewebhook/mocks"
 
+import (
+	"github.com/slok/kubewebhook/pkg/observability/metrics"
+)
+
 // Mutating mocks.
 //go:generate mockery -output ./webhook/mutating -outpkg mutating -dir ../pkg/webhook/mutating -name Mutator
 
@@ -14,4 +18,25 @@ package mocks // import "github.com/slok/kubewebhook/mocks"
 //go:generate mockery -output ./webhook -outpkg webhook -dir ../pkg/webhook -name Webhook
 
 // Observability mocks.
-//go:generate mockery -output ./observability/metrics -outpkg metrics -dir ../pkg/observability/metrics -name Recorder
+//go:generate mockery -output ./observability/metrics -outpkg metrics -dir . -name Recorder
+
+// Recorder is the metrics recorder with all the optional recorder extensions, the
+// recorder mock is generated from it so the tests can assert the extension metrics.
+type Recorder interface {
+	metrics.Recorder
+	metrics.ReplicasClampedRecorder
+	metrics.MutatorErrorSkippedRecorder
+	metrics.MutationNoOpRecorder
+	metrics.GoroutineGrowthRecorder
+	metrics.AnnotationReadRecorder
+	metrics.OwnerKindRecorder
+	metrics.ObjectSizeRecorder
+	metrics.HTTPHandlerDurationRecorder
+	metrics.SlowReviewRecorder
+	metrics.FailOpenRecorder
+	metrics.CircuitBreakerRecorder
+	metrics.PatchRoundTripRecorder
+	metrics.SelfTestRecorder
+	metrics.NearDeadlineRecorder
+	metrics.PatchEffectiveRecorder
+}
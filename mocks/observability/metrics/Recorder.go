@@ -34,3 +34,78 @@ func (_m *Recorder) ObserveAdmissionReviewDuration(webhook string, namespace str
 func (_m *Recorder) IncValidationReviewResult(webhook string, namespace string, resource string, operation v1beta1.Operation, allowed bool) {
 	_m.Called(webhook, namespace, resource, operation, allowed)
 }
+
+// IncReplicasClamped provides a mock function with given fields: namespace, kind
+func (_m *Recorder) IncReplicasClamped(namespace string, kind string) {
+	_m.Called(namespace, kind)
+}
+
+// IncMutatorErrorSkipped provides a mock function with given fields: webhook, mutator
+func (_m *Recorder) IncMutatorErrorSkipped(webhook string, mutator string) {
+	_m.Called(webhook, mutator)
+}
+
+// IncMutationNoOp provides a mock function with given fields: webhook
+func (_m *Recorder) IncMutationNoOp(webhook string) {
+	_m.Called(webhook)
+}
+
+// IncGoroutineGrowthWarning provides a mock function with given fields: webhook
+func (_m *Recorder) IncGoroutineGrowthWarning(webhook string) {
+	_m.Called(webhook)
+}
+
+// IncAnnotationReadNotAllowed provides a mock function with given fields: webhook, annotation
+func (_m *Recorder) IncAnnotationReadNotAllowed(webhook string, annotation string) {
+	_m.Called(webhook, annotation)
+}
+
+// IncAdmissionReviewOwnerKind provides a mock function with given fields: webhook, ownerKind
+func (_m *Recorder) IncAdmissionReviewOwnerKind(webhook string, ownerKind string) {
+	_m.Called(webhook, ownerKind)
+}
+
+// ObserveHTTPHandlerDuration provides a mock function with given fields: webhook, start
+func (_m *Recorder) ObserveHTTPHandlerDuration(webhook string, start time.Time) {
+	_m.Called(webhook, start)
+}
+
+// ObserveAdmissionReviewObjectSize provides a mock function with given fields: webhook, kind, size
+func (_m *Recorder) ObserveAdmissionReviewObjectSize(webhook string, kind string, size int) {
+	_m.Called(webhook, kind, size)
+}
+
+// IncAdmissionReviewSlow provides a mock function with given fields: webhook
+func (_m *Recorder) IncAdmissionReviewSlow(webhook string) {
+	_m.Called(webhook)
+}
+
+// IncWebhookFailOpen provides a mock function with given fields: webhook, reason
+func (_m *Recorder) IncWebhookFailOpen(webhook string, reason string) {
+	_m.Called(webhook, reason)
+}
+
+// SetCircuitBreakerState provides a mock function with given fields: webhook, state
+func (_m *Recorder) SetCircuitBreakerState(webhook string, state metrics.CircuitBreakerState) {
+	_m.Called(webhook, state)
+}
+
+// IncPatchRoundTripError provides a mock function with given fields: webhook
+func (_m *Recorder) IncPatchRoundTripError(webhook string) {
+	_m.Called(webhook)
+}
+
+// IncSelfTestResult provides a mock function with given fields: webhook, testCase, passed
+func (_m *Recorder) IncSelfTestResult(webhook string, testCase string, passed bool) {
+	_m.Called(webhook, testCase, passed)
+}
+
+// IncAdmissionReviewNearDeadline provides a mock function with given fields: webhook
+func (_m *Recorder) IncAdmissionReviewNearDeadline(webhook string) {
+	_m.Called(webhook)
+}
+
+// IncPatchEffective provides a mock function with given fields: webhook, effective
+func (_m *Recorder) IncPatchEffective(webhook string, effective bool) {
+	_m.Called(webhook, effective)
+}
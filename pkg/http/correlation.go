@@ -0,0 +1,57 @@
+package http
+
+import (
+	"encoding/json"
+	"net/http"
+
+	admissionv1beta1 "k8s.io/api/admission/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// CorrelationIDExtractor knows how to extract the correlation ID (e.g an upstream request ID) of
+// a received admission review, used to correlate the webhook logs and traces. Returning an empty
+// ID will fallback to the admission review UID.
+type CorrelationIDExtractor interface {
+	ExtractCorrelationID(r *http.Request, ar *admissionv1beta1.AdmissionReview) string
+}
+
+// CorrelationIDExtractorFunc is a helper type to create correlation ID extractors from functions.
+type CorrelationIDExtractorFunc func(r *http.Request, ar *admissionv1beta1.AdmissionReview) string
+
+// ExtractCorrelationID satisfies CorrelationIDExtractor interface.
+func (f CorrelationIDExtractorFunc) ExtractCorrelationID(r *http.Request, ar *admissionv1beta1.AdmissionReview) string {
+	return f(r, ar)
+}
+
+// HeaderCorrelationIDExtractor returns a correlation ID extractor that gets the correlation ID
+// from an HTTP request header (e.g `X-Request-Id`).
+func HeaderCorrelationIDExtractor(header string) CorrelationIDExtractor {
+	return CorrelationIDExtractorFunc(func(r *http.Request, _ *admissionv1beta1.AdmissionReview) string {
+		if r == nil {
+			return ""
+		}
+		return r.Header.Get(header)
+	})
+}
+
+// AnnotationCorrelationIDExtractor returns a correlation ID extractor that gets the correlation ID
+// from an annotation of the reviewed object.
+func AnnotationCorrelationIDExtractor(annotation string) CorrelationIDExtractor {
+	return CorrelationIDExtractorFunc(func(_ *http.Request, ar *admissionv1beta1.AdmissionReview) string {
+		if ar == nil || ar.Request == nil {
+			return ""
+		}
+
+		raw := ar.Request.Object.Raw
+		if len(raw) == 0 {
+			raw = ar.Request.OldObject.Raw
+		}
+
+		obj := &metav1.PartialObjectMetadata{}
+		if err := json.Unmarshal(raw, obj); err != nil {
+			return ""
+		}
+
+		return obj.Annotations[annotation]
+	})
+}
@@ -1,18 +1,23 @@
 package http
 
 import (
+	"bytes"
+	"compress/gzip"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io/ioutil"
 	"net/http"
+	"strconv"
+	"strings"
+	"time"
 
-	admissionv1beta1 "k8s.io/api/admission/v1beta1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/serializer"
 
+	"github.com/slok/kubewebhook/pkg/observability/metrics"
 	"github.com/slok/kubewebhook/pkg/webhook"
-	whcontext "github.com/slok/kubewebhook/pkg/webhook/context"
 )
 
 var (
@@ -21,6 +26,75 @@ var (
 	deserializer  = codecs.UniversalDeserializer()
 )
 
+// JSONEncoder knows how to encode the admission review responses into JSON.
+// This can be used to plug faster JSON libraries than the standard library
+// (e.g `json-iterator`).
+type JSONEncoder interface {
+	Marshal(v interface{}) ([]byte, error)
+}
+
+// JSONEncoderFunc is a helper type to create JSON encoders from functions.
+type JSONEncoderFunc func(v interface{}) ([]byte, error)
+
+// Marshal satisfies JSONEncoder interface.
+func (f JSONEncoderFunc) Marshal(v interface{}) ([]byte, error) { return f(v) }
+
+// StdJSONEncoder is the JSON encoder that uses the Go standard library `encoding/json`.
+var StdJSONEncoder = JSONEncoderFunc(json.Marshal)
+
+// HandlerConfig is the configuration of the webhook HTTP handler.
+type HandlerConfig struct {
+	// Webhook is the webhook that will handle the admission reviews.
+	Webhook webhook.Webhook
+	// Encoder is the JSON encoder used to write the admission review responses.
+	// By default the standard library encoder will be used.
+	Encoder JSONEncoder
+	// DebugPatchHeader will set the `X-Kubewebhook-Patch-Ops` header on the responses with
+	// the number of JSON patch operations and its operation paths (truncated). Useful to
+	// inspect the mutations behind debugging proxies. Only for development.
+	DebugPatchHeader bool
+	// GzipMinSize is the minimum response size (in bytes) to compress the response with gzip
+	// when the client accepts gzip encoding. Useful for very large patches. By default (0)
+	// the responses will not be compressed.
+	GzipMinSize int
+	// InternalServerErrorOnFailure will respond with a 500 HTTP status code when the webhook
+	// review fails. By default the failed reviews respond with a 200 and a denying admission
+	// review with the failure status, the API server expects an admission review even on
+	// webhook internal errors and treats the non 200 responses opaquely using the webhook
+	// failure policy.
+	InternalServerErrorOnFailure bool
+	// CorrelationIDExtractor extracts the correlation ID of the reviews (e.g from an HTTP header
+	// or an object annotation) that will be set on the review context, used to correlate the
+	// logs and traces. By default the admission review UID will be used.
+	CorrelationIDExtractor CorrelationIDExtractor
+	// Name is the name of the handler used on the metrics, normally the webhook name.
+	Name string
+	// MetricsRecorder records the total duration of the handler, including the request read
+	// and the response write, unlike the review duration this shows if the slow clients are
+	// the issue. By default the metrics will not be recorded.
+	MetricsRecorder metrics.Recorder
+}
+
+func (c *HandlerConfig) defaults() error {
+	if c.Webhook == nil {
+		return fmt.Errorf("webhook can't be nil")
+	}
+
+	if c.Encoder == nil {
+		c.Encoder = StdJSONEncoder
+	}
+
+	if c.MetricsRecorder == nil {
+		c.MetricsRecorder = metrics.Dummy
+	}
+
+	if c.GzipMinSize < 0 {
+		return fmt.Errorf("gzip min size can't be negative")
+	}
+
+	return nil
+}
+
 // MustHandlerFor it's the same as HandleFor but will panic instead of returning
 // a error.
 func MustHandlerFor(webhook webhook.Webhook) http.Handler {
@@ -34,11 +108,22 @@ func MustHandlerFor(webhook webhook.Webhook) http.Handler {
 // HandlerFor returns a new http.Handler ready to handle admission reviews using a
 // a webhook.
 func HandlerFor(webhook webhook.Webhook) (http.Handler, error) {
-	if webhook == nil {
-		return nil, fmt.Errorf("webhook can't be nil")
+	return HandlerForConfig(HandlerConfig{Webhook: webhook})
+}
+
+// HandlerForConfig returns a new http.Handler ready to handle admission reviews using
+// the webhook and options of the configuration.
+func HandlerForConfig(cfg HandlerConfig) (http.Handler, error) {
+	if err := cfg.defaults(); err != nil {
+		return nil, fmt.Errorf("handler configuration is not valid: %w", err)
 	}
+	processor := &ReviewProcessor{cfg: cfg}
 
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if rec, ok := cfg.MetricsRecorder.(metrics.HTTPHandlerDurationRecorder); ok {
+			defer rec.ObserveHTTPHandlerDuration(cfg.Name, time.Now())
+		}
+
 		// Get webhook body with the admission review.
 		var body []byte
 		if r.Body != nil {
@@ -46,44 +131,130 @@ func HandlerFor(webhook webhook.Webhook) (http.Handler, error) {
 				body = data
 			}
 		}
-		if len(body) == 0 {
+
+		resp, admissionResp, err := processor.processReview(r.Context(), r, body)
+		switch {
+		case errors.Is(err, ErrEmptyReview):
 			http.Error(w, "no body found", http.StatusBadRequest)
 			return
-		}
-
-		ar := &admissionv1beta1.AdmissionReview{}
-		if _, _, err := deserializer.Decode(body, nil, ar); err != nil {
+		case errors.Is(err, ErrInvalidReview):
 			http.Error(w, "could not decode the admission review from the request", http.StatusBadRequest)
 			return
+		case err != nil:
+			http.Error(w, "error marshaling to json admission review response", http.StatusInternalServerError)
+			return
 		}
 
-		// Set the admission request on the context.
-		ctx := whcontext.SetAdmissionRequest(r.Context(), ar.Request)
-
-		// Mutation logic.
-		admissionResp := webhook.Review(ctx, ar)
-
-		// Forge the review response.
-		aResponse := admissionv1beta1.AdmissionReview{
-			Response: admissionResp,
+		// Forge the HTTP response.
+		w.Header().Set("Content-Type", "application/json")
+		if cfg.DebugPatchHeader && len(admissionResp.Patch) > 0 {
+			w.Header().Set(patchOpsHeader, patchOpsHeaderValue(admissionResp.Patch))
 		}
 
-		resp, err := json.Marshal(aResponse)
-		if err != nil {
-			http.Error(w, "error marshaling to json admission review response", http.StatusInternalServerError)
-			return
+		if cfg.GzipMinSize > 0 && len(resp) >= cfg.GzipMinSize && acceptsGzip(r) {
+			compressed, err := gzipCompress(resp)
+			if err != nil {
+				http.Error(w, "error compressing admission review response", http.StatusInternalServerError)
+				return
+			}
+			resp = compressed
+			w.Header().Set("Content-Encoding", "gzip")
+			w.Header().Add("Vary", "Accept-Encoding")
 		}
 
-		// Forge the HTTP response.
-		// If the received admission review has failed mark the response as failed.
-		if admissionResp.Result != nil && admissionResp.Result.Status == metav1.StatusFailure {
+		// If the received admission review has failed and we want HTTP errors, mark the response as failed.
+		if cfg.InternalServerErrorOnFailure && admissionResp.Result != nil && admissionResp.Result.Status == metav1.StatusFailure {
 			w.WriteHeader(http.StatusInternalServerError)
 		}
 
-		w.Header().Set("Content-Type", "application/json")
-
 		if _, err := w.Write(resp); err != nil {
 			http.Error(w, fmt.Sprintf("could not write response: %v", err), http.StatusInternalServerError)
 		}
 	}), nil
 }
+
+// acceptsGzip returns true if the request accepts gzip encoded responses, an encoding
+// with a zero quality value (e.g `gzip;q=0`) is not acceptable.
+func acceptsGzip(r *http.Request) bool {
+	gzipQ, anyQ := -1.0, -1.0
+	for _, v := range r.Header.Values("Accept-Encoding") {
+		for _, enc := range strings.Split(v, ",") {
+			name, q := parseAcceptEncoding(enc)
+			switch name {
+			case "gzip":
+				gzipQ = q
+			case "*":
+				anyQ = q
+			}
+		}
+	}
+
+	// The explicit gzip encoding takes precedence over the wildcard.
+	if gzipQ >= 0 {
+		return gzipQ > 0
+	}
+	return anyQ > 0
+}
+
+// parseAcceptEncoding returns the name and the quality value of an `Accept-Encoding` header
+// encoding, by default the quality value is 1 and the invalid ones are 0.
+func parseAcceptEncoding(enc string) (name string, q float64) {
+	params := strings.Split(enc, ";")
+	name = strings.ToLower(strings.TrimSpace(params[0]))
+	q = 1
+	for _, p := range params[1:] {
+		kv := strings.SplitN(p, "=", 2)
+		if len(kv) != 2 || strings.TrimSpace(kv[0]) != "q" {
+			continue
+		}
+
+		v, err := strconv.ParseFloat(strings.TrimSpace(kv[1]), 64)
+		if err != nil {
+			return name, 0
+		}
+		q = v
+	}
+
+	return name, q
+}
+
+func gzipCompress(data []byte) ([]byte, error) {
+	var b bytes.Buffer
+	gw := gzip.NewWriter(&b)
+	if _, err := gw.Write(data); err != nil {
+		return nil, err
+	}
+	if err := gw.Close(); err != nil {
+		return nil, err
+	}
+	return b.Bytes(), nil
+}
+
+const (
+	patchOpsHeader            = "X-Kubewebhook-Patch-Ops"
+	patchOpsHeaderValueMaxLen = 1024
+)
+
+// patchOpsHeaderValue returns the debug header value of a JSON patch, in the form
+// of `{count} {op} {path},{op} {path}...`.
+func patchOpsHeaderValue(patch []byte) string {
+	ops := []struct {
+		Op   string `json:"op"`
+		Path string `json:"path"`
+	}{}
+	if err := json.Unmarshal(patch, &ops); err != nil {
+		return "invalid patch"
+	}
+
+	opsStr := make([]string, 0, len(ops))
+	for _, op := range ops {
+		opsStr = append(opsStr, op.Op+" "+op.Path)
+	}
+
+	v := fmt.Sprintf("%d %s", len(ops), strings.Join(opsStr, ","))
+	if len(v) > patchOpsHeaderValueMaxLen {
+		v = v[:patchOpsHeaderValueMaxLen-3] + "..."
+	}
+
+	return v
+}
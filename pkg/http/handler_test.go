@@ -2,19 +2,34 @@ package http_test
 
 import (
 	"bytes"
+	"compress/gzip"
+	"context"
 	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
 	"net/http/httptest"
+	"strings"
+	"sync"
 	"testing"
+	"time"
 
+	opentracing "github.com/opentracing/opentracing-go"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
 	admissionv1beta1 "k8s.io/api/admission/v1beta1"
+	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
 
+	mmetrics "github.com/slok/kubewebhook/mocks/observability/metrics"
 	mwebhook "github.com/slok/kubewebhook/mocks/webhook"
 	kubewebhookhttp "github.com/slok/kubewebhook/pkg/http"
+	"github.com/slok/kubewebhook/pkg/log"
+	"github.com/slok/kubewebhook/pkg/observability/metrics"
+	whcontext "github.com/slok/kubewebhook/pkg/webhook/context"
+	"github.com/slok/kubewebhook/pkg/webhook/validating"
 )
 
 func getTestAdmissionReviewRequestStr(uid string) string {
@@ -58,7 +73,7 @@ func TestDefaultWebhookFlow(t *testing.T) {
 			expCode: 200,
 		},
 		{
-			name: "A regular call to the webhook handler should execute the webhook and return error if something failed",
+			name: "A regular call to the webhook handler should execute the webhook and return a denying review if something failed",
 			body: getTestAdmissionReviewRequestStr("1234567890"),
 			reviewResponse: &admissionv1beta1.AdmissionResponse{
 				UID: "1234567890",
@@ -68,7 +83,7 @@ func TestDefaultWebhookFlow(t *testing.T) {
 				},
 			},
 			expBody: `{"response":{"uid":"1234567890","allowed":false,"status":{"metadata":{},"status":"Failure","message":"wanted error"}}}`,
-			expCode: 500,
+			expCode: 200,
 		},
 	}
 
@@ -93,3 +108,480 @@ func TestDefaultWebhookFlow(t *testing.T) {
 		})
 	}
 }
+
+func TestHandlerInternalErrorStatusCode(t *testing.T) {
+	tests := map[string]struct {
+		internalServerErrorOnFailure bool
+		expCode                      int
+	}{
+		"By default an internal error of the webhook should respond with a 200 and a denying review.": {
+			expCode: 200,
+		},
+
+		"Enabling the internal server error on failure, an internal error of the webhook should respond with a 500 and a denying review.": {
+			internalServerErrorOnFailure: true,
+			expCode:                      500,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+			require := require.New(t)
+
+			// Use a real webhook that fails internally.
+			wh, err := validating.NewWebhook(validating.WebhookConfig{Name: "test", Obj: &corev1.Pod{}},
+				validating.ValidatorFunc(func(_ context.Context, _ metav1.Object) (bool, validating.ValidatorResult, error) {
+					return true, validating.ValidatorResult{}, fmt.Errorf("wanted error")
+				}), &opentracing.NoopTracer{}, metrics.Dummy, log.Dummy)
+			require.NoError(err)
+
+			h, err := kubewebhookhttp.HandlerForConfig(kubewebhookhttp.HandlerConfig{
+				Webhook:                      wh,
+				InternalServerErrorOnFailure: test.internalServerErrorOnFailure,
+			})
+			require.NoError(err)
+
+			body := `{"kind":"AdmissionReview","apiVersion":"admission.k8s.io/v1beta1","request":{"uid":"1234567890","object":{"kind":"Pod","apiVersion":"v1","metadata":{"name":"test"}}}}`
+			req := httptest.NewRequest("POST", "/awesome/webhook", bytes.NewBufferString(body))
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, req)
+
+			assert.Equal(test.expCode, w.Code)
+			assert.Equal("application/json", w.Header().Get("Content-Type"))
+			assert.JSONEq(`{"response":{"uid":"1234567890","allowed":false,"status":{"metadata":{},"status":"Failure","message":"wanted error"}}}`, w.Body.String())
+		})
+	}
+}
+
+// bufferPoolJSONEncoder is a JSON encoder that reuses the buffers
+// used to encode, used to test custom encoders.
+type bufferPoolJSONEncoder struct {
+	pool sync.Pool
+}
+
+func newBufferPoolJSONEncoder() *bufferPoolJSONEncoder {
+	return &bufferPoolJSONEncoder{
+		pool: sync.Pool{New: func() interface{} { return &bytes.Buffer{} }},
+	}
+}
+
+func (b *bufferPoolJSONEncoder) Marshal(v interface{}) ([]byte, error) {
+	buf := b.pool.Get().(*bytes.Buffer)
+	defer b.pool.Put(buf)
+	buf.Reset()
+
+	if err := json.NewEncoder(buf).Encode(v); err != nil {
+		return nil, err
+	}
+
+	// Remove the trailing newline added by the encoder.
+	data := bytes.TrimSuffix(buf.Bytes(), []byte("\n"))
+	res := make([]byte, len(data))
+	copy(res, data)
+	return res, nil
+}
+
+func TestHandlerJSONEncoders(t *testing.T) {
+	reviewResponse := &admissionv1beta1.AdmissionResponse{
+		UID:     "1234567890",
+		Allowed: false,
+		Result: &metav1.Status{
+			Status:  metav1.StatusFailure,
+			Message: "wanted error",
+		},
+		Patch: []byte(`[{"op":"add","path":"/metadata/labels/test","value":"test"}]`),
+	}
+
+	tests := map[string]struct {
+		encoder kubewebhookhttp.JSONEncoder
+	}{
+		"Standard library encoder should encode the response.": {
+			encoder: kubewebhookhttp.StdJSONEncoder,
+		},
+
+		"Custom encoder should encode the same response as the standard library.": {
+			encoder: newBufferPoolJSONEncoder(),
+		},
+
+		"Not setting an encoder should encode the same response as the standard library.": {
+			encoder: nil,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+			require := require.New(t)
+
+			// Mocks.
+			mwh := &mwebhook.Webhook{}
+			mwh.On("Review", mock.Anything, mock.Anything).Once().Return(reviewResponse, nil)
+
+			h, err := kubewebhookhttp.HandlerForConfig(kubewebhookhttp.HandlerConfig{
+				Webhook: mwh,
+				Encoder: test.encoder,
+			})
+			require.NoError(err)
+
+			req := httptest.NewRequest("GET", "/awesome/webhook", bytes.NewBufferString(getTestAdmissionReviewRequestStr("1234567890")))
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, req)
+
+			expBody, err := json.Marshal(admissionv1beta1.AdmissionReview{Response: reviewResponse})
+			require.NoError(err)
+			assert.Equal(string(expBody), w.Body.String())
+		})
+	}
+}
+
+func BenchmarkHandlerJSONEncoders(b *testing.B) {
+	benchs := map[string]kubewebhookhttp.JSONEncoder{
+		"std":         kubewebhookhttp.StdJSONEncoder,
+		"buffer-pool": newBufferPoolJSONEncoder(),
+	}
+
+	reviewResponse := &admissionv1beta1.AdmissionResponse{
+		UID:     "1234567890",
+		Allowed: true,
+		Patch:   []byte(`[{"op":"add","path":"/metadata/labels/test","value":"test"}]`),
+	}
+	body := getTestAdmissionReviewRequestStr("1234567890")
+
+	for name, encoder := range benchs {
+		b.Run(name, func(b *testing.B) {
+			mwh := &mwebhook.Webhook{}
+			mwh.On("Review", mock.Anything, mock.Anything).Return(reviewResponse, nil)
+			h, _ := kubewebhookhttp.HandlerForConfig(kubewebhookhttp.HandlerConfig{
+				Webhook: mwh,
+				Encoder: encoder,
+			})
+
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				req := httptest.NewRequest("GET", "/awesome/webhook", bytes.NewBufferString(body))
+				w := httptest.NewRecorder()
+				h.ServeHTTP(w, req)
+			}
+		})
+	}
+}
+
+func TestHandlerLegacyV1alpha1Review(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	body := `{
+		"apiVersion": "admission.k8s.io/v1alpha1",
+		"kind": "AdmissionReview",
+		"spec": {
+			"kind": {"group": "", "version": "v1", "kind": "Pod"},
+			"object": {"kind": "Pod", "apiVersion": "v1", "metadata": {"name": "test"}},
+			"operation": "CREATE",
+			"name": "test",
+			"namespace": "default",
+			"resource": {"group": "", "version": "v1", "resource": "pods"},
+			"userInfo": {"username": "user1"}
+		}
+	}`
+
+	// Mocks.
+	mwh := &mwebhook.Webhook{}
+	expReview := mock.MatchedBy(func(ar *admissionv1beta1.AdmissionReview) bool {
+		r := ar.Request
+		return r.UID != "" &&
+			r.Kind == metav1.GroupVersionKind{Version: "v1", Kind: "Pod"} &&
+			r.Resource == metav1.GroupVersionResource{Version: "v1", Resource: "pods"} &&
+			r.Operation == admissionv1beta1.Create &&
+			r.Name == "test" &&
+			r.Namespace == "default" &&
+			r.UserInfo.Username == "user1" &&
+			string(r.Object.Raw) == `{"kind": "Pod", "apiVersion": "v1", "metadata": {"name": "test"}}`
+	})
+	mwh.On("Review", mock.Anything, expReview).Once().Return(&admissionv1beta1.AdmissionResponse{
+		Allowed: false,
+		Result:  &metav1.Status{Message: "denied"},
+	})
+
+	h, err := kubewebhookhttp.HandlerFor(mwh)
+	require.NoError(err)
+
+	req := httptest.NewRequest("POST", "/awesome/webhook", bytes.NewBufferString(body))
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, req)
+
+	mwh.AssertExpectations(t)
+	assert.Equal(200, w.Code)
+	assert.Equal(`{"kind":"AdmissionReview","apiVersion":"admission.k8s.io/v1alpha1","spec":{"kind":{"group":"","version":"","kind":""},"object":null,"oldObject":null,"resource":{"group":"","version":"","resource":""},"userInfo":{}},"status":{"allowed":false,"status":{"metadata":{},"message":"denied"}}}`, w.Body.String())
+}
+
+func TestHandlerDebugPatchHeader(t *testing.T) {
+	tests := map[string]struct {
+		debugPatchHeader bool
+		reviewResponse   *admissionv1beta1.AdmissionResponse
+		expHeader        string
+	}{
+		"A mutating response with the debug patch header enabled should set the patch ops header.": {
+			debugPatchHeader: true,
+			reviewResponse: &admissionv1beta1.AdmissionResponse{
+				UID:     "1234567890",
+				Allowed: true,
+				Patch:   []byte(`[{"op":"add","path":"/metadata/labels/test","value":"test"},{"op":"remove","path":"/spec/hostname"}]`),
+			},
+			expHeader: "2 add /metadata/labels/test,remove /spec/hostname",
+		},
+
+		"A mutating response with the debug patch header disabled should not set the patch ops header.": {
+			debugPatchHeader: false,
+			reviewResponse: &admissionv1beta1.AdmissionResponse{
+				UID:     "1234567890",
+				Allowed: true,
+				Patch:   []byte(`[{"op":"add","path":"/metadata/labels/test","value":"test"}]`),
+			},
+			expHeader: "",
+		},
+
+		"A response without patch should not set the patch ops header.": {
+			debugPatchHeader: true,
+			reviewResponse: &admissionv1beta1.AdmissionResponse{
+				UID:     "1234567890",
+				Allowed: true,
+			},
+			expHeader: "",
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+			require := require.New(t)
+
+			// Mocks.
+			mwh := &mwebhook.Webhook{}
+			mwh.On("Review", mock.Anything, mock.Anything).Once().Return(test.reviewResponse, nil)
+
+			h, err := kubewebhookhttp.HandlerForConfig(kubewebhookhttp.HandlerConfig{
+				Webhook:          mwh,
+				DebugPatchHeader: test.debugPatchHeader,
+			})
+			require.NoError(err)
+
+			req := httptest.NewRequest("POST", "/awesome/webhook", bytes.NewBufferString(getTestAdmissionReviewRequestStr("1234567890")))
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, req)
+
+			assert.Equal(200, w.Code)
+			assert.Equal(test.expHeader, w.Header().Get("X-Kubewebhook-Patch-Ops"))
+		})
+	}
+}
+
+func TestHandlerGzipCompression(t *testing.T) {
+	largePatch := `[{"op":"add","path":"/metadata/annotations/test","value":"` + strings.Repeat("a", 2048) + `"}]`
+	smallPatch := `[{"op":"add","path":"/metadata/annotations/test","value":"a"}]`
+
+	tests := map[string]struct {
+		acceptEncoding string
+		patch          string
+		expCompressed  bool
+	}{
+		"A large response with gzip accepted should be compressed.": {
+			acceptEncoding: "deflate, gzip;q=1.0",
+			patch:          largePatch,
+			expCompressed:  true,
+		},
+
+		"A small response with gzip accepted should not be compressed.": {
+			acceptEncoding: "gzip",
+			patch:          smallPatch,
+			expCompressed:  false,
+		},
+
+		"A large response without gzip accepted should not be compressed.": {
+			acceptEncoding: "",
+			patch:          largePatch,
+			expCompressed:  false,
+		},
+
+		"A large response with gzip not acceptable should not be compressed.": {
+			acceptEncoding: "gzip;q=0",
+			patch:          largePatch,
+			expCompressed:  false,
+		},
+
+		"A large response with gzip not acceptable and any encoding accepted should not be compressed.": {
+			acceptEncoding: "gzip;q=0, *",
+			patch:          largePatch,
+			expCompressed:  false,
+		},
+
+		"A large response with any encoding accepted should be compressed.": {
+			acceptEncoding: "deflate, *;q=0.5",
+			patch:          largePatch,
+			expCompressed:  true,
+		},
+
+		"A large response with any encoding not acceptable should not be compressed.": {
+			acceptEncoding: "*;q=0",
+			patch:          largePatch,
+			expCompressed:  false,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+			require := require.New(t)
+
+			reviewResponse := &admissionv1beta1.AdmissionResponse{
+				UID:     "1234567890",
+				Allowed: true,
+				Patch:   []byte(test.patch),
+			}
+			expBody, err := json.Marshal(admissionv1beta1.AdmissionReview{Response: reviewResponse})
+			require.NoError(err)
+
+			// Mocks.
+			mwh := &mwebhook.Webhook{}
+			mwh.On("Review", mock.Anything, mock.Anything).Once().Return(reviewResponse, nil)
+
+			h, err := kubewebhookhttp.HandlerForConfig(kubewebhookhttp.HandlerConfig{
+				Webhook:     mwh,
+				GzipMinSize: 1024,
+			})
+			require.NoError(err)
+
+			req := httptest.NewRequest("POST", "/awesome/webhook", bytes.NewBufferString(getTestAdmissionReviewRequestStr("1234567890")))
+			if test.acceptEncoding != "" {
+				req.Header.Set("Accept-Encoding", test.acceptEncoding)
+			}
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, req)
+
+			assert.Equal(200, w.Code)
+			body := w.Body.Bytes()
+			if test.expCompressed {
+				assert.Equal("gzip", w.Header().Get("Content-Encoding"))
+				gr, err := gzip.NewReader(bytes.NewReader(body))
+				require.NoError(err)
+				body, err = ioutil.ReadAll(gr)
+				require.NoError(err)
+			} else {
+				assert.Empty(w.Header().Get("Content-Encoding"))
+			}
+			assert.Equal(string(expBody), string(body))
+		})
+	}
+}
+
+func TestHandlerCorrelationID(t *testing.T) {
+	tests := map[string]struct {
+		extractor        kubewebhookhttp.CorrelationIDExtractor
+		headers          map[string]string
+		obj              string
+		expCorrelationID string
+	}{
+		"By default the admission review UID should be used as correlation ID.": {
+			headers:          map[string]string{"X-Request-Id": "test-request-id"},
+			expCorrelationID: "1234567890",
+		},
+
+		"A header extractor should use the custom header ID as correlation ID.": {
+			extractor:        kubewebhookhttp.HeaderCorrelationIDExtractor("X-Request-Id"),
+			headers:          map[string]string{"X-Request-Id": "test-request-id"},
+			expCorrelationID: "test-request-id",
+		},
+
+		"A header extractor without the header should fallback to the admission review UID.": {
+			extractor:        kubewebhookhttp.HeaderCorrelationIDExtractor("X-Request-Id"),
+			expCorrelationID: "1234567890",
+		},
+
+		"An annotation extractor should use the object annotation as correlation ID.": {
+			extractor:        kubewebhookhttp.AnnotationCorrelationIDExtractor("slok.dev/request-id"),
+			obj:              `{"metadata":{"annotations":{"slok.dev/request-id":"test-annotation-id"}}}`,
+			expCorrelationID: "test-annotation-id",
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+			require := require.New(t)
+
+			// Mocks.
+			var gotCorrelationID string
+			mwh := &mwebhook.Webhook{}
+			mwh.On("Review", mock.Anything, mock.Anything).Once().Run(func(args mock.Arguments) {
+				gotCorrelationID = whcontext.GetCorrelationID(args.Get(0).(context.Context))
+			}).Return(&admissionv1beta1.AdmissionResponse{UID: "1234567890", Allowed: true})
+
+			h, err := kubewebhookhttp.HandlerForConfig(kubewebhookhttp.HandlerConfig{
+				Webhook:                mwh,
+				CorrelationIDExtractor: test.extractor,
+			})
+			require.NoError(err)
+
+			ar := admissionv1beta1.AdmissionReview{
+				Request: &admissionv1beta1.AdmissionRequest{UID: "1234567890"},
+			}
+			if test.obj != "" {
+				ar.Request.Object.Raw = []byte(test.obj)
+			}
+			body, err := json.Marshal(ar)
+			require.NoError(err)
+
+			req := httptest.NewRequest("POST", "/awesome/webhook", bytes.NewBuffer(body))
+			for k, v := range test.headers {
+				req.Header.Set(k, v)
+			}
+			h.ServeHTTP(httptest.NewRecorder(), req)
+
+			assert.Equal(test.expCorrelationID, gotCorrelationID)
+		})
+	}
+}
+
+// slowReader is a reader that waits before the first read, simulates a slow client.
+type slowReader struct {
+	r     io.Reader
+	delay time.Duration
+	once  sync.Once
+}
+
+func (s *slowReader) Read(p []byte) (int, error) {
+	s.once.Do(func() { time.Sleep(s.delay) })
+	return s.r.Read(p)
+}
+
+func TestHandlerDurationMetrics(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	// Mocks.
+	mwh := &mwebhook.Webhook{}
+	mwh.On("Review", mock.Anything, mock.Anything).Once().Return(&admissionv1beta1.AdmissionResponse{UID: "1234567890", Allowed: true})
+
+	var gotDuration time.Duration
+	mm := &mmetrics.Recorder{}
+	mm.On("ObserveHTTPHandlerDuration", "test", mock.Anything).Once().Run(func(args mock.Arguments) {
+		gotDuration = time.Since(args.Get(1).(time.Time))
+	})
+
+	h, err := kubewebhookhttp.HandlerForConfig(kubewebhookhttp.HandlerConfig{
+		Webhook:         mwh,
+		Name:            "test",
+		MetricsRecorder: mm,
+	})
+	require.NoError(err)
+
+	body := &slowReader{r: strings.NewReader(getTestAdmissionReviewRequestStr("1234567890")), delay: 50 * time.Millisecond}
+	req := httptest.NewRequest("POST", "/awesome/webhook", body)
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, req)
+
+	// The handler duration should capture the request read delay.
+	assert.Equal(200, w.Code)
+	mm.AssertExpectations(t)
+	assert.True(gotDuration >= 50*time.Millisecond, "handler duration %s should include the read delay", gotDuration)
+}
@@ -0,0 +1,57 @@
+// Package v1alpha1 has the legacy external admission webhook `admission.k8s.io/v1alpha1` types.
+//
+// LEGACY: This version was used by the external admission webhooks of Kubernetes v1.8 and
+// was removed from Kubernetes, only use it to integrate with old systems.
+package v1alpha1
+
+import (
+	authenticationv1 "k8s.io/api/authentication/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+const (
+	// APIVersion is the API version of the legacy admission review.
+	APIVersion = "admission.k8s.io/v1alpha1"
+	// Kind is the kind of the legacy admission review.
+	Kind = "AdmissionReview"
+)
+
+// AdmissionReview describes an admission request.
+type AdmissionReview struct {
+	metav1.TypeMeta `json:",inline"`
+	// Spec describes the attributes for the admission request.
+	Spec AdmissionReviewSpec `json:"spec,omitempty"`
+	// Status is filled in by the webhook and indicates whether the admission request should be permitted.
+	Status AdmissionReviewStatus `json:"status,omitempty"`
+}
+
+// AdmissionReviewSpec describes the admission.Attributes for the admission request.
+type AdmissionReviewSpec struct {
+	// Kind is the type of object being manipulated.
+	Kind metav1.GroupVersionKind `json:"kind,omitempty"`
+	// Object is the object from the incoming request prior to default values being applied.
+	Object runtime.RawExtension `json:"object,omitempty"`
+	// OldObject is the existing object. Only populated for UPDATE requests.
+	OldObject runtime.RawExtension `json:"oldObject,omitempty"`
+	// Operation is the operation being performed.
+	Operation string `json:"operation,omitempty"`
+	// Name is the name of the object as presented in the request.
+	Name string `json:"name,omitempty"`
+	// Namespace is the namespace associated with the request (if any).
+	Namespace string `json:"namespace,omitempty"`
+	// Resource is the name of the resource being requested.
+	Resource metav1.GroupVersionResource `json:"resource,omitempty"`
+	// SubResource is the name of the subresource being requested.
+	SubResource string `json:"subResource,omitempty"`
+	// UserInfo is information about the requesting user.
+	UserInfo authenticationv1.UserInfo `json:"userInfo,omitempty"`
+}
+
+// AdmissionReviewStatus describes the status of the admission request.
+type AdmissionReviewStatus struct {
+	// Allowed indicates whether or not the admission request was permitted.
+	Allowed bool `json:"allowed"`
+	// Result contains extra details into why an admission request was denied.
+	Result *metav1.Status `json:"status,omitempty"`
+}
@@ -0,0 +1,74 @@
+package http
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+
+	admissionv1beta1 "k8s.io/api/admission/v1beta1"
+
+	whcontext "github.com/slok/kubewebhook/pkg/webhook/context"
+)
+
+var (
+	// ErrEmptyReview is returned when the received admission review is empty.
+	ErrEmptyReview = errors.New("empty admission review")
+	// ErrInvalidReview is returned when the received admission review can't be decoded.
+	ErrInvalidReview = errors.New("invalid admission review")
+)
+
+// ReviewProcessor processes raw admission reviews using a webhook independently of the
+// transport, this way the webhooks can be served over transports other than `net/http`
+// (e.g an in-process bus for testing or a gRPC bridge). The HTTP handler uses it too.
+type ReviewProcessor struct {
+	cfg HandlerConfig
+}
+
+// NewReviewProcessor returns a new review processor using the webhook and encoder of the
+// configuration, the HTTP specific options are ignored.
+func NewReviewProcessor(cfg HandlerConfig) (*ReviewProcessor, error) {
+	if err := cfg.defaults(); err != nil {
+		return nil, fmt.Errorf("review processor configuration is not valid: %w", err)
+	}
+
+	return &ReviewProcessor{cfg: cfg}, nil
+}
+
+// ProcessReview decodes the raw admission review, reviews it with the webhook and returns
+// the raw admission review response on the same version of the received review.
+func (p *ReviewProcessor) ProcessReview(ctx context.Context, review []byte) ([]byte, error) {
+	resp, _, err := p.processReview(ctx, nil, review)
+	return resp, err
+}
+
+// processReview processes the raw review, the HTTP request is optional and only used to extract
+// the correlation ID.
+func (p *ReviewProcessor) processReview(ctx context.Context, r *http.Request, review []byte) ([]byte, *admissionv1beta1.AdmissionResponse, error) {
+	if len(review) == 0 {
+		return nil, nil, ErrEmptyReview
+	}
+
+	version := negotiateReviewVersion(review)
+	ar, err := version.decode(review)
+	if err != nil {
+		return nil, nil, fmt.Errorf("%w: %s", ErrInvalidReview, err)
+	}
+
+	// Set the admission request and the correlation ID on the context.
+	ctx = whcontext.SetAdmissionRequest(ctx, ar.Request)
+	if p.cfg.CorrelationIDExtractor != nil {
+		ctx = whcontext.SetCorrelationID(ctx, p.cfg.CorrelationIDExtractor.ExtractCorrelationID(r, ar))
+	}
+
+	// Mutation logic.
+	admissionResp := p.cfg.Webhook.Review(ctx, ar)
+
+	// Forge the review response on the same version of the review.
+	resp, err := p.cfg.Encoder.Marshal(version.response(admissionResp))
+	if err != nil {
+		return nil, nil, fmt.Errorf("could not marshal admission review response: %w", err)
+	}
+
+	return resp, admissionResp, nil
+}
@@ -0,0 +1,73 @@
+package http_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	admissionv1beta1 "k8s.io/api/admission/v1beta1"
+
+	mwebhook "github.com/slok/kubewebhook/mocks/webhook"
+	kubewebhookhttp "github.com/slok/kubewebhook/pkg/http"
+	whcontext "github.com/slok/kubewebhook/pkg/webhook/context"
+)
+
+func TestReviewProcessor(t *testing.T) {
+	tests := map[string]struct {
+		review         []byte
+		reviewResponse *admissionv1beta1.AdmissionResponse
+		expResponse    string
+		expErr         error
+	}{
+		"An empty review should return an error.": {
+			review: nil,
+			expErr: kubewebhookhttp.ErrEmptyReview,
+		},
+
+		"An invalid review should return an error.": {
+			review: []byte("wrong review"),
+			expErr: kubewebhookhttp.ErrInvalidReview,
+		},
+
+		"A regular review should be processed by the webhook and return the raw response.": {
+			review: []byte(getTestAdmissionReviewRequestStr("1234567890")),
+			reviewResponse: &admissionv1beta1.AdmissionResponse{
+				UID:     "1234567890",
+				Allowed: true,
+			},
+			expResponse: `{"response":{"uid":"1234567890","allowed":true}}`,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+			require := require.New(t)
+
+			// Mocks.
+			mwh := &mwebhook.Webhook{}
+			if test.reviewResponse != nil {
+				mwh.On("Review", mock.Anything, mock.Anything).Once().Run(func(args mock.Arguments) {
+					// The admission request should be on the context.
+					ctx := args.Get(0).(context.Context)
+					assert.Equal(test.reviewResponse.UID, whcontext.GetAdmissionRequest(ctx).UID)
+				}).Return(test.reviewResponse, nil)
+			}
+
+			p, err := kubewebhookhttp.NewReviewProcessor(kubewebhookhttp.HandlerConfig{Webhook: mwh})
+			require.NoError(err)
+
+			gotResp, err := p.ProcessReview(context.TODO(), test.review)
+
+			if test.expErr != nil {
+				assert.True(errors.Is(err, test.expErr))
+			} else if assert.NoError(err) {
+				assert.Equal(test.expResponse, string(gotResp))
+			}
+			mwh.AssertExpectations(t)
+		})
+	}
+}
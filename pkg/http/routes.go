@@ -0,0 +1,86 @@
+package http
+
+import (
+	"fmt"
+	"net/http"
+
+	admissionregistrationv1beta1 "k8s.io/api/admissionregistration/v1beta1"
+
+	"github.com/slok/kubewebhook/pkg/webhook"
+)
+
+// Route is a webhook served on an HTTP path. Routes should be the single source of
+// truth for the webhook paths, the HTTP server and the webhook registration manifests
+// (`clientConfig.service.path`) should be generated from them so they don't drift.
+type Route struct {
+	// Path is the HTTP path where the webhook will be served.
+	Path string
+	// Webhook is the webhook served on the path.
+	Webhook webhook.Webhook
+	// Handler is the optional HTTP handler configuration of the route. If the handler
+	// configuration webhook is missing, the route webhook will be used.
+	Handler HandlerConfig
+}
+
+// NewServeMux returns a new HTTP mux that serves the webhooks of the routes on their paths.
+func NewServeMux(routes ...Route) (*http.ServeMux, error) {
+	mux := http.NewServeMux()
+	for _, r := range routes {
+		if r.Path == "" {
+			return nil, fmt.Errorf("route path can't be empty")
+		}
+
+		cfg := r.Handler
+		if cfg.Webhook == nil {
+			cfg.Webhook = r.Webhook
+		}
+
+		h, err := HandlerForConfig(cfg)
+		if err != nil {
+			return nil, fmt.Errorf("could not create handler for %q route: %w", r.Path, err)
+		}
+		mux.Handle(r.Path, h)
+	}
+
+	return mux, nil
+}
+
+// ServiceClientConfig returns the webhook registration client configuration that points to
+// the route served by a Kubernetes service.
+func ServiceClientConfig(r Route, namespace, name string, caBundle []byte) admissionregistrationv1beta1.WebhookClientConfig {
+	path := r.Path
+	return admissionregistrationv1beta1.WebhookClientConfig{
+		Service: &admissionregistrationv1beta1.ServiceReference{
+			Namespace: namespace,
+			Name:      name,
+			Path:      &path,
+		},
+		CABundle: caBundle,
+	}
+}
+
+// CheckServiceClientConfigPaths checks that all the service client configurations
+// have a path that is served by one of the routes. Useful on tests to assert that the
+// registration manifests are consistent with the server routing.
+func CheckServiceClientConfigPaths(routes []Route, cfgs ...admissionregistrationv1beta1.WebhookClientConfig) error {
+	paths := map[string]bool{}
+	for _, r := range routes {
+		paths[r.Path] = true
+	}
+
+	for _, cfg := range cfgs {
+		if cfg.Service == nil {
+			continue
+		}
+
+		if cfg.Service.Path == nil {
+			return fmt.Errorf("service %s/%s client config is missing the path", cfg.Service.Namespace, cfg.Service.Name)
+		}
+
+		if !paths[*cfg.Service.Path] {
+			return fmt.Errorf("service %s/%s client config path %q is not served by any route", cfg.Service.Namespace, cfg.Service.Name, *cfg.Service.Path)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,176 @@
+package http_test
+
+import (
+	"bytes"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	admissionv1beta1 "k8s.io/api/admission/v1beta1"
+	admissionregistrationv1beta1 "k8s.io/api/admissionregistration/v1beta1"
+
+	mwebhook "github.com/slok/kubewebhook/mocks/webhook"
+	kubewebhookhttp "github.com/slok/kubewebhook/pkg/http"
+)
+
+func TestRoutesManifestConsistency(t *testing.T) {
+	tests := map[string]struct {
+		routes    func() []kubewebhookhttp.Route
+		manifests func(routes []kubewebhookhttp.Route) []admissionregistrationv1beta1.WebhookClientConfig
+		expErr    bool
+	}{
+		"Manifests generated from the routes should be consistent.": {
+			routes: func() []kubewebhookhttp.Route {
+				return []kubewebhookhttp.Route{{Path: "/mutate/pods", Webhook: &mwebhook.Webhook{}}}
+			},
+			manifests: func(routes []kubewebhookhttp.Route) []admissionregistrationv1beta1.WebhookClientConfig {
+				return []admissionregistrationv1beta1.WebhookClientConfig{
+					kubewebhookhttp.ServiceClientConfig(routes[0], "default", "webhook", nil),
+				}
+			},
+		},
+
+		"Manifests with paths not served by the routes should fail.": {
+			routes: func() []kubewebhookhttp.Route {
+				return []kubewebhookhttp.Route{{Path: "/v2/mutate/pods", Webhook: &mwebhook.Webhook{}}}
+			},
+			manifests: func(routes []kubewebhookhttp.Route) []admissionregistrationv1beta1.WebhookClientConfig {
+				stale := kubewebhookhttp.ServiceClientConfig(kubewebhookhttp.Route{Path: "/mutate/pods"}, "default", "webhook", nil)
+				return []admissionregistrationv1beta1.WebhookClientConfig{stale}
+			},
+			expErr: true,
+		},
+
+		"Manifests without path should fail.": {
+			routes: func() []kubewebhookhttp.Route {
+				return []kubewebhookhttp.Route{{Path: "/mutate/pods", Webhook: &mwebhook.Webhook{}}}
+			},
+			manifests: func(routes []kubewebhookhttp.Route) []admissionregistrationv1beta1.WebhookClientConfig {
+				return []admissionregistrationv1beta1.WebhookClientConfig{
+					{Service: &admissionregistrationv1beta1.ServiceReference{Namespace: "default", Name: "webhook"}},
+				}
+			},
+			expErr: true,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+			require := require.New(t)
+
+			routes := test.routes()
+			manifests := test.manifests(routes)
+			require.NotEmpty(manifests)
+
+			err := kubewebhookhttp.CheckServiceClientConfigPaths(routes, manifests...)
+			if test.expErr {
+				assert.Error(err)
+			} else {
+				assert.NoError(err)
+			}
+		})
+	}
+}
+
+func TestNewServeMux(t *testing.T) {
+	tests := map[string]struct {
+		routes     func(mwh *mwebhook.Webhook) []kubewebhookhttp.Route
+		reqPath    func(routes []kubewebhookhttp.Route) string
+		mock       func(mwh *mwebhook.Webhook)
+		expCode    int
+		expHeaders map[string]string
+	}{
+		"Requests to the generated manifest path should be served by the route webhook.": {
+			routes: func(mwh *mwebhook.Webhook) []kubewebhookhttp.Route {
+				return []kubewebhookhttp.Route{
+					{Path: "/validate/pods", Webhook: &mwebhook.Webhook{}},
+					{Path: "/mutate/pods", Webhook: mwh},
+				}
+			},
+			reqPath: func(routes []kubewebhookhttp.Route) string {
+				cfg := kubewebhookhttp.ServiceClientConfig(routes[1], "default", "webhook", nil)
+				return *cfg.Service.Path
+			},
+			mock: func(mwh *mwebhook.Webhook) {
+				mwh.On("Review", mock.Anything, mock.Anything).Once().Return(&admissionv1beta1.AdmissionResponse{UID: "1234567890", Allowed: true})
+			},
+			expCode: 200,
+		},
+
+		"Changing a route path should change the served path and the generated manifest path.": {
+			routes: func(mwh *mwebhook.Webhook) []kubewebhookhttp.Route {
+				return []kubewebhookhttp.Route{{Path: "/v2/mutate/pods", Webhook: mwh}}
+			},
+			reqPath: func(routes []kubewebhookhttp.Route) string {
+				cfg := kubewebhookhttp.ServiceClientConfig(routes[0], "default", "webhook", nil)
+				return *cfg.Service.Path
+			},
+			mock: func(mwh *mwebhook.Webhook) {
+				mwh.On("Review", mock.Anything, mock.Anything).Once().Return(&admissionv1beta1.AdmissionResponse{UID: "1234567890", Allowed: true})
+			},
+			expCode: 200,
+		},
+
+		"Requests to a stale path should not be served.": {
+			routes: func(mwh *mwebhook.Webhook) []kubewebhookhttp.Route {
+				return []kubewebhookhttp.Route{{Path: "/v2/mutate/pods", Webhook: mwh}}
+			},
+			reqPath: func(routes []kubewebhookhttp.Route) string {
+				return "/mutate/pods"
+			},
+			mock:    func(mwh *mwebhook.Webhook) {},
+			expCode: 404,
+		},
+
+		"The route handler configuration should be used to serve the route.": {
+			routes: func(mwh *mwebhook.Webhook) []kubewebhookhttp.Route {
+				return []kubewebhookhttp.Route{{
+					Path:    "/mutate/pods",
+					Webhook: mwh,
+					Handler: kubewebhookhttp.HandlerConfig{DebugPatchHeader: true},
+				}}
+			},
+			reqPath: func(routes []kubewebhookhttp.Route) string {
+				return routes[0].Path
+			},
+			mock: func(mwh *mwebhook.Webhook) {
+				mwh.On("Review", mock.Anything, mock.Anything).Once().Return(&admissionv1beta1.AdmissionResponse{
+					UID:     "1234567890",
+					Allowed: true,
+					Patch:   []byte(`[{"op":"add","path":"/metadata/labels","value":{"test":"test"}}]`),
+				})
+			},
+			expCode: 200,
+			expHeaders: map[string]string{
+				"X-Kubewebhook-Patch-Ops": "1 add /metadata/labels",
+			},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+			require := require.New(t)
+
+			mwh := &mwebhook.Webhook{}
+			test.mock(mwh)
+
+			routes := test.routes(mwh)
+			mux, err := kubewebhookhttp.NewServeMux(routes...)
+			require.NoError(err)
+
+			req := httptest.NewRequest("POST", test.reqPath(routes), bytes.NewBufferString(getTestAdmissionReviewRequestStr("1234567890")))
+			w := httptest.NewRecorder()
+			mux.ServeHTTP(w, req)
+
+			assert.Equal(test.expCode, w.Code)
+			for k, v := range test.expHeaders {
+				assert.Equal(v, w.Header().Get(k))
+			}
+			mwh.AssertExpectations(t)
+		})
+	}
+}
@@ -0,0 +1,103 @@
+package http
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+
+	admissionv1beta1 "k8s.io/api/admission/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	"github.com/slok/kubewebhook/pkg/http/internal/v1alpha1"
+)
+
+// reviewVersion knows how to decode the admission reviews of a specific version into
+// the internal admission review representation and how to encode the response back
+// on the same version.
+type reviewVersion interface {
+	decode(body []byte) (*admissionv1beta1.AdmissionReview, error)
+	response(resp *admissionv1beta1.AdmissionResponse) interface{}
+}
+
+// negotiateReviewVersion returns the review version of the received admission review
+// body, by default `admission.k8s.io/v1beta1`.
+func negotiateReviewVersion(body []byte) reviewVersion {
+	tm := metav1.TypeMeta{}
+	if err := json.Unmarshal(body, &tm); err == nil && tm.APIVersion == v1alpha1.APIVersion {
+		return v1alpha1ReviewVersion{}
+	}
+
+	return v1beta1ReviewVersion{}
+}
+
+type v1beta1ReviewVersion struct{}
+
+func (v1beta1ReviewVersion) decode(body []byte) (*admissionv1beta1.AdmissionReview, error) {
+	ar := &admissionv1beta1.AdmissionReview{}
+	if _, _, err := deserializer.Decode(body, nil, ar); err != nil {
+		return nil, err
+	}
+	return ar, nil
+}
+
+func (v1beta1ReviewVersion) response(resp *admissionv1beta1.AdmissionResponse) interface{} {
+	return admissionv1beta1.AdmissionReview{
+		Response: resp,
+	}
+}
+
+// v1alpha1ReviewVersion is the LEGACY external admission webhook version.
+//
+// This version doesn't have UIDs (a random one will be used) and doesn't support
+// mutations, so the patches of the responses will be ignored.
+type v1alpha1ReviewVersion struct{}
+
+func (v1alpha1ReviewVersion) decode(body []byte) (*admissionv1beta1.AdmissionReview, error) {
+	lar := &v1alpha1.AdmissionReview{}
+	if err := json.Unmarshal(body, lar); err != nil {
+		return nil, err
+	}
+
+	uid, err := newLegacyUID()
+	if err != nil {
+		return nil, err
+	}
+
+	return &admissionv1beta1.AdmissionReview{
+		Request: &admissionv1beta1.AdmissionRequest{
+			UID:         uid,
+			Kind:        lar.Spec.Kind,
+			Resource:    lar.Spec.Resource,
+			SubResource: lar.Spec.SubResource,
+			Name:        lar.Spec.Name,
+			Namespace:   lar.Spec.Namespace,
+			Operation:   admissionv1beta1.Operation(lar.Spec.Operation),
+			UserInfo:    lar.Spec.UserInfo,
+			Object:      lar.Spec.Object,
+			OldObject:   lar.Spec.OldObject,
+		},
+	}, nil
+}
+
+func (v1alpha1ReviewVersion) response(resp *admissionv1beta1.AdmissionResponse) interface{} {
+	return v1alpha1.AdmissionReview{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: v1alpha1.APIVersion,
+			Kind:       v1alpha1.Kind,
+		},
+		Status: v1alpha1.AdmissionReviewStatus{
+			Allowed: resp.Allowed,
+			Result:  resp.Result,
+		},
+	}
+}
+
+func newLegacyUID() (types.UID, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("could not generate UID: %w", err)
+	}
+	return types.UID(hex.EncodeToString(b)), nil
+}
@@ -0,0 +1,64 @@
+// Package testutil has the helpers shared by the library tests.
+package testutil
+
+import (
+	"fmt"
+	"reflect"
+	"sync"
+
+	"github.com/stretchr/testify/mock"
+
+	mmetrics "github.com/slok/kubewebhook/mocks/observability/metrics"
+)
+
+// Logger is a logger that records the logged messages by level.
+type Logger struct {
+	mu       sync.Mutex
+	Infos    []string
+	Warnings []string
+	Errors   []string
+	Debugs   []string
+}
+
+// Infof satisfies log.Logger interface.
+func (l *Logger) Infof(format string, args ...interface{}) {
+	l.record(&l.Infos, format, args...)
+}
+
+// Warningf satisfies log.Logger interface.
+func (l *Logger) Warningf(format string, args ...interface{}) {
+	l.record(&l.Warnings, format, args...)
+}
+
+// Errorf satisfies log.Logger interface.
+func (l *Logger) Errorf(format string, args ...interface{}) {
+	l.record(&l.Errors, format, args...)
+}
+
+// Debugf satisfies log.Logger interface.
+func (l *Logger) Debugf(format string, args ...interface{}) {
+	l.record(&l.Debugs, format, args...)
+}
+
+func (l *Logger) record(msgs *[]string, format string, args ...interface{}) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	*msgs = append(*msgs, fmt.Sprintf(format, args...))
+}
+
+// IgnoreRecorderCalls sets the metrics recorder mock to accept any call to the methods
+// without asserting them, useful to ignore the metrics that are not under test.
+func IgnoreRecorderCalls(rec *mmetrics.Recorder, methods ...string) {
+	for _, m := range methods {
+		method, ok := reflect.TypeOf(rec).MethodByName(m)
+		if !ok {
+			panic(fmt.Sprintf("metrics recorder mock doesn't have %q method", m))
+		}
+
+		args := make([]interface{}, method.Type.NumIn()-1)
+		for i := range args {
+			args[i] = mock.Anything
+		}
+		rec.On(m, args...).Maybe()
+	}
+}
@@ -3,6 +3,7 @@ package log
 import (
 	"fmt"
 	"log"
+	"strings"
 )
 
 // Logger is the interface that the loggers used by the library will use.
@@ -47,3 +48,46 @@ func (s *Std) Debugf(format string, args ...interface{}) {
 		s.logWithPrefix("[DEBUG]", format, args...)
 	}
 }
+
+// ValuesLogger is an optional Logger extension for the loggers that know how to attach
+// key-value pairs to all the logged messages (e.g structured loggers).
+type ValuesLogger interface {
+	WithValues(keyValues ...interface{}) Logger
+}
+
+// WithValues returns a logger that logs the key-value pairs with all the messages. If the logger
+// satisfies ValuesLogger interface it will be used, if not, the pairs will be appended to the
+// messages in `key=value` format.
+func WithValues(l Logger, keyValues ...interface{}) Logger {
+	if vl, ok := l.(ValuesLogger); ok {
+		return vl.WithValues(keyValues...)
+	}
+
+	values := make([]string, 0, len(keyValues)/2)
+	for i := 0; i+1 < len(keyValues); i += 2 {
+		values = append(values, fmt.Sprintf("%v=%v", keyValues[i], keyValues[i+1]))
+	}
+	if len(values) == 0 {
+		return l
+	}
+
+	return &valuesLogger{Logger: l, values: strings.Join(values, " ")}
+}
+
+type valuesLogger struct {
+	Logger
+	values string
+}
+
+func (v *valuesLogger) Infof(format string, args ...interface{}) {
+	v.Logger.Infof(format+" %s", append(args[:len(args):len(args)], v.values)...)
+}
+func (v *valuesLogger) Warningf(format string, args ...interface{}) {
+	v.Logger.Warningf(format+" %s", append(args[:len(args):len(args)], v.values)...)
+}
+func (v *valuesLogger) Errorf(format string, args ...interface{}) {
+	v.Logger.Errorf(format+" %s", append(args[:len(args):len(args)], v.values)...)
+}
+func (v *valuesLogger) Debugf(format string, args ...interface{}) {
+	v.Logger.Debugf(format+" %s", append(args[:len(args):len(args)], v.values)...)
+}
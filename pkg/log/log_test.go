@@ -0,0 +1,77 @@
+package log_test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/slok/kubewebhook/pkg/log"
+)
+
+type testLogger struct {
+	msgs []string
+}
+
+func (t *testLogger) Infof(format string, args ...interface{}) {
+	t.msgs = append(t.msgs, fmt.Sprintf(format, args...))
+}
+func (t *testLogger) Warningf(format string, args ...interface{}) { t.Infof(format, args...) }
+func (t *testLogger) Errorf(format string, args ...interface{})   { t.Infof(format, args...) }
+func (t *testLogger) Debugf(format string, args ...interface{})   { t.Infof(format, args...) }
+
+type testValuesLogger struct {
+	testLogger
+	values []interface{}
+}
+
+func (t *testValuesLogger) WithValues(keyValues ...interface{}) log.Logger {
+	t.values = append(t.values, keyValues...)
+	return t
+}
+
+func TestWithValues(t *testing.T) {
+	tests := map[string]struct {
+		keyValues []interface{}
+		expMsgs   []string
+	}{
+		"Without values the messages should not be modified.": {
+			expMsgs: []string{"test 1", "test 2"},
+		},
+
+		"The values should be appended to the messages.": {
+			keyValues: []interface{}{"correlationID", "1234567890", "webhook", 42},
+			expMsgs:   []string{"test 1 correlationID=1234567890 webhook=42", "test 2 correlationID=1234567890 webhook=42"},
+		},
+
+		"A key without value should be ignored.": {
+			keyValues: []interface{}{"correlationID", "1234567890", "webhook"},
+			expMsgs:   []string{"test 1 correlationID=1234567890", "test 2 correlationID=1234567890"},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+
+			tl := &testLogger{}
+			logger := log.WithValues(tl, test.keyValues...)
+			logger.Infof("test %d", 1)
+			logger.Errorf("test %s", "2")
+
+			assert.Equal(test.expMsgs, tl.msgs)
+		})
+	}
+}
+
+func TestWithValuesValuesLogger(t *testing.T) {
+	assert := assert.New(t)
+
+	tl := &testValuesLogger{}
+	logger := log.WithValues(tl, "correlationID", "1234567890")
+	logger.Infof("test")
+
+	// The values logger should handle the values by itself.
+	assert.Equal([]interface{}{"correlationID", "1234567890"}, tl.values)
+	assert.Equal([]string{"test"}, tl.msgs)
+}
@@ -0,0 +1,128 @@
+package log
+
+import (
+	"encoding/json"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// Redacted is the value that replaces the redacted data.
+const Redacted = "REDACTED"
+
+// Redactor knows how to redact the sensitive data of the objects before logging them.
+type Redactor interface {
+	// RedactObject returns the JSON object of the kind with the sensitive data redacted.
+	RedactObject(gk schema.GroupKind, objJSON []byte) []byte
+	// RedactPatch returns the JSON patch of an object of the kind with the sensitive data redacted.
+	RedactPatch(gk schema.GroupKind, patchJSON []byte) []byte
+}
+
+// RedactRule is a redaction rule of the fields of a kind.
+type RedactRule struct {
+	// GroupKind is the kind of the object the rule applies to.
+	GroupKind schema.GroupKind
+	// Fields are the paths of the object fields (e.g `data`, `spec.credentials.password`) that
+	// will be redacted.
+	Fields []string
+}
+
+// DefaultRedactRules are the redaction rules of the sensitive data of the core kinds.
+var DefaultRedactRules = []RedactRule{
+	{GroupKind: schema.GroupKind{Kind: "Secret"}, Fields: []string{"data", "stringData"}},
+}
+
+// DefaultRedactor is the redactor with the default redaction rules.
+var DefaultRedactor = NewRedactor()
+
+// NewRedactor returns a new redactor that will redact using the default rules and the
+// custom ones (e.g for the CRDs with sensitive data, or ConfigMaps).
+func NewRedactor(rules ...RedactRule) Redactor {
+	r := redactor{rules: map[schema.GroupKind][][]string{}}
+	for _, rule := range append(append([]RedactRule{}, DefaultRedactRules...), rules...) {
+		for _, f := range rule.Fields {
+			r.rules[rule.GroupKind] = append(r.rules[rule.GroupKind], strings.Split(f, "."))
+		}
+	}
+	return r
+}
+
+type redactor struct {
+	rules map[schema.GroupKind][][]string
+}
+
+func (r redactor) RedactObject(gk schema.GroupKind, objJSON []byte) []byte {
+	fields, ok := r.rules[gk]
+	if !ok {
+		return objJSON
+	}
+
+	obj := map[string]interface{}{}
+	if err := json.Unmarshal(objJSON, &obj); err != nil {
+		// If we can't understand the data, we can't log it.
+		return []byte(Redacted)
+	}
+
+	for _, f := range fields {
+		redactField(obj, f)
+	}
+
+	res, err := json.Marshal(obj)
+	if err != nil {
+		return []byte(Redacted)
+	}
+	return res
+}
+
+func redactField(obj map[string]interface{}, path []string) {
+	v, ok := obj[path[0]]
+	if !ok {
+		return
+	}
+
+	if len(path) == 1 {
+		obj[path[0]] = Redacted
+		return
+	}
+
+	if child, ok := v.(map[string]interface{}); ok {
+		redactField(child, path[1:])
+	}
+}
+
+func (r redactor) RedactPatch(gk schema.GroupKind, patchJSON []byte) []byte {
+	fields, ok := r.rules[gk]
+	if !ok {
+		return patchJSON
+	}
+
+	ops := []map[string]interface{}{}
+	if err := json.Unmarshal(patchJSON, &ops); err != nil {
+		return []byte(Redacted)
+	}
+
+	for _, op := range ops {
+		path, _ := op["path"].(string)
+		for _, f := range fields {
+			fpath := "/" + strings.Join(f, "/")
+			switch {
+			// The operation is on the redacted field or inside it.
+			case path == fpath || strings.HasPrefix(path, fpath+"/"):
+				if _, ok := op["value"]; ok {
+					op["value"] = Redacted
+				}
+			// The operation is on a parent of the redacted field.
+			case strings.HasPrefix(fpath, path+"/"):
+				if value, ok := op["value"].(map[string]interface{}); ok {
+					redactField(value, strings.Split(strings.TrimPrefix(fpath, path+"/"), "/"))
+				}
+			}
+		}
+	}
+
+	res, err := json.Marshal(ops)
+	if err != nil {
+		return []byte(Redacted)
+	}
+	return res
+}
@@ -0,0 +1,69 @@
+package log_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	"github.com/slok/kubewebhook/pkg/log"
+)
+
+func TestRedactor(t *testing.T) {
+	secretGK := schema.GroupKind{Kind: "Secret"}
+	crdGK := schema.GroupKind{Group: "db.slok.dev", Kind: "Database"}
+
+	tests := map[string]struct {
+		redactor log.Redactor
+		gk       schema.GroupKind
+		obj      string
+		patch    string
+		expObj   string
+		expPatch string
+	}{
+		"A secret should have its data redacted.": {
+			redactor: log.DefaultRedactor,
+			gk:       secretGK,
+			obj:      `{"kind":"Secret","metadata":{"name":"test"},"data":{"password":"c2VjcmV0"},"stringData":{"user":"admin"}}`,
+			patch:    `[{"op":"add","path":"/data/token","value":"dG9rZW4="},{"op":"remove","path":"/stringData/user"},{"op":"add","path":"/metadata/labels","value":{"a":"b"}}]`,
+			expObj:   `{"kind":"Secret","metadata":{"name":"test"},"data":"REDACTED","stringData":"REDACTED"}`,
+			expPatch: `[{"op":"add","path":"/data/token","value":"REDACTED"},{"op":"remove","path":"/stringData/user"},{"op":"add","path":"/metadata/labels","value":{"a":"b"}}]`,
+		},
+
+		"A patch replacing a parent of the secret data should have the data redacted.": {
+			redactor: log.DefaultRedactor,
+			gk:       secretGK,
+			obj:      `{"kind":"Secret"}`,
+			patch:    `[{"op":"replace","path":"","value":{"kind":"Secret","data":{"password":"c2VjcmV0"}}}]`,
+			expObj:   `{"kind":"Secret"}`,
+			expPatch: `[{"op":"replace","path":"","value":{"kind":"Secret","data":"REDACTED"}}]`,
+		},
+
+		"A not sensitive kind should not be redacted.": {
+			redactor: log.DefaultRedactor,
+			gk:       schema.GroupKind{Kind: "Pod"},
+			obj:      `{"kind":"Pod","data":{"a":"b"}}`,
+			patch:    `[{"op":"add","path":"/data/a","value":"b"}]`,
+			expObj:   `{"kind":"Pod","data":{"a":"b"}}`,
+			expPatch: `[{"op":"add","path":"/data/a","value":"b"}]`,
+		},
+
+		"A custom rule should redact the custom kind nested fields.": {
+			redactor: log.NewRedactor(log.RedactRule{GroupKind: crdGK, Fields: []string{"spec.credentials.password"}}),
+			gk:       crdGK,
+			obj:      `{"kind":"Database","spec":{"credentials":{"user":"admin","password":"secret"}}}`,
+			patch:    `[{"op":"add","path":"/spec/credentials","value":{"user":"admin","password":"secret"}}]`,
+			expObj:   `{"kind":"Database","spec":{"credentials":{"user":"admin","password":"REDACTED"}}}`,
+			expPatch: `[{"op":"add","path":"/spec/credentials","value":{"user":"admin","password":"REDACTED"}}]`,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+
+			assert.JSONEq(test.expObj, string(test.redactor.RedactObject(test.gk, []byte(test.obj))))
+			assert.JSONEq(test.expPatch, string(test.redactor.RedactPatch(test.gk, []byte(test.patch))))
+		})
+	}
+}
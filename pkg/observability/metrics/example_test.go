@@ -12,6 +12,7 @@ import (
 	whhttp "github.com/slok/kubewebhook/pkg/http"
 	"github.com/slok/kubewebhook/pkg/observability/metrics"
 	"github.com/slok/kubewebhook/pkg/webhook/mutating"
+	"github.com/slok/kubewebhook/pkg/webhook/validating"
 )
 
 // Prometheus shows how to serve a webhook and its prometheus metrics in a separate server.
@@ -49,3 +50,25 @@ func ExamplePrometheus_servePrometheusMetrics() {
 		_ = http.ListenAndServe(":8081", promHandler)
 	}()
 }
+
+// PrometheusMultipleWebhooks shows how to share the same metrics recorder between multiple webhooks.
+func ExamplePrometheus_multipleWebhooks() {
+	// Create a single metrics recorder for all the webhooks, the metrics
+	// are labeled with the webhook name.
+	metricsRec := metrics.NewPrometheus(prometheus.NewRegistry())
+
+	// Create stub mutator and validator.
+	m := mutating.MutatorFunc(func(_ context.Context, obj metav1.Object) (bool, error) {
+		return false, nil
+	})
+	v := validating.ValidatorFunc(func(_ context.Context, obj metav1.Object) (bool, validating.ValidatorResult, error) {
+		return false, validating.ValidatorResult{Valid: true}, nil
+	})
+
+	// Create webhooks sharing the recorder (don't check error).
+	mcfg := mutating.WebhookConfig{Name: "mutatingWebhook", Obj: &corev1.Pod{}}
+	_, _ = mutating.NewWebhook(mcfg, m, nil, metricsRec, nil)
+
+	vcfg := validating.WebhookConfig{Name: "validatingWebhook", Obj: &corev1.Pod{}}
+	_, _ = validating.NewWebhook(vcfg, v, nil, metricsRec, nil)
+}
@@ -20,7 +20,39 @@ const (
 	ValidatingReviewKind ReviewKind = "validating"
 )
 
+// Fail-open reasons, these are the reasons the webhooks admit the objects without
+// mutating or validating them.
+const (
+	// FailOpenReasonKindMismatch is used when the request kind doesn't match the webhook object kind.
+	FailOpenReasonKindMismatch = "kind_mismatch"
+	// FailOpenReasonDecodeError is used when the object can't be decoded.
+	FailOpenReasonDecodeError = "decode_error"
+	// FailOpenReasonPanic is used when the mutator panics and the fallback mutator is used.
+	FailOpenReasonPanic = "panic"
+	// FailOpenReasonCircuitOpen is used when the webhook circuit breaker is open.
+	FailOpenReasonCircuitOpen = "circuit_open"
+	// FailOpenReasonMutatorError is used when an optional chain mutator fails and is skipped.
+	FailOpenReasonMutatorError = "mutator_error"
+)
+
+// CircuitBreakerState is the state of a webhook circuit breaker.
+type CircuitBreakerState string
+
+const (
+	// CircuitBreakerStateClosed is the state of the circuit breaker when the reviews are handled by the webhook.
+	CircuitBreakerStateClosed CircuitBreakerState = "closed"
+	// CircuitBreakerStateOpen is the state of the circuit breaker when the reviews are allowed without the webhook.
+	CircuitBreakerStateOpen CircuitBreakerState = "open"
+	// CircuitBreakerStateHalfOpen is the state of the circuit breaker when a trial review is handled by the webhook
+	// to check if the webhook has recovered.
+	CircuitBreakerStateHalfOpen CircuitBreakerState = "half_open"
+)
+
 // Recorder knows how to record metrics.
+//
+// The recorders can optionally implement the extension interfaces (e.g ExemplarRecorder,
+// MutationNoOpRecorder...) to record the metrics of the optional features, the features
+// will only record their metrics when the recorder implements its extension.
 type Recorder interface {
 	// IncAdmissionReview will increment in one the admission review counter.
 	IncAdmissionReview(webhook, namespace, resource string, operation Operation, kind ReviewKind)
@@ -32,6 +64,119 @@ type Recorder interface {
 	IncValidationReviewResult(webhook, namespace, resource string, operation Operation, allowed bool)
 }
 
+// ExemplarRecorder is an optional Recorder extension that knows how to observe the admission
+// review durations with exemplars, the exemplar labels (e.g the admission review UID) correlate
+// the metrics with the logs and traces.
+type ExemplarRecorder interface {
+	// ObserveAdmissionReviewDurationWithExemplar will observe the duration of a admission review with an exemplar.
+	ObserveAdmissionReviewDurationWithExemplar(webhook, namespace, resource string, operation Operation, kind ReviewKind, start time.Time, exemplar map[string]string)
+}
+
+// ReplicasClampedRecorder is an optional Recorder extension that knows how to record the
+// workloads that had their replicas clamped by the max replicas mutator.
+type ReplicasClampedRecorder interface {
+	// IncReplicasClamped will increment in one the counter of workloads that had their replicas clamped.
+	IncReplicasClamped(namespace, kind string)
+}
+
+// MutatorErrorSkippedRecorder is an optional Recorder extension that knows how to record the
+// optional mutator errors skipped by the mutator chains.
+type MutatorErrorSkippedRecorder interface {
+	// IncMutatorErrorSkipped will increment in one the counter of optional mutator errors that have been skipped.
+	IncMutatorErrorSkipped(webhook, mutator string)
+}
+
+// MutationNoOpRecorder is an optional Recorder extension that knows how to record the mutating
+// reviews that didn't mutate the object.
+type MutationNoOpRecorder interface {
+	// IncMutationNoOp will increment in one the counter of mutating reviews that didn't mutate the object.
+	IncMutationNoOp(webhook string)
+}
+
+// GoroutineGrowthRecorder is an optional Recorder extension that knows how to record the reviews
+// flagged by the goroutine guard.
+type GoroutineGrowthRecorder interface {
+	// IncGoroutineGrowthWarning will increment in one the counter of reviews that increased the number of goroutines unexpectedly.
+	IncGoroutineGrowthWarning(webhook string)
+}
+
+// AnnotationReadRecorder is an optional Recorder extension that knows how to record the
+// annotation reads outside of the annotation allowlist.
+type AnnotationReadRecorder interface {
+	// IncAnnotationReadNotAllowed will increment in one the counter of annotation reads outside of the allowlist.
+	IncAnnotationReadNotAllowed(webhook, annotation string)
+}
+
+// OwnerKindRecorder is an optional Recorder extension that knows how to record the admission
+// reviews by the reviewed object controller owner kind.
+type OwnerKindRecorder interface {
+	// IncAdmissionReviewOwnerKind will increment in one the admission review counter by the reviewed object controller owner kind.
+	IncAdmissionReviewOwnerKind(webhook, ownerKind string)
+}
+
+// ObjectSizeRecorder is an optional Recorder extension that knows how to record the size of the
+// admission review objects.
+type ObjectSizeRecorder interface {
+	// ObserveAdmissionReviewObjectSize will observe the size in bytes of the admission review object.
+	ObserveAdmissionReviewObjectSize(webhook, kind string, size int)
+}
+
+// HTTPHandlerDurationRecorder is an optional Recorder extension that knows how to record the
+// total duration of the webhook HTTP handlers.
+type HTTPHandlerDurationRecorder interface {
+	// ObserveHTTPHandlerDuration will observe the total duration of the HTTP handler, including the request read and the response write.
+	ObserveHTTPHandlerDuration(webhook string, start time.Time)
+}
+
+// SlowReviewRecorder is an optional Recorder extension that knows how to record the admission
+// reviews that exceeded the slow threshold.
+type SlowReviewRecorder interface {
+	// IncAdmissionReviewSlow will increment in one the admission reviews that exceeded the slow threshold counter.
+	IncAdmissionReviewSlow(webhook string)
+}
+
+// FailOpenRecorder is an optional Recorder extension that knows how to record the reviews
+// admitted by a fail-open path.
+type FailOpenRecorder interface {
+	// IncWebhookFailOpen will increment in one the counter of reviews admitted by a fail-open path (e.g decode error allowed kinds).
+	IncWebhookFailOpen(webhook, reason string)
+}
+
+// CircuitBreakerRecorder is an optional Recorder extension that knows how to record the webhook
+// circuit breaker states.
+type CircuitBreakerRecorder interface {
+	// SetCircuitBreakerState will set the current state of the webhook circuit breaker.
+	SetCircuitBreakerState(webhook string, state CircuitBreakerState)
+}
+
+// PatchRoundTripRecorder is an optional Recorder extension that knows how to record the mutating
+// reviews with patches that don't round-trip.
+type PatchRoundTripRecorder interface {
+	// IncPatchRoundTripError will increment in one the counter of mutating reviews with a patch that doesn't round-trip through the object scheme.
+	IncPatchRoundTripError(webhook string)
+}
+
+// SelfTestRecorder is an optional Recorder extension that knows how to record the webhook self-
+// test results.
+type SelfTestRecorder interface {
+	// IncSelfTestResult will increment in one the counter of the webhook self-test case results.
+	IncSelfTestResult(webhook, testCase string, passed bool)
+}
+
+// NearDeadlineRecorder is an optional Recorder extension that knows how to record the admission
+// reviews close to the expected webhook timeout.
+type NearDeadlineRecorder interface {
+	// IncAdmissionReviewNearDeadline will increment in one the admission reviews that exceeded the deadline warning threshold of the expected timeout counter.
+	IncAdmissionReviewNearDeadline(webhook string)
+}
+
+// PatchEffectiveRecorder is an optional Recorder extension that knows how to record if the
+// webhook mutations are present on the admitted objects.
+type PatchEffectiveRecorder interface {
+	// IncPatchEffective will increment in one the counter of the admitted objects checked for the webhook mutation, by the mutation being present or not.
+	IncPatchEffective(webhook string, effective bool)
+}
+
 // Dummy is a dummy recorder useful for tests.
 var Dummy = &dummy{}
 
@@ -0,0 +1,191 @@
+package metrics
+
+import (
+	"time"
+
+	"github.com/slok/kubewebhook/pkg/log"
+)
+
+// MultiRecorder returns a recorder that records every metric on all the recorders, useful to
+// record the metrics on multiple backends at the same time (e.g migrating from Prometheus to OpenTelemetry).
+// A recorder panicking will not stop the recording on the other recorders, the panic will be logged
+// with the logger (if nil `log.Dummy` will be used).
+//
+// The returned recorder implements all the optional Recorder extensions, the extension metrics
+// are only recorded on the recorders that implement the extension. The recorders that don't
+// support exemplars will observe the durations without them.
+func MultiRecorder(logger log.Logger, recorders ...Recorder) Recorder {
+	if logger == nil {
+		logger = log.Dummy
+	}
+
+	return multiRecorder{recorders: recorders, logger: logger}
+}
+
+type multiRecorder struct {
+	recorders []Recorder
+	logger    log.Logger
+}
+
+// record calls the record function for all the recorders, isolating them from the panics
+// of the other recorders.
+func (m multiRecorder) record(f func(r Recorder)) {
+	for _, r := range m.recorders {
+		func() {
+			defer func() {
+				if rec := recover(); rec != nil {
+					m.logger.Errorf("%T metrics recorder panicked: %v", r, rec)
+				}
+			}()
+			f(r)
+		}()
+	}
+}
+
+func (m multiRecorder) IncAdmissionReview(webhook, namespace, resource string, operation Operation, kind ReviewKind) {
+	m.record(func(r Recorder) { r.IncAdmissionReview(webhook, namespace, resource, operation, kind) })
+}
+
+func (m multiRecorder) IncAdmissionReviewError(webhook, namespace, resource string, operation Operation, kind ReviewKind) {
+	m.record(func(r Recorder) { r.IncAdmissionReviewError(webhook, namespace, resource, operation, kind) })
+}
+
+func (m multiRecorder) ObserveAdmissionReviewDuration(webhook, namespace, resource string, operation Operation, kind ReviewKind, start time.Time) {
+	m.record(func(r Recorder) {
+		r.ObserveAdmissionReviewDuration(webhook, namespace, resource, operation, kind, start)
+	})
+}
+
+func (m multiRecorder) ObserveAdmissionReviewDurationWithExemplar(webhook, namespace, resource string, operation Operation, kind ReviewKind, start time.Time, exemplar map[string]string) {
+	m.record(func(r Recorder) {
+		if er, ok := r.(ExemplarRecorder); ok {
+			er.ObserveAdmissionReviewDurationWithExemplar(webhook, namespace, resource, operation, kind, start, exemplar)
+			return
+		}
+		r.ObserveAdmissionReviewDuration(webhook, namespace, resource, operation, kind, start)
+	})
+}
+
+func (m multiRecorder) IncValidationReviewResult(webhook, namespace, resource string, operation Operation, allowed bool) {
+	m.record(func(r Recorder) { r.IncValidationReviewResult(webhook, namespace, resource, operation, allowed) })
+}
+
+func (m multiRecorder) IncReplicasClamped(namespace, kind string) {
+	m.record(func(r Recorder) {
+		if er, ok := r.(ReplicasClampedRecorder); ok {
+			er.IncReplicasClamped(namespace, kind)
+		}
+	})
+}
+
+func (m multiRecorder) IncMutatorErrorSkipped(webhook, mutator string) {
+	m.record(func(r Recorder) {
+		if er, ok := r.(MutatorErrorSkippedRecorder); ok {
+			er.IncMutatorErrorSkipped(webhook, mutator)
+		}
+	})
+}
+
+func (m multiRecorder) IncMutationNoOp(webhook string) {
+	m.record(func(r Recorder) {
+		if er, ok := r.(MutationNoOpRecorder); ok {
+			er.IncMutationNoOp(webhook)
+		}
+	})
+}
+
+func (m multiRecorder) IncGoroutineGrowthWarning(webhook string) {
+	m.record(func(r Recorder) {
+		if er, ok := r.(GoroutineGrowthRecorder); ok {
+			er.IncGoroutineGrowthWarning(webhook)
+		}
+	})
+}
+
+func (m multiRecorder) IncAnnotationReadNotAllowed(webhook, annotation string) {
+	m.record(func(r Recorder) {
+		if er, ok := r.(AnnotationReadRecorder); ok {
+			er.IncAnnotationReadNotAllowed(webhook, annotation)
+		}
+	})
+}
+
+func (m multiRecorder) IncAdmissionReviewOwnerKind(webhook, ownerKind string) {
+	m.record(func(r Recorder) {
+		if er, ok := r.(OwnerKindRecorder); ok {
+			er.IncAdmissionReviewOwnerKind(webhook, ownerKind)
+		}
+	})
+}
+
+func (m multiRecorder) ObserveAdmissionReviewObjectSize(webhook, kind string, size int) {
+	m.record(func(r Recorder) {
+		if er, ok := r.(ObjectSizeRecorder); ok {
+			er.ObserveAdmissionReviewObjectSize(webhook, kind, size)
+		}
+	})
+}
+
+func (m multiRecorder) ObserveHTTPHandlerDuration(webhook string, start time.Time) {
+	m.record(func(r Recorder) {
+		if er, ok := r.(HTTPHandlerDurationRecorder); ok {
+			er.ObserveHTTPHandlerDuration(webhook, start)
+		}
+	})
+}
+
+func (m multiRecorder) IncAdmissionReviewSlow(webhook string) {
+	m.record(func(r Recorder) {
+		if er, ok := r.(SlowReviewRecorder); ok {
+			er.IncAdmissionReviewSlow(webhook)
+		}
+	})
+}
+
+func (m multiRecorder) IncWebhookFailOpen(webhook, reason string) {
+	m.record(func(r Recorder) {
+		if er, ok := r.(FailOpenRecorder); ok {
+			er.IncWebhookFailOpen(webhook, reason)
+		}
+	})
+}
+
+func (m multiRecorder) SetCircuitBreakerState(webhook string, state CircuitBreakerState) {
+	m.record(func(r Recorder) {
+		if er, ok := r.(CircuitBreakerRecorder); ok {
+			er.SetCircuitBreakerState(webhook, state)
+		}
+	})
+}
+
+func (m multiRecorder) IncPatchRoundTripError(webhook string) {
+	m.record(func(r Recorder) {
+		if er, ok := r.(PatchRoundTripRecorder); ok {
+			er.IncPatchRoundTripError(webhook)
+		}
+	})
+}
+
+func (m multiRecorder) IncSelfTestResult(webhook, testCase string, passed bool) {
+	m.record(func(r Recorder) {
+		if er, ok := r.(SelfTestRecorder); ok {
+			er.IncSelfTestResult(webhook, testCase, passed)
+		}
+	})
+}
+
+func (m multiRecorder) IncAdmissionReviewNearDeadline(webhook string) {
+	m.record(func(r Recorder) {
+		if er, ok := r.(NearDeadlineRecorder); ok {
+			er.IncAdmissionReviewNearDeadline(webhook)
+		}
+	})
+}
+
+func (m multiRecorder) IncPatchEffective(webhook string, effective bool) {
+	m.record(func(r Recorder) {
+		if er, ok := r.(PatchEffectiveRecorder); ok {
+			er.IncPatchEffective(webhook, effective)
+		}
+	})
+}
@@ -0,0 +1,189 @@
+package metrics_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	admissionv1beta1 "k8s.io/api/admission/v1beta1"
+
+	mmetrics "github.com/slok/kubewebhook/mocks/observability/metrics"
+	"github.com/slok/kubewebhook/pkg/internal/testutil"
+	"github.com/slok/kubewebhook/pkg/log"
+	"github.com/slok/kubewebhook/pkg/observability/metrics"
+)
+
+func TestMultiRecorder(t *testing.T) {
+	now := time.Now()
+
+	tests := map[string]struct {
+		record  func(r metrics.Recorder)
+		method  string
+		expArgs []interface{}
+	}{
+		"IncAdmissionReview should be recorded on all the recorders.": {
+			record: func(r metrics.Recorder) {
+				r.IncAdmissionReview("wh", "ns", "v1/pods", admissionv1beta1.Create, metrics.MutatingReviewKind)
+			},
+			method:  "IncAdmissionReview",
+			expArgs: []interface{}{"wh", "ns", "v1/pods", admissionv1beta1.Create, metrics.MutatingReviewKind},
+		},
+		"IncAdmissionReviewError should be recorded on all the recorders.": {
+			record: func(r metrics.Recorder) {
+				r.IncAdmissionReviewError("wh", "ns", "v1/pods", admissionv1beta1.Create, metrics.MutatingReviewKind)
+			},
+			method:  "IncAdmissionReviewError",
+			expArgs: []interface{}{"wh", "ns", "v1/pods", admissionv1beta1.Create, metrics.MutatingReviewKind},
+		},
+		"ObserveAdmissionReviewDuration should be recorded on all the recorders.": {
+			record: func(r metrics.Recorder) {
+				r.ObserveAdmissionReviewDuration("wh", "ns", "v1/pods", admissionv1beta1.Create, metrics.MutatingReviewKind, now)
+			},
+			method:  "ObserveAdmissionReviewDuration",
+			expArgs: []interface{}{"wh", "ns", "v1/pods", admissionv1beta1.Create, metrics.MutatingReviewKind, now},
+		},
+		"ObserveAdmissionReviewDurationWithExemplar should be recorded without exemplar on the recorders without exemplar support.": {
+			record: func(r metrics.Recorder) {
+				r.(metrics.ExemplarRecorder).ObserveAdmissionReviewDurationWithExemplar("wh", "ns", "v1/pods", admissionv1beta1.Create, metrics.MutatingReviewKind, now, map[string]string{"uid": "test"})
+			},
+			method:  "ObserveAdmissionReviewDuration",
+			expArgs: []interface{}{"wh", "ns", "v1/pods", admissionv1beta1.Create, metrics.MutatingReviewKind, now},
+		},
+		"IncValidationReviewResult should be recorded on all the recorders.": {
+			record: func(r metrics.Recorder) {
+				r.IncValidationReviewResult("wh", "ns", "v1/pods", admissionv1beta1.Create, true)
+			},
+			method:  "IncValidationReviewResult",
+			expArgs: []interface{}{"wh", "ns", "v1/pods", admissionv1beta1.Create, true},
+		},
+		"IncReplicasClamped should be recorded on all the recorders.": {
+			record:  func(r metrics.Recorder) { r.(metrics.ReplicasClampedRecorder).IncReplicasClamped("ns", "Deployment") },
+			method:  "IncReplicasClamped",
+			expArgs: []interface{}{"ns", "Deployment"},
+		},
+		"IncMutatorErrorSkipped should be recorded on all the recorders.": {
+			record:  func(r metrics.Recorder) { r.(metrics.MutatorErrorSkippedRecorder).IncMutatorErrorSkipped("wh", "m") },
+			method:  "IncMutatorErrorSkipped",
+			expArgs: []interface{}{"wh", "m"},
+		},
+		"IncMutationNoOp should be recorded on all the recorders.": {
+			record:  func(r metrics.Recorder) { r.(metrics.MutationNoOpRecorder).IncMutationNoOp("wh") },
+			method:  "IncMutationNoOp",
+			expArgs: []interface{}{"wh"},
+		},
+		"IncGoroutineGrowthWarning should be recorded on all the recorders.": {
+			record:  func(r metrics.Recorder) { r.(metrics.GoroutineGrowthRecorder).IncGoroutineGrowthWarning("wh") },
+			method:  "IncGoroutineGrowthWarning",
+			expArgs: []interface{}{"wh"},
+		},
+		"IncAnnotationReadNotAllowed should be recorded on all the recorders.": {
+			record:  func(r metrics.Recorder) { r.(metrics.AnnotationReadRecorder).IncAnnotationReadNotAllowed("wh", "a") },
+			method:  "IncAnnotationReadNotAllowed",
+			expArgs: []interface{}{"wh", "a"},
+		},
+		"IncAdmissionReviewOwnerKind should be recorded on all the recorders.": {
+			record: func(r metrics.Recorder) {
+				r.(metrics.OwnerKindRecorder).IncAdmissionReviewOwnerKind("wh", "ReplicaSet")
+			},
+			method:  "IncAdmissionReviewOwnerKind",
+			expArgs: []interface{}{"wh", "ReplicaSet"},
+		},
+		"ObserveAdmissionReviewObjectSize should be recorded on all the recorders.": {
+			record: func(r metrics.Recorder) {
+				r.(metrics.ObjectSizeRecorder).ObserveAdmissionReviewObjectSize("wh", "Pod", 1024)
+			},
+			method:  "ObserveAdmissionReviewObjectSize",
+			expArgs: []interface{}{"wh", "Pod", 1024},
+		},
+		"ObserveHTTPHandlerDuration should be recorded on all the recorders.": {
+			record: func(r metrics.Recorder) {
+				r.(metrics.HTTPHandlerDurationRecorder).ObserveHTTPHandlerDuration("wh", now)
+			},
+			method:  "ObserveHTTPHandlerDuration",
+			expArgs: []interface{}{"wh", now},
+		},
+		"IncAdmissionReviewSlow should be recorded on all the recorders.": {
+			record:  func(r metrics.Recorder) { r.(metrics.SlowReviewRecorder).IncAdmissionReviewSlow("wh") },
+			method:  "IncAdmissionReviewSlow",
+			expArgs: []interface{}{"wh"},
+		},
+		"IncWebhookFailOpen should be recorded on all the recorders.": {
+			record: func(r metrics.Recorder) {
+				r.(metrics.FailOpenRecorder).IncWebhookFailOpen("wh", metrics.FailOpenReasonPanic)
+			},
+			method:  "IncWebhookFailOpen",
+			expArgs: []interface{}{"wh", metrics.FailOpenReasonPanic},
+		},
+		"SetCircuitBreakerState should be recorded on all the recorders.": {
+			record: func(r metrics.Recorder) {
+				r.(metrics.CircuitBreakerRecorder).SetCircuitBreakerState("wh", metrics.CircuitBreakerStateOpen)
+			},
+			method:  "SetCircuitBreakerState",
+			expArgs: []interface{}{"wh", metrics.CircuitBreakerStateOpen},
+		},
+		"IncPatchRoundTripError should be recorded on all the recorders.": {
+			record:  func(r metrics.Recorder) { r.(metrics.PatchRoundTripRecorder).IncPatchRoundTripError("wh") },
+			method:  "IncPatchRoundTripError",
+			expArgs: []interface{}{"wh"},
+		},
+		"IncSelfTestResult should be recorded on all the recorders.": {
+			record:  func(r metrics.Recorder) { r.(metrics.SelfTestRecorder).IncSelfTestResult("wh", "pod", true) },
+			method:  "IncSelfTestResult",
+			expArgs: []interface{}{"wh", "pod", true},
+		},
+		"IncAdmissionReviewNearDeadline should be recorded on all the recorders.": {
+			record:  func(r metrics.Recorder) { r.(metrics.NearDeadlineRecorder).IncAdmissionReviewNearDeadline("wh") },
+			method:  "IncAdmissionReviewNearDeadline",
+			expArgs: []interface{}{"wh"},
+		},
+		"IncPatchEffective should be recorded on all the recorders.": {
+			record:  func(r metrics.Recorder) { r.(metrics.PatchEffectiveRecorder).IncPatchEffective("wh", true) },
+			method:  "IncPatchEffective",
+			expArgs: []interface{}{"wh", true},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			mrec1 := &mmetrics.Recorder{}
+			mrec1.On(test.method, test.expArgs...).Once()
+			mrec2 := &mmetrics.Recorder{}
+			mrec2.On(test.method, test.expArgs...).Once()
+
+			test.record(metrics.MultiRecorder(log.Dummy, mrec1, mrec2))
+
+			mrec1.AssertExpectations(t)
+			mrec2.AssertExpectations(t)
+		})
+	}
+}
+
+func TestMultiRecorderPanic(t *testing.T) {
+	assert := assert.New(t)
+
+	// The first recorder doesn't expect any call so it will panic.
+	mrec1 := &mmetrics.Recorder{}
+	mrec2 := &mmetrics.Recorder{}
+	mrec2.On("IncMutationNoOp", "wh").Once()
+
+	logger := &testutil.Logger{}
+	rec := metrics.MultiRecorder(logger, mrec1, mrec2)
+	assert.NotPanics(func() { rec.(metrics.MutationNoOpRecorder).IncMutationNoOp("wh") })
+
+	// The panic should be logged and the other recorder should record the metric.
+	mrec2.AssertExpectations(t)
+	if assert.Len(logger.Errors, 1) {
+		assert.Contains(logger.Errors[0], "*metrics.Recorder metrics recorder panicked")
+	}
+}
+
+func TestMultiRecorderWithoutExtension(t *testing.T) {
+	// The dummy recorder doesn't implement the extensions, so only the mock will record them.
+	mrec := &mmetrics.Recorder{}
+	mrec.On("IncMutationNoOp", "wh").Once()
+
+	rec := metrics.MultiRecorder(nil, metrics.Dummy, mrec)
+	rec.(metrics.MutationNoOpRecorder).IncMutationNoOp("wh")
+
+	mrec.AssertExpectations(t)
+}
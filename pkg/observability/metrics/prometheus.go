@@ -1,28 +1,61 @@
 package metrics
 
 import (
+	"fmt"
+	"net/http"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	dto "github.com/prometheus/client_model/go"
+
+	"github.com/slok/kubewebhook/pkg/log"
 )
 
 const (
 	promNamespace        = "kubewebhook"
 	promWebhookSubsystem = "admission_webhook"
+	promMutatorSubsystem = "mutator"
 )
 
 // Prometheus is the implementation of a metrics Recorder for
 // Prometheus system.
+//
+// The recommended usage is to create a single recorder and share it between all
+// the webhooks, the metrics are labeled with the webhook name. Anyway, creating
+// multiple recorders on the same registry is safe, the already registered metrics
+// will be reused.
 type Prometheus struct {
 	// Metrics.
-	admissionReview         *prometheus.CounterVec
-	admissionReviewErr      *prometheus.CounterVec
-	admissionReviewDuration *prometheus.HistogramVec
+	admissionReview           *prometheus.CounterVec
+	admissionReviewErr        *prometheus.CounterVec
+	admissionReviewDuration   *prometheus.HistogramVec
+	admissionReviewObjectSize *prometheus.HistogramVec
 	// Validation Metrics
 	validationReviewResult *prometheus.CounterVec
+	// Mutator metrics.
+	replicasClamped             *prometheus.CounterVec
+	mutatorErrorSkipped         *prometheus.CounterVec
+	mutationNoOp                *prometheus.CounterVec
+	goroutineGrowthWarning      *prometheus.CounterVec
+	annotationReadNotAllowed    *prometheus.CounterVec
+	admissionReviewOwnerKind    *prometheus.CounterVec
+	admissionReviewSlow         *prometheus.CounterVec
+	webhookFailOpen             *prometheus.CounterVec
+	circuitBreakerState         *prometheus.GaugeVec
+	patchRoundTripError         *prometheus.CounterVec
+	selfTestResult              *prometheus.CounterVec
+	admissionReviewNearDeadline *prometheus.CounterVec
+	patchEffective              *prometheus.CounterVec
+	// HTTP metrics.
+	httpHandlerDuration *prometheus.HistogramVec
+	// Application metrics.
+	buildInfo *prometheus.GaugeVec
 
-	reg prometheus.Registerer
+	reg        prometheus.Registerer
+	collectors []prometheus.Collector
 }
 
 // NewPrometheus returns a new Prometheus metrics backend.
@@ -51,12 +84,111 @@ func NewPrometheus(registry prometheus.Registerer) *Prometheus {
 			Help:      "The duration of the admission review.",
 		}, []string{"webhook", "namespace", "resource", "operation", "kind"}),
 
+		admissionReviewObjectSize: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: promNamespace,
+			Subsystem: promWebhookSubsystem,
+			Name:      "admission_review_object_size_bytes",
+			Help:      "The size of the admission review objects.",
+			// From 256B to 4MB.
+			Buckets: prometheus.ExponentialBuckets(256, 4, 8),
+		}, []string{"webhook", "kind"}),
+
 		validationReviewResult: prometheus.NewCounterVec(prometheus.CounterOpts{
 			Namespace: promNamespace,
 			Subsystem: promWebhookSubsystem,
 			Name:      "validation_review_results_total",
 			Help:      "Total number of validation reviews",
 		}, []string{"webhook", "namespace", "resource", "operation", "allowed"}),
+
+		replicasClamped: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: promNamespace,
+			Subsystem: promMutatorSubsystem,
+			Name:      "replicas_clamped_total",
+			Help:      "Total number of workloads that had their replicas clamped.",
+		}, []string{"namespace", "kind"}),
+		mutatorErrorSkipped: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: promNamespace,
+			Subsystem: promMutatorSubsystem,
+			Name:      "skipped_errors_total",
+			Help:      "Total number of optional mutator errors that have been skipped.",
+		}, []string{"webhook", "mutator"}),
+		mutationNoOp: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: promNamespace,
+			Subsystem: promWebhookSubsystem,
+			Name:      "mutation_noop_total",
+			Help:      "Total number of mutating admission reviews that didn't mutate the object.",
+		}, []string{"webhook"}),
+		goroutineGrowthWarning: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: promNamespace,
+			Subsystem: promWebhookSubsystem,
+			Name:      "goroutine_growth_warnings_total",
+			Help:      "Total number of admission reviews that increased the number of goroutines unexpectedly.",
+		}, []string{"webhook"}),
+		annotationReadNotAllowed: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: promNamespace,
+			Subsystem: promWebhookSubsystem,
+			Name:      "annotation_reads_not_allowed_total",
+			Help:      "Total number of annotation reads outside of the allowlist.",
+		}, []string{"webhook", "annotation"}),
+		admissionReviewOwnerKind: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: promNamespace,
+			Subsystem: promWebhookSubsystem,
+			Name:      "admission_reviews_owner_kind_total",
+			Help:      "Total number of admission reviews by the reviewed object controller owner kind.",
+		}, []string{"webhook", "owner_kind"}),
+		httpHandlerDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: promNamespace,
+			Subsystem: promWebhookSubsystem,
+			Name:      "http_handler_duration_seconds",
+			Help:      "The total duration of the HTTP handler, including the request read and the response write.",
+		}, []string{"webhook"}),
+		admissionReviewSlow: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: promNamespace,
+			Subsystem: promWebhookSubsystem,
+			Name:      "admission_reviews_slow_total",
+			Help:      "Total number of admission reviews that took more than the slow threshold.",
+		}, []string{"webhook"}),
+		webhookFailOpen: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: promNamespace,
+			Subsystem: promWebhookSubsystem,
+			Name:      "fail_open_total",
+			Help:      "Total number of admission reviews admitted by a fail-open path.",
+		}, []string{"webhook", "reason"}),
+		circuitBreakerState: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: promNamespace,
+			Subsystem: promWebhookSubsystem,
+			Name:      "circuit_breaker_state",
+			Help:      "The state of the webhook circuit breaker, the current state has the value 1.",
+		}, []string{"webhook", "state"}),
+		patchRoundTripError: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: promNamespace,
+			Subsystem: promWebhookSubsystem,
+			Name:      "patch_round_trip_errors_total",
+			Help:      "Total number of mutating reviews with a patch that doesn't produce a valid object.",
+		}, []string{"webhook"}),
+		selfTestResult: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: promNamespace,
+			Subsystem: promWebhookSubsystem,
+			Name:      "self_test_results_total",
+			Help:      "Total number of webhook self-test case results.",
+		}, []string{"webhook", "case", "passed"}),
+		admissionReviewNearDeadline: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: promNamespace,
+			Subsystem: promWebhookSubsystem,
+			Name:      "admission_reviews_near_deadline_total",
+			Help:      "Total number of admission reviews close to the expected webhook timeout.",
+		}, []string{"webhook"}),
+		patchEffective: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: promNamespace,
+			Subsystem: promWebhookSubsystem,
+			Name:      "patch_effective_total",
+			Help:      "Total number of admitted objects checked for the webhook mutation, by the mutation being effective.",
+		}, []string{"webhook", "effective"}),
+		buildInfo: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: promNamespace,
+			Name:      "build_info",
+			Help:      "The build information of the application, the value is always 1.",
+		}, []string{"version", "commit"}),
 	}
 
 	p.registerMetrics()
@@ -64,12 +196,124 @@ func NewPrometheus(registry prometheus.Registerer) *Prometheus {
 }
 
 func (p *Prometheus) registerMetrics() {
-	p.reg.MustRegister(
-		p.admissionReview,
-		p.admissionReviewErr,
-		p.admissionReviewDuration,
-		p.validationReviewResult,
+	p.admissionReview = p.register(p.admissionReview).(*prometheus.CounterVec)
+	p.admissionReviewErr = p.register(p.admissionReviewErr).(*prometheus.CounterVec)
+	p.admissionReviewDuration = p.register(p.admissionReviewDuration).(*prometheus.HistogramVec)
+	p.admissionReviewObjectSize = p.register(p.admissionReviewObjectSize).(*prometheus.HistogramVec)
+	p.validationReviewResult = p.register(p.validationReviewResult).(*prometheus.CounterVec)
+	p.replicasClamped = p.register(p.replicasClamped).(*prometheus.CounterVec)
+	p.mutatorErrorSkipped = p.register(p.mutatorErrorSkipped).(*prometheus.CounterVec)
+	p.mutationNoOp = p.register(p.mutationNoOp).(*prometheus.CounterVec)
+	p.goroutineGrowthWarning = p.register(p.goroutineGrowthWarning).(*prometheus.CounterVec)
+	p.annotationReadNotAllowed = p.register(p.annotationReadNotAllowed).(*prometheus.CounterVec)
+	p.admissionReviewOwnerKind = p.register(p.admissionReviewOwnerKind).(*prometheus.CounterVec)
+	p.httpHandlerDuration = p.register(p.httpHandlerDuration).(*prometheus.HistogramVec)
+	p.admissionReviewSlow = p.register(p.admissionReviewSlow).(*prometheus.CounterVec)
+	p.circuitBreakerState = p.register(p.circuitBreakerState).(*prometheus.GaugeVec)
+	p.webhookFailOpen = p.register(p.webhookFailOpen).(*prometheus.CounterVec)
+	p.patchRoundTripError = p.register(p.patchRoundTripError).(*prometheus.CounterVec)
+	p.selfTestResult = p.register(p.selfTestResult).(*prometheus.CounterVec)
+	p.admissionReviewNearDeadline = p.register(p.admissionReviewNearDeadline).(*prometheus.CounterVec)
+	p.patchEffective = p.register(p.patchEffective).(*prometheus.CounterVec)
+	p.buildInfo = p.register(p.buildInfo).(*prometheus.GaugeVec)
+}
+
+// RegisterBuildInfo sets the `kubewebhook_build_info` metric with the version and commit of
+// the application using the webhooks, the metric value is always 1, this way the dashboards can
+// correlate the webhooks behavior with the deployed versions. It should be called once, after
+// creating the recorder, calling it again will replace the build information.
+func (p *Prometheus) RegisterBuildInfo(version, commit string) {
+	p.buildInfo.Reset()
+	p.buildInfo.WithLabelValues(version, commit).Set(1)
+}
+
+// NewPrometheusWithRuntimeMetrics returns a new Prometheus metrics backend on a new registry that
+// also has the standard Go runtime and process collectors, and the HTTP handler that serves
+// all the registry metrics. Useful to have a single scrape endpoint for the webhook and the
+// application runtime metrics.
+func NewPrometheusWithRuntimeMetrics() (*Prometheus, http.Handler) {
+	reg := prometheus.NewRegistry()
+	reg.MustRegister(
+		prometheus.NewGoCollector(),
+		prometheus.NewProcessCollector(prometheus.ProcessCollectorOpts{}),
 	)
+
+	return NewPrometheus(reg), promhttp.HandlerFor(reg, promhttp.HandlerOpts{})
+}
+
+// OpenMetricsHandler returns the HTTP handler that serves the metrics of the recorder registry
+// with OpenMetrics format support, this format is required to scrape the exemplars of the
+// admission review duration histogram. The recorder registry must be a gatherer (e.g
+// `prometheus.NewRegistry()` or `prometheus.DefaultRegisterer`).
+func (p *Prometheus) OpenMetricsHandler() (http.Handler, error) {
+	g, ok := p.reg.(prometheus.Gatherer)
+	if !ok {
+		return nil, fmt.Errorf("recorder registry is not a gatherer")
+	}
+
+	return promhttp.HandlerFor(g, promhttp.HandlerOpts{EnableOpenMetrics: true}), nil
+}
+
+// register registers the collector, if the collector has been already registered
+// (e.g multiple recorders on the same registry) it will return the registered one.
+func (p *Prometheus) register(c prometheus.Collector) prometheus.Collector {
+	err := p.reg.Register(c)
+	if err != nil {
+		are, ok := err.(prometheus.AlreadyRegisteredError)
+		if !ok {
+			panic(err)
+		}
+		c = are.ExistingCollector
+	}
+
+	p.collectors = append(p.collectors, c)
+	return c
+}
+
+// DumpMetrics logs the current values of the recorder metrics, the counters and gauges values,
+// and the histograms sample count and sum. Useful to have a final snapshot of the metrics on
+// the logs for post-mortem analysis (e.g on the shutdown of the application).
+func (p *Prometheus) DumpMetrics(logger log.Logger) error {
+	reg := prometheus.NewRegistry()
+	for _, c := range p.collectors {
+		if err := reg.Register(c); err != nil {
+			return fmt.Errorf("could not register the metrics to dump: %w", err)
+		}
+	}
+
+	mfs, err := reg.Gather()
+	if err != nil {
+		return fmt.Errorf("could not gather the metrics to dump: %w", err)
+	}
+
+	for _, mf := range mfs {
+		for _, m := range mf.GetMetric() {
+			name := mf.GetName() + metricLabelsString(m)
+			switch mf.GetType() {
+			case dto.MetricType_COUNTER:
+				logger.Infof("metric %s %v", name, m.GetCounter().GetValue())
+			case dto.MetricType_GAUGE:
+				logger.Infof("metric %s %v", name, m.GetGauge().GetValue())
+			case dto.MetricType_HISTOGRAM:
+				logger.Infof("metric %s count=%d sum=%v", name, m.GetHistogram().GetSampleCount(), m.GetHistogram().GetSampleSum())
+			}
+		}
+	}
+
+	return nil
+}
+
+func metricLabelsString(m *dto.Metric) string {
+	if len(m.GetLabel()) == 0 {
+		return ""
+	}
+
+	labels := make([]string, 0, len(m.GetLabel()))
+	for _, l := range m.GetLabel() {
+		labels = append(labels, fmt.Sprintf("%s=%q", l.GetName(), l.GetValue()))
+	}
+
+	return "{" + strings.Join(labels, ",") + "}"
 }
 
 // IncAdmissionReview satisfies Recorder interface.
@@ -103,6 +347,29 @@ func (p *Prometheus) ObserveAdmissionReviewDuration(webhook, namespace, resource
 		string(kind)).Observe(secs)
 }
 
+// ObserveAdmissionReviewObjectSize satisfies ObjectSizeRecorder interface.
+func (p *Prometheus) ObserveAdmissionReviewObjectSize(webhook, kind string, size int) {
+	p.admissionReviewObjectSize.WithLabelValues(webhook, kind).Observe(float64(size))
+}
+
+// ObserveAdmissionReviewDurationWithExemplar satisfies ExemplarRecorder interface.
+func (p *Prometheus) ObserveAdmissionReviewDurationWithExemplar(webhook, namespace, resource string, operation Operation, kind ReviewKind, start time.Time, exemplar map[string]string) {
+	secs := p.getDuration(start).Seconds()
+	o := p.admissionReviewDuration.WithLabelValues(
+		webhook,
+		namespace,
+		string(resource),
+		string(operation),
+		string(kind))
+
+	eo, ok := o.(prometheus.ExemplarObserver)
+	if !ok || len(exemplar) == 0 {
+		o.Observe(secs)
+		return
+	}
+	eo.ObserveWithExemplar(secs, prometheus.Labels(exemplar))
+}
+
 // IncValidationReviewResult satisfies Recorder interface.
 func (p *Prometheus) IncValidationReviewResult(webhook, namespace, resource string, operation Operation, allowed bool) {
 	p.validationReviewResult.WithLabelValues(
@@ -114,6 +381,82 @@ func (p *Prometheus) IncValidationReviewResult(webhook, namespace, resource stri
 	).Inc()
 }
 
+// IncReplicasClamped satisfies ReplicasClampedRecorder interface.
+func (p *Prometheus) IncReplicasClamped(namespace, kind string) {
+	p.replicasClamped.WithLabelValues(namespace, kind).Inc()
+}
+
+// IncMutatorErrorSkipped satisfies MutatorErrorSkippedRecorder interface.
+func (p *Prometheus) IncMutatorErrorSkipped(webhook, mutator string) {
+	p.mutatorErrorSkipped.WithLabelValues(webhook, mutator).Inc()
+}
+
+// IncMutationNoOp satisfies MutationNoOpRecorder interface.
+func (p *Prometheus) IncMutationNoOp(webhook string) {
+	p.mutationNoOp.WithLabelValues(webhook).Inc()
+}
+
+// IncGoroutineGrowthWarning satisfies GoroutineGrowthRecorder interface.
+func (p *Prometheus) IncGoroutineGrowthWarning(webhook string) {
+	p.goroutineGrowthWarning.WithLabelValues(webhook).Inc()
+}
+
+// IncAnnotationReadNotAllowed satisfies AnnotationReadRecorder interface.
+func (p *Prometheus) IncAnnotationReadNotAllowed(webhook, annotation string) {
+	p.annotationReadNotAllowed.WithLabelValues(webhook, annotation).Inc()
+}
+
+// IncAdmissionReviewOwnerKind satisfies OwnerKindRecorder interface.
+func (p *Prometheus) IncAdmissionReviewOwnerKind(webhook, ownerKind string) {
+	p.admissionReviewOwnerKind.WithLabelValues(webhook, ownerKind).Inc()
+}
+
+// ObserveHTTPHandlerDuration satisfies HTTPHandlerDurationRecorder interface.
+func (p *Prometheus) ObserveHTTPHandlerDuration(webhook string, start time.Time) {
+	p.httpHandlerDuration.WithLabelValues(webhook).Observe(p.getDuration(start).Seconds())
+}
+
+// IncAdmissionReviewSlow satisfies SlowReviewRecorder interface.
+func (p *Prometheus) IncAdmissionReviewSlow(webhook string) {
+	p.admissionReviewSlow.WithLabelValues(webhook).Inc()
+}
+
+// IncWebhookFailOpen satisfies FailOpenRecorder interface.
+func (p *Prometheus) IncWebhookFailOpen(webhook, reason string) {
+	p.webhookFailOpen.WithLabelValues(webhook, reason).Inc()
+}
+
+// SetCircuitBreakerState satisfies CircuitBreakerRecorder interface.
+func (p *Prometheus) SetCircuitBreakerState(webhook string, state CircuitBreakerState) {
+	for _, s := range []CircuitBreakerState{CircuitBreakerStateClosed, CircuitBreakerStateOpen, CircuitBreakerStateHalfOpen} {
+		var v float64
+		if s == state {
+			v = 1
+		}
+		p.circuitBreakerState.WithLabelValues(webhook, string(s)).Set(v)
+	}
+}
+
+// IncPatchRoundTripError satisfies PatchRoundTripRecorder interface.
+func (p *Prometheus) IncPatchRoundTripError(webhook string) {
+	p.patchRoundTripError.WithLabelValues(webhook).Inc()
+}
+
+// IncSelfTestResult satisfies SelfTestRecorder interface.
+func (p *Prometheus) IncSelfTestResult(webhook, testCase string, passed bool) {
+	p.selfTestResult.WithLabelValues(webhook, testCase, strconv.FormatBool(passed)).Inc()
+}
+
+// IncAdmissionReviewNearDeadline satisfies NearDeadlineRecorder interface.
+func (p *Prometheus) IncAdmissionReviewNearDeadline(webhook string) {
+	p.admissionReviewNearDeadline.WithLabelValues(webhook).Inc()
+}
+
+// IncPatchEffective satisfies PatchEffectiveRecorder interface.
+func (p *Prometheus) IncPatchEffective(webhook string, effective bool) {
+	p.patchEffective.WithLabelValues(webhook, strconv.FormatBool(effective)).Inc()
+}
+
 func (p *Prometheus) getDuration(start time.Time) time.Duration {
 	return time.Since(start)
 }
@@ -1,6 +1,7 @@
 package metrics_test
 
 import (
+	"context"
 	"io/ioutil"
 	"net/http"
 	"net/http/httptest"
@@ -10,9 +11,15 @@ import (
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	admissionv1beta1 "k8s.io/api/admission/v1beta1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime"
 
+	"github.com/slok/kubewebhook/pkg/internal/testutil"
+	"github.com/slok/kubewebhook/pkg/log"
 	"github.com/slok/kubewebhook/pkg/observability/metrics"
+	"github.com/slok/kubewebhook/pkg/webhook/mutating"
 )
 
 func TestPrometheus(t *testing.T) {
@@ -77,6 +84,185 @@ func TestPrometheus(t *testing.T) {
 				`kubewebhook_admission_webhook_validation_review_results_total{allowed="true",namespace="test",operation="UPDATE",resource="v1/ingress",webhook="testWH2"} 1`,
 			},
 		},
+		{
+			name: "Record replicas clamped counts should set the correct metrics",
+			recordMetrics: func(m metrics.Recorder) {
+				m.(metrics.ReplicasClampedRecorder).IncReplicasClamped("test", "Deployment")
+				m.(metrics.ReplicasClampedRecorder).IncReplicasClamped("test", "Deployment")
+				m.(metrics.ReplicasClampedRecorder).IncReplicasClamped("test2", "StatefulSet")
+			},
+			expMetrics: []string{
+				`kubewebhook_mutator_replicas_clamped_total{kind="Deployment",namespace="test"} 2`,
+				`kubewebhook_mutator_replicas_clamped_total{kind="StatefulSet",namespace="test2"} 1`,
+			},
+		},
+		{
+			name: "Record skipped mutator errors should set the correct metrics",
+			recordMetrics: func(m metrics.Recorder) {
+				m.(metrics.MutatorErrorSkippedRecorder).IncMutatorErrorSkipped("test", "enrichment")
+				m.(metrics.MutatorErrorSkippedRecorder).IncMutatorErrorSkipped("test", "enrichment")
+			},
+			expMetrics: []string{
+				`kubewebhook_mutator_skipped_errors_total{mutator="enrichment",webhook="test"} 2`,
+			},
+		},
+		{
+			name: "Record mutation no-ops should set the correct metrics",
+			recordMetrics: func(m metrics.Recorder) {
+				m.(metrics.MutationNoOpRecorder).IncMutationNoOp("test")
+				m.(metrics.MutationNoOpRecorder).IncMutationNoOp("test")
+				m.(metrics.MutationNoOpRecorder).IncMutationNoOp("test2")
+			},
+			expMetrics: []string{
+				`kubewebhook_admission_webhook_mutation_noop_total{webhook="test"} 2`,
+				`kubewebhook_admission_webhook_mutation_noop_total{webhook="test2"} 1`,
+			},
+		},
+		{
+			name: "Record goroutine growth warnings should set the correct metrics",
+			recordMetrics: func(m metrics.Recorder) {
+				m.(metrics.GoroutineGrowthRecorder).IncGoroutineGrowthWarning("test")
+			},
+			expMetrics: []string{
+				`kubewebhook_admission_webhook_goroutine_growth_warnings_total{webhook="test"} 1`,
+			},
+		},
+		{
+			name: "Record not allowed annotation reads should set the correct metrics",
+			recordMetrics: func(m metrics.Recorder) {
+				m.(metrics.AnnotationReadRecorder).IncAnnotationReadNotAllowed("test", "slok.dev/key")
+				m.(metrics.AnnotationReadRecorder).IncAnnotationReadNotAllowed("test", "slok.dev/key")
+			},
+			expMetrics: []string{
+				`kubewebhook_admission_webhook_annotation_reads_not_allowed_total{annotation="slok.dev/key",webhook="test"} 2`,
+			},
+		},
+		{
+			name: "Record admission reviews by owner kind should set the correct metrics",
+			recordMetrics: func(m metrics.Recorder) {
+				m.(metrics.OwnerKindRecorder).IncAdmissionReviewOwnerKind("test", "ReplicaSet")
+				m.(metrics.OwnerKindRecorder).IncAdmissionReviewOwnerKind("test", "ReplicaSet")
+				m.(metrics.OwnerKindRecorder).IncAdmissionReviewOwnerKind("test", "none")
+			},
+			expMetrics: []string{
+				`kubewebhook_admission_webhook_admission_reviews_owner_kind_total{owner_kind="ReplicaSet",webhook="test"} 2`,
+				`kubewebhook_admission_webhook_admission_reviews_owner_kind_total{owner_kind="none",webhook="test"} 1`,
+			},
+		},
+		{
+			name: "Record admission review object sizes should set the correct metrics",
+			recordMetrics: func(m metrics.Recorder) {
+				m.(metrics.ObjectSizeRecorder).ObserveAdmissionReviewObjectSize("testWH", "Pod", 200)
+				m.(metrics.ObjectSizeRecorder).ObserveAdmissionReviewObjectSize("testWH", "Pod", 3000)
+				m.(metrics.ObjectSizeRecorder).ObserveAdmissionReviewObjectSize("testWH", "Pod", 5000000)
+			},
+			expMetrics: []string{
+				`kubewebhook_admission_webhook_admission_review_object_size_bytes_bucket{kind="Pod",webhook="testWH",le="256"} 1`,
+				`kubewebhook_admission_webhook_admission_review_object_size_bytes_bucket{kind="Pod",webhook="testWH",le="4096"} 2`,
+				`kubewebhook_admission_webhook_admission_review_object_size_bytes_bucket{kind="Pod",webhook="testWH",le="4.194304e+06"} 2`,
+				`kubewebhook_admission_webhook_admission_review_object_size_bytes_bucket{kind="Pod",webhook="testWH",le="+Inf"} 3`,
+				`kubewebhook_admission_webhook_admission_review_object_size_bytes_sum{kind="Pod",webhook="testWH"} 5.0032e+06`,
+			},
+		},
+		{
+			name: "Record slow admission reviews should set the correct metrics",
+			recordMetrics: func(m metrics.Recorder) {
+				m.(metrics.SlowReviewRecorder).IncAdmissionReviewSlow("test")
+				m.(metrics.SlowReviewRecorder).IncAdmissionReviewSlow("test")
+				m.(metrics.SlowReviewRecorder).IncAdmissionReviewSlow("test2")
+			},
+			expMetrics: []string{
+				`kubewebhook_admission_webhook_admission_reviews_slow_total{webhook="test"} 2`,
+				`kubewebhook_admission_webhook_admission_reviews_slow_total{webhook="test2"} 1`,
+			},
+		},
+		{
+			name: "Record fail-open reviews should set the correct metrics",
+			recordMetrics: func(m metrics.Recorder) {
+				m.(metrics.FailOpenRecorder).IncWebhookFailOpen("test", metrics.FailOpenReasonDecodeError)
+				m.(metrics.FailOpenRecorder).IncWebhookFailOpen("test", metrics.FailOpenReasonDecodeError)
+				m.(metrics.FailOpenRecorder).IncWebhookFailOpen("test", metrics.FailOpenReasonPanic)
+			},
+			expMetrics: []string{
+				`kubewebhook_admission_webhook_fail_open_total{reason="decode_error",webhook="test"} 2`,
+				`kubewebhook_admission_webhook_fail_open_total{reason="panic",webhook="test"} 1`,
+			},
+		},
+		{
+			name: "Record circuit breaker state should set the correct metrics",
+			recordMetrics: func(m metrics.Recorder) {
+				m.(metrics.CircuitBreakerRecorder).SetCircuitBreakerState("test", metrics.CircuitBreakerStateClosed)
+				m.(metrics.CircuitBreakerRecorder).SetCircuitBreakerState("test", metrics.CircuitBreakerStateOpen)
+				m.(metrics.CircuitBreakerRecorder).SetCircuitBreakerState("test2", metrics.CircuitBreakerStateHalfOpen)
+			},
+			expMetrics: []string{
+				`kubewebhook_admission_webhook_circuit_breaker_state{state="closed",webhook="test"} 0`,
+				`kubewebhook_admission_webhook_circuit_breaker_state{state="open",webhook="test"} 1`,
+				`kubewebhook_admission_webhook_circuit_breaker_state{state="half_open",webhook="test"} 0`,
+				`kubewebhook_admission_webhook_circuit_breaker_state{state="half_open",webhook="test2"} 1`,
+			},
+		},
+		{
+			name: "Record patch round-trip errors should set the correct metrics",
+			recordMetrics: func(m metrics.Recorder) {
+				m.(metrics.PatchRoundTripRecorder).IncPatchRoundTripError("test")
+				m.(metrics.PatchRoundTripRecorder).IncPatchRoundTripError("test")
+				m.(metrics.PatchRoundTripRecorder).IncPatchRoundTripError("test2")
+			},
+			expMetrics: []string{
+				`kubewebhook_admission_webhook_patch_round_trip_errors_total{webhook="test"} 2`,
+				`kubewebhook_admission_webhook_patch_round_trip_errors_total{webhook="test2"} 1`,
+			},
+		},
+		{
+			name: "Record self-test results should set the correct metrics",
+			recordMetrics: func(m metrics.Recorder) {
+				m.(metrics.SelfTestRecorder).IncSelfTestResult("test", "pod", true)
+				m.(metrics.SelfTestRecorder).IncSelfTestResult("test", "pod", true)
+				m.(metrics.SelfTestRecorder).IncSelfTestResult("test", "deployment", false)
+			},
+			expMetrics: []string{
+				`kubewebhook_admission_webhook_self_test_results_total{case="deployment",passed="false",webhook="test"} 1`,
+				`kubewebhook_admission_webhook_self_test_results_total{case="pod",passed="true",webhook="test"} 2`,
+			},
+		},
+		{
+			name: "Record near deadline admission reviews should set the correct metrics",
+			recordMetrics: func(m metrics.Recorder) {
+				m.(metrics.NearDeadlineRecorder).IncAdmissionReviewNearDeadline("test")
+				m.(metrics.NearDeadlineRecorder).IncAdmissionReviewNearDeadline("test")
+				m.(metrics.NearDeadlineRecorder).IncAdmissionReviewNearDeadline("test2")
+			},
+			expMetrics: []string{
+				`kubewebhook_admission_webhook_admission_reviews_near_deadline_total{webhook="test"} 2`,
+				`kubewebhook_admission_webhook_admission_reviews_near_deadline_total{webhook="test2"} 1`,
+			},
+		},
+		{
+			name: "Record patch effective should set the correct metrics",
+			recordMetrics: func(m metrics.Recorder) {
+				m.(metrics.PatchEffectiveRecorder).IncPatchEffective("test", true)
+				m.(metrics.PatchEffectiveRecorder).IncPatchEffective("test", true)
+				m.(metrics.PatchEffectiveRecorder).IncPatchEffective("test", false)
+			},
+			expMetrics: []string{
+				`kubewebhook_admission_webhook_patch_effective_total{effective="false",webhook="test"} 1`,
+				`kubewebhook_admission_webhook_patch_effective_total{effective="true",webhook="test"} 2`,
+			},
+		},
+		{
+			name: "Record HTTP handler duration should set the correct metrics",
+			recordMetrics: func(m metrics.Recorder) {
+				m.(metrics.HTTPHandlerDurationRecorder).ObserveHTTPHandlerDuration("testWH", time.Now().Add(-2*time.Millisecond))
+				m.(metrics.HTTPHandlerDurationRecorder).ObserveHTTPHandlerDuration("testWH", time.Now().Add(-3*time.Second))
+			},
+			expMetrics: []string{
+				`kubewebhook_admission_webhook_http_handler_duration_seconds_bucket{webhook="testWH",le="0.005"} 1`,
+				`kubewebhook_admission_webhook_http_handler_duration_seconds_bucket{webhook="testWH",le="2.5"} 1`,
+				`kubewebhook_admission_webhook_http_handler_duration_seconds_bucket{webhook="testWH",le="5"} 2`,
+				`kubewebhook_admission_webhook_http_handler_duration_seconds_count{webhook="testWH"} 2`,
+			},
+		},
 	}
 
 	for _, test := range tests {
@@ -106,3 +292,135 @@ func TestPrometheus(t *testing.T) {
 		})
 	}
 }
+
+func TestPrometheusMultipleRecorders(t *testing.T) {
+	assert := assert.New(t)
+
+	reg := prometheus.NewRegistry()
+
+	// Creating multiple recorders on the same registry should not panic.
+	var p1, p2 *metrics.Prometheus
+	assert.NotPanics(func() {
+		p1 = metrics.NewPrometheus(reg)
+		p2 = metrics.NewPrometheus(reg)
+	})
+
+	// Multiple webhooks sharing a recorder should not panic.
+	assert.NotPanics(func() {
+		cfg1 := mutating.WebhookConfig{Name: "testWH1", Obj: &corev1.Pod{}}
+		_, _ = mutating.NewWebhook(cfg1, mutating.NewChain(log.Dummy), nil, p1, log.Dummy)
+		cfg2 := mutating.WebhookConfig{Name: "testWH2", Obj: &corev1.Pod{}}
+		_, _ = mutating.NewWebhook(cfg2, mutating.NewChain(log.Dummy), nil, p1, log.Dummy)
+	})
+
+	p1.IncAdmissionReview("testWH", "test", "v1/pods", admissionv1beta1.Create, metrics.ValidatingReviewKind)
+	p2.IncAdmissionReview("testWH", "test", "v1/pods", admissionv1beta1.Create, metrics.ValidatingReviewKind)
+
+	// Both recorders should record on the same metrics.
+	h := promhttp.HandlerFor(reg, promhttp.HandlerOpts{})
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+	body, _ := ioutil.ReadAll(rec.Result().Body)
+	assert.Contains(string(body), `kubewebhook_admission_webhook_admission_reviews_total{kind="validating",namespace="test",operation="CREATE",resource="v1/pods",webhook="testWH"} 2`)
+}
+
+func TestPrometheusRegisterBuildInfo(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	reg := prometheus.NewRegistry()
+	p := metrics.NewPrometheus(reg)
+	p.RegisterBuildInfo("v0.1.0", "0000000")
+	p.RegisterBuildInfo("v1.2.3", "a1b2c3d")
+
+	// Registering the build info from another recorder on the same registry should not panic.
+	assert.NotPanics(func() {
+		metrics.NewPrometheus(reg).RegisterBuildInfo("v1.2.3", "a1b2c3d")
+	})
+
+	h := promhttp.HandlerFor(reg, promhttp.HandlerOpts{})
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+	body, _ := ioutil.ReadAll(rec.Result().Body)
+
+	// Only the latest build info should be exposed.
+	assert.Contains(string(body), `kubewebhook_build_info{commit="a1b2c3d",version="v1.2.3"} 1`)
+	assert.NotContains(string(body), `version="v0.1.0"`)
+
+	// Dumping the metrics should not fail with a duplicated build info metric.
+	logger := &testutil.Logger{}
+	require.NoError(p.DumpMetrics(logger))
+	assert.Equal([]string{`metric kubewebhook_build_info{commit="a1b2c3d",version="v1.2.3"} 1`}, logger.Infos)
+}
+
+func TestPrometheusWithRuntimeMetrics(t *testing.T) {
+	assert := assert.New(t)
+
+	p, h := metrics.NewPrometheusWithRuntimeMetrics()
+	p.IncAdmissionReview("testWH", "test", "v1/pods", admissionv1beta1.Create, metrics.MutatingReviewKind)
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+	resp := rec.Result()
+
+	// Check the webhook and the runtime metrics are present.
+	if assert.Equal(http.StatusOK, resp.StatusCode) {
+		body, _ := ioutil.ReadAll(resp.Body)
+		assert.Contains(string(body), `kubewebhook_admission_webhook_admission_reviews_total{kind="mutating",namespace="test",operation="CREATE",resource="v1/pods",webhook="testWH"} 1`)
+		assert.Contains(string(body), "go_goroutines")
+		assert.Contains(string(body), "go_memstats_alloc_bytes")
+	}
+}
+
+func TestPrometheusDumpMetrics(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	p := metrics.NewPrometheus(prometheus.NewRegistry())
+	p.IncAdmissionReview("testWH", "test", "v1/pods", admissionv1beta1.Create, metrics.MutatingReviewKind)
+	p.IncAdmissionReview("testWH", "test", "v1/pods", admissionv1beta1.Create, metrics.MutatingReviewKind)
+	p.IncAdmissionReviewError("testWH", "test", "v1/pods", admissionv1beta1.Create, metrics.MutatingReviewKind)
+	p.ObserveAdmissionReviewDuration("testWH", "test", "v1/pods", admissionv1beta1.Create, metrics.MutatingReviewKind, time.Now())
+
+	logger := &testutil.Logger{}
+	err := p.DumpMetrics(logger)
+	require.NoError(err)
+
+	assert.Contains(logger.Infos, `metric kubewebhook_admission_webhook_admission_reviews_total{kind="mutating",namespace="test",operation="CREATE",resource="v1/pods",webhook="testWH"} 2`)
+	assert.Contains(logger.Infos, `metric kubewebhook_admission_webhook_admission_review_errors_total{kind="mutating",namespace="test",operation="CREATE",resource="v1/pods",webhook="testWH"} 1`)
+	assert.Len(logger.Infos, 3)
+}
+
+func TestPrometheusOpenMetricsHandler(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	p := metrics.NewPrometheus(prometheus.NewRegistry())
+	h, err := p.OpenMetricsHandler()
+	require.NoError(err)
+
+	// Review with a webhook so the review duration is observed with the review UID exemplar.
+	wh, err := mutating.NewWebhook(mutating.WebhookConfig{Name: "testWH", Obj: &corev1.Pod{}}, mutating.NewChain(log.Dummy), nil, p, log.Dummy)
+	require.NoError(err)
+	wh.Review(context.TODO(), &admissionv1beta1.AdmissionReview{
+		Request: &admissionv1beta1.AdmissionRequest{
+			UID:       "test-uid",
+			Namespace: "test",
+			Operation: admissionv1beta1.Create,
+			Object:    runtime.RawExtension{Raw: []byte(`{"kind":"Pod","apiVersion":"v1","metadata":{"name":"test"}}`)},
+		},
+	})
+
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest("GET", "/metrics", nil)
+	req.Header.Set("Accept", "application/openmetrics-text; version=0.0.1")
+	h.ServeHTTP(rec, req)
+	resp := rec.Result()
+
+	// Check the OpenMetrics format and the exemplar.
+	if assert.Equal(http.StatusOK, resp.StatusCode) {
+		assert.Contains(resp.Header.Get("Content-Type"), "application/openmetrics-text")
+		body, _ := ioutil.ReadAll(resp.Body)
+		assert.Regexp(`kubewebhook_admission_webhook_admission_review_duration_seconds_bucket\{.*webhook="testWH".*\} 1 # \{uid="test-uid"\} `, string(body))
+	}
+}
@@ -0,0 +1,75 @@
+package webhook
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/slok/kubewebhook/pkg/log"
+	"github.com/slok/kubewebhook/pkg/observability/metrics"
+)
+
+// AnnotationAllowlistConfig is the configuration of the annotation allowlist.
+type AnnotationAllowlistConfig struct {
+	// Name is the name of the webhook used on the logs and metrics.
+	Name string
+	// Allowed are the annotation keys the webhook is allowed to read.
+	Allowed []string
+	// Strict will hide the not allowed annotations to the readers, as if they were
+	// missing. By default the not allowed reads are only flagged.
+	Strict bool
+	// MetricsRecorder is the recorder used to measure the not allowed reads, by default `metrics.Dummy`.
+	MetricsRecorder metrics.Recorder
+	// Logger is the logger used to log the not allowed reads, by default `log.Dummy`.
+	Logger log.Logger
+}
+
+func (c *AnnotationAllowlistConfig) defaults() {
+	if c.MetricsRecorder == nil {
+		c.MetricsRecorder = metrics.Dummy
+	}
+
+	if c.Logger == nil {
+		c.Logger = log.Dummy
+	}
+}
+
+// AnnotationAllowlist knows how to read the annotations of the objects flagging the reads of
+// annotations that are not on the allowlist. Go can't enforce the read access of the objects,
+// so the mutators and validators should read the annotations using the allowlist getters,
+// this way the operators can assert a webhook only reads specific annotations (least privilege).
+type AnnotationAllowlist struct {
+	cfg     AnnotationAllowlistConfig
+	allowed map[string]bool
+}
+
+// NewAnnotationAllowlist returns a new annotation allowlist.
+func NewAnnotationAllowlist(cfg AnnotationAllowlistConfig) *AnnotationAllowlist {
+	cfg.defaults()
+
+	allowed := map[string]bool{}
+	for _, a := range cfg.Allowed {
+		allowed[a] = true
+	}
+
+	return &AnnotationAllowlist{
+		cfg:     cfg,
+		allowed: allowed,
+	}
+}
+
+// Get returns the value of the object annotation and if the annotation is present. If the
+// annotation is not on the allowlist the read will be logged and measured, in strict mode the
+// annotation will be returned as missing.
+func (a *AnnotationAllowlist) Get(obj metav1.Object, key string) (string, bool) {
+	if !a.allowed[key] {
+		a.cfg.Logger.Warningf("webhook %s read the %q annotation of %s/%s that is not on the allowlist", a.cfg.Name, key, obj.GetNamespace(), obj.GetName())
+		if rec, ok := a.cfg.MetricsRecorder.(metrics.AnnotationReadRecorder); ok {
+			rec.IncAnnotationReadNotAllowed(a.cfg.Name, key)
+		}
+		if a.cfg.Strict {
+			return "", false
+		}
+	}
+
+	v, ok := obj.GetAnnotations()[key]
+	return v, ok
+}
@@ -0,0 +1,93 @@
+package webhook_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	mmetrics "github.com/slok/kubewebhook/mocks/observability/metrics"
+	"github.com/slok/kubewebhook/pkg/internal/testutil"
+	"github.com/slok/kubewebhook/pkg/webhook"
+)
+
+func TestAnnotationAllowlist(t *testing.T) {
+	obj := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "test",
+			Namespace: "test-ns",
+			Annotations: map[string]string{
+				"allowed.slok.dev/key":     "allowed-value",
+				"not-allowed.slok.dev/key": "not-allowed-value",
+			},
+		},
+	}
+
+	tests := map[string]struct {
+		strict     bool
+		key        string
+		expValue   string
+		expOK      bool
+		expFlagged bool
+	}{
+		"Reading an allowed annotation should not be flagged.": {
+			key:      "allowed.slok.dev/key",
+			expValue: "allowed-value",
+			expOK:    true,
+		},
+
+		"Reading a missing allowed annotation should not be flagged.": {
+			key:   "allowed.slok.dev/missing",
+			expOK: false,
+		},
+
+		"Reading an annotation outside the allowlist should be flagged.": {
+			key:        "not-allowed.slok.dev/key",
+			expValue:   "not-allowed-value",
+			expOK:      true,
+			expFlagged: true,
+		},
+
+		"Reading an annotation outside the allowlist in strict mode should be flagged and hidden.": {
+			strict:     true,
+			key:        "not-allowed.slok.dev/key",
+			expOK:      false,
+			expFlagged: true,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+			require := require.New(t)
+
+			mrec := &mmetrics.Recorder{}
+			if test.expFlagged {
+				mrec.On("IncAnnotationReadNotAllowed", "test", test.key).Once()
+			}
+			logger := &testutil.Logger{}
+
+			al := webhook.NewAnnotationAllowlist(webhook.AnnotationAllowlistConfig{
+				Name:            "test",
+				Allowed:         []string{"allowed.slok.dev/key", "allowed.slok.dev/missing"},
+				Strict:          test.strict,
+				MetricsRecorder: mrec,
+				Logger:          logger,
+			})
+
+			gotValue, gotOK := al.Get(obj, test.key)
+
+			assert.Equal(test.expValue, gotValue)
+			assert.Equal(test.expOK, gotOK)
+			if test.expFlagged {
+				require.Len(logger.Warnings, 1)
+				assert.Contains(logger.Warnings[0], test.key)
+			} else {
+				assert.Empty(logger.Warnings)
+			}
+			mrec.AssertExpectations(t)
+		})
+	}
+}
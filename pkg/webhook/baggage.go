@@ -0,0 +1,15 @@
+package webhook
+
+// Tracing baggage keys set by the webhooks with the trace baggage enabled, the baggage is
+// propagated with the trace context, so the spans of the downstream services called by the
+// mutators and validators can be correlated with the reviewed object.
+const (
+	// BaggageGroupKey is the tracing baggage key of the reviewed object group.
+	BaggageGroupKey = "kubewebhook.review.group"
+	// BaggageVersionKey is the tracing baggage key of the reviewed object version.
+	BaggageVersionKey = "kubewebhook.review.version"
+	// BaggageKindKey is the tracing baggage key of the reviewed object kind.
+	BaggageKindKey = "kubewebhook.review.kind"
+	// BaggageOperationKey is the tracing baggage key of the review operation (e.g `CREATE`).
+	BaggageOperationKey = "kubewebhook.review.operation"
+)
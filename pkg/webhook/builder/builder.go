@@ -0,0 +1,206 @@
+// Package builder has the helpers to create the webhooks from a declarative configuration (e.g
+// decoded from a configuration file), the webhooks reference the mutators and validators by
+// name from a registry of the application mutators and validators.
+package builder
+
+import (
+	"context"
+	"fmt"
+
+	opentracing "github.com/opentracing/opentracing-go"
+	admissionv1beta1 "k8s.io/api/admission/v1beta1"
+
+	"github.com/slok/kubewebhook/pkg/log"
+	"github.com/slok/kubewebhook/pkg/observability/metrics"
+	"github.com/slok/kubewebhook/pkg/webhook"
+	"github.com/slok/kubewebhook/pkg/webhook/mutating"
+	"github.com/slok/kubewebhook/pkg/webhook/validating"
+)
+
+// WebhookKind is the kind of a webhook.
+type WebhookKind string
+
+const (
+	// WebhookKindMutating is the kind of the mutating webhooks.
+	WebhookKindMutating WebhookKind = "mutating"
+	// WebhookKindValidating is the kind of the validating webhooks.
+	WebhookKindValidating WebhookKind = "validating"
+)
+
+// Registry has the mutators and validators by name that the webhooks configuration can reference.
+type Registry struct {
+	// Mutators are the mutators by name.
+	Mutators map[string]mutating.Mutator
+	// Validators are the validators by name.
+	Validators map[string]validating.Validator
+}
+
+// Config is the declarative configuration of a set of webhooks.
+type Config struct {
+	// Webhooks are the webhooks configuration.
+	Webhooks []WebhookConfig `json:"webhooks"`
+}
+
+// WebhookConfig is the declarative configuration of a webhook.
+type WebhookConfig struct {
+	// Name is the name of the webhook, must be unique.
+	Name string `json:"name"`
+	// Kind is the kind of the webhook (`mutating` or `validating`).
+	Kind WebhookKind `json:"kind"`
+	// Mutators are the names of the registry mutators executed in order by the mutating webhooks.
+	Mutators []string `json:"mutators,omitempty"`
+	// Validators are the names of the registry validators executed in order by the validating webhooks.
+	Validators []string `json:"validators,omitempty"`
+	// Operations are the operations (e.g `CREATE`) reviewed by the webhook, the requests with other
+	// operations will be allowed without review. If empty all the operations will be reviewed.
+	Operations []admissionv1beta1.Operation `json:"operations,omitempty"`
+	// Namespaces are the namespaces reviewed by the webhook, the requests of other namespaces will be
+	// allowed without review. If empty all the namespaces will be reviewed.
+	Namespaces []string `json:"namespaces,omitempty"`
+	// ExcludedNamespaces are the namespaces not reviewed by the webhook (e.g `kube-system`), the
+	// requests of these namespaces will be allowed without review.
+	ExcludedNamespaces []string `json:"excludedNamespaces,omitempty"`
+}
+
+func (c WebhookConfig) validate(reg Registry) error {
+	if c.Name == "" {
+		return fmt.Errorf("name is required")
+	}
+
+	switch c.Kind {
+	case WebhookKindMutating:
+		if len(c.Validators) > 0 {
+			return fmt.Errorf("mutating webhooks can't have validators")
+		}
+		if len(c.Mutators) == 0 {
+			return fmt.Errorf("at least one mutator is required")
+		}
+		for _, m := range c.Mutators {
+			if _, ok := reg.Mutators[m]; !ok {
+				return fmt.Errorf("mutator %q is not registered", m)
+			}
+		}
+	case WebhookKindValidating:
+		if len(c.Mutators) > 0 {
+			return fmt.Errorf("validating webhooks can't have mutators")
+		}
+		if len(c.Validators) == 0 {
+			return fmt.Errorf("at least one validator is required")
+		}
+		for _, v := range c.Validators {
+			if _, ok := reg.Validators[v]; !ok {
+				return fmt.Errorf("validator %q is not registered", v)
+			}
+		}
+	default:
+		return fmt.Errorf("unknown webhook kind %q", c.Kind)
+	}
+
+	return nil
+}
+
+// Build returns the webhooks of the configuration by name, the webhooks infer the type of the
+// reviewed objects. The tracer, metrics recorder and logger are shared by all the webhooks, these
+// are optional like on the webhook constructors.
+func Build(cfg Config, reg Registry, ot opentracing.Tracer, recorder metrics.Recorder, logger log.Logger) (map[string]webhook.Webhook, error) {
+	if logger == nil {
+		logger = log.Dummy
+	}
+
+	whs := map[string]webhook.Webhook{}
+	for _, whCfg := range cfg.Webhooks {
+		if err := whCfg.validate(reg); err != nil {
+			return nil, fmt.Errorf("invalid %q webhook configuration: %w", whCfg.Name, err)
+		}
+		if _, ok := whs[whCfg.Name]; ok {
+			return nil, fmt.Errorf("duplicated %q webhook", whCfg.Name)
+		}
+
+		wh, err := buildWebhook(whCfg, reg, ot, recorder, logger)
+		if err != nil {
+			return nil, fmt.Errorf("could not create %q webhook: %w", whCfg.Name, err)
+		}
+
+		if len(whCfg.Operations) > 0 || len(whCfg.Namespaces) > 0 || len(whCfg.ExcludedNamespaces) > 0 {
+			wh = newFilterWebhook(wh, whCfg)
+		}
+		whs[whCfg.Name] = wh
+	}
+
+	return whs, nil
+}
+
+func buildWebhook(cfg WebhookConfig, reg Registry, ot opentracing.Tracer, recorder metrics.Recorder, logger log.Logger) (webhook.Webhook, error) {
+	if cfg.Kind == WebhookKindMutating {
+		mutators := make([]mutating.Mutator, 0, len(cfg.Mutators))
+		for _, name := range cfg.Mutators {
+			mutators = append(mutators, mutating.ChainMutator{Name: name, Mutator: reg.Mutators[name]})
+		}
+		return mutating.NewWebhook(mutating.WebhookConfig{Name: cfg.Name}, mutating.NewChain(logger, mutators...), ot, recorder, logger)
+	}
+
+	validators := make([]validating.Validator, 0, len(cfg.Validators))
+	for _, name := range cfg.Validators {
+		validators = append(validators, reg.Validators[name])
+	}
+	return validating.NewWebhook(validating.WebhookConfig{Name: cfg.Name}, validating.NewChain(logger, validators...), ot, recorder, logger)
+}
+
+// filterWebhook is a webhook that only reviews the requests of the configured operations and
+// namespaces, the rest are allowed without review.
+type filterWebhook struct {
+	webhook            webhook.Webhook
+	operations         map[admissionv1beta1.Operation]bool
+	namespaces         map[string]bool
+	excludedNamespaces map[string]bool
+	cfg                WebhookConfig
+}
+
+func newFilterWebhook(wh webhook.Webhook, cfg WebhookConfig) webhook.Webhook {
+	f := &filterWebhook{
+		webhook:            wh,
+		operations:         map[admissionv1beta1.Operation]bool{},
+		namespaces:         map[string]bool{},
+		excludedNamespaces: map[string]bool{},
+		cfg:                cfg,
+	}
+	for _, op := range cfg.Operations {
+		f.operations[op] = true
+	}
+	for _, ns := range cfg.Namespaces {
+		f.namespaces[ns] = true
+	}
+	for _, ns := range cfg.ExcludedNamespaces {
+		f.excludedNamespaces[ns] = true
+	}
+
+	return f
+}
+
+func (f *filterWebhook) Review(ctx context.Context, ar *admissionv1beta1.AdmissionReview) *admissionv1beta1.AdmissionResponse {
+	req := ar.Request
+	skip := (len(f.operations) > 0 && !f.operations[req.Operation]) ||
+		(len(f.namespaces) > 0 && !f.namespaces[req.Namespace]) ||
+		f.excludedNamespaces[req.Namespace]
+	if skip {
+		return &admissionv1beta1.AdmissionResponse{
+			UID:     req.UID,
+			Allowed: true,
+		}
+	}
+
+	return f.webhook.Review(ctx, ar)
+}
+
+// ConfigView satisfies webhook.ConfigViewer interface.
+func (f *filterWebhook) ConfigView() webhook.ConfigView {
+	cv, _ := webhook.GetConfigView(f.webhook)
+	if cv.Options == nil {
+		cv.Options = map[string]interface{}{}
+	}
+	cv.Options["operations"] = f.cfg.Operations
+	cv.Options["namespaces"] = f.cfg.Namespaces
+	cv.Options["excludedNamespaces"] = f.cfg.ExcludedNamespaces
+
+	return cv
+}
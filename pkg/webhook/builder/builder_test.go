@@ -0,0 +1,184 @@
+package builder_test
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	admissionv1beta1 "k8s.io/api/admission/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	"github.com/slok/kubewebhook/pkg/log"
+	"github.com/slok/kubewebhook/pkg/webhook/builder"
+	"github.com/slok/kubewebhook/pkg/webhook/mutating"
+	"github.com/slok/kubewebhook/pkg/webhook/validating"
+)
+
+var testRegistry = builder.Registry{
+	Mutators: map[string]mutating.Mutator{
+		"team-label": mutating.MutatorFunc(func(_ context.Context, obj metav1.Object) (bool, error) {
+			labels := obj.GetLabels()
+			if labels == nil {
+				labels = map[string]string{}
+			}
+			labels["team"] = "test"
+			obj.SetLabels(labels)
+			return false, nil
+		}),
+	},
+	Validators: map[string]validating.Validator{
+		"forbidden-name": validating.ValidatorFunc(func(_ context.Context, obj metav1.Object) (bool, validating.ValidatorResult, error) {
+			if obj.GetName() == "forbidden" {
+				return true, validating.ValidatorResult{Valid: false, Message: "forbidden name"}, nil
+			}
+			return false, validating.ValidatorResult{Valid: true}, nil
+		}),
+	},
+}
+
+func TestBuild(t *testing.T) {
+	rawCfg := []byte(`{
+		"webhooks": [
+			{
+				"name": "team-labels",
+				"kind": "mutating",
+				"mutators": ["team-label"],
+				"operations": ["CREATE"],
+				"excludedNamespaces": ["kube-system"]
+			},
+			{
+				"name": "names",
+				"kind": "validating",
+				"validators": ["forbidden-name"],
+				"namespaces": ["default"]
+			}
+		]
+	}`)
+	var cfg builder.Config
+	require.NoError(t, json.Unmarshal(rawCfg, &cfg))
+
+	whs, err := builder.Build(cfg, testRegistry, nil, nil, log.Dummy)
+	require.NoError(t, err)
+	require.Len(t, whs, 2)
+
+	tests := map[string]struct {
+		webhook    string
+		operation  admissionv1beta1.Operation
+		namespace  string
+		name       string
+		expAllowed bool
+		expPatch   string
+	}{
+		"The mutating webhook should mutate the filtered operations.": {
+			webhook:    "team-labels",
+			operation:  admissionv1beta1.Create,
+			namespace:  "default",
+			name:       "test",
+			expAllowed: true,
+			expPatch:   `[{"op":"add","path":"/metadata/labels","value":{"team":"test"}}]`,
+		},
+
+		"The mutating webhook should not mutate the operations not filtered.": {
+			webhook:    "team-labels",
+			operation:  admissionv1beta1.Update,
+			namespace:  "default",
+			name:       "test",
+			expAllowed: true,
+		},
+
+		"The mutating webhook should not mutate the excluded namespaces.": {
+			webhook:    "team-labels",
+			operation:  admissionv1beta1.Create,
+			namespace:  "kube-system",
+			name:       "test",
+			expAllowed: true,
+		},
+
+		"The validating webhook should validate the filtered namespaces.": {
+			webhook:    "names",
+			operation:  admissionv1beta1.Create,
+			namespace:  "default",
+			name:       "forbidden",
+			expAllowed: false,
+		},
+
+		"The validating webhook should not validate the namespaces not filtered.": {
+			webhook:    "names",
+			operation:  admissionv1beta1.Create,
+			namespace:  "other",
+			name:       "forbidden",
+			expAllowed: true,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+			require := require.New(t)
+
+			wh, ok := whs[test.webhook]
+			require.True(ok)
+
+			raw := []byte(`{"kind":"House","apiVersion":"building.slok.dev/v1","metadata":{"name":"` + test.name + `","namespace":"` + test.namespace + `"}}`)
+			ar := &admissionv1beta1.AdmissionReview{
+				Request: &admissionv1beta1.AdmissionRequest{
+					UID:       "test",
+					Operation: test.operation,
+					Namespace: test.namespace,
+					Object:    runtime.RawExtension{Raw: raw},
+				},
+			}
+			gotResponse := wh.Review(context.TODO(), ar)
+
+			assert.Equal(test.expAllowed, gotResponse.Allowed)
+			if test.expPatch != "" {
+				assert.Equal(test.expPatch, string(gotResponse.Patch))
+			} else {
+				assert.Empty(gotResponse.Patch)
+			}
+		})
+	}
+}
+
+func TestBuildInvalidConfig(t *testing.T) {
+	tests := map[string]struct {
+		cfg builder.Config
+	}{
+		"A webhook without name should fail.": {
+			cfg: builder.Config{Webhooks: []builder.WebhookConfig{{Kind: builder.WebhookKindMutating, Mutators: []string{"team-label"}}}},
+		},
+
+		"A webhook with an unknown kind should fail.": {
+			cfg: builder.Config{Webhooks: []builder.WebhookConfig{{Name: "test", Kind: "unknown", Mutators: []string{"team-label"}}}},
+		},
+
+		"A webhook referencing a not registered mutator should fail.": {
+			cfg: builder.Config{Webhooks: []builder.WebhookConfig{{Name: "test", Kind: builder.WebhookKindMutating, Mutators: []string{"missing"}}}},
+		},
+
+		"A webhook referencing a not registered validator should fail.": {
+			cfg: builder.Config{Webhooks: []builder.WebhookConfig{{Name: "test", Kind: builder.WebhookKindValidating, Validators: []string{"missing"}}}},
+		},
+
+		"A mutating webhook with validators should fail.": {
+			cfg: builder.Config{Webhooks: []builder.WebhookConfig{{Name: "test", Kind: builder.WebhookKindMutating, Mutators: []string{"team-label"}, Validators: []string{"forbidden-name"}}}},
+		},
+
+		"Duplicated webhooks should fail.": {
+			cfg: builder.Config{Webhooks: []builder.WebhookConfig{
+				{Name: "test", Kind: builder.WebhookKindMutating, Mutators: []string{"team-label"}},
+				{Name: "test", Kind: builder.WebhookKindValidating, Validators: []string{"forbidden-name"}},
+			}},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			_, err := builder.Build(test.cfg, testRegistry, nil, nil, log.Dummy)
+			assert.Error(t, err)
+		})
+	}
+}
@@ -0,0 +1,189 @@
+package webhook
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	admissionv1beta1 "k8s.io/api/admission/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/slok/kubewebhook/pkg/log"
+	"github.com/slok/kubewebhook/pkg/observability/metrics"
+	whcontext "github.com/slok/kubewebhook/pkg/webhook/context"
+)
+
+// CircuitBreakerSettings are the settings of the circuit breaker.
+type CircuitBreakerSettings struct {
+	// Name is the name of the webhook used on the logs and metrics.
+	Name string
+	// MaxConsecutiveErrors is the number of consecutive review errors that will trip
+	// the circuit breaker. By default 5.
+	MaxConsecutiveErrors int
+	// Cooldown is the time the circuit breaker will be open before letting a trial
+	// review reach the webhook. By default 30s.
+	Cooldown time.Duration
+	// MetricsRecorder is the recorder used to measure the circuit breaker state and the
+	// fail-open reviews, by default `metrics.Dummy`.
+	MetricsRecorder metrics.Recorder
+	// Logger is the logger used to log the state changes, by default `log.Dummy`.
+	Logger log.Logger
+}
+
+func (s *CircuitBreakerSettings) defaults() error {
+	if s.Name == "" {
+		return fmt.Errorf("name is required")
+	}
+
+	if s.MaxConsecutiveErrors < 0 || s.Cooldown < 0 {
+		return fmt.Errorf("max consecutive errors and cooldown can't be negative")
+	}
+
+	if s.MaxConsecutiveErrors == 0 {
+		s.MaxConsecutiveErrors = 5
+	}
+
+	if s.Cooldown == 0 {
+		s.Cooldown = 30 * time.Second
+	}
+
+	if s.MetricsRecorder == nil {
+		s.MetricsRecorder = metrics.Dummy
+	}
+
+	if s.Logger == nil {
+		s.Logger = log.Dummy
+	}
+
+	return nil
+}
+
+type circuitBreaker struct {
+	webhook  Webhook
+	settings CircuitBreakerSettings
+
+	mu       sync.Mutex
+	state    metrics.CircuitBreakerState
+	errors   int
+	openedAt time.Time
+	trialing bool
+}
+
+// NewCircuitBreaker returns a webhook that wraps the webhook with a circuit breaker. When the webhook
+// review fails consecutively (e.g the mutator dependency is down) the circuit breaker trips and the
+// reviews are allowed without reaching the webhook (fail-open) during the cooldown. After the cooldown
+// the circuit breaker half-opens and lets a single trial review reach the webhook, if it succeeds the
+// circuit breaker closes, if not, it opens again for another cooldown.
+//
+// The circuit breaker state is exposed as a metric, and the reviews allowed by the open circuit
+// breaker are measured as fail-open reviews.
+func NewCircuitBreaker(wh Webhook, settings CircuitBreakerSettings) (Webhook, error) {
+	if err := settings.defaults(); err != nil {
+		return nil, fmt.Errorf("invalid configuration: %w", err)
+	}
+
+	if rec, ok := settings.MetricsRecorder.(metrics.CircuitBreakerRecorder); ok {
+		rec.SetCircuitBreakerState(settings.Name, metrics.CircuitBreakerStateClosed)
+	}
+
+	return &circuitBreaker{
+		webhook:  wh,
+		settings: settings,
+		state:    metrics.CircuitBreakerStateClosed,
+	}, nil
+}
+
+func (c *circuitBreaker) Review(ctx context.Context, ar *admissionv1beta1.AdmissionReview) *admissionv1beta1.AdmissionResponse {
+	// The self-test reviews don't change the circuit breaker state.
+	if whcontext.IsSelfTest(ctx) {
+		return c.webhook.Review(ctx, ar)
+	}
+
+	if !c.allow() {
+		if rec, ok := c.settings.MetricsRecorder.(metrics.FailOpenRecorder); ok {
+			rec.IncWebhookFailOpen(c.settings.Name, metrics.FailOpenReasonCircuitOpen)
+		}
+		return &admissionv1beta1.AdmissionResponse{
+			UID:     ar.Request.UID,
+			Allowed: true,
+		}
+	}
+
+	// Record on a defer so a panicking review is recorded as failed, otherwise the half-open
+	// trial would never end.
+	failed := true
+	defer func() { c.record(failed) }()
+
+	resp := c.webhook.Review(ctx, ar)
+	failed = resp.Result != nil && resp.Result.Status == metav1.StatusFailure
+
+	return resp
+}
+
+// allow returns true if the review can reach the webhook.
+func (c *circuitBreaker) allow() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	switch c.state {
+	case metrics.CircuitBreakerStateOpen:
+		if time.Since(c.openedAt) < c.settings.Cooldown {
+			return false
+		}
+		c.setState(metrics.CircuitBreakerStateHalfOpen)
+		c.trialing = true
+		return true
+	case metrics.CircuitBreakerStateHalfOpen:
+		// Only a single trial review at a time.
+		if c.trialing {
+			return false
+		}
+		c.trialing = true
+		return true
+	}
+
+	return true
+}
+
+// record records the result of a review that reached the webhook.
+func (c *circuitBreaker) record(failed bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.state == metrics.CircuitBreakerStateHalfOpen {
+		c.trialing = false
+		if failed {
+			c.open()
+			return
+		}
+		c.errors = 0
+		c.setState(metrics.CircuitBreakerStateClosed)
+		return
+	}
+
+	if !failed {
+		c.errors = 0
+		return
+	}
+
+	c.errors++
+	if c.state == metrics.CircuitBreakerStateClosed && c.errors >= c.settings.MaxConsecutiveErrors {
+		c.open()
+	}
+}
+
+func (c *circuitBreaker) open() {
+	c.openedAt = time.Now()
+	c.setState(metrics.CircuitBreakerStateOpen)
+}
+
+func (c *circuitBreaker) setState(state metrics.CircuitBreakerState) {
+	if c.state != state {
+		c.settings.Logger.Warningf("webhook %s circuit breaker state changed from %s to %s", c.settings.Name, c.state, state)
+	}
+	c.state = state
+	if rec, ok := c.settings.MetricsRecorder.(metrics.CircuitBreakerRecorder); ok {
+		rec.SetCircuitBreakerState(c.settings.Name, state)
+	}
+}
@@ -0,0 +1,159 @@
+package webhook_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	admissionv1beta1 "k8s.io/api/admission/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	"github.com/slok/kubewebhook/pkg/observability/metrics"
+	"github.com/slok/kubewebhook/pkg/webhook"
+)
+
+// circuitBreakerRecorder is a recorder that stores the circuit breaker metrics.
+type circuitBreakerRecorder struct {
+	metrics.Recorder
+	state     metrics.CircuitBreakerState
+	failOpens map[string]int
+}
+
+func (c *circuitBreakerRecorder) SetCircuitBreakerState(webhook string, state metrics.CircuitBreakerState) {
+	c.state = state
+}
+
+func (c *circuitBreakerRecorder) IncWebhookFailOpen(webhook, reason string) {
+	c.failOpens[reason]++
+}
+
+func TestCircuitBreaker(t *testing.T) {
+	const cooldown = 50 * time.Millisecond
+
+	type step struct {
+		fail       bool
+		panic      bool
+		wait       time.Duration
+		expCalled  bool
+		expAllowed bool
+		expState   metrics.CircuitBreakerState
+	}
+
+	tests := map[string]struct {
+		steps        []step
+		expFailOpens int
+	}{
+		"Successful reviews should keep the circuit breaker closed.": {
+			steps: []step{
+				{expCalled: true, expAllowed: true, expState: metrics.CircuitBreakerStateClosed},
+				{fail: true, expCalled: true, expAllowed: false, expState: metrics.CircuitBreakerStateClosed},
+				{expCalled: true, expAllowed: true, expState: metrics.CircuitBreakerStateClosed},
+				{fail: true, expCalled: true, expAllowed: false, expState: metrics.CircuitBreakerStateClosed},
+			},
+		},
+
+		"Consecutive failures should trip the circuit breaker and fail-open.": {
+			steps: []step{
+				{fail: true, expCalled: true, expAllowed: false, expState: metrics.CircuitBreakerStateClosed},
+				{fail: true, expCalled: true, expAllowed: false, expState: metrics.CircuitBreakerStateOpen},
+				{fail: true, expCalled: false, expAllowed: true, expState: metrics.CircuitBreakerStateOpen},
+				{fail: true, expCalled: false, expAllowed: true, expState: metrics.CircuitBreakerStateOpen},
+			},
+			expFailOpens: 2,
+		},
+
+		"A successful trial after the cooldown should close the circuit breaker.": {
+			steps: []step{
+				{fail: true, expCalled: true, expAllowed: false, expState: metrics.CircuitBreakerStateClosed},
+				{fail: true, expCalled: true, expAllowed: false, expState: metrics.CircuitBreakerStateOpen},
+				{expCalled: false, expAllowed: true, expState: metrics.CircuitBreakerStateOpen},
+				{wait: cooldown, expCalled: true, expAllowed: true, expState: metrics.CircuitBreakerStateClosed},
+				{fail: true, expCalled: true, expAllowed: false, expState: metrics.CircuitBreakerStateClosed},
+			},
+			expFailOpens: 1,
+		},
+
+		"A failed trial after the cooldown should open the circuit breaker again.": {
+			steps: []step{
+				{fail: true, expCalled: true, expAllowed: false, expState: metrics.CircuitBreakerStateClosed},
+				{fail: true, expCalled: true, expAllowed: false, expState: metrics.CircuitBreakerStateOpen},
+				{wait: cooldown, fail: true, expCalled: true, expAllowed: false, expState: metrics.CircuitBreakerStateOpen},
+				{expCalled: false, expAllowed: true, expState: metrics.CircuitBreakerStateOpen},
+			},
+			expFailOpens: 1,
+		},
+
+		"A panicking trial after the cooldown should open the circuit breaker again.": {
+			steps: []step{
+				{fail: true, expCalled: true, expAllowed: false, expState: metrics.CircuitBreakerStateClosed},
+				{fail: true, expCalled: true, expAllowed: false, expState: metrics.CircuitBreakerStateOpen},
+				{wait: cooldown, panic: true, expCalled: true, expState: metrics.CircuitBreakerStateOpen},
+				{expCalled: false, expAllowed: true, expState: metrics.CircuitBreakerStateOpen},
+				{wait: cooldown, expCalled: true, expAllowed: true, expState: metrics.CircuitBreakerStateClosed},
+			},
+			expFailOpens: 1,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+			require := require.New(t)
+
+			var fail, panics, called bool
+			wh := reviewFunc(func(ctx context.Context, ar *admissionv1beta1.AdmissionReview) *admissionv1beta1.AdmissionResponse {
+				called = true
+				if panics {
+					panic("wanted panic")
+				}
+				if fail {
+					return &admissionv1beta1.AdmissionResponse{
+						UID:    ar.Request.UID,
+						Result: &metav1.Status{Status: metav1.StatusFailure, Message: "dependency down"},
+					}
+				}
+				return &admissionv1beta1.AdmissionResponse{UID: ar.Request.UID, Allowed: true}
+			})
+
+			rec := &circuitBreakerRecorder{Recorder: metrics.Dummy, failOpens: map[string]int{}}
+			cb, err := webhook.NewCircuitBreaker(wh, webhook.CircuitBreakerSettings{
+				Name:                 "test",
+				MaxConsecutiveErrors: 2,
+				Cooldown:             cooldown,
+				MetricsRecorder:      rec,
+			})
+			require.NoError(err)
+			assert.Equal(metrics.CircuitBreakerStateClosed, rec.state)
+
+			for i, s := range test.steps {
+				time.Sleep(s.wait)
+				fail, panics, called = s.fail, s.panic, false
+
+				uid := fmt.Sprintf("test-%d", i)
+				ar := &admissionv1beta1.AdmissionReview{Request: &admissionv1beta1.AdmissionRequest{UID: types.UID(uid)}}
+				if s.panic {
+					assert.Panics(func() { cb.Review(context.TODO(), ar) }, "step %d", i)
+					assert.Equal(s.expCalled, called, "step %d", i)
+					assert.Equal(s.expState, rec.state, "step %d", i)
+					continue
+				}
+				resp := cb.Review(context.TODO(), ar)
+
+				assert.Equal(s.expCalled, called, "step %d", i)
+				assert.Equal(s.expAllowed, resp.Allowed, "step %d", i)
+				assert.Equal(uid, string(resp.UID), "step %d", i)
+				assert.Equal(s.expState, rec.state, "step %d", i)
+			}
+			assert.Equal(test.expFailOpens, rec.failOpens[metrics.FailOpenReasonCircuitOpen])
+		})
+	}
+}
+
+func TestCircuitBreakerInvalidSettings(t *testing.T) {
+	_, err := webhook.NewCircuitBreaker(reviewFunc(nil), webhook.CircuitBreakerSettings{})
+	assert.Error(t, err)
+}
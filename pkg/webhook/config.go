@@ -0,0 +1,37 @@
+package webhook
+
+// ConfigView is a redacted and serializable view of the active configuration of a webhook,
+// useful for debugging endpoints (e.g `/config`). The view never has sensitive data, the
+// options that could have it (e.g schemas, redactors or mutators) are only shown as enabled
+// or disabled.
+type ConfigView struct {
+	// Name is the name of the webhook.
+	Name string `json:"name"`
+	// Kind is the kind of the webhook (`mutating` or `validating`).
+	Kind string `json:"kind"`
+	// Object is the object kind of the webhook, empty if the webhook infers the type.
+	Object string `json:"object,omitempty"`
+	// MetricsEnabled is true if the webhook has a metrics recorder.
+	MetricsEnabled bool `json:"metricsEnabled"`
+	// TracingEnabled is true if the webhook has a tracer.
+	TracingEnabled bool `json:"tracingEnabled"`
+	// Options are the specific options of the webhook kind.
+	Options map[string]interface{} `json:"options,omitempty"`
+}
+
+// ConfigViewer knows how to return the configuration view of a webhook, the webhooks created
+// by the library implement it.
+type ConfigViewer interface {
+	ConfigView() ConfigView
+}
+
+// GetConfigView returns the configuration view of the webhook, if the webhook can't return
+// its configuration view it will return false.
+func GetConfigView(wh Webhook) (ConfigView, bool) {
+	cv, ok := wh.(ConfigViewer)
+	if !ok {
+		return ConfigView{}, false
+	}
+
+	return cv.ConfigView(), true
+}
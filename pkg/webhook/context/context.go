@@ -4,6 +4,8 @@ import (
 	"context"
 
 	admissionv1beta1 "k8s.io/api/admission/v1beta1"
+	authenticationv1 "k8s.io/api/authentication/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
 type contextKey string
@@ -41,3 +43,86 @@ func IsAdmissionRequestDryRun(ctx context.Context) bool {
 
 	return *ar.DryRun
 }
+
+// GetAdmissionRequestKind returns the kind of the object of the admission request stored
+// on the context. When the webhook uses `matchPolicy: Equivalent` this is the kind
+// matched by the webhook rules (the object has been converted to this version), this
+// can differ from the kind submitted by the client (check GetAdmissionRequestOriginalKind).
+// If the request is missing it will return false.
+func GetAdmissionRequestKind(ctx context.Context) (metav1.GroupVersionKind, bool) {
+	ar := GetAdmissionRequest(ctx)
+	if ar == nil {
+		return metav1.GroupVersionKind{}, false
+	}
+
+	return ar.Kind, true
+}
+
+// GetAdmissionRequestOriginalKind returns the kind of the original API request (the kind submitted
+// by the client) of the admission request stored on the context. If the admission request doesn't
+// have the original kind it will fallback to the admission request kind. If the request is missing
+// it will return false.
+func GetAdmissionRequestOriginalKind(ctx context.Context) (metav1.GroupVersionKind, bool) {
+	ar := GetAdmissionRequest(ctx)
+	if ar == nil {
+		return metav1.GroupVersionKind{}, false
+	}
+
+	if ar.RequestKind == nil {
+		return ar.Kind, true
+	}
+
+	return *ar.RequestKind, true
+}
+
+var correlationIDKey = contextKey("correlationID")
+
+// SetCorrelationID will set the correlation ID of the review (e.g an upstream request ID) on the
+// context and return the new context that has the correlation ID set.
+func SetCorrelationID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, correlationIDKey, id)
+}
+
+// GetCorrelationID returns the correlation ID stored on the context. If there is no correlation
+// ID on the context it will fallback to the UID of the admission request stored on the context,
+// if the request is missing it will return an empty string.
+func GetCorrelationID(ctx context.Context) string {
+	if id, ok := ctx.Value(correlationIDKey).(string); ok && id != "" {
+		return id
+	}
+
+	ar := GetAdmissionRequest(ctx)
+	if ar == nil {
+		return ""
+	}
+
+	return string(ar.UID)
+}
+
+var selfTestKey = contextKey("selfTest")
+
+// SetSelfTest will mark the context as a webhook self-test review context and return the new
+// context.
+func SetSelfTest(ctx context.Context) context.Context {
+	return context.WithValue(ctx, selfTestKey, true)
+}
+
+// IsSelfTest returns true if the context is from a webhook self-test review. The self-test reviews
+// are not measured, and the mutators and validators can use it to skip their side effects (e.g
+// calls to external services).
+func IsSelfTest(ctx context.Context) bool {
+	selfTest, _ := ctx.Value(selfTestKey).(bool)
+	return selfTest
+}
+
+// GetUserInfo returns the information of the user that made the request (including the
+// groups and the extra information) of the admission request stored on the context. If
+// the request is missing it will return false.
+func GetUserInfo(ctx context.Context) (authenticationv1.UserInfo, bool) {
+	ar := GetAdmissionRequest(ctx)
+	if ar == nil {
+		return authenticationv1.UserInfo{}, false
+	}
+
+	return ar.UserInfo, true
+}
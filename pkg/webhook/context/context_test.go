@@ -6,6 +6,8 @@ import (
 
 	"github.com/stretchr/testify/assert"
 	admissionv1beta1 "k8s.io/api/admission/v1beta1"
+	authenticationv1 "k8s.io/api/authentication/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
 	whcontext "github.com/slok/kubewebhook/pkg/webhook/context"
 )
@@ -97,3 +99,138 @@ func TestIsAdmissionRequestDryRun(t *testing.T) {
 		})
 	}
 }
+
+func TestAdmissionRequestKinds(t *testing.T) {
+	tests := []struct {
+		name            string
+		ar              *admissionv1beta1.AdmissionRequest
+		expKind         metav1.GroupVersionKind
+		expOriginalKind metav1.GroupVersionKind
+		expOK           bool
+	}{
+		{
+			name:  "Missing admission review should return false.",
+			ar:    nil,
+			expOK: false,
+		},
+		{
+			name: "A review without request kind should return the kind as the original kind.",
+			ar: &admissionv1beta1.AdmissionRequest{
+				Kind: metav1.GroupVersionKind{Group: "apps", Version: "v1", Kind: "Deployment"},
+			},
+			expKind:         metav1.GroupVersionKind{Group: "apps", Version: "v1", Kind: "Deployment"},
+			expOriginalKind: metav1.GroupVersionKind{Group: "apps", Version: "v1", Kind: "Deployment"},
+			expOK:           true,
+		},
+		{
+			name: "A review with different kind and request kind should return both kinds.",
+			ar: &admissionv1beta1.AdmissionRequest{
+				Kind:        metav1.GroupVersionKind{Group: "apps", Version: "v1", Kind: "Deployment"},
+				RequestKind: &metav1.GroupVersionKind{Group: "apps", Version: "v1beta1", Kind: "Deployment"},
+			},
+			expKind:         metav1.GroupVersionKind{Group: "apps", Version: "v1", Kind: "Deployment"},
+			expOriginalKind: metav1.GroupVersionKind{Group: "apps", Version: "v1beta1", Kind: "Deployment"},
+			expOK:           true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert := assert.New(t)
+
+			ctx := context.TODO()
+			ctx = whcontext.SetAdmissionRequest(ctx, test.ar)
+
+			gotKind, ok := whcontext.GetAdmissionRequestKind(ctx)
+			assert.Equal(test.expOK, ok)
+			assert.Equal(test.expKind, gotKind)
+
+			gotOriginalKind, ok := whcontext.GetAdmissionRequestOriginalKind(ctx)
+			assert.Equal(test.expOK, ok)
+			assert.Equal(test.expOriginalKind, gotOriginalKind)
+		})
+	}
+}
+
+func TestCorrelationID(t *testing.T) {
+	tests := map[string]struct {
+		ctx   func() context.Context
+		expID string
+	}{
+		"A context without correlation ID and admission request should return an empty ID.": {
+			ctx:   func() context.Context { return context.TODO() },
+			expID: "",
+		},
+
+		"A context without correlation ID should fallback to the admission request UID.": {
+			ctx: func() context.Context {
+				return whcontext.SetAdmissionRequest(context.TODO(), &admissionv1beta1.AdmissionRequest{UID: "test-uid"})
+			},
+			expID: "test-uid",
+		},
+
+		"A context with correlation ID should return the correlation ID.": {
+			ctx: func() context.Context {
+				ctx := whcontext.SetAdmissionRequest(context.TODO(), &admissionv1beta1.AdmissionRequest{UID: "test-uid"})
+				return whcontext.SetCorrelationID(ctx, "test-correlation-id")
+			},
+			expID: "test-correlation-id",
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+
+			assert.Equal(test.expID, whcontext.GetCorrelationID(test.ctx()))
+		})
+	}
+}
+
+func TestSelfTest(t *testing.T) {
+	assert := assert.New(t)
+
+	assert.False(whcontext.IsSelfTest(context.TODO()))
+	assert.True(whcontext.IsSelfTest(whcontext.SetSelfTest(context.TODO())))
+}
+
+func TestUserInfo(t *testing.T) {
+	tests := map[string]struct {
+		ctx         context.Context
+		expUserInfo authenticationv1.UserInfo
+		expOK       bool
+	}{
+		"A context without admission request should return false.": {
+			ctx:   context.TODO(),
+			expOK: false,
+		},
+
+		"A context with admission request should return the full user information.": {
+			ctx: whcontext.SetAdmissionRequest(context.TODO(), &admissionv1beta1.AdmissionRequest{
+				UserInfo: authenticationv1.UserInfo{
+					Username: "john",
+					UID:      "1234",
+					Groups:   []string{"system:authenticated", "developers"},
+					Extra:    map[string]authenticationv1.ExtraValue{"scopes": {"view", "edit"}},
+				},
+			}),
+			expUserInfo: authenticationv1.UserInfo{
+				Username: "john",
+				UID:      "1234",
+				Groups:   []string{"system:authenticated", "developers"},
+				Extra:    map[string]authenticationv1.ExtraValue{"scopes": {"view", "edit"}},
+			},
+			expOK: true,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+
+			gotUserInfo, gotOK := whcontext.GetUserInfo(test.ctx)
+			assert.Equal(test.expOK, gotOK)
+			assert.Equal(test.expUserInfo, gotUserInfo)
+		})
+	}
+}
@@ -0,0 +1,33 @@
+package webhook
+
+import (
+	"fmt"
+	"math"
+	"time"
+)
+
+// RetryAfterError is an error of a transient failure (e.g a dependency temporarily unavailable).
+// When a mutator or validator returns this error, the webhook will deny the object with a
+// `ServerTimeout` status that has the retry after seconds details, so the API clients know they
+// should retry the request.
+type RetryAfterError struct {
+	// Err is the failure.
+	Err error
+	// RetryAfterSeconds are the seconds the client should wait before retrying.
+	RetryAfterSeconds int32
+}
+
+// NewRetryAfterError returns a new RetryAfterError. The retry after duration is rounded up to seconds.
+func NewRetryAfterError(err error, retryAfter time.Duration) error {
+	return &RetryAfterError{
+		Err:               err,
+		RetryAfterSeconds: int32(math.Ceil(retryAfter.Seconds())),
+	}
+}
+
+func (r *RetryAfterError) Error() string {
+	return fmt.Sprintf("%s (retry after %ds)", r.Err, r.RetryAfterSeconds)
+}
+
+// Unwrap returns the wrapped error.
+func (r *RetryAfterError) Unwrap() error { return r.Err }
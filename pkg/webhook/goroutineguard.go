@@ -0,0 +1,82 @@
+package webhook
+
+import (
+	"context"
+	"fmt"
+	"runtime"
+
+	admissionv1beta1 "k8s.io/api/admission/v1beta1"
+
+	"github.com/slok/kubewebhook/pkg/log"
+	"github.com/slok/kubewebhook/pkg/observability/metrics"
+)
+
+// GoroutineGuardConfig is the configuration of the goroutine guard.
+type GoroutineGuardConfig struct {
+	// Webhook is the guarded webhook.
+	Webhook Webhook
+	// Name is the name of the webhook used on the logs and metrics.
+	Name string
+	// MaxGrowth is the number of goroutines a review can leave running after it finishes
+	// without being considered a leak. By default 0.
+	MaxGrowth int
+	// MetricsRecorder is the recorder used to measure the warnings, by default `metrics.Dummy`.
+	MetricsRecorder metrics.Recorder
+	// Logger is the logger used to log the warnings, by default `log.Dummy`.
+	Logger log.Logger
+}
+
+func (c *GoroutineGuardConfig) defaults() error {
+	if c.Webhook == nil {
+		return fmt.Errorf("webhook is required")
+	}
+
+	if c.MaxGrowth < 0 {
+		return fmt.Errorf("max growth can't be negative")
+	}
+
+	if c.MetricsRecorder == nil {
+		c.MetricsRecorder = metrics.Dummy
+	}
+
+	if c.Logger == nil {
+		c.Logger = log.Dummy
+	}
+
+	return nil
+}
+
+type goroutineGuard struct {
+	cfg GoroutineGuardConfig
+}
+
+// NewGoroutineGuard returns a webhook that wraps the webhook and samples the number of goroutines
+// before and after the review, if the number of goroutines has grown more than expected it will
+// log and measure a warning. This is a diagnostic aid to detect goroutine leaks on mutators and
+// validators (e.g parallel lookups), the review result is not modified.
+//
+// The number of goroutines is global to the process, the concurrent reviews and the rest of the
+// application goroutines can produce false positives, so the guard should only be used as a
+// leak detection signal on development or with low concurrency.
+func NewGoroutineGuard(cfg GoroutineGuardConfig) (Webhook, error) {
+	if err := cfg.defaults(); err != nil {
+		return nil, fmt.Errorf("invalid configuration: %w", err)
+	}
+
+	return goroutineGuard{cfg: cfg}, nil
+}
+
+func (g goroutineGuard) Review(ctx context.Context, ar *admissionv1beta1.AdmissionReview) *admissionv1beta1.AdmissionResponse {
+	before := runtime.NumGoroutine()
+	resp := g.cfg.Webhook.Review(ctx, ar)
+	after := runtime.NumGoroutine()
+
+	if growth := after - before; growth > g.cfg.MaxGrowth {
+		g.cfg.Logger.Warningf("webhook %s review %s increased the number of goroutines by %d (%d -> %d), possible goroutine leak", g.cfg.Name, ar.Request.UID, growth, before, after)
+		if rec, ok := g.cfg.MetricsRecorder.(metrics.GoroutineGrowthRecorder); ok {
+			rec.IncGoroutineGrowthWarning(g.cfg.Name)
+		}
+	}
+
+	return resp
+}
@@ -0,0 +1,103 @@
+package webhook_test
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	admissionv1beta1 "k8s.io/api/admission/v1beta1"
+
+	mmetrics "github.com/slok/kubewebhook/mocks/observability/metrics"
+	"github.com/slok/kubewebhook/pkg/internal/testutil"
+	"github.com/slok/kubewebhook/pkg/webhook"
+)
+
+type reviewFunc func(ctx context.Context, ar *admissionv1beta1.AdmissionReview) *admissionv1beta1.AdmissionResponse
+
+func (r reviewFunc) Review(ctx context.Context, ar *admissionv1beta1.AdmissionReview) *admissionv1beta1.AdmissionResponse {
+	return r(ctx, ar)
+}
+
+func TestGoroutineGuard(t *testing.T) {
+	// The number of goroutines is global to the process, the cases run in order and wait for their
+	// leaked goroutines to end, and the max growth has a margin for the unrelated goroutines.
+	tests := []struct {
+		name             string
+		leakedGoroutines int
+		maxGrowth        int
+		expWarning       bool
+	}{
+		{
+			name:             "A review that doesn't leak goroutines should not warn.",
+			leakedGoroutines: 0,
+			maxGrowth:        2,
+			expWarning:       false,
+		},
+		{
+			name:             "A review that leaks goroutines should warn.",
+			leakedGoroutines: 10,
+			maxGrowth:        2,
+			expWarning:       true,
+		},
+		{
+			name:             "A review that leaks goroutines under the max growth should not warn.",
+			leakedGoroutines: 3,
+			maxGrowth:        10,
+			expWarning:       false,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert := assert.New(t)
+			require := require.New(t)
+
+			// Simulate a goroutine leak, the goroutines are released and waited at the end of the test.
+			release := make(chan struct{})
+			var wg sync.WaitGroup
+			defer func() {
+				close(release)
+				wg.Wait()
+			}()
+			wh := reviewFunc(func(_ context.Context, ar *admissionv1beta1.AdmissionReview) *admissionv1beta1.AdmissionResponse {
+				for i := 0; i < test.leakedGoroutines; i++ {
+					wg.Add(1)
+					go func() {
+						defer wg.Done()
+						<-release
+					}()
+				}
+				return &admissionv1beta1.AdmissionResponse{UID: ar.Request.UID, Allowed: true}
+			})
+
+			mrec := &mmetrics.Recorder{}
+			if test.expWarning {
+				mrec.On("IncGoroutineGrowthWarning", "test").Once()
+			}
+			logger := &testutil.Logger{}
+
+			guard, err := webhook.NewGoroutineGuard(webhook.GoroutineGuardConfig{
+				Webhook:         wh,
+				Name:            "test",
+				MaxGrowth:       test.maxGrowth,
+				MetricsRecorder: mrec,
+				Logger:          logger,
+			})
+			require.NoError(err)
+
+			resp := guard.Review(context.TODO(), &admissionv1beta1.AdmissionReview{Request: &admissionv1beta1.AdmissionRequest{UID: "1234"}})
+
+			// The review result should not be modified.
+			assert.Equal(&admissionv1beta1.AdmissionResponse{UID: "1234", Allowed: true}, resp)
+			if test.expWarning {
+				require.Len(logger.Warnings, 1)
+				assert.Contains(logger.Warnings[0], "possible goroutine leak")
+			} else {
+				assert.Empty(logger.Warnings)
+			}
+			mrec.AssertExpectations(t)
+		})
+	}
+}
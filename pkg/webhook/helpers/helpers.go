@@ -0,0 +1,86 @@
+// Package helpers has public utilities to help the users of the library, for example
+// to test their webhooks end to end.
+package helpers
+
+import (
+	"fmt"
+
+	jsonpatch "github.com/evanphx/json-patch"
+	admissionv1beta1 "k8s.io/api/admission/v1beta1"
+	"k8s.io/apimachinery/pkg/util/strategicpatch"
+	clientsetscheme "k8s.io/client-go/kubernetes/scheme"
+)
+
+const (
+	// PatchTypeMergePatch is the JSON merge patch (RFC 7386) type.
+	PatchTypeMergePatch admissionv1beta1.PatchType = "MergePatch"
+	// PatchTypeStrategicMergePatch is the Kubernetes strategic merge patch type.
+	PatchTypeStrategicMergePatch admissionv1beta1.PatchType = "StrategicMergePatch"
+)
+
+// ApplyResponse simulates the Kubernetes API server applying the patch of an admission
+// response to the original raw object and returns the final object raw data. Useful to
+// write golden tests of webhooks (given an input object, expect an output object).
+//
+// If the response doesn't have a patch, the original object will be returned. The
+// responses that don't allow the object will return an error because the API server
+// would not persist the object.
+//
+// Apart from JSON patches (the only type the API server supports at this moment),
+// JSON merge and strategic merge patches are supported. Strategic merge patches need
+// the object type registered on the client-go scheme.
+func ApplyResponse(originalRaw []byte, resp *admissionv1beta1.AdmissionResponse) ([]byte, error) {
+	if resp == nil {
+		return nil, fmt.Errorf("admission response is required")
+	}
+
+	if !resp.Allowed {
+		msg := ""
+		if resp.Result != nil {
+			msg = resp.Result.Message
+		}
+		return nil, fmt.Errorf("admission response is not allowed: %s", msg)
+	}
+
+	if len(resp.Patch) == 0 {
+		return originalRaw, nil
+	}
+
+	patchType := admissionv1beta1.PatchTypeJSONPatch
+	if resp.PatchType != nil {
+		patchType = *resp.PatchType
+	}
+
+	switch patchType {
+	case admissionv1beta1.PatchTypeJSONPatch:
+		patch, err := jsonpatch.DecodePatch(resp.Patch)
+		if err != nil {
+			return nil, fmt.Errorf("could not decode JSON patch: %w", err)
+		}
+		res, err := patch.Apply(originalRaw)
+		if err != nil {
+			return nil, fmt.Errorf("could not apply JSON patch: %w", err)
+		}
+		return res, nil
+
+	case PatchTypeMergePatch:
+		res, err := jsonpatch.MergePatch(originalRaw, resp.Patch)
+		if err != nil {
+			return nil, fmt.Errorf("could not apply merge patch: %w", err)
+		}
+		return res, nil
+
+	case PatchTypeStrategicMergePatch:
+		obj, _, err := clientsetscheme.Codecs.UniversalDeserializer().Decode(originalRaw, nil, nil)
+		if err != nil {
+			return nil, fmt.Errorf("could not infer the object type for the strategic merge patch: %w", err)
+		}
+		res, err := strategicpatch.StrategicMergePatch(originalRaw, resp.Patch, obj)
+		if err != nil {
+			return nil, fmt.Errorf("could not apply strategic merge patch: %w", err)
+		}
+		return res, nil
+	}
+
+	return nil, fmt.Errorf("unsupported patch type: %s", patchType)
+}
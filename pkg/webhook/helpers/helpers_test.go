@@ -0,0 +1,101 @@
+package helpers_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	admissionv1beta1 "k8s.io/api/admission/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/slok/kubewebhook/pkg/webhook/helpers"
+)
+
+func TestApplyResponse(t *testing.T) {
+	patchTypePtr := func(pt admissionv1beta1.PatchType) *admissionv1beta1.PatchType { return &pt }
+	originalRaw := `{"apiVersion":"v1","kind":"Pod","metadata":{"name":"test","labels":{"app":"test"}},"spec":{"containers":[{"name":"app","image":"app:v1"},{"name":"sidecar","image":"sidecar:v1"}]}}`
+
+	tests := map[string]struct {
+		resp   *admissionv1beta1.AdmissionResponse
+		expObj string
+		expErr bool
+	}{
+		"A response without patch should return the original object.": {
+			resp:   &admissionv1beta1.AdmissionResponse{Allowed: true},
+			expObj: originalRaw,
+		},
+
+		"A not allowed response should fail.": {
+			resp:   &admissionv1beta1.AdmissionResponse{Allowed: false, Result: &metav1.Status{Message: "denied"}},
+			expErr: true,
+		},
+
+		"A JSON patch response should apply the patch.": {
+			resp: &admissionv1beta1.AdmissionResponse{
+				Allowed:   true,
+				Patch:     []byte(`[{"op":"add","path":"/metadata/labels/team","value":"a"},{"op":"remove","path":"/spec/containers/1"}]`),
+				PatchType: patchTypePtr(admissionv1beta1.PatchTypeJSONPatch),
+			},
+			expObj: `{"apiVersion":"v1","kind":"Pod","metadata":{"name":"test","labels":{"app":"test","team":"a"}},"spec":{"containers":[{"name":"app","image":"app:v1"}]}}`,
+		},
+
+		"A JSON patch response without patch type should apply the patch as a JSON patch.": {
+			resp: &admissionv1beta1.AdmissionResponse{
+				Allowed: true,
+				Patch:   []byte(`[{"op":"replace","path":"/metadata/name","value":"test2"}]`),
+			},
+			expObj: `{"apiVersion":"v1","kind":"Pod","metadata":{"name":"test2","labels":{"app":"test"}},"spec":{"containers":[{"name":"app","image":"app:v1"},{"name":"sidecar","image":"sidecar:v1"}]}}`,
+		},
+
+		"An invalid JSON patch response should fail.": {
+			resp: &admissionv1beta1.AdmissionResponse{
+				Allowed: true,
+				Patch:   []byte(`[{"op":"remove","path":"/metadata/annotations/missing"}]`),
+			},
+			expErr: true,
+		},
+
+		"A merge patch response should apply the patch replacing the lists.": {
+			resp: &admissionv1beta1.AdmissionResponse{
+				Allowed:   true,
+				Patch:     []byte(`{"metadata":{"labels":{"app":null,"team":"a"}},"spec":{"containers":[{"name":"app","image":"app:v2"}]}}`),
+				PatchType: patchTypePtr(helpers.PatchTypeMergePatch),
+			},
+			expObj: `{"apiVersion":"v1","kind":"Pod","metadata":{"name":"test","labels":{"team":"a"}},"spec":{"containers":[{"name":"app","image":"app:v2"}]}}`,
+		},
+
+		"A strategic merge patch response should apply the patch merging the lists by key.": {
+			resp: &admissionv1beta1.AdmissionResponse{
+				Allowed:   true,
+				Patch:     []byte(`{"metadata":{"labels":{"team":"a"}},"spec":{"containers":[{"name":"app","image":"app:v2"}]}}`),
+				PatchType: patchTypePtr(helpers.PatchTypeStrategicMergePatch),
+			},
+			expObj: `{"apiVersion":"v1","kind":"Pod","metadata":{"name":"test","labels":{"app":"test","team":"a"}},"spec":{"containers":[{"name":"app","image":"app:v2"},{"name":"sidecar","image":"sidecar:v1"}]}}`,
+		},
+
+		"An unknown patch type should fail.": {
+			resp: &admissionv1beta1.AdmissionResponse{
+				Allowed:   true,
+				Patch:     []byte(`{}`),
+				PatchType: patchTypePtr("Unknown"),
+			},
+			expErr: true,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+			require := require.New(t)
+
+			gotObj, err := helpers.ApplyResponse([]byte(originalRaw), test.resp)
+
+			if test.expErr {
+				assert.Error(err)
+			} else if assert.NoError(err) {
+				require.NotNil(gotObj)
+				assert.JSONEq(test.expObj, string(gotObj))
+			}
+		})
+	}
+}
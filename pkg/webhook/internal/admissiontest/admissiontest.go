@@ -0,0 +1,70 @@
+// Package admissiontest has the test helpers to check the admission reviews
+// produced by the webhooks.
+package admissiontest
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	jsonpatch "github.com/evanphx/json-patch"
+	admissionv1beta1 "k8s.io/api/admission/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// ValidateResponse validates an admission response against the admission API schema
+// and the request it responds to, returning an error with all the violations.
+func ValidateResponse(req *admissionv1beta1.AdmissionRequest, resp *admissionv1beta1.AdmissionResponse) error {
+	if resp == nil {
+		return fmt.Errorf("invalid admission response: response is missing")
+	}
+
+	var errs []string
+
+	if resp.UID == "" {
+		errs = append(errs, "uid is missing")
+	} else if req != nil && resp.UID != req.UID {
+		errs = append(errs, fmt.Sprintf("uid %q doesn't match the request uid %q", resp.UID, req.UID))
+	}
+
+	if resp.PatchType != nil && *resp.PatchType != admissionv1beta1.PatchTypeJSONPatch {
+		errs = append(errs, fmt.Sprintf("unknown patch type %q", *resp.PatchType))
+	}
+
+	if len(resp.Patch) > 0 {
+		if resp.PatchType == nil {
+			errs = append(errs, "patch type is missing")
+		}
+		if !resp.Allowed {
+			errs = append(errs, "patch is not allowed on denied responses")
+		}
+		if _, err := jsonpatch.DecodePatch(resp.Patch); err != nil {
+			errs = append(errs, fmt.Sprintf("invalid JSON patch: %s", err))
+		}
+	}
+
+	if resp.Result != nil {
+		switch resp.Result.Status {
+		case "", metav1.StatusSuccess, metav1.StatusFailure:
+		default:
+			errs = append(errs, fmt.Sprintf("unknown result status %q", resp.Result.Status))
+		}
+	}
+
+	for k := range resp.AuditAnnotations {
+		if k == "" {
+			errs = append(errs, "audit annotation key is empty")
+		}
+	}
+
+	// The response needs to be serializable to be sent to the API server.
+	if _, err := json.Marshal(resp); err != nil {
+		errs = append(errs, fmt.Sprintf("response is not serializable: %s", err))
+	}
+
+	if len(errs) > 0 {
+		return fmt.Errorf("invalid admission response: %s", strings.Join(errs, "; "))
+	}
+
+	return nil
+}
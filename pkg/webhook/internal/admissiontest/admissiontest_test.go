@@ -0,0 +1,125 @@
+package admissiontest_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	admissionv1beta1 "k8s.io/api/admission/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/slok/kubewebhook/pkg/webhook/internal/admissiontest"
+)
+
+func TestValidateResponse(t *testing.T) {
+	jsonPatch := admissionv1beta1.PatchTypeJSONPatch
+	unknownPatch := admissionv1beta1.PatchType("MergePatch")
+	req := &admissionv1beta1.AdmissionRequest{UID: "test"}
+
+	tests := map[string]struct {
+		resp   *admissionv1beta1.AdmissionResponse
+		expErr string
+	}{
+		"A valid allowed response should be valid.": {
+			resp: &admissionv1beta1.AdmissionResponse{UID: "test", Allowed: true},
+		},
+
+		"A valid patched response should be valid.": {
+			resp: &admissionv1beta1.AdmissionResponse{
+				UID:       "test",
+				Allowed:   true,
+				PatchType: &jsonPatch,
+				Patch:     []byte(`[{"op":"add","path":"/metadata/labels","value":{"a":"b"}}]`),
+			},
+		},
+
+		"A valid denied response should be valid.": {
+			resp: &admissionv1beta1.AdmissionResponse{
+				UID:     "test",
+				Allowed: false,
+				Result:  &metav1.Status{Status: metav1.StatusFailure, Message: "denied"},
+			},
+		},
+
+		"A missing response should be invalid.": {
+			resp:   nil,
+			expErr: "invalid admission response: response is missing",
+		},
+
+		"A response without UID should be invalid.": {
+			resp:   &admissionv1beta1.AdmissionResponse{Allowed: true},
+			expErr: "invalid admission response: uid is missing",
+		},
+
+		"A response with a different UID than the request should be invalid.": {
+			resp:   &admissionv1beta1.AdmissionResponse{UID: "other", Allowed: true},
+			expErr: `invalid admission response: uid "other" doesn't match the request uid "test"`,
+		},
+
+		"A response with a patch without patch type should be invalid.": {
+			resp: &admissionv1beta1.AdmissionResponse{
+				UID:     "test",
+				Allowed: true,
+				Patch:   []byte(`[]`),
+			},
+			expErr: "invalid admission response: patch type is missing",
+		},
+
+		"A response with an unknown patch type should be invalid.": {
+			resp: &admissionv1beta1.AdmissionResponse{
+				UID:       "test",
+				Allowed:   true,
+				PatchType: &unknownPatch,
+				Patch:     []byte(`[]`),
+			},
+			expErr: `invalid admission response: unknown patch type "MergePatch"`,
+		},
+
+		"A denied response with a patch should be invalid.": {
+			resp: &admissionv1beta1.AdmissionResponse{
+				UID:       "test",
+				PatchType: &jsonPatch,
+				Patch:     []byte(`[]`),
+			},
+			expErr: "invalid admission response: patch is not allowed on denied responses",
+		},
+
+		"A response with an invalid JSON patch should be invalid.": {
+			resp: &admissionv1beta1.AdmissionResponse{
+				UID:       "test",
+				Allowed:   true,
+				PatchType: &jsonPatch,
+				Patch:     []byte(`{"op":"add"}`),
+			},
+			expErr: "invalid admission response: invalid JSON patch: json: cannot unmarshal object into Go value of type jsonpatch.Patch",
+		},
+
+		"A response with an unknown result status should be invalid.": {
+			resp: &admissionv1beta1.AdmissionResponse{
+				UID:    "test",
+				Result: &metav1.Status{Status: "Denied"},
+			},
+			expErr: `invalid admission response: unknown result status "Denied"`,
+		},
+
+		"A response with multiple violations should return all of them.": {
+			resp: &admissionv1beta1.AdmissionResponse{
+				Patch: []byte(`[]`),
+			},
+			expErr: "invalid admission response: uid is missing; patch type is missing; patch is not allowed on denied responses",
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+
+			err := admissiontest.ValidateResponse(req, test.resp)
+
+			if test.expErr != "" {
+				assert.EqualError(err, test.expErr)
+			} else {
+				assert.NoError(err)
+			}
+		})
+	}
+}
@@ -1,7 +1,9 @@
 package helpers
 
 import (
+	"errors"
 	"fmt"
+	"net/http"
 	"reflect"
 	"strings"
 
@@ -9,23 +11,73 @@ import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/apimachinery/pkg/runtime/serializer"
 	"k8s.io/apimachinery/pkg/types"
 	clientsetscheme "k8s.io/client-go/kubernetes/scheme"
 
 	"github.com/slok/kubewebhook/pkg/log"
+	"github.com/slok/kubewebhook/pkg/webhook"
 )
 
 // ToAdmissionErrorResponse transforms an error into a admission response with error.
+// In case of a retry after error, the response status will have the `ServerTimeout` reason, the
+// retry after details and the 500 code (like `apierrors.NewServerTimeout`), without the code the
+// API server would respond with a forbidden status and the clients would not retry.
 func ToAdmissionErrorResponse(uid types.UID, err error, logger log.Logger) *admissionv1beta1.AdmissionResponse {
 	logger.Errorf("admission webhook error: %s", err)
-	return &admissionv1beta1.AdmissionResponse{
+	resp := &admissionv1beta1.AdmissionResponse{
 		UID: uid,
 		Result: &metav1.Status{
 			Message: err.Error(),
 			Status:  metav1.StatusFailure,
 		},
 	}
+
+	var raErr *webhook.RetryAfterError
+	if errors.As(err, &raErr) {
+		resp.Result.Reason = metav1.StatusReasonServerTimeout
+		resp.Result.Code = http.StatusInternalServerError
+		resp.Result.Details = &metav1.StatusDetails{RetryAfterSeconds: raErr.RetryAfterSeconds}
+	}
+
+	return resp
+}
+
+// ToAdmissionAllowedNoOpResponse returns an admission response that allows the resource
+// without any modification.
+func ToAdmissionAllowedNoOpResponse(uid types.UID) *admissionv1beta1.AdmissionResponse {
+	return &admissionv1beta1.AdmissionResponse{
+		UID:     uid,
+		Allowed: true,
+	}
+}
+
+// GroupKindIn returns true if the group and kind of the received GroupVersionKind
+// are in the list of GroupKinds.
+func GroupKindIn(gvk metav1.GroupVersionKind, gks []schema.GroupKind) bool {
+	for _, gk := range gks {
+		if gk.Group == gvk.Group && gk.Kind == gvk.Kind {
+			return true
+		}
+	}
+	return false
+}
+
+// ObjectGroupKind returns the group and kind of a Kubernetes object type registered on the
+// global client Scheme. If the type is not registered it will return false.
+func ObjectGroupKind(obj metav1.Object) (schema.GroupKind, bool) {
+	robj, ok := obj.(runtime.Object)
+	if !ok {
+		return schema.GroupKind{}, false
+	}
+
+	gvks, _, err := clientsetscheme.Scheme.ObjectKinds(robj)
+	if err != nil || len(gvks) == 0 {
+		return schema.GroupKind{}, false
+	}
+
+	return gvks[0].GroupKind(), true
 }
 
 // NewK8sObj returns a new object of a Kubernetes type based on the type.
@@ -114,3 +166,26 @@ func (d dynamicObjectCreator) NewObject(rawJSON []byte) (runtime.Object, error)
 	}
 	return runtimeObj, err
 }
+
+// ConfigViewObject returns the object kind of a webhook configuration view, if the object group
+// kind is unknown the object type will be used.
+func ConfigViewObject(obj metav1.Object, gk *schema.GroupKind) string {
+	if obj == nil {
+		return ""
+	}
+
+	if gk != nil {
+		return gk.String()
+	}
+
+	return fmt.Sprintf("%T", obj)
+}
+
+// GroupKindsStrings returns the string representation of the group kinds.
+func GroupKindsStrings(gks []schema.GroupKind) []string {
+	strs := make([]string, 0, len(gks))
+	for _, gk := range gks {
+		strs = append(strs, gk.String())
+	}
+	return strs
+}
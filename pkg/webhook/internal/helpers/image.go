@@ -0,0 +1,23 @@
+package helpers
+
+import "strings"
+
+// DefaultImageRegistry is the registry used by the images that don't have a registry.
+const DefaultImageRegistry = "docker.io"
+
+// ImageRegistry returns the registry host of a container image reference, the images without
+// registry (e.g `nginx:1.19`, `library/nginx`) will return the default registry.
+func ImageRegistry(image string) string {
+	i := strings.IndexRune(image, '/')
+	if i < 0 {
+		return DefaultImageRegistry
+	}
+
+	// Same logic as Docker, the first component is a registry if it looks like a host.
+	first := image[:i]
+	if !strings.ContainsAny(first, ".:") && first != "localhost" {
+		return DefaultImageRegistry
+	}
+
+	return first
+}
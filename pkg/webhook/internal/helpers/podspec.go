@@ -0,0 +1,62 @@
+package helpers
+
+import (
+	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
+	batchv1beta1 "k8s.io/api/batch/v1beta1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// PodTemplate returns the pod metadata and pod spec of the object. The object can be a pod
+// or a workload that has a pod template (e.g Deployment, Job, CronJob...). If the object
+// doesn't have a pod spec it will return false.
+//
+// In case of a pod, the returned metadata will be the pod metadata itself.
+func PodTemplate(obj metav1.Object) (meta *metav1.ObjectMeta, spec *corev1.PodSpec, ok bool) {
+	switch o := obj.(type) {
+	case *corev1.Pod:
+		return &o.ObjectMeta, &o.Spec, true
+	case *corev1.PodTemplate:
+		return &o.Template.ObjectMeta, &o.Template.Spec, true
+	case *corev1.ReplicationController:
+		if o.Spec.Template == nil {
+			return nil, nil, false
+		}
+		return &o.Spec.Template.ObjectMeta, &o.Spec.Template.Spec, true
+	case *appsv1.Deployment:
+		return &o.Spec.Template.ObjectMeta, &o.Spec.Template.Spec, true
+	case *appsv1.StatefulSet:
+		return &o.Spec.Template.ObjectMeta, &o.Spec.Template.Spec, true
+	case *appsv1.DaemonSet:
+		return &o.Spec.Template.ObjectMeta, &o.Spec.Template.Spec, true
+	case *appsv1.ReplicaSet:
+		return &o.Spec.Template.ObjectMeta, &o.Spec.Template.Spec, true
+	case *batchv1.Job:
+		return &o.Spec.Template.ObjectMeta, &o.Spec.Template.Spec, true
+	case *batchv1beta1.CronJob:
+		return &o.Spec.JobTemplate.Spec.Template.ObjectMeta, &o.Spec.JobTemplate.Spec.Template.Spec, true
+	}
+
+	return nil, nil, false
+}
+
+// PodSpec returns the pod spec of the object, check PodTemplate for more information.
+func PodSpec(obj metav1.Object) (*corev1.PodSpec, bool) {
+	_, spec, ok := PodTemplate(obj)
+	return spec, ok
+}
+
+// AllowedRestartPolicies returns the restart policies that the pods of the object
+// can have. Pods can have any restart policy, Jobs only `OnFailure` and `Never`, and
+// the rest of the long running workloads only `Always`.
+func AllowedRestartPolicies(obj metav1.Object) []corev1.RestartPolicy {
+	switch obj.(type) {
+	case *corev1.Pod, *corev1.PodTemplate:
+		return []corev1.RestartPolicy{corev1.RestartPolicyAlways, corev1.RestartPolicyOnFailure, corev1.RestartPolicyNever}
+	case *batchv1.Job, *batchv1beta1.CronJob:
+		return []corev1.RestartPolicy{corev1.RestartPolicyOnFailure, corev1.RestartPolicyNever}
+	}
+
+	return []corev1.RestartPolicy{corev1.RestartPolicyAlways}
+}
@@ -2,15 +2,20 @@ package instrumenting
 
 import (
 	"context"
+	"encoding/json"
+	"fmt"
 	"time"
 
 	opentracing "github.com/opentracing/opentracing-go"
 	opentracingext "github.com/opentracing/opentracing-go/ext"
 	admissionv1beta1 "k8s.io/api/admission/v1beta1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
 
+	"github.com/slok/kubewebhook/pkg/log"
 	"github.com/slok/kubewebhook/pkg/observability/metrics"
 	"github.com/slok/kubewebhook/pkg/webhook"
+	whcontext "github.com/slok/kubewebhook/pkg/webhook/context"
 	"github.com/slok/kubewebhook/pkg/webhook/internal/helpers"
 )
 
@@ -23,24 +28,96 @@ type Webhook struct {
 	ReviewKind      metrics.ReviewKind
 	MetricsRecorder metrics.Recorder
 	Tracer          opentracing.Tracer
+	// LogRedactor redacts the sensitive data of the patches logged on the traces.
+	LogRedactor log.Redactor
+	// OwnerKind will instrument the reviews with the kind of the reviewed object controller owner.
+	OwnerKind bool
+	// SlowThreshold is the review duration that once exceeded will mark the review as slow, the
+	// slow reviews will succeed but with a warning and will be measured. If 0 it will be disabled.
+	SlowThreshold time.Duration
+	// ExpectedTimeout is the webhook timeout configured on the API server, the reviews that exceed the
+	// DeadlineWarningRatio of it will succeed but with a warning and will be measured. If 0 it will be disabled.
+	ExpectedTimeout      time.Duration
+	DeadlineWarningRatio float64
+	// TraceBaggage will set the reviewed object group, version, kind and the operation as tracing
+	// baggage, so these are propagated to the downstream services called by the webhook.
+	TraceBaggage bool
 }
 
 // Review will review using the webhook wrapping it with instrumentation.
 func (w *Webhook) Review(ctx context.Context, ar *admissionv1beta1.AdmissionReview) *admissionv1beta1.AdmissionResponse {
+	// The self-test reviews are not measured, only traced.
+	if whcontext.IsSelfTest(ctx) && w.MetricsRecorder != metrics.Dummy {
+		wc := *w
+		wc.MetricsRecorder = metrics.Dummy
+		return wc.Review(ctx, ar)
+	}
+
+	// Set the admission request on the context if missing (e.g the webhook not being served by
+	// the library HTTP handler), so the context helpers (e.g the correlation ID) can rely on it.
+	if whcontext.GetAdmissionRequest(ctx) == nil {
+		ctx = whcontext.SetAdmissionRequest(ctx, ar.Request)
+	}
+
 	// Initialize metrics.
 	w.incAdmissionReviewMetric(ar, false)
 	start := time.Now()
 	defer w.observeAdmissionReviewDuration(ar, start)
 
+	// Delete operations have the object as the old object.
+	raw := ar.Request.Object.Raw
+	if ar.Request.Operation == admissionv1beta1.Delete {
+		raw = ar.Request.OldObject.Raw
+	}
+	if rec, ok := w.MetricsRecorder.(metrics.ObjectSizeRecorder); ok {
+		rec.ObserveAdmissionReviewObjectSize(w.WebhookName, ar.Request.Kind.Kind, len(raw))
+	}
+
 	// Create the span, add to the context and defer the finish of the span.
 	span := w.createReviewSpan(ctx, ar)
 	ctx = opentracing.ContextWithSpan(ctx, span)
 	defer span.Finish()
 
+	if w.TraceBaggage {
+		setReviewBaggage(span, ar)
+	}
+
+	if w.OwnerKind {
+		ownerKind := reviewOwnerKind(ar)
+		if rec, ok := w.MetricsRecorder.(metrics.OwnerKindRecorder); ok {
+			rec.IncAdmissionReviewOwnerKind(w.WebhookName, ownerKind)
+		}
+		span.SetTag("kubernetes.review.ownerKind", ownerKind)
+	}
+
 	// Call the review process.
 	span.LogKV("event", "start_review")
 	resp := w.Webhook.Review(ctx, ar)
 
+	// Mark the slow reviews.
+	if d := time.Since(start); w.SlowThreshold > 0 && d > w.SlowThreshold {
+		if rec, ok := w.MetricsRecorder.(metrics.SlowReviewRecorder); ok {
+			rec.IncAdmissionReviewSlow(w.WebhookName)
+		}
+		resp.Warnings = append(resp.Warnings, fmt.Sprintf("webhook %q review took %s, more than the %s slow threshold", w.WebhookName, d.Round(time.Millisecond), w.SlowThreshold))
+		span.LogKV(
+			"event", "slow_review",
+			"duration", d.String(),
+		)
+	}
+
+	// Mark the reviews close to the API server timeout.
+	if d := time.Since(start); w.ExpectedTimeout > 0 && d > time.Duration(float64(w.ExpectedTimeout)*w.DeadlineWarningRatio) {
+		if rec, ok := w.MetricsRecorder.(metrics.NearDeadlineRecorder); ok {
+			rec.IncAdmissionReviewNearDeadline(w.WebhookName)
+		}
+		resp.Warnings = append(resp.Warnings, fmt.Sprintf("webhook %q review took %s, more than %g%% of the %s expected timeout", w.WebhookName, d.Round(time.Millisecond), w.DeadlineWarningRatio*100, w.ExpectedTimeout))
+		span.LogKV(
+			"event", "near_deadline_review",
+			"duration", d.String(),
+		)
+	}
+
 	// Check if we had an error on the review or it ended correctly.
 	if resp.Result != nil && resp.Result.Status == metav1.StatusFailure {
 		w.incAdmissionReviewMetric(ar, true)
@@ -57,6 +134,11 @@ func (w *Webhook) Review(ctx context.Context, ar *admissionv1beta1.AdmissionRevi
 		w.incValidationReviewResultMetric(ar, resp.Allowed)
 	}
 
+	patch := resp.Patch
+	if w.LogRedactor != nil && len(patch) > 0 {
+		patch = w.LogRedactor.RedactPatch(schema.GroupKind{Group: ar.Request.Kind.Group, Kind: ar.Request.Kind.Kind}, patch)
+	}
+
 	var msg, status string
 	if resp.Result != nil {
 		msg = resp.Result.Message
@@ -66,13 +148,60 @@ func (w *Webhook) Review(ctx context.Context, ar *admissionv1beta1.AdmissionRevi
 		"event", "end_review",
 		"allowed", resp.Allowed,
 		"message", msg,
-		"patch", string(resp.Patch),
+		"patch", string(patch),
 		"status", status,
 	)
 
 	return resp
 }
 
+// ConfigView satisfies webhook.ConfigViewer interface.
+func (w *Webhook) ConfigView() webhook.ConfigView {
+	cv, _ := webhook.GetConfigView(w.Webhook)
+	cv.Name = w.WebhookName
+	cv.Kind = string(w.ReviewKind)
+	cv.MetricsEnabled = w.MetricsRecorder != nil && w.MetricsRecorder != metrics.Dummy
+	_, noopTracer := w.Tracer.(*opentracing.NoopTracer)
+	cv.TracingEnabled = w.Tracer != nil && !noopTracer
+	if cv.Options == nil {
+		cv.Options = map[string]interface{}{}
+	}
+	cv.Options["instrumentOwnerKind"] = w.OwnerKind
+	cv.Options["slowThreshold"] = w.SlowThreshold.String()
+	cv.Options["expectedTimeout"] = w.ExpectedTimeout.String()
+	cv.Options["deadlineWarningRatio"] = w.DeadlineWarningRatio
+	cv.Options["traceBaggage"] = w.TraceBaggage
+
+	return cv
+}
+
+// noOwnerKind is the owner kind of the objects without owner.
+const noOwnerKind = "none"
+
+// reviewOwnerKind returns the kind of the reviewed object controller owner (or the first owner
+// if there isn't a controller). Only the kind is used to have a bounded cardinality.
+func reviewOwnerKind(ar *admissionv1beta1.AdmissionReview) string {
+	raw := ar.Request.Object.Raw
+	if len(raw) == 0 {
+		raw = ar.Request.OldObject.Raw
+	}
+
+	obj := &metav1.PartialObjectMetadata{}
+	if err := json.Unmarshal(raw, obj); err != nil {
+		return noOwnerKind
+	}
+
+	ref := metav1.GetControllerOf(obj)
+	if ref == nil {
+		if len(obj.OwnerReferences) == 0 {
+			return noOwnerKind
+		}
+		ref = &obj.OwnerReferences[0]
+	}
+
+	return ref.Kind
+}
+
 func (w *Webhook) incAdmissionReviewMetric(ar *admissionv1beta1.AdmissionReview, err bool) {
 	if err {
 		w.MetricsRecorder.IncAdmissionReviewError(
@@ -92,6 +221,19 @@ func (w *Webhook) incAdmissionReviewMetric(ar *admissionv1beta1.AdmissionReview,
 }
 
 func (w *Webhook) observeAdmissionReviewDuration(ar *admissionv1beta1.AdmissionReview, start time.Time) {
+	// Use the review UID as exemplar if the recorder supports exemplars.
+	if er, ok := w.MetricsRecorder.(metrics.ExemplarRecorder); ok && ar.Request.UID != "" {
+		er.ObserveAdmissionReviewDurationWithExemplar(
+			w.WebhookName,
+			ar.Request.Namespace,
+			helpers.GroupVersionResourceToString(ar.Request.Resource),
+			ar.Request.Operation,
+			w.ReviewKind,
+			start,
+			map[string]string{"uid": string(ar.Request.UID)})
+		return
+	}
+
 	w.MetricsRecorder.ObserveAdmissionReviewDuration(
 		w.WebhookName,
 		ar.Request.Namespace,
@@ -111,6 +253,22 @@ func (w *Webhook) incValidationReviewResultMetric(ar *admissionv1beta1.Admission
 	)
 }
 
+// setReviewBaggage sets the review object GVK and operation as the span baggage, the empty
+// values are not set.
+func setReviewBaggage(span opentracing.Span, ar *admissionv1beta1.AdmissionReview) {
+	baggage := map[string]string{
+		webhook.BaggageGroupKey:     ar.Request.Kind.Group,
+		webhook.BaggageVersionKey:   ar.Request.Kind.Version,
+		webhook.BaggageKindKey:      ar.Request.Kind.Kind,
+		webhook.BaggageOperationKey: string(ar.Request.Operation),
+	}
+	for k, v := range baggage {
+		if v != "" {
+			span.SetBaggageItem(k, v)
+		}
+	}
+}
+
 func (w *Webhook) createReviewSpan(ctx context.Context, ar *admissionv1beta1.AdmissionReview) opentracing.Span {
 	var spanOpts []opentracing.StartSpanOption
 
@@ -128,6 +286,7 @@ func (w *Webhook) createReviewSpan(ctx context.Context, ar *admissionv1beta1.Adm
 	span.SetTag("kubewebhook.webhook.kind", w.ReviewKind)
 	span.SetTag("kubewebhook.webhook.name", w.WebhookName)
 
+	span.SetTag("kubewebhook.review.correlationID", whcontext.GetCorrelationID(ctx))
 	span.SetTag("kubernetes.review.uid", ar.Request.UID)
 	span.SetTag("kubernetes.review.namespace", ar.Request.Namespace)
 	span.SetTag("kubernetes.review.name", ar.Request.Name)
@@ -2,15 +2,22 @@ package instrumenting_test
 
 import (
 	"context"
+	"encoding/json"
 	"testing"
 
 	opentracing "github.com/opentracing/opentracing-go"
+	"github.com/opentracing/opentracing-go/mocktracer"
+	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
 	admissionv1beta1 "k8s.io/api/admission/v1beta1"
+	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
 
 	mmetrics "github.com/slok/kubewebhook/mocks/observability/metrics"
 	mwebhook "github.com/slok/kubewebhook/mocks/webhook"
+	"github.com/slok/kubewebhook/pkg/internal/testutil"
 	"github.com/slok/kubewebhook/pkg/observability/metrics"
 	"github.com/slok/kubewebhook/pkg/webhook/internal/instrumenting"
 )
@@ -63,6 +70,7 @@ func TestInstrumentedMetricsWebhook(t *testing.T) {
 			mm := &mmetrics.Recorder{}
 			mm.On("IncAdmissionReview", test.whName, mock.Anything, mock.Anything, mock.Anything, test.whKind).Once()
 			mm.On("ObserveAdmissionReviewDuration", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).Once()
+			mm.On("ObserveAdmissionReviewObjectSize", test.whName, mock.Anything, mock.Anything).Once()
 			if test.expErr {
 				mm.On("IncAdmissionReviewError", test.whName, mock.Anything, mock.Anything, mock.Anything, test.whKind).Once()
 			}
@@ -86,3 +94,136 @@ func TestInstrumentedMetricsWebhook(t *testing.T) {
 		})
 	}
 }
+
+func TestInstrumentedOwnerKindWebhook(t *testing.T) {
+	tests := map[string]struct {
+		obj          metav1.Object
+		expOwnerKind string
+	}{
+		"A pod owned by a ReplicaSet should be instrumented with the ReplicaSet owner kind.": {
+			obj: &corev1.Pod{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "test",
+					OwnerReferences: []metav1.OwnerReference{
+						{Kind: "CustomOwner", Name: "custom"},
+						{Kind: "ReplicaSet", Name: "test-7d4b9c", Controller: boolPtr(true)},
+					},
+				},
+			},
+			expOwnerKind: "ReplicaSet",
+		},
+
+		"A pod without controller owner should be instrumented with the first owner kind.": {
+			obj: &corev1.Pod{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:            "test",
+					OwnerReferences: []metav1.OwnerReference{{Kind: "Job", Name: "test"}},
+				},
+			},
+			expOwnerKind: "Job",
+		},
+
+		"A pod without owner should be instrumented without owner kind.": {
+			obj:          &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "test"}},
+			expOwnerKind: "none",
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+			require := require.New(t)
+
+			raw, err := json.Marshal(test.obj)
+			require.NoError(err)
+			ar := &admissionv1beta1.AdmissionReview{
+				Request: &admissionv1beta1.AdmissionRequest{
+					UID:    "test",
+					Object: runtime.RawExtension{Raw: raw},
+				},
+			}
+
+			// Mocks
+			mwh := &mwebhook.Webhook{}
+			mwh.On("Review", mock.Anything, mock.Anything).Once().Return(&admissionv1beta1.AdmissionResponse{Allowed: true})
+
+			mm := &mmetrics.Recorder{}
+			testutil.IgnoreRecorderCalls(mm, "IncAdmissionReview", "ObserveAdmissionReviewDuration", "ObserveAdmissionReviewObjectSize", "IncValidationReviewResult")
+			mm.On("IncAdmissionReviewOwnerKind", "test-webhook", test.expOwnerKind).Once()
+
+			tracer := mocktracer.New()
+			wh := instrumenting.Webhook{
+				Webhook:         mwh,
+				WebhookName:     "test-webhook",
+				ReviewKind:      metrics.ValidatingReviewKind,
+				MetricsRecorder: mm,
+				Tracer:          tracer,
+				OwnerKind:       true,
+			}
+
+			wh.Review(context.TODO(), ar)
+
+			// Check calls.
+			mm.AssertExpectations(t)
+			spans := tracer.FinishedSpans()
+			require.Len(spans, 1)
+			assert.Equal(test.expOwnerKind, spans[0].Tag("kubernetes.review.ownerKind"))
+		})
+	}
+}
+
+func boolPtr(b bool) *bool { return &b }
+
+func TestInstrumentedObjectSizeWebhook(t *testing.T) {
+	raw := []byte(`{"kind":"Pod","apiVersion":"v1","metadata":{"name":"test","namespace":"default"}}`)
+
+	tests := map[string]struct {
+		ar      *admissionv1beta1.AdmissionRequest
+		expSize int
+	}{
+		"A create review should observe the object size.": {
+			ar: &admissionv1beta1.AdmissionRequest{
+				UID:       "test",
+				Kind:      metav1.GroupVersionKind{Version: "v1", Kind: "Pod"},
+				Operation: admissionv1beta1.Create,
+				Object:    runtime.RawExtension{Raw: raw},
+			},
+			expSize: len(raw),
+		},
+
+		"A delete review should observe the old object size.": {
+			ar: &admissionv1beta1.AdmissionRequest{
+				UID:       "test",
+				Kind:      metav1.GroupVersionKind{Version: "v1", Kind: "Pod"},
+				Operation: admissionv1beta1.Delete,
+				OldObject: runtime.RawExtension{Raw: raw},
+			},
+			expSize: len(raw),
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			// Mocks
+			mwh := &mwebhook.Webhook{}
+			mwh.On("Review", mock.Anything, mock.Anything).Once().Return(&admissionv1beta1.AdmissionResponse{Allowed: true})
+
+			mm := &mmetrics.Recorder{}
+			testutil.IgnoreRecorderCalls(mm, "IncAdmissionReview", "ObserveAdmissionReviewDuration", "IncValidationReviewResult")
+			mm.On("ObserveAdmissionReviewObjectSize", "test-webhook", "Pod", test.expSize).Once()
+
+			wh := instrumenting.Webhook{
+				Webhook:         mwh,
+				WebhookName:     "test-webhook",
+				ReviewKind:      metrics.ValidatingReviewKind,
+				MetricsRecorder: mm,
+				Tracer:          &opentracing.NoopTracer{},
+			}
+
+			wh.Review(context.TODO(), &admissionv1beta1.AdmissionReview{Request: test.ar})
+
+			// Check calls.
+			mm.AssertExpectations(t)
+		})
+	}
+}
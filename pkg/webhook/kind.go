@@ -0,0 +1,25 @@
+package webhook
+
+// KindMismatchPolicy is the policy applied by the webhooks with a static object type when
+// the kind of the admission request doesn't match the kind of the webhook object (e.g a
+// webhook registered with too broad rules).
+type KindMismatchPolicy string
+
+const (
+	// KindMismatchPolicyIgnore will not check the request kind, the object will be decoded on
+	// the webhook object type even if the kind doesn't match.
+	KindMismatchPolicyIgnore KindMismatchPolicy = "ignore"
+	// KindMismatchPolicyError will return an error.
+	KindMismatchPolicyError KindMismatchPolicy = "error"
+	// KindMismatchPolicyAllow will allow the object without mutating or validating it.
+	KindMismatchPolicyAllow KindMismatchPolicy = "allow"
+)
+
+// Valid returns true if the policy is a known policy.
+func (k KindMismatchPolicy) Valid() bool {
+	switch k {
+	case KindMismatchPolicyIgnore, KindMismatchPolicyError, KindMismatchPolicyAllow:
+		return true
+	}
+	return false
+}
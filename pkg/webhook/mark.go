@@ -0,0 +1,57 @@
+package webhook
+
+import (
+	"strings"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// MutationMarkAnnotation is the annotation that the mutating webhooks use to mark the
+// objects that have been processed by them. The value is a comma separated list of
+// the webhook names.
+//
+// Kubernetes doesn't support reinvoking validating webhooks, these are always executed
+// after all the mutating webhooks, so this mark is the closest mechanism a validating
+// webhook has to know if the object that is receiving has already been mutated.
+const MutationMarkAnnotation = "kubewebhook.slok.dev/mutated-by"
+
+// MarkMutated marks the object as mutated by the webhook.
+func MarkMutated(obj metav1.Object, webhookName string) {
+	if IsMutationMarked(obj, webhookName) {
+		return
+	}
+
+	annotations := obj.GetAnnotations()
+	if annotations == nil {
+		annotations = map[string]string{}
+	}
+
+	if marks := annotations[MutationMarkAnnotation]; marks != "" {
+		annotations[MutationMarkAnnotation] = marks + "," + webhookName
+	} else {
+		annotations[MutationMarkAnnotation] = webhookName
+	}
+	obj.SetAnnotations(annotations)
+}
+
+// IsMutationMarked returns true if the object has been marked as mutated by the webhook.
+// If the webhook name is empty it will return true if the object has been marked
+// by any webhook.
+func IsMutationMarked(obj metav1.Object, webhookName string) bool {
+	marks, ok := obj.GetAnnotations()[MutationMarkAnnotation]
+	if !ok {
+		return false
+	}
+
+	if webhookName == "" {
+		return true
+	}
+
+	for _, mark := range strings.Split(marks, ",") {
+		if mark == webhookName {
+			return true
+		}
+	}
+
+	return false
+}
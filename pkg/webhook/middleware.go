@@ -0,0 +1,16 @@
+package webhook
+
+// Middleware wraps a webhook with a cross-cutting concern (e.g panic recovery, rate
+// limiting, metrics) returning the wrapped webhook.
+type Middleware func(Webhook) Webhook
+
+// Chain wraps the webhook with the middlewares. The middlewares are applied from the outermost
+// to the innermost, this is, the first middleware will be the first to receive the reviews and
+// the last to return the responses, e.g `Chain(wh, a, b)` is the same as `a(b(wh))`.
+func Chain(wh Webhook, middlewares ...Middleware) Webhook {
+	for i := len(middlewares) - 1; i >= 0; i-- {
+		wh = middlewares[i](wh)
+	}
+
+	return wh
+}
@@ -0,0 +1,58 @@
+package webhook_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	admissionv1beta1 "k8s.io/api/admission/v1beta1"
+
+	"github.com/slok/kubewebhook/pkg/webhook"
+)
+
+func TestChain(t *testing.T) {
+	tests := map[string]struct {
+		middlewares func(calls *[]string) []webhook.Middleware
+		expCalls    []string
+	}{
+		"Without middlewares it should only call the webhook.": {
+			middlewares: func(calls *[]string) []webhook.Middleware { return nil },
+			expCalls:    []string{"webhook"},
+		},
+
+		"The middlewares should be called from the outermost to the innermost around the review.": {
+			middlewares: func(calls *[]string) []webhook.Middleware {
+				mw := func(name string) webhook.Middleware {
+					return func(next webhook.Webhook) webhook.Webhook {
+						return reviewFunc(func(ctx context.Context, ar *admissionv1beta1.AdmissionReview) *admissionv1beta1.AdmissionResponse {
+							*calls = append(*calls, name+"-before")
+							resp := next.Review(ctx, ar)
+							*calls = append(*calls, name+"-after")
+							return resp
+						})
+					}
+				}
+				return []webhook.Middleware{mw("m1"), mw("m2"), mw("m3")}
+			},
+			expCalls: []string{"m1-before", "m2-before", "m3-before", "webhook", "m3-after", "m2-after", "m1-after"},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+
+			calls := []string{}
+			wh := reviewFunc(func(ctx context.Context, ar *admissionv1beta1.AdmissionReview) *admissionv1beta1.AdmissionResponse {
+				calls = append(calls, "webhook")
+				return &admissionv1beta1.AdmissionResponse{UID: ar.Request.UID, Allowed: true}
+			})
+
+			chained := webhook.Chain(wh, test.middlewares(&calls)...)
+			resp := chained.Review(context.TODO(), &admissionv1beta1.AdmissionReview{Request: &admissionv1beta1.AdmissionRequest{UID: "test"}})
+
+			assert.Equal(&admissionv1beta1.AdmissionResponse{UID: "test", Allowed: true}, resp)
+			assert.Equal(test.expCalls, calls)
+		})
+	}
+}
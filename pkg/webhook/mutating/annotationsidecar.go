@@ -0,0 +1,90 @@
+package mutating
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/slok/kubewebhook/pkg/webhook/internal/helpers"
+)
+
+// DefaultSidecarContainerName is the default name of the sidecar containers injected by the
+// annotation sidecar mutator.
+const DefaultSidecarContainerName = "sidecar"
+
+// AnnotationSidecarConfig is the sidecar configuration read from the object annotation.
+type AnnotationSidecarConfig struct {
+	// Name is the name of the sidecar container. By default `DefaultSidecarContainerName`.
+	Name string `json:"name,omitempty"`
+	// Image is the image of the sidecar container.
+	Image string `json:"image"`
+	// Args are the arguments of the sidecar container.
+	Args []string `json:"args,omitempty"`
+	// Env are the environment variables of the sidecar container.
+	Env []corev1.EnvVar `json:"env,omitempty"`
+	// Resources are the resources of the sidecar container.
+	Resources corev1.ResourceRequirements `json:"resources,omitempty"`
+}
+
+func (c *AnnotationSidecarConfig) defaults() error {
+	if c.Image == "" {
+		return fmt.Errorf("image is required")
+	}
+
+	if c.Name == "" {
+		c.Name = DefaultSidecarContainerName
+	}
+
+	return nil
+}
+
+// NewAnnotationSidecarMutator returns a mutator that injects a sidecar container on the pods (or the
+// pod templates of the workloads) that have the annotation (e.g `inject/config`), the annotation value
+// is the JSON sidecar configuration (check AnnotationSidecarConfig). The annotation is searched on the
+// object metadata and then on the pod template metadata.
+//
+// The objects without the annotation will not be mutated, and if the pod already has a container with
+// the sidecar name, the sidecar will not be injected again. Malformed configurations will return an error.
+func NewAnnotationSidecarMutator(annotation string) Mutator {
+	return MutatorFunc(func(_ context.Context, obj metav1.Object) (bool, error) {
+		meta, spec, ok := helpers.PodTemplate(obj)
+		if !ok {
+			return false, nil
+		}
+
+		value, ok := obj.GetAnnotations()[annotation]
+		if !ok {
+			value, ok = meta.Annotations[annotation]
+		}
+		if !ok {
+			return false, nil
+		}
+
+		cfg := AnnotationSidecarConfig{}
+		if err := json.Unmarshal([]byte(value), &cfg); err != nil {
+			return true, fmt.Errorf("invalid %q annotation sidecar configuration: %w", annotation, err)
+		}
+		if err := cfg.defaults(); err != nil {
+			return true, fmt.Errorf("invalid %q annotation sidecar configuration: %w", annotation, err)
+		}
+
+		for _, c := range spec.Containers {
+			if c.Name == cfg.Name {
+				return false, nil
+			}
+		}
+
+		spec.Containers = append(spec.Containers, corev1.Container{
+			Name:      cfg.Name,
+			Image:     cfg.Image,
+			Args:      cfg.Args,
+			Env:       cfg.Env,
+			Resources: cfg.Resources,
+		})
+
+		return false, nil
+	})
+}
@@ -0,0 +1,112 @@
+package mutating_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/slok/kubewebhook/pkg/webhook/mutating"
+)
+
+func TestAnnotationSidecarMutator(t *testing.T) {
+	tests := map[string]struct {
+		obj    metav1.Object
+		expObj metav1.Object
+		expErr bool
+	}{
+		"A pod with a valid annotation should have the sidecar injected.": {
+			obj: &corev1.Pod{
+				ObjectMeta: metav1.ObjectMeta{Annotations: map[string]string{
+					"inject/config": `{"name":"proxy","image":"slok/proxy:v1","args":["--port=8080"],"resources":{"requests":{"cpu":"100m"}}}`,
+				}},
+				Spec: corev1.PodSpec{Containers: []corev1.Container{{Name: "app"}}},
+			},
+			expObj: &corev1.Pod{
+				ObjectMeta: metav1.ObjectMeta{Annotations: map[string]string{
+					"inject/config": `{"name":"proxy","image":"slok/proxy:v1","args":["--port=8080"],"resources":{"requests":{"cpu":"100m"}}}`,
+				}},
+				Spec: corev1.PodSpec{Containers: []corev1.Container{
+					{Name: "app"},
+					{
+						Name:      "proxy",
+						Image:     "slok/proxy:v1",
+						Args:      []string{"--port=8080"},
+						Resources: corev1.ResourceRequirements{Requests: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("100m")}},
+					},
+				}},
+			},
+		},
+
+		"A workload with a valid annotation on the pod template should have the sidecar injected with the default name.": {
+			obj: &appsv1.Deployment{
+				Spec: appsv1.DeploymentSpec{Template: corev1.PodTemplateSpec{
+					ObjectMeta: metav1.ObjectMeta{Annotations: map[string]string{"inject/config": `{"image":"slok/proxy:v1"}`}},
+					Spec:       corev1.PodSpec{Containers: []corev1.Container{{Name: "app"}}},
+				}},
+			},
+			expObj: &appsv1.Deployment{
+				Spec: appsv1.DeploymentSpec{Template: corev1.PodTemplateSpec{
+					ObjectMeta: metav1.ObjectMeta{Annotations: map[string]string{"inject/config": `{"image":"slok/proxy:v1"}`}},
+					Spec: corev1.PodSpec{Containers: []corev1.Container{
+						{Name: "app"},
+						{Name: "sidecar", Image: "slok/proxy:v1"},
+					}},
+				}},
+			},
+		},
+
+		"A pod with the sidecar already injected should not be mutated.": {
+			obj: &corev1.Pod{
+				ObjectMeta: metav1.ObjectMeta{Annotations: map[string]string{"inject/config": `{"image":"slok/proxy:v1"}`}},
+				Spec:       corev1.PodSpec{Containers: []corev1.Container{{Name: "app"}, {Name: "sidecar", Image: "slok/proxy:v0"}}},
+			},
+			expObj: &corev1.Pod{
+				ObjectMeta: metav1.ObjectMeta{Annotations: map[string]string{"inject/config": `{"image":"slok/proxy:v1"}`}},
+				Spec:       corev1.PodSpec{Containers: []corev1.Container{{Name: "app"}, {Name: "sidecar", Image: "slok/proxy:v0"}}},
+			},
+		},
+
+		"A pod with a malformed annotation should fail.": {
+			obj: &corev1.Pod{
+				ObjectMeta: metav1.ObjectMeta{Annotations: map[string]string{"inject/config": `{"image":`}},
+				Spec:       corev1.PodSpec{Containers: []corev1.Container{{Name: "app"}}},
+			},
+			expErr: true,
+		},
+
+		"A pod with an annotation without image should fail.": {
+			obj: &corev1.Pod{
+				ObjectMeta: metav1.ObjectMeta{Annotations: map[string]string{"inject/config": `{"name":"proxy"}`}},
+				Spec:       corev1.PodSpec{Containers: []corev1.Container{{Name: "app"}}},
+			},
+			expErr: true,
+		},
+
+		"A pod without the annotation should not be mutated.": {
+			obj:    &corev1.Pod{Spec: corev1.PodSpec{Containers: []corev1.Container{{Name: "app"}}}},
+			expObj: &corev1.Pod{Spec: corev1.PodSpec{Containers: []corev1.Container{{Name: "app"}}}},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+			require := require.New(t)
+
+			m := mutating.NewAnnotationSidecarMutator("inject/config")
+			_, err := m.Mutate(context.TODO(), test.obj)
+			if test.expErr {
+				assert.Error(err)
+				return
+			}
+			require.NoError(err)
+			assert.Equal(test.expObj, test.obj)
+		})
+	}
+}
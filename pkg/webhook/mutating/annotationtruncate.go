@@ -0,0 +1,70 @@
+package mutating
+
+import (
+	"context"
+	"fmt"
+	"unicode/utf8"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// AnnotationTruncateMutatorConfig is the configuration of the annotation truncate mutator.
+type AnnotationTruncateMutatorConfig struct {
+	// Keys are the annotation keys whose values will be truncated.
+	Keys []string
+	// MaxLength is the max length in bytes of the annotation values, including the marker.
+	MaxLength int
+	// Marker is the marker appended to the truncated values, by default `...`.
+	Marker string
+}
+
+func (c *AnnotationTruncateMutatorConfig) defaults() error {
+	if c.Marker == "" {
+		c.Marker = "..."
+	}
+
+	if len(c.Keys) == 0 {
+		return fmt.Errorf("annotation keys are required")
+	}
+
+	if c.MaxLength <= len(c.Marker) {
+		return fmt.Errorf("max length must be greater than the marker length")
+	}
+
+	return nil
+}
+
+// NewAnnotationTruncateMutator returns a mutator that truncates the values of the configured
+// annotation keys that exceed the max length, appending the marker to the truncated values so
+// the readers know the value is not complete. The values are never cut in the middle of a UTF-8
+// character. The annotations that are not configured are not mutated.
+func NewAnnotationTruncateMutator(cfg AnnotationTruncateMutatorConfig) (Mutator, error) {
+	if err := cfg.defaults(); err != nil {
+		return nil, fmt.Errorf("invalid configuration: %w", err)
+	}
+
+	return MutatorFunc(func(_ context.Context, obj metav1.Object) (bool, error) {
+		annotations := obj.GetAnnotations()
+		truncated := false
+		for _, k := range cfg.Keys {
+			v, ok := annotations[k]
+			if !ok || len(v) <= cfg.MaxLength {
+				continue
+			}
+
+			cut := cfg.MaxLength - len(cfg.Marker)
+			for cut > 0 && !utf8.RuneStart(v[cut]) {
+				cut--
+			}
+			annotations[k] = v[:cut] + cfg.Marker
+			truncated = true
+		}
+
+		// Some objects (e.g unstructured) return a copy of the annotations.
+		if truncated {
+			obj.SetAnnotations(annotations)
+		}
+
+		return false, nil
+	}), nil
+}
@@ -0,0 +1,127 @@
+package mutating_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"github.com/slok/kubewebhook/pkg/webhook/mutating"
+)
+
+func TestAnnotationTruncateMutator(t *testing.T) {
+	tests := map[string]struct {
+		cfg            mutating.AnnotationTruncateMutatorConfig
+		obj            metav1.Object
+		expObj         metav1.Object
+		expConfigError bool
+	}{
+		"A configuration without keys should fail.": {
+			cfg:            mutating.AnnotationTruncateMutatorConfig{MaxLength: 10},
+			expConfigError: true,
+		},
+
+		"A configuration with a max length not greater than the marker should fail.": {
+			cfg:            mutating.AnnotationTruncateMutatorConfig{Keys: []string{"description"}, MaxLength: 3},
+			expConfigError: true,
+		},
+
+		"An object without annotations should not be mutated.": {
+			cfg:    mutating.AnnotationTruncateMutatorConfig{Keys: []string{"description"}, MaxLength: 10},
+			obj:    &corev1.Pod{},
+			expObj: &corev1.Pod{},
+		},
+
+		"Values within the limit should not be mutated.": {
+			cfg: mutating.AnnotationTruncateMutatorConfig{Keys: []string{"description"}, MaxLength: 10},
+			obj: &corev1.Pod{
+				ObjectMeta: metav1.ObjectMeta{Annotations: map[string]string{"description": "0123456789"}},
+			},
+			expObj: &corev1.Pod{
+				ObjectMeta: metav1.ObjectMeta{Annotations: map[string]string{"description": "0123456789"}},
+			},
+		},
+
+		"Over-length values should be truncated with the marker.": {
+			cfg: mutating.AnnotationTruncateMutatorConfig{Keys: []string{"description"}, MaxLength: 10},
+			obj: &corev1.Pod{
+				ObjectMeta: metav1.ObjectMeta{Annotations: map[string]string{"description": "0123456789abcdef"}},
+			},
+			expObj: &corev1.Pod{
+				ObjectMeta: metav1.ObjectMeta{Annotations: map[string]string{"description": "0123456..."}},
+			},
+		},
+
+		"Over-length values should be truncated with a custom marker.": {
+			cfg: mutating.AnnotationTruncateMutatorConfig{Keys: []string{"description"}, MaxLength: 10, Marker: "[cut]"},
+			obj: &corev1.Pod{
+				ObjectMeta: metav1.ObjectMeta{Annotations: map[string]string{"description": "0123456789abcdef"}},
+			},
+			expObj: &corev1.Pod{
+				ObjectMeta: metav1.ObjectMeta{Annotations: map[string]string{"description": "01234[cut]"}},
+			},
+		},
+
+		"Over-length values should not be truncated in the middle of a character.": {
+			cfg: mutating.AnnotationTruncateMutatorConfig{Keys: []string{"description"}, MaxLength: 10},
+			obj: &corev1.Pod{
+				ObjectMeta: metav1.ObjectMeta{Annotations: map[string]string{"description": "012345éééééé"}},
+			},
+			expObj: &corev1.Pod{
+				ObjectMeta: metav1.ObjectMeta{Annotations: map[string]string{"description": "012345..."}},
+			},
+		},
+
+		"Over-length values of not configured keys should not be mutated.": {
+			cfg: mutating.AnnotationTruncateMutatorConfig{Keys: []string{"description"}, MaxLength: 10},
+			obj: &corev1.Pod{
+				ObjectMeta: metav1.ObjectMeta{Annotations: map[string]string{
+					"description": "0123456789abcdef",
+					"other":       "0123456789abcdef",
+				}},
+			},
+			expObj: &corev1.Pod{
+				ObjectMeta: metav1.ObjectMeta{Annotations: map[string]string{
+					"description": "0123456...",
+					"other":       "0123456789abcdef",
+				}},
+			},
+		},
+
+		"Over-length values of unstructured objects should be truncated with the marker.": {
+			cfg: mutating.AnnotationTruncateMutatorConfig{Keys: []string{"description"}, MaxLength: 10},
+			obj: &unstructured.Unstructured{Object: map[string]interface{}{
+				"metadata": map[string]interface{}{
+					"annotations": map[string]interface{}{"description": "0123456789abcdef"},
+				},
+			}},
+			expObj: &unstructured.Unstructured{Object: map[string]interface{}{
+				"metadata": map[string]interface{}{
+					"annotations": map[string]interface{}{"description": "0123456..."},
+				},
+			}},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+			require := require.New(t)
+
+			m, err := mutating.NewAnnotationTruncateMutator(test.cfg)
+			if test.expConfigError {
+				assert.Error(err)
+				return
+			}
+			require.NoError(err)
+
+			_, err = m.Mutate(context.TODO(), test.obj)
+			require.NoError(err)
+			assert.Equal(test.expObj, test.obj)
+		})
+	}
+}
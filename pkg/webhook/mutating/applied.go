@@ -0,0 +1,30 @@
+package mutating
+
+import (
+	"context"
+)
+
+// mutatorsAppliedAuditAnnotation is the audit annotation key that has the mutators that modified
+// the object. The keys can't have a slash, the API server prefixes them with the webhook name.
+const mutatorsAppliedAuditAnnotation = "mutators-applied"
+
+type appliedMutatorsKey struct{}
+
+// appliedMutators tracks the names of the mutators that modified the object.
+type appliedMutators struct {
+	names []string
+}
+
+// withAppliedMutators returns a context that will track the mutators of the chains
+// that modify the object.
+func withAppliedMutators(ctx context.Context) (context.Context, *appliedMutators) {
+	am := &appliedMutators{}
+	return context.WithValue(ctx, appliedMutatorsKey{}, am), am
+}
+
+// getAppliedMutators returns the applied mutators tracker of the context, nil if
+// the context is not tracking them.
+func getAppliedMutators(ctx context.Context) *appliedMutators {
+	am, _ := ctx.Value(appliedMutatorsKey{}).(*appliedMutators)
+	return am
+}
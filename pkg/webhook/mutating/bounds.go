@@ -0,0 +1,67 @@
+package mutating
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+// jsonFrame is a JSON object or array being processed by checkJSONBounds.
+type jsonFrame struct {
+	object    bool
+	expectKey bool
+}
+
+// checkJSONBounds checks the JSON data doesn't exceed the max nesting depth and the max
+// number of object fields, a max of 0 means no limit. The data is processed as a stream
+// of tokens, so the pathological objects are rejected without decoding them.
+func checkJSONBounds(data []byte, maxDepth, maxFields int) error {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	var stack []*jsonFrame
+	fields := 0
+
+	// valueEnd marks the end of a value on the current object, so the next token is a key.
+	valueEnd := func() {
+		if len(stack) > 0 && stack[len(stack)-1].object {
+			stack[len(stack)-1].expectKey = true
+		}
+	}
+
+	for {
+		tok, err := dec.Token()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("could not read object: %w", err)
+		}
+
+		switch t := tok.(type) {
+		case json.Delim:
+			switch t {
+			case '{', '[':
+				if len(stack) > 0 && stack[len(stack)-1].object {
+					stack[len(stack)-1].expectKey = false
+				}
+				stack = append(stack, &jsonFrame{object: t == '{', expectKey: t == '{'})
+				if maxDepth > 0 && len(stack) > maxDepth {
+					return fmt.Errorf("object exceeds the max nesting depth of %d", maxDepth)
+				}
+			case '}', ']':
+				stack = stack[:len(stack)-1]
+				valueEnd()
+			}
+		default:
+			if len(stack) > 0 && stack[len(stack)-1].object && stack[len(stack)-1].expectKey {
+				stack[len(stack)-1].expectKey = false
+				fields++
+				if maxFields > 0 && fields > maxFields {
+					return fmt.Errorf("object exceeds the max number of fields of %d", maxFields)
+				}
+				continue
+			}
+			valueEnd()
+		}
+	}
+}
@@ -0,0 +1,84 @@
+package mutating
+
+import (
+	"context"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/slok/kubewebhook/pkg/webhook/internal/helpers"
+)
+
+// ContainerOrderMutatorConfig is the configuration of the container order mutator.
+type ContainerOrderMutatorConfig struct {
+	// First are the names of the containers that will be placed first, in the same order.
+	First []string
+	// Last are the names of the containers that will be placed last, in the same order.
+	Last []string
+}
+
+func (c *ContainerOrderMutatorConfig) defaults() error {
+	seen := map[string]bool{}
+	for _, names := range [][]string{c.First, c.Last} {
+		for _, name := range names {
+			if seen[name] {
+				return fmt.Errorf("container %q is configured more than once", name)
+			}
+			seen[name] = true
+		}
+	}
+
+	return nil
+}
+
+// NewContainerOrderMutator returns a mutator that reorders the containers of the pods (or the pod
+// templates of the workloads) placing the configured containers first or last (e.g an injected
+// sidecar always last), the rest of the containers keep their relative order. The containers
+// not present on the pod are ignored. A container can't be configured more than once (e.g
+// first and last at the same time).
+func NewContainerOrderMutator(cfg ContainerOrderMutatorConfig) (Mutator, error) {
+	if err := cfg.defaults(); err != nil {
+		return nil, fmt.Errorf("invalid configuration: %w", err)
+	}
+
+	placed := map[string]bool{}
+	for _, names := range [][]string{cfg.First, cfg.Last} {
+		for _, name := range names {
+			placed[name] = true
+		}
+	}
+
+	return MutatorFunc(func(_ context.Context, obj metav1.Object) (bool, error) {
+		spec, ok := helpers.PodSpec(obj)
+		if !ok || len(spec.Containers) == 0 {
+			return false, nil
+		}
+
+		byName := map[string]corev1.Container{}
+		for _, c := range spec.Containers {
+			byName[c.Name] = c
+		}
+
+		containers := make([]corev1.Container, 0, len(spec.Containers))
+		appendNamed := func(names []string) {
+			for _, name := range names {
+				if c, ok := byName[name]; ok {
+					containers = append(containers, c)
+				}
+			}
+		}
+
+		appendNamed(cfg.First)
+		for _, c := range spec.Containers {
+			if !placed[c.Name] {
+				containers = append(containers, c)
+			}
+		}
+		appendNamed(cfg.Last)
+
+		spec.Containers = containers
+
+		return false, nil
+	}), nil
+}
@@ -0,0 +1,96 @@
+package mutating_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+
+	"github.com/slok/kubewebhook/pkg/webhook/mutating"
+)
+
+func TestContainerOrderMutator(t *testing.T) {
+	containers := func(names ...string) []corev1.Container {
+		cs := make([]corev1.Container, 0, len(names))
+		for _, n := range names {
+			cs = append(cs, corev1.Container{Name: n, Image: n + ":latest"})
+		}
+		return cs
+	}
+
+	tests := map[string]struct {
+		cfg           mutating.ContainerOrderMutatorConfig
+		containers    []corev1.Container
+		expContainers []corev1.Container
+		expErr        bool
+	}{
+		"A sidecar configured as last should end up last.": {
+			cfg:           mutating.ContainerOrderMutatorConfig{Last: []string{"sidecar"}},
+			containers:    containers("sidecar", "app", "worker"),
+			expContainers: containers("app", "worker", "sidecar"),
+		},
+
+		"A sidecar configured as last already last should not change the order.": {
+			cfg:           mutating.ContainerOrderMutatorConfig{Last: []string{"sidecar"}},
+			containers:    containers("app", "worker", "sidecar"),
+			expContainers: containers("app", "worker", "sidecar"),
+		},
+
+		"A sidecar configured as first should end up first.": {
+			cfg:           mutating.ContainerOrderMutatorConfig{First: []string{"sidecar"}},
+			containers:    containers("app", "worker", "sidecar"),
+			expContainers: containers("sidecar", "app", "worker"),
+		},
+
+		"Multiple first and last containers should follow the configured order.": {
+			cfg: mutating.ContainerOrderMutatorConfig{
+				First: []string{"init-proxy", "vault"},
+				Last:  []string{"logger", "proxy"},
+			},
+			containers:    containers("proxy", "app", "vault", "logger", "worker", "init-proxy"),
+			expContainers: containers("init-proxy", "vault", "app", "worker", "logger", "proxy"),
+		},
+
+		"Configured containers missing on the pod should be ignored.": {
+			cfg:           mutating.ContainerOrderMutatorConfig{First: []string{"missing"}, Last: []string{"sidecar"}},
+			containers:    containers("sidecar", "app"),
+			expContainers: containers("app", "sidecar"),
+		},
+
+		"A container configured as first and last should fail.": {
+			cfg:    mutating.ContainerOrderMutatorConfig{First: []string{"sidecar"}, Last: []string{"logger", "sidecar"}},
+			expErr: true,
+		},
+
+		"A container configured twice as first should fail.": {
+			cfg:    mutating.ContainerOrderMutatorConfig{First: []string{"sidecar", "sidecar"}},
+			expErr: true,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+			require := require.New(t)
+
+			pod := &corev1.Pod{Spec: corev1.PodSpec{Containers: test.containers}}
+			m, err := mutating.NewContainerOrderMutator(test.cfg)
+			if test.expErr {
+				assert.Error(err)
+				return
+			}
+			require.NoError(err)
+
+			_, err = m.Mutate(context.TODO(), pod)
+			require.NoError(err)
+			assert.Equal(test.expContainers, pod.Spec.Containers)
+
+			// Mutating again should be idempotent.
+			_, err = m.Mutate(context.TODO(), pod)
+			require.NoError(err)
+			assert.Equal(test.expContainers, pod.Spec.Containers)
+		})
+	}
+}
@@ -0,0 +1,69 @@
+package mutating
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/slok/kubewebhook/pkg/webhook/internal/helpers"
+)
+
+// ContainerPortsMutatorConfig is the configuration of the container ports mutator.
+type ContainerPortsMutatorConfig struct {
+	// ImagePattern is the regex pattern that the container images must match to have
+	// the default port (e.g `^nginx(:.*)?$`), if empty all the containers match.
+	ImagePattern string
+	// Port is the default named port of the containers.
+	Port corev1.ContainerPort
+}
+
+func (c *ContainerPortsMutatorConfig) defaults() error {
+	if c.Port.Name == "" {
+		return fmt.Errorf("port name is required")
+	}
+
+	if c.Port.ContainerPort <= 0 {
+		return fmt.Errorf("port number is required")
+	}
+
+	if c.Port.Protocol == "" {
+		c.Port.Protocol = corev1.ProtocolTCP
+	}
+
+	return nil
+}
+
+// NewContainerPortsMutator returns a mutator that sets the default named port on the containers
+// of the pods (or the pod templates of the workloads) that match the image pattern and don't
+// expose any port, this way the services can discover the containers using the port name. The
+// containers that already have ports will never be mutated.
+func NewContainerPortsMutator(cfg ContainerPortsMutatorConfig) (Mutator, error) {
+	if err := cfg.defaults(); err != nil {
+		return nil, fmt.Errorf("invalid configuration: %w", err)
+	}
+
+	rgx, err := regexp.Compile(cfg.ImagePattern)
+	if err != nil {
+		return nil, fmt.Errorf("invalid image pattern: %w", err)
+	}
+
+	return MutatorFunc(func(_ context.Context, obj metav1.Object) (bool, error) {
+		spec, ok := helpers.PodSpec(obj)
+		if !ok {
+			return false, nil
+		}
+
+		for i := range spec.Containers {
+			c := &spec.Containers[i]
+			if len(c.Ports) > 0 || !rgx.MatchString(c.Image) {
+				continue
+			}
+			c.Ports = []corev1.ContainerPort{cfg.Port}
+		}
+
+		return false, nil
+	}), nil
+}
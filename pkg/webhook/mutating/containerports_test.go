@@ -0,0 +1,85 @@
+package mutating_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+
+	"github.com/slok/kubewebhook/pkg/webhook/mutating"
+)
+
+func TestContainerPortsMutator(t *testing.T) {
+	httpPort := corev1.ContainerPort{Name: "http", ContainerPort: 8080, Protocol: corev1.ProtocolTCP}
+
+	tests := map[string]struct {
+		cfg           mutating.ContainerPortsMutatorConfig
+		containers    []corev1.Container
+		expContainers []corev1.Container
+		expErr        bool
+	}{
+		"A missing port name should fail.": {
+			cfg:    mutating.ContainerPortsMutatorConfig{Port: corev1.ContainerPort{ContainerPort: 8080}},
+			expErr: true,
+		},
+
+		"A missing port number should fail.": {
+			cfg:    mutating.ContainerPortsMutatorConfig{Port: corev1.ContainerPort{Name: "http"}},
+			expErr: true,
+		},
+
+		"An invalid image pattern should fail.": {
+			cfg:    mutating.ContainerPortsMutatorConfig{ImagePattern: "[", Port: httpPort},
+			expErr: true,
+		},
+
+		"Containers without ports should have the default port.": {
+			cfg:           mutating.ContainerPortsMutatorConfig{Port: corev1.ContainerPort{Name: "http", ContainerPort: 8080}},
+			containers:    []corev1.Container{{Name: "app", Image: "app:v1"}},
+			expContainers: []corev1.Container{{Name: "app", Image: "app:v1", Ports: []corev1.ContainerPort{httpPort}}},
+		},
+
+		"Containers with ports should not be mutated.": {
+			cfg: mutating.ContainerPortsMutatorConfig{Port: httpPort},
+			containers: []corev1.Container{
+				{Name: "app", Image: "app:v1", Ports: []corev1.ContainerPort{{Name: "metrics", ContainerPort: 9090}}},
+			},
+			expContainers: []corev1.Container{
+				{Name: "app", Image: "app:v1", Ports: []corev1.ContainerPort{{Name: "metrics", ContainerPort: 9090}}},
+			},
+		},
+
+		"Only the containers without ports matching the image pattern should have the default port.": {
+			cfg: mutating.ContainerPortsMutatorConfig{ImagePattern: `^slok/app(:.*)?$`, Port: httpPort},
+			containers: []corev1.Container{
+				{Name: "app", Image: "slok/app:v1"},
+				{Name: "sidecar", Image: "slok/sidecar:v1"},
+			},
+			expContainers: []corev1.Container{
+				{Name: "app", Image: "slok/app:v1", Ports: []corev1.ContainerPort{httpPort}},
+				{Name: "sidecar", Image: "slok/sidecar:v1"},
+			},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+			require := require.New(t)
+
+			m, err := mutating.NewContainerPortsMutator(test.cfg)
+			if test.expErr {
+				assert.Error(err)
+				return
+			}
+			require.NoError(err)
+
+			pod := &corev1.Pod{Spec: corev1.PodSpec{Containers: test.containers}}
+			_, err = m.Mutate(context.TODO(), pod)
+			require.NoError(err)
+			assert.Equal(test.expContainers, pod.Spec.Containers)
+		})
+	}
+}
@@ -0,0 +1,81 @@
+package mutating
+
+import (
+	"context"
+	"strconv"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/slok/kubewebhook/pkg/webhook/internal/helpers"
+)
+
+// CPULimitEnvMutatorConfig is the configuration of the CPU limit env mutator.
+type CPULimitEnvMutatorConfig struct {
+	// EnvVars are the names of the env vars that will be set with the container CPU limit
+	// rounded up to whole CPUs (e.g `1500m` is `2`), by default `GOMAXPROCS`.
+	EnvVars []string
+	// Containers are the names of the containers that will be mutated, if empty all the
+	// containers will be mutated.
+	Containers []string
+}
+
+func (c *CPULimitEnvMutatorConfig) defaults() {
+	if len(c.EnvVars) == 0 {
+		c.EnvVars = []string{"GOMAXPROCS"}
+	}
+}
+
+// NewCPULimitEnvMutator returns a mutator that sets env vars derived from the container CPU limit,
+// useful for the runtimes that size their thread pools based on the node CPUs instead of the
+// container CPU quota (e.g Go `GOMAXPROCS` or the JVM active processor count).
+//
+// The containers without CPU limit are not mutated, and the env vars already set on the container
+// are not replaced.
+func NewCPULimitEnvMutator(cfg CPULimitEnvMutatorConfig) Mutator {
+	cfg.defaults()
+
+	return MutatorFunc(func(_ context.Context, obj metav1.Object) (bool, error) {
+		spec, ok := helpers.PodSpec(obj)
+		if !ok {
+			return false, nil
+		}
+
+		for i := range spec.Containers {
+			c := &spec.Containers[i]
+			if !containerSelected(c.Name, cfg.Containers) {
+				continue
+			}
+
+			cpu, ok := c.Resources.Limits[corev1.ResourceCPU]
+			if !ok || cpu.IsZero() {
+				continue
+			}
+
+			// Round up to whole CPUs, with at least one CPU.
+			cpus := (cpu.MilliValue() + 999) / 1000
+			if cpus < 1 {
+				cpus = 1
+			}
+			value := strconv.FormatInt(cpus, 10)
+
+			for _, name := range cfg.EnvVars {
+				if hasEnvVar(c.Env, name) {
+					continue
+				}
+				c.Env = append(c.Env, corev1.EnvVar{Name: name, Value: value})
+			}
+		}
+
+		return false, nil
+	})
+}
+
+func hasEnvVar(env []corev1.EnvVar, name string) bool {
+	for _, e := range env {
+		if e.Name == name {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,87 @@
+package mutating_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+
+	"github.com/slok/kubewebhook/pkg/webhook/mutating"
+)
+
+func TestCPULimitEnvMutator(t *testing.T) {
+	container := func(name, cpuLimit string, env ...corev1.EnvVar) corev1.Container {
+		c := corev1.Container{Name: name, Env: env}
+		if cpuLimit != "" {
+			c.Resources.Limits = corev1.ResourceList{corev1.ResourceCPU: resource.MustParse(cpuLimit)}
+		}
+		return c
+	}
+
+	tests := map[string]struct {
+		cfg           mutating.CPULimitEnvMutatorConfig
+		containers    []corev1.Container
+		expContainers []corev1.Container
+	}{
+		"A container with an integer CPU limit should have the env var set to the CPUs.": {
+			containers: []corev1.Container{container("app", "2")},
+			expContainers: []corev1.Container{
+				container("app", "2", corev1.EnvVar{Name: "GOMAXPROCS", Value: "2"}),
+			},
+		},
+
+		"A container with a millicpu CPU limit should have the env var set to the CPUs rounded up.": {
+			containers: []corev1.Container{container("app", "1500m"), container("small", "250m")},
+			expContainers: []corev1.Container{
+				container("app", "1500m", corev1.EnvVar{Name: "GOMAXPROCS", Value: "2"}),
+				container("small", "250m", corev1.EnvVar{Name: "GOMAXPROCS", Value: "1"}),
+			},
+		},
+
+		"A container without CPU limit should not be mutated.": {
+			containers:    []corev1.Container{container("app", "")},
+			expContainers: []corev1.Container{container("app", "")},
+		},
+
+		"A container with the env var already set should not replace it.": {
+			containers: []corev1.Container{
+				container("app", "4", corev1.EnvVar{Name: "GOMAXPROCS", Value: "8"}),
+			},
+			expContainers: []corev1.Container{
+				container("app", "4", corev1.EnvVar{Name: "GOMAXPROCS", Value: "8"}),
+			},
+		},
+
+		"Custom env vars should be set on the selected containers.": {
+			cfg: mutating.CPULimitEnvMutatorConfig{
+				EnvVars:    []string{"JAVA_ACTIVE_PROCESSOR_COUNT", "WORKER_THREADS"},
+				Containers: []string{"app"},
+			},
+			containers: []corev1.Container{container("app", "3"), container("sidecar", "1")},
+			expContainers: []corev1.Container{
+				container("app", "3",
+					corev1.EnvVar{Name: "JAVA_ACTIVE_PROCESSOR_COUNT", Value: "3"},
+					corev1.EnvVar{Name: "WORKER_THREADS", Value: "3"},
+				),
+				container("sidecar", "1"),
+			},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+			require := require.New(t)
+
+			pod := &corev1.Pod{Spec: corev1.PodSpec{Containers: test.containers}}
+			m := mutating.NewCPULimitEnvMutator(test.cfg)
+			_, err := m.Mutate(context.TODO(), pod)
+			require.NoError(err)
+
+			assert.Equal(test.expContainers, pod.Spec.Containers)
+		})
+	}
+}
@@ -0,0 +1,141 @@
+package mutating
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/cache"
+
+	"github.com/slok/kubewebhook/pkg/log"
+	"github.com/slok/kubewebhook/pkg/observability/metrics"
+)
+
+// Informer knows how to register the handlers of the object events, `cache.SharedInformer`
+// satisfies it.
+type Informer interface {
+	AddEventHandler(handler cache.ResourceEventHandler)
+}
+
+// PatchEffectiveTrackerConfig is the configuration of the patch effective tracker.
+type PatchEffectiveTrackerConfig struct {
+	// Name is the name of the tracked webhook used on the logs and metrics.
+	Name string
+	// Informer is the informer of the objects mutated by the webhook (e.g the pods).
+	Informer Informer
+	// Mutator is the mutator of the webhook, it must be idempotent.
+	Mutator Mutator
+	// Filter returns true if the object needs to be checked, the objects that the webhook doesn't
+	// mutate (e.g skipped by namespace or annotation) should be filtered, otherwise they will be
+	// measured as not effective. If nil all the objects will be checked.
+	Filter func(obj metav1.Object) bool
+	// MetricsRecorder is the recorder used to measure the patch effectiveness, by default `metrics.Dummy`.
+	MetricsRecorder metrics.Recorder
+	// Logger is the logger used to log the not effective patches, by default `log.Dummy`.
+	Logger log.Logger
+}
+
+func (c *PatchEffectiveTrackerConfig) defaults() error {
+	if c.Name == "" {
+		return fmt.Errorf("name is required")
+	}
+
+	if c.Informer == nil {
+		return fmt.Errorf("informer is required")
+	}
+
+	if c.Mutator == nil {
+		return fmt.Errorf("mutator is required")
+	}
+
+	if c.MetricsRecorder == nil {
+		c.MetricsRecorder = metrics.Dummy
+	}
+
+	if c.Logger == nil {
+		c.Logger = log.Dummy
+	}
+
+	return nil
+}
+
+// RegisterPatchEffectiveTracker registers a tracker on the informer that checks if the webhook mutation
+// is present on the objects after their admission, and measures it with the patch effective metric.
+// The webhook can't know if the API server applied its patch (e.g another webhook reverted it or
+// the webhook response was ignored by the failure policy), this tracker closes the loop.
+//
+// The mutation is checked applying the webhook mutator again on a copy of the object, if the mutator
+// doesn't change the object the mutation is effective. For this reason the mutator must be idempotent
+// and not depend on the admission request (not available outside the webhook). Only the objects
+// added to the informer are checked, so each object is measured once (the updates and the resyncs
+// are ignored), take into account that the objects created before the webhook will be checked
+// with the informer initial list.
+func RegisterPatchEffectiveTracker(cfg PatchEffectiveTrackerConfig) error {
+	if err := cfg.defaults(); err != nil {
+		return fmt.Errorf("invalid configuration: %w", err)
+	}
+
+	t := patchEffectiveTracker{cfg: cfg}
+	cfg.Informer.AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc: t.track,
+	})
+
+	return nil
+}
+
+type patchEffectiveTracker struct {
+	cfg PatchEffectiveTrackerConfig
+}
+
+func (t patchEffectiveTracker) track(obj interface{}) {
+	robj, ok := obj.(runtime.Object)
+	if !ok {
+		return
+	}
+
+	if mobj, ok := obj.(metav1.Object); ok && t.cfg.Filter != nil && !t.cfg.Filter(mobj) {
+		return
+	}
+
+	effective, err := t.mutationPresent(robj)
+	if err != nil {
+		t.cfg.Logger.Warningf("could not check webhook %q mutation: %s", t.cfg.Name, err)
+		return
+	}
+
+	if !effective {
+		if mobj, ok := robj.(metav1.Object); ok {
+			t.cfg.Logger.Warningf("webhook %q mutation is not present on %s/%s object", t.cfg.Name, mobj.GetNamespace(), mobj.GetName())
+		}
+	}
+	if rec, ok := t.cfg.MetricsRecorder.(metrics.PatchEffectiveRecorder); ok {
+		rec.IncPatchEffective(t.cfg.Name, effective)
+	}
+}
+
+// mutationPresent returns true if mutating again the object doesn't change it.
+func (t patchEffectiveTracker) mutationPresent(obj runtime.Object) (bool, error) {
+	mutated, ok := obj.DeepCopyObject().(metav1.Object)
+	if !ok {
+		return false, fmt.Errorf("impossible to type assert the deep copy to metav1.Object")
+	}
+
+	if _, err := t.cfg.Mutator.Mutate(context.Background(), mutated); err != nil {
+		return false, err
+	}
+
+	// Compare the JSON representations, like the webhook patches.
+	original, err := json.Marshal(obj)
+	if err != nil {
+		return false, err
+	}
+	mutatedJSON, err := json.Marshal(mutated)
+	if err != nil {
+		return false, err
+	}
+
+	return bytes.Equal(original, mutatedJSON), nil
+}
@@ -0,0 +1,128 @@
+package mutating_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/cache"
+
+	mmetrics "github.com/slok/kubewebhook/mocks/observability/metrics"
+	"github.com/slok/kubewebhook/pkg/log"
+	"github.com/slok/kubewebhook/pkg/webhook/mutating"
+)
+
+// fakeInformer is an informer that sends the events to the registered handlers.
+type fakeInformer struct {
+	handlers []cache.ResourceEventHandler
+}
+
+func (f *fakeInformer) AddEventHandler(handler cache.ResourceEventHandler) {
+	f.handlers = append(f.handlers, handler)
+}
+
+func (f *fakeInformer) add(obj interface{}) {
+	for _, h := range f.handlers {
+		h.OnAdd(obj)
+	}
+}
+
+func (f *fakeInformer) update(oldObj, newObj interface{}) {
+	for _, h := range f.handlers {
+		h.OnUpdate(oldObj, newObj)
+	}
+}
+
+func TestPatchEffectiveTracker(t *testing.T) {
+	teamLabelMutator := mutating.MutatorFunc(func(_ context.Context, obj metav1.Object) (bool, error) {
+		labels := obj.GetLabels()
+		if labels == nil {
+			labels = map[string]string{}
+		}
+		labels["team"] = "test"
+		obj.SetLabels(labels)
+		return false, nil
+	})
+	labeledPod := func(rv string, labels map[string]string) *corev1.Pod {
+		return &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "test", Namespace: "test", ResourceVersion: rv, Labels: labels}}
+	}
+
+	tests := map[string]struct {
+		filter          func(obj metav1.Object) bool
+		events          func(f *fakeInformer)
+		expEffective    int
+		expNotEffective int
+	}{
+		"An added object with the mutation should be measured as effective.": {
+			events: func(f *fakeInformer) {
+				f.add(labeledPod("1", map[string]string{"team": "test"}))
+			},
+			expEffective: 1,
+		},
+
+		"An added object without the mutation should be measured as not effective.": {
+			events: func(f *fakeInformer) {
+				f.add(labeledPod("1", map[string]string{"team": "other"}))
+				f.add(labeledPod("1", nil))
+			},
+			expNotEffective: 2,
+		},
+
+		"An updated object should not be measured again.": {
+			events: func(f *fakeInformer) {
+				f.add(labeledPod("1", map[string]string{"team": "test"}))
+				f.update(labeledPod("1", map[string]string{"team": "test"}), labeledPod("2", nil))
+				f.update(labeledPod("2", nil), labeledPod("2", nil))
+			},
+			expEffective: 1,
+		},
+
+		"A filtered object should not be measured.": {
+			filter: func(obj metav1.Object) bool { return obj.GetNamespace() != "kube-system" },
+			events: func(f *fakeInformer) {
+				pod := labeledPod("1", nil)
+				pod.Namespace = "kube-system"
+				f.add(pod)
+				f.add(labeledPod("1", map[string]string{"team": "test"}))
+			},
+			expEffective: 1,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			require := require.New(t)
+
+			mrec := &mmetrics.Recorder{}
+			if test.expEffective > 0 {
+				mrec.On("IncPatchEffective", "test", true).Times(test.expEffective)
+			}
+			if test.expNotEffective > 0 {
+				mrec.On("IncPatchEffective", "test", false).Times(test.expNotEffective)
+			}
+
+			informer := &fakeInformer{}
+			err := mutating.RegisterPatchEffectiveTracker(mutating.PatchEffectiveTrackerConfig{
+				Name:            "test",
+				Informer:        informer,
+				Mutator:         teamLabelMutator,
+				Filter:          test.filter,
+				MetricsRecorder: mrec,
+				Logger:          log.Dummy,
+			})
+			require.NoError(err)
+
+			test.events(informer)
+
+			mrec.AssertExpectations(t)
+		})
+	}
+}
+
+func TestPatchEffectiveTrackerInvalidConfig(t *testing.T) {
+	err := mutating.RegisterPatchEffectiveTracker(mutating.PatchEffectiveTrackerConfig{Name: "test"})
+	assert.Error(t, err)
+}
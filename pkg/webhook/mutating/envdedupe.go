@@ -0,0 +1,107 @@
+package mutating
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/slok/kubewebhook/pkg/webhook/internal/helpers"
+)
+
+// EnvDedupePolicy is the policy used to select the env var kept from the duplicated ones.
+type EnvDedupePolicy string
+
+const (
+	// EnvDedupePolicyLastWins will keep the last env var of the duplicated ones, at the position of
+	// the last one. This is the value the containers receive when the env vars are duplicated.
+	EnvDedupePolicyLastWins EnvDedupePolicy = "last-wins"
+	// EnvDedupePolicyFirstWins will keep the first env var of the duplicated ones, at the position of
+	// the first one.
+	EnvDedupePolicyFirstWins EnvDedupePolicy = "first-wins"
+)
+
+// EnvDedupeMutatorConfig is the configuration of the env dedupe mutator.
+type EnvDedupeMutatorConfig struct {
+	// Policy is the policy used to select the env var kept from the duplicated ones, by default `last-wins`.
+	Policy EnvDedupePolicy
+	// Sort will sort the env vars by name. The env vars that reference other env vars (e.g `$(VAR)`)
+	// need the referenced env vars defined before them, so don't sort the env vars that use references.
+	Sort bool
+	// Containers are the names of the containers that will be mutated, if empty all the containers
+	// (including the init containers) will be mutated.
+	Containers []string
+}
+
+func (c *EnvDedupeMutatorConfig) defaults() error {
+	if c.Policy == "" {
+		c.Policy = EnvDedupePolicyLastWins
+	}
+
+	if c.Policy != EnvDedupePolicyLastWins && c.Policy != EnvDedupePolicyFirstWins {
+		return fmt.Errorf("unknown env dedupe policy %q", c.Policy)
+	}
+
+	return nil
+}
+
+// NewEnvDedupeMutator returns a mutator that removes the duplicated env vars (by name) of the pod
+// containers (or the pod templates of the workloads) and optionally sorts them, this way the
+// env vars merged from multiple sources (e.g other mutators) have a deterministic output and
+// the mutations are idempotent. Normally used as the last mutator of a chain.
+func NewEnvDedupeMutator(cfg EnvDedupeMutatorConfig) (Mutator, error) {
+	if err := cfg.defaults(); err != nil {
+		return nil, fmt.Errorf("invalid configuration: %w", err)
+	}
+
+	return MutatorFunc(func(_ context.Context, obj metav1.Object) (bool, error) {
+		spec, ok := helpers.PodSpec(obj)
+		if !ok {
+			return false, nil
+		}
+
+		for _, cs := range [][]corev1.Container{spec.InitContainers, spec.Containers} {
+			for i := range cs {
+				c := &cs[i]
+				if len(c.Env) == 0 || !containerSelected(c.Name, cfg.Containers) {
+					continue
+				}
+
+				c.Env = dedupeEnvVars(c.Env, cfg.Policy)
+				if cfg.Sort {
+					sort.SliceStable(c.Env, func(i, j int) bool { return c.Env[i].Name < c.Env[j].Name })
+				}
+			}
+		}
+
+		return false, nil
+	}), nil
+}
+
+// dedupeEnvVars returns the env vars without the duplicated names, keeping the first or the last
+// env var of the duplicated ones based on the policy.
+func dedupeEnvVars(env []corev1.EnvVar, policy EnvDedupePolicy) []corev1.EnvVar {
+	// Select the index of the kept env var of each name.
+	kept := map[string]int{}
+	for i, e := range env {
+		if _, ok := kept[e.Name]; ok && policy == EnvDedupePolicyFirstWins {
+			continue
+		}
+		kept[e.Name] = i
+	}
+
+	if len(kept) == len(env) {
+		return env
+	}
+
+	deduped := make([]corev1.EnvVar, 0, len(kept))
+	for i, e := range env {
+		if kept[e.Name] == i {
+			deduped = append(deduped, e)
+		}
+	}
+
+	return deduped
+}
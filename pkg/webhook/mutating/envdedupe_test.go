@@ -0,0 +1,115 @@
+package mutating_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/slok/kubewebhook/pkg/webhook/mutating"
+)
+
+func TestEnvDedupeMutator(t *testing.T) {
+	env := func(kvs ...string) []corev1.EnvVar {
+		var env []corev1.EnvVar
+		for i := 0; i < len(kvs); i += 2 {
+			env = append(env, corev1.EnvVar{Name: kvs[i], Value: kvs[i+1]})
+		}
+		return env
+	}
+
+	tests := map[string]struct {
+		cfg            mutating.EnvDedupeMutatorConfig
+		obj            metav1.Object
+		expObj         metav1.Object
+		expConfigError bool
+	}{
+		"An unknown policy should fail.": {
+			cfg:            mutating.EnvDedupeMutatorConfig{Policy: "unknown"},
+			expConfigError: true,
+		},
+
+		"Env vars without duplicates should not be mutated.": {
+			cfg:    mutating.EnvDedupeMutatorConfig{},
+			obj:    &corev1.Pod{Spec: corev1.PodSpec{Containers: []corev1.Container{{Name: "app", Env: env("B", "1", "A", "2")}}}},
+			expObj: &corev1.Pod{Spec: corev1.PodSpec{Containers: []corev1.Container{{Name: "app", Env: env("B", "1", "A", "2")}}}},
+		},
+
+		"Duplicated env vars should keep the last one by default.": {
+			cfg:    mutating.EnvDedupeMutatorConfig{},
+			obj:    &corev1.Pod{Spec: corev1.PodSpec{Containers: []corev1.Container{{Name: "app", Env: env("A", "1", "B", "2", "A", "3")}}}},
+			expObj: &corev1.Pod{Spec: corev1.PodSpec{Containers: []corev1.Container{{Name: "app", Env: env("B", "2", "A", "3")}}}},
+		},
+
+		"Duplicated env vars should keep the first one with the first wins policy.": {
+			cfg:    mutating.EnvDedupeMutatorConfig{Policy: mutating.EnvDedupePolicyFirstWins},
+			obj:    &corev1.Pod{Spec: corev1.PodSpec{Containers: []corev1.Container{{Name: "app", Env: env("A", "1", "B", "2", "A", "3")}}}},
+			expObj: &corev1.Pod{Spec: corev1.PodSpec{Containers: []corev1.Container{{Name: "app", Env: env("A", "1", "B", "2")}}}},
+		},
+
+		"Unsorted env vars should be sorted if enabled.": {
+			cfg:    mutating.EnvDedupeMutatorConfig{Sort: true},
+			obj:    &corev1.Pod{Spec: corev1.PodSpec{Containers: []corev1.Container{{Name: "app", Env: env("C", "1", "A", "2", "B", "3", "A", "4")}}}},
+			expObj: &corev1.Pod{Spec: corev1.PodSpec{Containers: []corev1.Container{{Name: "app", Env: env("A", "4", "B", "3", "C", "1")}}}},
+		},
+
+		"A workload should have only the selected containers mutated, including the init containers.": {
+			cfg: mutating.EnvDedupeMutatorConfig{Sort: true, Containers: []string{"init", "app"}},
+			obj: &appsv1.Deployment{
+				Spec: appsv1.DeploymentSpec{
+					Template: corev1.PodTemplateSpec{
+						Spec: corev1.PodSpec{
+							InitContainers: []corev1.Container{{Name: "init", Env: env("B", "1", "A", "2", "B", "3")}},
+							Containers: []corev1.Container{
+								{Name: "app", Env: env("B", "1", "A", "2")},
+								{Name: "sidecar", Env: env("B", "1", "A", "2", "B", "3")},
+							},
+						},
+					},
+				},
+			},
+			expObj: &appsv1.Deployment{
+				Spec: appsv1.DeploymentSpec{
+					Template: corev1.PodTemplateSpec{
+						Spec: corev1.PodSpec{
+							InitContainers: []corev1.Container{{Name: "init", Env: env("A", "2", "B", "3")}},
+							Containers: []corev1.Container{
+								{Name: "app", Env: env("A", "2", "B", "1")},
+								{Name: "sidecar", Env: env("B", "1", "A", "2", "B", "3")},
+							},
+						},
+					},
+				},
+			},
+		},
+
+		"A non pod object should not be mutated.": {
+			cfg:    mutating.EnvDedupeMutatorConfig{Sort: true},
+			obj:    &corev1.Service{},
+			expObj: &corev1.Service{},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+			require := require.New(t)
+
+			m, err := mutating.NewEnvDedupeMutator(test.cfg)
+			if test.expConfigError {
+				assert.Error(err)
+				return
+			}
+			require.NoError(err)
+
+			_, err = m.Mutate(context.TODO(), test.obj)
+			require.NoError(err)
+
+			assert.Equal(test.expObj, test.obj)
+		})
+	}
+}
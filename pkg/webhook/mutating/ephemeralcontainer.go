@@ -0,0 +1,79 @@
+package mutating
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// NewEphemeralContainerMutator returns a mutator that injects an ephemeral container (e.g a debugging
+// container) on the `EphemeralContainers` objects of the pods `ephemeralcontainers` subresource
+// requests. The ephemeral containers can only be added using this subresource, the API server
+// rejects them on the pod creations and updates, so the rest of the objects (including the
+// pods) are not mutated.
+//
+// The ephemeral container is validated against the ephemeral container rules before returning the
+// mutator (it requires a name and an image, and it can't have ports, resources, probes or lifecycle
+// hooks). The subresource object doesn't have the pod containers, so the API server will be the one
+// rejecting a name already used by a pod container or a missing target container. The mutator is
+// idempotent, if the object already has an ephemeral container with the same name, it will not be
+// injected again.
+func NewEphemeralContainerMutator(ec corev1.EphemeralContainer) (Mutator, error) {
+	if err := validateEphemeralContainer(ec); err != nil {
+		return nil, fmt.Errorf("invalid ephemeral container: %w", err)
+	}
+
+	return MutatorFunc(func(_ context.Context, obj metav1.Object) (bool, error) {
+		ecs, ok := obj.(*corev1.EphemeralContainers)
+		if !ok || hasEphemeralContainer(ecs.EphemeralContainers, ec.Name) {
+			return false, nil
+		}
+
+		ecs.EphemeralContainers = append(ecs.EphemeralContainers, *ec.DeepCopy())
+
+		return false, nil
+	}), nil
+}
+
+// validateEphemeralContainer validates the ephemeral container fields that are not allowed
+// or required by the Kubernetes ephemeral containers.
+func validateEphemeralContainer(ec corev1.EphemeralContainer) error {
+	var errs []string
+	if ec.Name == "" {
+		errs = append(errs, "name is required")
+	}
+	if ec.Image == "" {
+		errs = append(errs, "image is required")
+	}
+	if len(ec.Ports) > 0 {
+		errs = append(errs, "ports are not allowed")
+	}
+	if len(ec.Resources.Limits) > 0 || len(ec.Resources.Requests) > 0 {
+		errs = append(errs, "resources are not allowed")
+	}
+	if ec.LivenessProbe != nil || ec.ReadinessProbe != nil || ec.StartupProbe != nil {
+		errs = append(errs, "probes are not allowed")
+	}
+	if ec.Lifecycle != nil {
+		errs = append(errs, "lifecycle is not allowed")
+	}
+
+	if len(errs) > 0 {
+		return fmt.Errorf("%s", strings.Join(errs, "; "))
+	}
+
+	return nil
+}
+
+func hasEphemeralContainer(ecs []corev1.EphemeralContainer, name string) bool {
+	for _, ec := range ecs {
+		if ec.Name == name {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,121 @@
+package mutating_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/slok/kubewebhook/pkg/webhook/mutating"
+)
+
+func TestEphemeralContainerMutator(t *testing.T) {
+	debug := func(target string) corev1.EphemeralContainer {
+		return corev1.EphemeralContainer{
+			EphemeralContainerCommon: corev1.EphemeralContainerCommon{
+				Name:  "debug",
+				Image: "busybox",
+				Stdin: true,
+				TTY:   true,
+			},
+			TargetContainerName: target,
+		}
+	}
+
+	tests := map[string]struct {
+		ec             corev1.EphemeralContainer
+		obj            metav1.Object
+		expObj         metav1.Object
+		expConfigError bool
+	}{
+		"An ephemeral container without image should fail.": {
+			ec: corev1.EphemeralContainer{
+				EphemeralContainerCommon: corev1.EphemeralContainerCommon{Name: "debug"},
+			},
+			expConfigError: true,
+		},
+
+		"An ephemeral container with resources should fail.": {
+			ec: corev1.EphemeralContainer{
+				EphemeralContainerCommon: corev1.EphemeralContainerCommon{
+					Name:      "debug",
+					Image:     "busybox",
+					Resources: corev1.ResourceRequirements{Limits: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("1")}},
+				},
+			},
+			expConfigError: true,
+		},
+
+		"An ephemeral container with probes should fail.": {
+			ec: corev1.EphemeralContainer{
+				EphemeralContainerCommon: corev1.EphemeralContainerCommon{
+					Name:           "debug",
+					Image:          "busybox",
+					ReadinessProbe: &corev1.Probe{},
+				},
+			},
+			expConfigError: true,
+		},
+
+		"An ephemeral container with ports should fail.": {
+			ec: corev1.EphemeralContainer{
+				EphemeralContainerCommon: corev1.EphemeralContainerCommon{
+					Name:  "debug",
+					Image: "busybox",
+					Ports: []corev1.ContainerPort{{ContainerPort: 8080}},
+				},
+			},
+			expConfigError: true,
+		},
+
+		"A valid ephemeral container should be injected on the ephemeral containers subresource object.": {
+			ec:     debug("app"),
+			obj:    &corev1.EphemeralContainers{},
+			expObj: &corev1.EphemeralContainers{EphemeralContainers: []corev1.EphemeralContainer{debug("app")}},
+		},
+
+		"An already injected ephemeral container should not be injected again.": {
+			ec:     debug(""),
+			obj:    &corev1.EphemeralContainers{EphemeralContainers: []corev1.EphemeralContainer{debug("")}},
+			expObj: &corev1.EphemeralContainers{EphemeralContainers: []corev1.EphemeralContainer{debug("")}},
+		},
+
+		"A pod should not be mutated.": {
+			ec: debug("app"),
+			obj: &corev1.Pod{
+				Spec: corev1.PodSpec{Containers: []corev1.Container{{Name: "app"}}},
+			},
+			expObj: &corev1.Pod{
+				Spec: corev1.PodSpec{Containers: []corev1.Container{{Name: "app"}}},
+			},
+		},
+
+		"A non ephemeral containers object should not be mutated.": {
+			ec:     debug(""),
+			obj:    &corev1.Service{},
+			expObj: &corev1.Service{},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+			require := require.New(t)
+
+			m, err := mutating.NewEphemeralContainerMutator(test.ec)
+			if test.expConfigError {
+				assert.Error(err)
+				return
+			}
+			require.NoError(err)
+
+			_, err = m.Mutate(context.TODO(), test.obj)
+			require.NoError(err)
+			assert.Equal(test.expObj, test.obj)
+		})
+	}
+}
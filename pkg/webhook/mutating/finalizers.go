@@ -0,0 +1,48 @@
+package mutating
+
+import (
+	"context"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// FinalizersMutatorConfig is the configuration of the finalizers mutator.
+type FinalizersMutatorConfig struct {
+	// Finalizers are the finalizers that will be added to the objects that don't have them.
+	Finalizers []string
+	// RemoveFinalizers are the finalizers no longer desired that will be removed from the
+	// objects (e.g finalizers of decommissioned controllers).
+	RemoveFinalizers []string
+}
+
+// NewFinalizersMutator returns a mutator that adds the configured finalizers to the objects that
+// don't have them and removes the finalizers no longer desired. The duplicated finalizers of the
+// objects are removed keeping the first occurrence order.
+func NewFinalizersMutator(cfg FinalizersMutatorConfig) Mutator {
+	remove := map[string]bool{}
+	for _, f := range cfg.RemoveFinalizers {
+		remove[f] = true
+	}
+
+	return MutatorFunc(func(_ context.Context, obj metav1.Object) (bool, error) {
+		current := obj.GetFinalizers()
+		finalizers := make([]string, 0, len(current)+len(cfg.Finalizers))
+		seen := map[string]bool{}
+		for _, fs := range [][]string{current, cfg.Finalizers} {
+			for _, f := range fs {
+				if seen[f] || remove[f] {
+					continue
+				}
+				seen[f] = true
+				finalizers = append(finalizers, f)
+			}
+		}
+
+		if len(finalizers) == 0 {
+			finalizers = nil
+		}
+		obj.SetFinalizers(finalizers)
+
+		return false, nil
+	})
+}
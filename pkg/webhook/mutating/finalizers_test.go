@@ -0,0 +1,66 @@
+package mutating_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/slok/kubewebhook/pkg/webhook/mutating"
+)
+
+func TestFinalizersMutator(t *testing.T) {
+	tests := map[string]struct {
+		cfg           mutating.FinalizersMutatorConfig
+		finalizers    []string
+		expFinalizers []string
+	}{
+		"An object without finalizers should have the finalizers added.": {
+			cfg:           mutating.FinalizersMutatorConfig{Finalizers: []string{"slok.dev/cleanup", "slok.dev/backup"}},
+			expFinalizers: []string{"slok.dev/cleanup", "slok.dev/backup"},
+		},
+
+		"An object with some of the finalizers should only have the missing finalizers added.": {
+			cfg:           mutating.FinalizersMutatorConfig{Finalizers: []string{"slok.dev/cleanup", "slok.dev/backup"}},
+			finalizers:    []string{"other.dev/finalizer", "slok.dev/backup"},
+			expFinalizers: []string{"other.dev/finalizer", "slok.dev/backup", "slok.dev/cleanup"},
+		},
+
+		"An object with duplicated finalizers should have them deduplicated.": {
+			cfg:           mutating.FinalizersMutatorConfig{Finalizers: []string{"slok.dev/cleanup"}},
+			finalizers:    []string{"slok.dev/cleanup", "other.dev/finalizer", "slok.dev/cleanup", "other.dev/finalizer"},
+			expFinalizers: []string{"slok.dev/cleanup", "other.dev/finalizer"},
+		},
+
+		"An object with finalizers no longer desired should have them removed.": {
+			cfg: mutating.FinalizersMutatorConfig{
+				Finalizers:       []string{"slok.dev/cleanup"},
+				RemoveFinalizers: []string{"slok.dev/legacy"},
+			},
+			finalizers:    []string{"slok.dev/legacy", "other.dev/finalizer"},
+			expFinalizers: []string{"other.dev/finalizer", "slok.dev/cleanup"},
+		},
+
+		"An object with only finalizers no longer desired should not have finalizers.": {
+			cfg:        mutating.FinalizersMutatorConfig{RemoveFinalizers: []string{"slok.dev/legacy"}},
+			finalizers: []string{"slok.dev/legacy"},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+			require := require.New(t)
+
+			pod := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Finalizers: test.finalizers}}
+			m := mutating.NewFinalizersMutator(test.cfg)
+			_, err := m.Mutate(context.TODO(), pod)
+			require.NoError(err)
+
+			assert.Equal(test.expFinalizers, pod.Finalizers)
+		})
+	}
+}
@@ -0,0 +1,55 @@
+package mutating
+
+import (
+	"context"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/slok/kubewebhook/pkg/webhook/internal/helpers"
+)
+
+// NewHostAliasesMutator returns a mutator that sets the host aliases (`/etc/hosts` entries) on
+// the pods (or the pod templates of the workloads). The aliases are merged with the existing ones,
+// if the IP already has an alias, the missing hostnames will be added to the same alias.
+func NewHostAliasesMutator(aliases []corev1.HostAlias) Mutator {
+	return MutatorFunc(func(_ context.Context, obj metav1.Object) (bool, error) {
+		spec, ok := helpers.PodSpec(obj)
+		if !ok {
+			return false, nil
+		}
+
+		for _, alias := range aliases {
+			spec.HostAliases = mergeHostAlias(spec.HostAliases, alias)
+		}
+
+		return false, nil
+	})
+}
+
+func mergeHostAlias(aliases []corev1.HostAlias, alias corev1.HostAlias) []corev1.HostAlias {
+	for i, a := range aliases {
+		if a.IP != alias.IP {
+			continue
+		}
+
+		// Same IP, add the missing hostnames.
+		hostnames := map[string]bool{}
+		for _, h := range a.Hostnames {
+			hostnames[h] = true
+		}
+		for _, h := range alias.Hostnames {
+			if !hostnames[h] {
+				a.Hostnames = append(a.Hostnames, h)
+				hostnames[h] = true
+			}
+		}
+		aliases[i] = a
+
+		return aliases
+	}
+
+	hostnames := make([]string, len(alias.Hostnames))
+	copy(hostnames, alias.Hostnames)
+	return append(aliases, corev1.HostAlias{IP: alias.IP, Hostnames: hostnames})
+}
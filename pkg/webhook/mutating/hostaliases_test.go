@@ -0,0 +1,91 @@
+package mutating_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/slok/kubewebhook/pkg/webhook/mutating"
+)
+
+func TestHostAliasesMutator(t *testing.T) {
+	aliases := []corev1.HostAlias{
+		{IP: "10.0.0.1", Hostnames: []string{"legacy-db", "legacy-db.local"}},
+		{IP: "10.0.0.2", Hostnames: []string{"legacy-api"}},
+	}
+
+	tests := map[string]struct {
+		obj    metav1.Object
+		expObj metav1.Object
+	}{
+		"A pod without host aliases should set the aliases.": {
+			obj: &corev1.Pod{},
+			expObj: &corev1.Pod{
+				Spec: corev1.PodSpec{
+					HostAliases: []corev1.HostAlias{
+						{IP: "10.0.0.1", Hostnames: []string{"legacy-db", "legacy-db.local"}},
+						{IP: "10.0.0.2", Hostnames: []string{"legacy-api"}},
+					},
+				},
+			},
+		},
+
+		"A pod with host aliases should merge the aliases without duplicating IPs.": {
+			obj: &corev1.Pod{
+				Spec: corev1.PodSpec{
+					HostAliases: []corev1.HostAlias{
+						{IP: "10.0.0.1", Hostnames: []string{"legacy-db", "other"}},
+						{IP: "10.0.0.3", Hostnames: []string{"something"}},
+					},
+				},
+			},
+			expObj: &corev1.Pod{
+				Spec: corev1.PodSpec{
+					HostAliases: []corev1.HostAlias{
+						{IP: "10.0.0.1", Hostnames: []string{"legacy-db", "other", "legacy-db.local"}},
+						{IP: "10.0.0.3", Hostnames: []string{"something"}},
+						{IP: "10.0.0.2", Hostnames: []string{"legacy-api"}},
+					},
+				},
+			},
+		},
+
+		"A deployment without host aliases should set the aliases on the pod template.": {
+			obj: &appsv1.Deployment{},
+			expObj: &appsv1.Deployment{
+				Spec: appsv1.DeploymentSpec{
+					Template: corev1.PodTemplateSpec{
+						Spec: corev1.PodSpec{
+							HostAliases: []corev1.HostAlias{
+								{IP: "10.0.0.1", Hostnames: []string{"legacy-db", "legacy-db.local"}},
+								{IP: "10.0.0.2", Hostnames: []string{"legacy-api"}},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+			require := require.New(t)
+
+			m := mutating.NewHostAliasesMutator(aliases)
+			_, err := m.Mutate(context.TODO(), test.obj)
+			require.NoError(err)
+
+			// Mutate again to check idempotency.
+			_, err = m.Mutate(context.TODO(), test.obj)
+			require.NoError(err)
+
+			assert.Equal(test.expObj, test.obj)
+		})
+	}
+}
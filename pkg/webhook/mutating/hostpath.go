@@ -0,0 +1,130 @@
+package mutating
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/slok/kubewebhook/pkg/webhook/internal/helpers"
+)
+
+// HostPathPolicy is the policy applied to the disallowed hostPath volumes.
+type HostPathPolicy string
+
+const (
+	// HostPathPolicyEmptyDir will rewrite the disallowed hostPath volumes to emptyDir volumes,
+	// the volume mounts are kept.
+	HostPathPolicyEmptyDir HostPathPolicy = "emptydir"
+	// HostPathPolicyStrip will remove the disallowed hostPath volumes and their volume mounts.
+	HostPathPolicyStrip HostPathPolicy = "strip"
+)
+
+// HostPathMutatorConfig is the configuration of the hostPath mutator.
+type HostPathMutatorConfig struct {
+	// DisallowedPrefixes are the host paths that are not allowed (e.g `/var/run/docker.sock`, `/etc`),
+	// the sub paths of the prefixes are also disallowed.
+	DisallowedPrefixes []string
+	// Policy is the policy applied to the disallowed hostPath volumes, by default `emptydir`.
+	Policy HostPathPolicy
+}
+
+func (c *HostPathMutatorConfig) defaults() error {
+	if c.Policy == "" {
+		c.Policy = HostPathPolicyEmptyDir
+	}
+
+	if c.Policy != HostPathPolicyEmptyDir && c.Policy != HostPathPolicyStrip {
+		return fmt.Errorf("unknown hostPath policy %q", c.Policy)
+	}
+
+	for _, p := range c.DisallowedPrefixes {
+		if !path.IsAbs(p) {
+			return fmt.Errorf("disallowed prefix %q is not an absolute path", p)
+		}
+	}
+
+	return nil
+}
+
+// NewHostPathMutator returns a mutator that rewrites the hostPath volumes of the pods (or the pod
+// templates of the workloads) under the disallowed prefixes, depending on the policy the volumes
+// will be rewritten to emptyDir volumes or removed with the volume mounts that use them.
+//
+// The rewritten volume mounts will have the mount propagation removed, it doesn't have any
+// meaning without the host path.
+func NewHostPathMutator(cfg HostPathMutatorConfig) (Mutator, error) {
+	if err := cfg.defaults(); err != nil {
+		return nil, fmt.Errorf("invalid configuration: %w", err)
+	}
+
+	return MutatorFunc(func(_ context.Context, obj metav1.Object) (bool, error) {
+		spec, ok := helpers.PodSpec(obj)
+		if !ok {
+			return false, nil
+		}
+
+		disallowed := map[string]bool{}
+		volumes := spec.Volumes[:0]
+		for _, v := range spec.Volumes {
+			if v.HostPath == nil || !pathUnderPrefixes(v.HostPath.Path, cfg.DisallowedPrefixes) {
+				volumes = append(volumes, v)
+				continue
+			}
+
+			disallowed[v.Name] = true
+			if cfg.Policy == HostPathPolicyEmptyDir {
+				v.VolumeSource = corev1.VolumeSource{EmptyDir: &corev1.EmptyDirVolumeSource{}}
+				volumes = append(volumes, v)
+			}
+		}
+		if len(disallowed) == 0 {
+			return false, nil
+		}
+		if len(volumes) == 0 {
+			volumes = nil
+		}
+		spec.Volumes = volumes
+
+		// Update the volume mounts so they are consistent with the volumes.
+		updateMounts := func(containers []corev1.Container) {
+			for i := range containers {
+				c := &containers[i]
+				mounts := c.VolumeMounts[:0]
+				for _, m := range c.VolumeMounts {
+					if disallowed[m.Name] {
+						if cfg.Policy == HostPathPolicyStrip {
+							continue
+						}
+						m.MountPropagation = nil
+					}
+					mounts = append(mounts, m)
+				}
+				if len(mounts) == 0 {
+					mounts = nil
+				}
+				c.VolumeMounts = mounts
+			}
+		}
+		updateMounts(spec.InitContainers)
+		updateMounts(spec.Containers)
+
+		return false, nil
+	}), nil
+}
+
+// pathUnderPrefixes returns true if the path is one of the prefixes or a sub path of them.
+func pathUnderPrefixes(p string, prefixes []string) bool {
+	p = path.Clean(p)
+	for _, prefix := range prefixes {
+		prefix = path.Clean(prefix)
+		if p == prefix || prefix == "/" || strings.HasPrefix(p, prefix+"/") {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,156 @@
+package mutating_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+
+	"github.com/slok/kubewebhook/pkg/webhook/mutating"
+)
+
+func TestHostPathMutator(t *testing.T) {
+	hostPathVolume := func(name, path string) corev1.Volume {
+		return corev1.Volume{Name: name, VolumeSource: corev1.VolumeSource{HostPath: &corev1.HostPathVolumeSource{Path: path}}}
+	}
+	emptyDirVolume := func(name string) corev1.Volume {
+		return corev1.Volume{Name: name, VolumeSource: corev1.VolumeSource{EmptyDir: &corev1.EmptyDirVolumeSource{}}}
+	}
+	propagation := corev1.MountPropagationHostToContainer
+
+	newPod := func() *corev1.Pod {
+		return &corev1.Pod{
+			Spec: corev1.PodSpec{
+				Volumes: []corev1.Volume{
+					hostPathVolume("docker", "/var/run/docker.sock"),
+					hostPathVolume("logs", "/var/log/app"),
+					hostPathVolume("variable", "/variable"),
+					emptyDirVolume("cache"),
+				},
+				InitContainers: []corev1.Container{
+					{Name: "init", VolumeMounts: []corev1.VolumeMount{{Name: "docker", MountPath: "/docker.sock"}}},
+				},
+				Containers: []corev1.Container{
+					{
+						Name: "app",
+						VolumeMounts: []corev1.VolumeMount{
+							{Name: "logs", MountPath: "/logs", MountPropagation: &propagation},
+							{Name: "variable", MountPath: "/variable"},
+							{Name: "cache", MountPath: "/cache"},
+						},
+					},
+				},
+			},
+		}
+	}
+
+	tests := map[string]struct {
+		cfg    mutating.HostPathMutatorConfig
+		pod    *corev1.Pod
+		expPod *corev1.Pod
+		expErr bool
+	}{
+		"Disallowed hostPaths with the emptydir policy should be rewritten to emptyDir volumes.": {
+			cfg: mutating.HostPathMutatorConfig{
+				DisallowedPrefixes: []string{"/var/run/docker.sock", "/var/log"},
+			},
+			pod: newPod(),
+			expPod: &corev1.Pod{
+				Spec: corev1.PodSpec{
+					Volumes: []corev1.Volume{
+						emptyDirVolume("docker"),
+						emptyDirVolume("logs"),
+						hostPathVolume("variable", "/variable"),
+						emptyDirVolume("cache"),
+					},
+					InitContainers: []corev1.Container{
+						{Name: "init", VolumeMounts: []corev1.VolumeMount{{Name: "docker", MountPath: "/docker.sock"}}},
+					},
+					Containers: []corev1.Container{
+						{
+							Name: "app",
+							VolumeMounts: []corev1.VolumeMount{
+								{Name: "logs", MountPath: "/logs"},
+								{Name: "variable", MountPath: "/variable"},
+								{Name: "cache", MountPath: "/cache"},
+							},
+						},
+					},
+				},
+			},
+		},
+
+		"Disallowed hostPaths with the strip policy should be removed with their volume mounts.": {
+			cfg: mutating.HostPathMutatorConfig{
+				DisallowedPrefixes: []string{"/var"},
+				Policy:             mutating.HostPathPolicyStrip,
+			},
+			pod: newPod(),
+			expPod: &corev1.Pod{
+				Spec: corev1.PodSpec{
+					Volumes: []corev1.Volume{
+						hostPathVolume("variable", "/variable"),
+						emptyDirVolume("cache"),
+					},
+					InitContainers: []corev1.Container{
+						{Name: "init"},
+					},
+					Containers: []corev1.Container{
+						{
+							Name: "app",
+							VolumeMounts: []corev1.VolumeMount{
+								{Name: "variable", MountPath: "/variable"},
+								{Name: "cache", MountPath: "/cache"},
+							},
+						},
+					},
+				},
+			},
+		},
+
+		"A pod without disallowed hostPaths should not be mutated.": {
+			cfg: mutating.HostPathMutatorConfig{
+				DisallowedPrefixes: []string{"/etc"},
+				Policy:             mutating.HostPathPolicyStrip,
+			},
+			pod:    newPod(),
+			expPod: newPod(),
+		},
+
+		"An unknown policy should fail.": {
+			cfg: mutating.HostPathMutatorConfig{
+				DisallowedPrefixes: []string{"/etc"},
+				Policy:             "wrong",
+			},
+			expErr: true,
+		},
+
+		"A relative disallowed prefix should fail.": {
+			cfg: mutating.HostPathMutatorConfig{
+				DisallowedPrefixes: []string{"etc"},
+			},
+			expErr: true,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+			require := require.New(t)
+
+			m, err := mutating.NewHostPathMutator(test.cfg)
+			if test.expErr {
+				assert.Error(err)
+				return
+			}
+			require.NoError(err)
+
+			_, err = m.Mutate(context.TODO(), test.pod)
+			require.NoError(err)
+
+			assert.Equal(test.expPod, test.pod)
+		})
+	}
+}
@@ -0,0 +1,41 @@
+package mutating
+
+import (
+	"context"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/slok/kubewebhook/pkg/webhook/internal/helpers"
+)
+
+// NewImagePullSecretsMutator returns a mutator that adds the image pull secrets required by the
+// registries used by the pods (or the pod templates of the workloads) containers. The
+// registrySecrets maps a registry host (e.g `registry.slok.dev`, `registry.slok.dev:5000`)
+// to the name of its image pull secret, already present secret references will not be duplicated.
+func NewImagePullSecretsMutator(registrySecrets map[string]string) Mutator {
+	return MutatorFunc(func(_ context.Context, obj metav1.Object) (bool, error) {
+		spec, ok := helpers.PodSpec(obj)
+		if !ok {
+			return false, nil
+		}
+
+		present := map[string]bool{}
+		for _, s := range spec.ImagePullSecrets {
+			present[s.Name] = true
+		}
+
+		containers := append(append([]corev1.Container{}, spec.InitContainers...), spec.Containers...)
+		for _, c := range containers {
+			secret, ok := registrySecrets[helpers.ImageRegistry(c.Image)]
+			if !ok || present[secret] {
+				continue
+			}
+
+			spec.ImagePullSecrets = append(spec.ImagePullSecrets, corev1.LocalObjectReference{Name: secret})
+			present[secret] = true
+		}
+
+		return false, nil
+	})
+}
@@ -0,0 +1,118 @@
+package mutating_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/slok/kubewebhook/pkg/webhook/mutating"
+)
+
+func TestImagePullSecretsMutator(t *testing.T) {
+	registrySecrets := map[string]string{
+		"registry.slok.dev":      "slok-registry",
+		"localhost:5000":         "local-registry",
+		"private.slok.dev:30000": "slok-registry",
+	}
+
+	tests := map[string]struct {
+		obj    metav1.Object
+		expObj metav1.Object
+	}{
+		"A pod using the private registry should set the image pull secret.": {
+			obj: &corev1.Pod{
+				Spec: corev1.PodSpec{
+					Containers: []corev1.Container{{Name: "app", Image: "registry.slok.dev/app:v1"}},
+				},
+			},
+			expObj: &corev1.Pod{
+				Spec: corev1.PodSpec{
+					Containers:       []corev1.Container{{Name: "app", Image: "registry.slok.dev/app:v1"}},
+					ImagePullSecrets: []corev1.LocalObjectReference{{Name: "slok-registry"}},
+				},
+			},
+		},
+
+		"A pod not using the private registries should not be mutated.": {
+			obj: &corev1.Pod{
+				Spec: corev1.PodSpec{
+					Containers: []corev1.Container{
+						{Name: "app", Image: "nginx:1.19"},
+						{Name: "sidecar", Image: "quay.io/slok/sidecar:v1"},
+					},
+				},
+			},
+			expObj: &corev1.Pod{
+				Spec: corev1.PodSpec{
+					Containers: []corev1.Container{
+						{Name: "app", Image: "nginx:1.19"},
+						{Name: "sidecar", Image: "quay.io/slok/sidecar:v1"},
+					},
+				},
+			},
+		},
+
+		"A pod using multiple private registries should set the image pull secrets without duplicates.": {
+			obj: &corev1.Pod{
+				Spec: corev1.PodSpec{
+					InitContainers: []corev1.Container{{Name: "init", Image: "localhost:5000/init:v1"}},
+					Containers: []corev1.Container{
+						{Name: "app", Image: "registry.slok.dev/app:v1"},
+						{Name: "sidecar", Image: "private.slok.dev:30000/sidecar:v1"},
+					},
+					ImagePullSecrets: []corev1.LocalObjectReference{{Name: "slok-registry"}},
+				},
+			},
+			expObj: &corev1.Pod{
+				Spec: corev1.PodSpec{
+					InitContainers: []corev1.Container{{Name: "init", Image: "localhost:5000/init:v1"}},
+					Containers: []corev1.Container{
+						{Name: "app", Image: "registry.slok.dev/app:v1"},
+						{Name: "sidecar", Image: "private.slok.dev:30000/sidecar:v1"},
+					},
+					ImagePullSecrets: []corev1.LocalObjectReference{{Name: "slok-registry"}, {Name: "local-registry"}},
+				},
+			},
+		},
+
+		"A deployment using the private registry should set the image pull secret on the pod template.": {
+			obj: &appsv1.Deployment{
+				Spec: appsv1.DeploymentSpec{
+					Template: corev1.PodTemplateSpec{
+						Spec: corev1.PodSpec{
+							Containers: []corev1.Container{{Name: "app", Image: "registry.slok.dev/app:v1"}},
+						},
+					},
+				},
+			},
+			expObj: &appsv1.Deployment{
+				Spec: appsv1.DeploymentSpec{
+					Template: corev1.PodTemplateSpec{
+						Spec: corev1.PodSpec{
+							Containers:       []corev1.Container{{Name: "app", Image: "registry.slok.dev/app:v1"}},
+							ImagePullSecrets: []corev1.LocalObjectReference{{Name: "slok-registry"}},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+			require := require.New(t)
+
+			m := mutating.NewImagePullSecretsMutator(registrySecrets)
+			_, err := m.Mutate(context.TODO(), test.obj)
+			require.NoError(err)
+
+			assert.Equal(test.expObj, test.obj)
+		})
+	}
+}
@@ -0,0 +1,45 @@
+package mutating
+
+import (
+	"context"
+	"strings"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// NewLabelValueNormalizerMutator returns a mutator that normalizes the values of the labels with
+// the label keys so they conform to the Kubernetes label value constraints instead of letting the
+// API server reject the object. The invalid characters are replaced with `-`, the value is truncated
+// to 63 characters and the not alphanumeric characters are removed from the edges.
+func NewLabelValueNormalizerMutator(labelKeys []string) Mutator {
+	return MutatorFunc(func(_ context.Context, obj metav1.Object) (bool, error) {
+		labels := obj.GetLabels()
+		if len(labels) == 0 {
+			return false, nil
+		}
+
+		for _, k := range labelKeys {
+			v, ok := labels[k]
+			if !ok {
+				continue
+			}
+			labels[k] = sanitizeLabelValue(v)
+		}
+		obj.SetLabels(labels)
+
+		return false, nil
+	})
+}
+
+// sanitizeLabelValue replaces the characters not allowed on label values with `-` and normalizes
+// the value.
+func sanitizeLabelValue(v string) string {
+	v = strings.Map(func(r rune) rune {
+		if r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '-' || r == '_' || r == '.' {
+			return r
+		}
+		return '-'
+	}, v)
+
+	return normalizeLabelValue(v)
+}
@@ -0,0 +1,66 @@
+package mutating_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/validation"
+
+	"github.com/slok/kubewebhook/pkg/webhook/mutating"
+)
+
+func TestLabelValueNormalizerMutator(t *testing.T) {
+	tests := map[string]struct {
+		labels    map[string]string
+		expLabels map[string]string
+	}{
+		"Valid label values should not be mutated.": {
+			labels:    map[string]string{"team": "platform_team-1.0"},
+			expLabels: map[string]string{"team": "platform_team-1.0"},
+		},
+
+		"Label values with invalid characters should replace them.": {
+			labels:    map[string]string{"team": "Platform Team/Core", "owner": "john@example.com"},
+			expLabels: map[string]string{"team": "Platform-Team-Core", "owner": "john-example.com"},
+		},
+
+		"Label values with invalid edge characters should remove them.": {
+			labels:    map[string]string{"team": " _platform. "},
+			expLabels: map[string]string{"team": "platform"},
+		},
+
+		"Over-length label values should be truncated.": {
+			labels:    map[string]string{"team": strings.Repeat("a", 62) + "-bbbbb"},
+			expLabels: map[string]string{"team": strings.Repeat("a", 62)},
+		},
+
+		"Not configured label keys should not be mutated.": {
+			labels:    map[string]string{"other": "Platform Team"},
+			expLabels: map[string]string{"other": "Platform Team"},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+			require := require.New(t)
+
+			pod := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Labels: test.labels}}
+			m := mutating.NewLabelValueNormalizerMutator([]string{"team", "owner"})
+			_, err := m.Mutate(context.TODO(), pod)
+			require.NoError(err)
+
+			assert.Equal(test.expLabels, pod.Labels)
+			for _, k := range []string{"team", "owner"} {
+				if v, ok := pod.Labels[k]; ok {
+					assert.Empty(validation.IsValidLabelValue(v))
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,104 @@
+package mutating
+
+import (
+	"context"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/slok/kubewebhook/pkg/webhook/internal/helpers"
+)
+
+// LoggingSidecarMutatorConfig is the configuration of the logging sidecar mutator.
+type LoggingSidecarMutatorConfig struct {
+	// Sidecar is the logging sidecar container (e.g FluentBit), the image is required. By
+	// default the container name is `logging`.
+	Sidecar corev1.Container
+	// LogDir is the directory where the application containers write the logs (e.g `/var/log/app`).
+	LogDir string
+	// SidecarLogDir is the directory where the sidecar reads the logs. By default the same as LogDir.
+	SidecarLogDir string
+	// VolumeName is the name of the shared emptyDir log volume. By default `logs`.
+	VolumeName string
+	// Containers are the names of the application containers that will have the log volume mounted,
+	// if empty all the containers will have it mounted.
+	Containers []string
+}
+
+func (c *LoggingSidecarMutatorConfig) defaults() error {
+	if c.Sidecar.Image == "" {
+		return fmt.Errorf("sidecar image is required")
+	}
+
+	if c.LogDir == "" {
+		return fmt.Errorf("log dir is required")
+	}
+
+	if c.Sidecar.Name == "" {
+		c.Sidecar.Name = "logging"
+	}
+
+	if c.SidecarLogDir == "" {
+		c.SidecarLogDir = c.LogDir
+	}
+
+	if c.VolumeName == "" {
+		c.VolumeName = "logs"
+	}
+
+	return nil
+}
+
+// NewLoggingSidecarMutator returns a mutator that injects a logging sidecar on the pods (or the pod
+// templates of the workloads) and a shared emptyDir volume mounted on the log directory of the
+// application containers and the sidecar (read-only), this way the sidecar can ship the logs
+// written by the application.
+//
+// The mutator is idempotent, if the pod already has the sidecar it will not be injected again, but
+// the log volume and mounts will be ensured.
+func NewLoggingSidecarMutator(cfg LoggingSidecarMutatorConfig) (Mutator, error) {
+	if err := cfg.defaults(); err != nil {
+		return nil, fmt.Errorf("invalid configuration: %w", err)
+	}
+
+	volume := corev1.Volume{
+		Name:         cfg.VolumeName,
+		VolumeSource: corev1.VolumeSource{EmptyDir: &corev1.EmptyDirVolumeSource{}},
+	}
+	appMount := corev1.VolumeMount{Name: cfg.VolumeName, MountPath: cfg.LogDir}
+	sidecarMount := corev1.VolumeMount{Name: cfg.VolumeName, MountPath: cfg.SidecarLogDir, ReadOnly: true}
+
+	return MutatorFunc(func(_ context.Context, obj metav1.Object) (bool, error) {
+		spec, ok := helpers.PodSpec(obj)
+		if !ok {
+			return false, nil
+		}
+
+		hasSidecar := false
+		for i := range spec.Containers {
+			c := &spec.Containers[i]
+			if c.Name == cfg.Sidecar.Name {
+				hasSidecar = true
+				c.VolumeMounts = setVolumeMount(c.VolumeMounts, sidecarMount)
+				continue
+			}
+
+			if containerSelected(c.Name, cfg.Containers) {
+				c.VolumeMounts = setVolumeMount(c.VolumeMounts, appMount)
+			}
+		}
+
+		if !hasSidecar {
+			sidecar := cfg.Sidecar.DeepCopy()
+			sidecar.VolumeMounts = setVolumeMount(sidecar.VolumeMounts, sidecarMount)
+			spec.Containers = append(spec.Containers, *sidecar)
+		}
+
+		if !hasVolume(spec.Volumes, cfg.VolumeName) {
+			spec.Volumes = append(spec.Volumes, *volume.DeepCopy())
+		}
+
+		return false, nil
+	}), nil
+}
@@ -0,0 +1,136 @@
+package mutating_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+
+	"github.com/slok/kubewebhook/pkg/webhook/mutating"
+)
+
+func TestLoggingSidecarMutator(t *testing.T) {
+	expVolume := corev1.Volume{Name: "logs", VolumeSource: corev1.VolumeSource{EmptyDir: &corev1.EmptyDirVolumeSource{}}}
+	expAppMount := corev1.VolumeMount{Name: "logs", MountPath: "/var/log/app"}
+	expSidecar := corev1.Container{
+		Name:         "logging",
+		Image:        "fluent/fluent-bit:1.6",
+		VolumeMounts: []corev1.VolumeMount{{Name: "logs", MountPath: "/var/log/app", ReadOnly: true}},
+	}
+
+	tests := map[string]struct {
+		cfg    mutating.LoggingSidecarMutatorConfig
+		pod    *corev1.Pod
+		expPod *corev1.Pod
+		expErr bool
+	}{
+		"A pod without the sidecar should have the sidecar and the shared log volume injected.": {
+			cfg: mutating.LoggingSidecarMutatorConfig{
+				Sidecar: corev1.Container{Image: "fluent/fluent-bit:1.6"},
+				LogDir:  "/var/log/app",
+			},
+			pod: &corev1.Pod{
+				Spec: corev1.PodSpec{
+					Containers: []corev1.Container{{Name: "app"}, {Name: "worker"}},
+				},
+			},
+			expPod: &corev1.Pod{
+				Spec: corev1.PodSpec{
+					Volumes: []corev1.Volume{expVolume},
+					Containers: []corev1.Container{
+						{Name: "app", VolumeMounts: []corev1.VolumeMount{expAppMount}},
+						{Name: "worker", VolumeMounts: []corev1.VolumeMount{expAppMount}},
+						expSidecar,
+					},
+				},
+			},
+		},
+
+		"A pod with the sidecar already injected should not be injected again.": {
+			cfg: mutating.LoggingSidecarMutatorConfig{
+				Sidecar: corev1.Container{Image: "fluent/fluent-bit:1.6"},
+				LogDir:  "/var/log/app",
+			},
+			pod: &corev1.Pod{
+				Spec: corev1.PodSpec{
+					Volumes: []corev1.Volume{expVolume},
+					Containers: []corev1.Container{
+						{Name: "app", VolumeMounts: []corev1.VolumeMount{expAppMount}},
+						{Name: "logging", Image: "fluent/fluent-bit:1.5", VolumeMounts: []corev1.VolumeMount{{Name: "logs", MountPath: "/var/log/app", ReadOnly: true}}},
+					},
+				},
+			},
+			expPod: &corev1.Pod{
+				Spec: corev1.PodSpec{
+					Volumes: []corev1.Volume{expVolume},
+					Containers: []corev1.Container{
+						{Name: "app", VolumeMounts: []corev1.VolumeMount{expAppMount}},
+						{Name: "logging", Image: "fluent/fluent-bit:1.5", VolumeMounts: []corev1.VolumeMount{{Name: "logs", MountPath: "/var/log/app", ReadOnly: true}}},
+					},
+				},
+			},
+		},
+
+		"Only the selected app containers should have the log volume mounted.": {
+			cfg: mutating.LoggingSidecarMutatorConfig{
+				Sidecar:       corev1.Container{Name: "fluent-bit", Image: "fluent/fluent-bit:1.6"},
+				LogDir:        "/var/log/app",
+				SidecarLogDir: "/logs",
+				VolumeName:    "app-logs",
+				Containers:    []string{"app"},
+			},
+			pod: &corev1.Pod{
+				Spec: corev1.PodSpec{
+					Containers: []corev1.Container{{Name: "app"}, {Name: "proxy"}},
+				},
+			},
+			expPod: &corev1.Pod{
+				Spec: corev1.PodSpec{
+					Volumes: []corev1.Volume{
+						{Name: "app-logs", VolumeSource: corev1.VolumeSource{EmptyDir: &corev1.EmptyDirVolumeSource{}}},
+					},
+					Containers: []corev1.Container{
+						{Name: "app", VolumeMounts: []corev1.VolumeMount{{Name: "app-logs", MountPath: "/var/log/app"}}},
+						{Name: "proxy"},
+						{
+							Name:         "fluent-bit",
+							Image:        "fluent/fluent-bit:1.6",
+							VolumeMounts: []corev1.VolumeMount{{Name: "app-logs", MountPath: "/logs", ReadOnly: true}},
+						},
+					},
+				},
+			},
+		},
+
+		"A configuration without sidecar image should fail.": {
+			cfg:    mutating.LoggingSidecarMutatorConfig{LogDir: "/var/log/app"},
+			expErr: true,
+		},
+
+		"A configuration without log dir should fail.": {
+			cfg:    mutating.LoggingSidecarMutatorConfig{Sidecar: corev1.Container{Image: "fluent/fluent-bit:1.6"}},
+			expErr: true,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+			require := require.New(t)
+
+			m, err := mutating.NewLoggingSidecarMutator(test.cfg)
+			if test.expErr {
+				assert.Error(err)
+				return
+			}
+			require.NoError(err)
+
+			_, err = m.Mutate(context.TODO(), test.pod)
+			require.NoError(err)
+
+			assert.Equal(test.expPod, test.pod)
+		})
+	}
+}
@@ -0,0 +1,74 @@
+package mutating
+
+import (
+	"context"
+	"fmt"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/slok/kubewebhook/pkg/observability/metrics"
+)
+
+// MaxReplicasMutatorConfig is the configuration of the max replicas mutator.
+type MaxReplicasMutatorConfig struct {
+	// MaxReplicas is the maximum number of replicas a workload can have.
+	MaxReplicas int32
+	// MetricsRecorder is the metrics recorder used to record the clamped replicas.
+	MetricsRecorder metrics.Recorder
+}
+
+func (c *MaxReplicasMutatorConfig) defaults() error {
+	if c.MaxReplicas < 0 {
+		return fmt.Errorf("max replicas can't be negative")
+	}
+
+	if c.MetricsRecorder == nil {
+		c.MetricsRecorder = metrics.Dummy
+	}
+
+	return nil
+}
+
+// NewMaxReplicasMutator returns a mutator that clamps the replicas of the workloads (Deployments,
+// StatefulSets, ReplicaSets and ReplicationControllers) to a maximum. The workloads without replicas
+// (defaulted by Kubernetes) or under the maximum will not be mutated.
+func NewMaxReplicasMutator(cfg MaxReplicasMutatorConfig) (Mutator, error) {
+	if err := cfg.defaults(); err != nil {
+		return nil, fmt.Errorf("invalid configuration: %w", err)
+	}
+
+	return MutatorFunc(func(_ context.Context, obj metav1.Object) (bool, error) {
+		replicas, kind, ok := workloadReplicas(obj)
+		if !ok || *replicas == nil {
+			return false, nil
+		}
+
+		if **replicas > cfg.MaxReplicas {
+			max := cfg.MaxReplicas
+			*replicas = &max
+			if rec, ok := cfg.MetricsRecorder.(metrics.ReplicasClampedRecorder); ok {
+				rec.IncReplicasClamped(obj.GetNamespace(), kind)
+			}
+		}
+
+		return false, nil
+	}), nil
+}
+
+// workloadReplicas returns the replicas field and the kind of the workload.
+func workloadReplicas(obj metav1.Object) (replicas **int32, kind string, ok bool) {
+	switch o := obj.(type) {
+	case *appsv1.Deployment:
+		return &o.Spec.Replicas, "Deployment", true
+	case *appsv1.StatefulSet:
+		return &o.Spec.Replicas, "StatefulSet", true
+	case *appsv1.ReplicaSet:
+		return &o.Spec.Replicas, "ReplicaSet", true
+	case *corev1.ReplicationController:
+		return &o.Spec.Replicas, "ReplicationController", true
+	}
+
+	return nil, "", false
+}
@@ -0,0 +1,91 @@
+package mutating_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	appsv1 "k8s.io/api/apps/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	mmetrics "github.com/slok/kubewebhook/mocks/observability/metrics"
+	"github.com/slok/kubewebhook/pkg/webhook/mutating"
+)
+
+func TestMaxReplicasMutator(t *testing.T) {
+	int32Ptr := func(i int32) *int32 { return &i }
+
+	tests := map[string]struct {
+		obj    metav1.Object
+		mock   func(m *mmetrics.Recorder)
+		expObj metav1.Object
+	}{
+		"A deployment over the max replicas should clamp the replicas.": {
+			obj: &appsv1.Deployment{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "test"},
+				Spec:       appsv1.DeploymentSpec{Replicas: int32Ptr(50)},
+			},
+			mock: func(m *mmetrics.Recorder) {
+				m.On("IncReplicasClamped", "test", "Deployment").Once()
+			},
+			expObj: &appsv1.Deployment{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "test"},
+				Spec:       appsv1.DeploymentSpec{Replicas: int32Ptr(10)},
+			},
+		},
+
+		"A statefulset over the max replicas should clamp the replicas.": {
+			obj: &appsv1.StatefulSet{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "test"},
+				Spec:       appsv1.StatefulSetSpec{Replicas: int32Ptr(11)},
+			},
+			mock: func(m *mmetrics.Recorder) {
+				m.On("IncReplicasClamped", "test", "StatefulSet").Once()
+			},
+			expObj: &appsv1.StatefulSet{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "test"},
+				Spec:       appsv1.StatefulSetSpec{Replicas: int32Ptr(10)},
+			},
+		},
+
+		"A deployment under the max replicas should not be mutated.": {
+			obj: &appsv1.Deployment{
+				Spec: appsv1.DeploymentSpec{Replicas: int32Ptr(10)},
+			},
+			mock: func(m *mmetrics.Recorder) {},
+			expObj: &appsv1.Deployment{
+				Spec: appsv1.DeploymentSpec{Replicas: int32Ptr(10)},
+			},
+		},
+
+		"A deployment without replicas should not be mutated.": {
+			obj:    &appsv1.Deployment{},
+			mock:   func(m *mmetrics.Recorder) {},
+			expObj: &appsv1.Deployment{},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+			require := require.New(t)
+
+			// Mocks.
+			mm := &mmetrics.Recorder{}
+			test.mock(mm)
+
+			m, err := mutating.NewMaxReplicasMutator(mutating.MaxReplicasMutatorConfig{
+				MaxReplicas:     10,
+				MetricsRecorder: mm,
+			})
+			require.NoError(err)
+
+			_, err = m.Mutate(context.TODO(), test.obj)
+			require.NoError(err)
+
+			assert.Equal(test.expObj, test.obj)
+			mm.AssertExpectations(t)
+		})
+	}
+}
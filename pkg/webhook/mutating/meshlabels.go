@@ -0,0 +1,50 @@
+package mutating
+
+import (
+	"context"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/slok/kubewebhook/pkg/webhook/internal/helpers"
+)
+
+// MeshLabelsMutatorConfig is the configuration of the service mesh labels mutator.
+type MeshLabelsMutatorConfig struct {
+	// Labels are the labels required by the service mesh (e.g `sidecar.istio.io/inject: "true"`).
+	Labels map[string]string
+	// Annotations are the annotations required by the service mesh (e.g `linkerd.io/inject: enabled`).
+	Annotations map[string]string
+}
+
+// NewMeshLabelsMutator returns a mutator that ensures the pods (or the pod templates of the
+// workloads) have the labels and annotations required by the service mesh, adding the missing
+// ones. The labels and annotations already present on the pod will never be overridden, so the
+// workloads can opt out explicitly (e.g `sidecar.istio.io/inject: "false"`).
+func NewMeshLabelsMutator(cfg MeshLabelsMutatorConfig) Mutator {
+	return MutatorFunc(func(_ context.Context, obj metav1.Object) (bool, error) {
+		meta, _, ok := helpers.PodTemplate(obj)
+		if !ok {
+			return false, nil
+		}
+
+		meta.Labels = setMissing(meta.Labels, cfg.Labels)
+		meta.Annotations = setMissing(meta.Annotations, cfg.Annotations)
+
+		return false, nil
+	})
+}
+
+// setMissing sets the values of the keys that are missing on dst, returning the resulting map.
+func setMissing(dst, values map[string]string) map[string]string {
+	for k, v := range values {
+		if _, ok := dst[k]; ok {
+			continue
+		}
+		if dst == nil {
+			dst = map[string]string{}
+		}
+		dst[k] = v
+	}
+
+	return dst
+}
@@ -0,0 +1,100 @@
+package mutating_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/slok/kubewebhook/pkg/webhook/mutating"
+)
+
+func TestMeshLabelsMutator(t *testing.T) {
+	cfg := mutating.MeshLabelsMutatorConfig{
+		Labels:      map[string]string{"sidecar.istio.io/inject": "true", "app.kubernetes.io/part-of": "mesh"},
+		Annotations: map[string]string{"proxy.istio.io/config": "{}"},
+	}
+
+	tests := map[string]struct {
+		obj    metav1.Object
+		expObj metav1.Object
+	}{
+		"A pod missing all the mesh labels should have all of them set.": {
+			obj: &corev1.Pod{},
+			expObj: &corev1.Pod{
+				ObjectMeta: metav1.ObjectMeta{
+					Labels:      map[string]string{"sidecar.istio.io/inject": "true", "app.kubernetes.io/part-of": "mesh"},
+					Annotations: map[string]string{"proxy.istio.io/config": "{}"},
+				},
+			},
+		},
+
+		"A pod missing some of the mesh labels should have the missing ones set.": {
+			obj: &corev1.Pod{
+				ObjectMeta: metav1.ObjectMeta{
+					Labels: map[string]string{"app": "test", "sidecar.istio.io/inject": "false"},
+				},
+			},
+			expObj: &corev1.Pod{
+				ObjectMeta: metav1.ObjectMeta{
+					Labels:      map[string]string{"app": "test", "sidecar.istio.io/inject": "false", "app.kubernetes.io/part-of": "mesh"},
+					Annotations: map[string]string{"proxy.istio.io/config": "{}"},
+				},
+			},
+		},
+
+		"A pod with all the mesh labels should not be mutated.": {
+			obj: &corev1.Pod{
+				ObjectMeta: metav1.ObjectMeta{
+					Labels:      map[string]string{"sidecar.istio.io/inject": "true", "app.kubernetes.io/part-of": "other"},
+					Annotations: map[string]string{"proxy.istio.io/config": `{"holdApplicationUntilProxyStarts": true}`},
+				},
+			},
+			expObj: &corev1.Pod{
+				ObjectMeta: metav1.ObjectMeta{
+					Labels:      map[string]string{"sidecar.istio.io/inject": "true", "app.kubernetes.io/part-of": "other"},
+					Annotations: map[string]string{"proxy.istio.io/config": `{"holdApplicationUntilProxyStarts": true}`},
+				},
+			},
+		},
+
+		"A deployment should have the mesh labels set on the pod template.": {
+			obj: &appsv1.Deployment{
+				ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{"app": "test"}},
+			},
+			expObj: &appsv1.Deployment{
+				ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{"app": "test"}},
+				Spec: appsv1.DeploymentSpec{
+					Template: corev1.PodTemplateSpec{
+						ObjectMeta: metav1.ObjectMeta{
+							Labels:      map[string]string{"sidecar.istio.io/inject": "true", "app.kubernetes.io/part-of": "mesh"},
+							Annotations: map[string]string{"proxy.istio.io/config": "{}"},
+						},
+					},
+				},
+			},
+		},
+
+		"A non pod object should not be mutated.": {
+			obj:    &corev1.Service{},
+			expObj: &corev1.Service{},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+			require := require.New(t)
+
+			m := mutating.NewMeshLabelsMutator(cfg)
+			_, err := m.Mutate(context.TODO(), test.obj)
+			require.NoError(err)
+
+			assert.Equal(test.expObj, test.obj)
+		})
+	}
+}
@@ -1,12 +1,17 @@
 package mutating
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
 	"fmt"
+	"reflect"
 
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
 
 	"github.com/slok/kubewebhook/pkg/log"
+	"github.com/slok/kubewebhook/pkg/observability/metrics"
 )
 
 // Mutator knows how to mutate the received kubernetes object.
@@ -25,6 +30,29 @@ func (f MutatorFunc) Mutate(ctx context.Context, obj metav1.Object) (bool, error
 	return f(ctx, obj)
 }
 
+// ChainMutator is a mutator with options about how it should be executed
+// by a Chain. It satisfies Mutator interface.
+type ChainMutator struct {
+	// Name is the name of the mutator used on logs and metrics.
+	Name string
+	// Mutator is the mutator.
+	Mutator Mutator
+	// ContinueOnError will make the chain skip the mutator if it returns an error
+	// and continue with the next ones instead of aborting the chain. Useful for optional
+	// mutators (e.g enrichments). The mutator is executed on a copy of the object, so a
+	// skipped mutator doesn't leave the object partially mutated.
+	ContinueOnError bool
+	// WebhookName is the name of the webhook using the mutator, used on the metrics.
+	WebhookName string
+	// MetricsRecorder is the recorder used to measure the skipped errors (also as fail-open).
+	MetricsRecorder metrics.Recorder
+}
+
+// Mutate satisfies Mutator interface.
+func (c ChainMutator) Mutate(ctx context.Context, obj metav1.Object) (bool, error) {
+	return c.Mutator.Mutate(ctx, obj)
+}
+
 // Chain is a chain of mutators that will execute secuentially all the
 // mutators that have been added to it. It satisfies Mutator interface.
 type Chain struct {
@@ -47,7 +75,26 @@ func (c *Chain) Mutate(ctx context.Context, obj metav1.Object) (bool, error) {
 		case <-ctx.Done():
 			return false, fmt.Errorf("mutator chain not finished correctly, context ended")
 		default:
-			stop, err := mt.Mutate(ctx, obj)
+			cm, ok := mt.(ChainMutator)
+			if ok && cm.ContinueOnError {
+				stop, err := c.mutateCopy(ctx, cm, obj)
+				if err != nil {
+					c.logger.Warningf("optional mutator %q failed, skipping: %s", cm.Name, err)
+					if rec, ok := cm.MetricsRecorder.(metrics.MutatorErrorSkippedRecorder); ok {
+						rec.IncMutatorErrorSkipped(cm.WebhookName, cm.Name)
+					}
+					if rec, ok := cm.MetricsRecorder.(metrics.FailOpenRecorder); ok {
+						rec.IncWebhookFailOpen(cm.WebhookName, metrics.FailOpenReasonMutatorError)
+					}
+					continue
+				}
+				if stop {
+					return true, nil
+				}
+				continue
+			}
+
+			stop, err := c.mutate(ctx, mt, obj)
 			if stop || err != nil {
 				return true, err
 			}
@@ -57,3 +104,51 @@ func (c *Chain) Mutate(ctx context.Context, obj metav1.Object) (bool, error) {
 	// Return false if used a chain of chains.
 	return false, nil
 }
+
+// mutate executes the mutator, if the context is tracking the applied mutators and the mutator
+// is a named ChainMutator, it will track the mutator if it modified the object.
+func (c *Chain) mutate(ctx context.Context, mt Mutator, obj metav1.Object) (bool, error) {
+	am := getAppliedMutators(ctx)
+	cm, ok := mt.(ChainMutator)
+	if am == nil || !ok || cm.Name == "" {
+		return mt.Mutate(ctx, obj)
+	}
+
+	before, err := json.Marshal(obj)
+	if err != nil {
+		return mt.Mutate(ctx, obj)
+	}
+
+	stop, err := mt.Mutate(ctx, obj)
+	if after, merr := json.Marshal(obj); err == nil && merr == nil && !bytes.Equal(before, after) {
+		am.names = append(am.names, cm.Name)
+	}
+
+	return stop, err
+}
+
+// mutateCopy executes the mutator on a copy of the object and only sets the mutated copy on the
+// object if the mutator succeeds, this way a failed mutator doesn't leave the object partially mutated.
+func (c *Chain) mutateCopy(ctx context.Context, mt Mutator, obj metav1.Object) (bool, error) {
+	robj, ok := obj.(runtime.Object)
+	if !ok {
+		return false, fmt.Errorf("object is not a runtime object, it can't be copied")
+	}
+	objCopy, ok := robj.DeepCopyObject().(metav1.Object)
+	if !ok {
+		return false, fmt.Errorf("object copy is not a metav1 object")
+	}
+
+	stop, err := c.mutate(ctx, mt, objCopy)
+	if err != nil {
+		return stop, err
+	}
+
+	dst, src := reflect.ValueOf(obj), reflect.ValueOf(objCopy)
+	if dst.Kind() != reflect.Ptr || dst.Type() != src.Type() {
+		return false, fmt.Errorf("object copy can't be set on the object")
+	}
+	dst.Elem().Set(src.Elem())
+
+	return stop, nil
+}
@@ -7,9 +7,14 @@ import (
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
+	mmetrics "github.com/slok/kubewebhook/mocks/observability/metrics"
 	mmutating "github.com/slok/kubewebhook/mocks/webhook/mutating"
 	"github.com/slok/kubewebhook/pkg/log"
+	"github.com/slok/kubewebhook/pkg/observability/metrics"
 	"github.com/slok/kubewebhook/pkg/webhook/mutating"
 )
 
@@ -75,3 +80,93 @@ func TestMutatorChain(t *testing.T) {
 		})
 	}
 }
+
+func TestMutatorChainContinueOnError(t *testing.T) {
+	failingMutator := mutating.MutatorFunc(func(_ context.Context, obj metav1.Object) (bool, error) {
+		return false, fmt.Errorf("wanted error")
+	})
+	labelMutator := mutating.MutatorFunc(func(_ context.Context, obj metav1.Object) (bool, error) {
+		obj.SetLabels(map[string]string{"mutated": "true"})
+		return false, nil
+	})
+	partialMutator := mutating.MutatorFunc(func(_ context.Context, obj metav1.Object) (bool, error) {
+		obj.SetLabels(map[string]string{"partial": "true"})
+		return false, fmt.Errorf("wanted error")
+	})
+
+	tests := map[string]struct {
+		mutators  func(rec *mmetrics.Recorder) []mutating.Mutator
+		mock      func(rec *mmetrics.Recorder)
+		expLabels map[string]string
+		expErr    bool
+	}{
+		"A failing optional mutator should be skipped and the required mutators should be applied.": {
+			mutators: func(rec *mmetrics.Recorder) []mutating.Mutator {
+				return []mutating.Mutator{
+					mutating.ChainMutator{Name: "enrichment", Mutator: failingMutator, ContinueOnError: true, WebhookName: "test", MetricsRecorder: rec},
+					mutating.ChainMutator{Name: "labels", Mutator: labelMutator},
+				}
+			},
+			mock: func(rec *mmetrics.Recorder) {
+				rec.On("IncMutatorErrorSkipped", "test", "enrichment").Once().Return()
+				rec.On("IncWebhookFailOpen", "test", metrics.FailOpenReasonMutatorError).Once().Return()
+			},
+			expLabels: map[string]string{"mutated": "true"},
+		},
+
+		"A failing optional mutator should not leave the object partially mutated.": {
+			mutators: func(rec *mmetrics.Recorder) []mutating.Mutator {
+				return []mutating.Mutator{
+					mutating.ChainMutator{Name: "enrichment", Mutator: partialMutator, ContinueOnError: true, WebhookName: "test", MetricsRecorder: rec},
+				}
+			},
+			mock: func(rec *mmetrics.Recorder) {
+				rec.On("IncMutatorErrorSkipped", "test", "enrichment").Once().Return()
+				rec.On("IncWebhookFailOpen", "test", metrics.FailOpenReasonMutatorError).Once().Return()
+			},
+		},
+
+		"A successful optional mutator should mutate the object.": {
+			mutators: func(rec *mmetrics.Recorder) []mutating.Mutator {
+				return []mutating.Mutator{
+					mutating.ChainMutator{Name: "labels", Mutator: labelMutator, ContinueOnError: true, WebhookName: "test", MetricsRecorder: rec},
+				}
+			},
+			mock:      func(rec *mmetrics.Recorder) {},
+			expLabels: map[string]string{"mutated": "true"},
+		},
+
+		"A failing required mutator should abort the chain.": {
+			mutators: func(rec *mmetrics.Recorder) []mutating.Mutator {
+				return []mutating.Mutator{
+					mutating.ChainMutator{Name: "critical", Mutator: failingMutator},
+					mutating.ChainMutator{Name: "labels", Mutator: labelMutator},
+				}
+			},
+			mock:   func(rec *mmetrics.Recorder) {},
+			expErr: true,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+			require := require.New(t)
+
+			rec := &mmetrics.Recorder{}
+			test.mock(rec)
+
+			pod := &corev1.Pod{}
+			chain := mutating.NewChain(log.Dummy, test.mutators(rec)...)
+			_, err := chain.Mutate(context.TODO(), pod)
+
+			if test.expErr {
+				assert.Error(err)
+			} else {
+				require.NoError(err)
+			}
+			assert.Equal(test.expLabels, pod.Labels)
+			rec.AssertExpectations(t)
+		})
+	}
+}
@@ -0,0 +1,76 @@
+package mutating
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	whcontext "github.com/slok/kubewebhook/pkg/webhook/context"
+)
+
+// NamePrefixMutatorConfig is the configuration of the name prefix mutator.
+type NamePrefixMutatorConfig struct {
+	// UserPrefixes are the prefixes (e.g the tenant identifier) by the username that
+	// made the request. These have priority over the namespace prefixes.
+	UserPrefixes map[string]string
+	// NamespacePrefixes are the prefixes (e.g the tenant identifier) by the namespace of the object.
+	NamespacePrefixes map[string]string
+	// Separator is the separator added after the prefix, by default `-`.
+	Separator string
+}
+
+func (c *NamePrefixMutatorConfig) defaults() error {
+	if c.Separator == "" {
+		c.Separator = "-"
+	}
+
+	if len(c.UserPrefixes) == 0 && len(c.NamespacePrefixes) == 0 {
+		return fmt.Errorf("user or namespace prefixes are required")
+	}
+
+	return nil
+}
+
+// NewNamePrefixMutator returns a mutator that prepends the prefix of the requesting user or the object
+// namespace to the `generateName` of the objects that don't have an explicit name, this way the
+// objects created by the tenants have consistent names. The generated names still have the random
+// suffix added by the API server.
+//
+// The objects with an explicit name are not mutated, renaming them would break the clients that
+// reference them by name. The `generateName` that already has the prefix is not prefixed again.
+func NewNamePrefixMutator(cfg NamePrefixMutatorConfig) (Mutator, error) {
+	if err := cfg.defaults(); err != nil {
+		return nil, fmt.Errorf("invalid configuration: %w", err)
+	}
+
+	return MutatorFunc(func(ctx context.Context, obj metav1.Object) (bool, error) {
+		if obj.GetName() != "" || obj.GetGenerateName() == "" {
+			return false, nil
+		}
+
+		prefix, ok := "", false
+		if ui, uok := whcontext.GetUserInfo(ctx); uok {
+			prefix, ok = cfg.UserPrefixes[ui.Username]
+		}
+		if !ok {
+			ns := obj.GetNamespace()
+			if ar := whcontext.GetAdmissionRequest(ctx); ns == "" && ar != nil {
+				ns = ar.Namespace
+			}
+			prefix, ok = cfg.NamespacePrefixes[ns]
+		}
+		if !ok || prefix == "" {
+			return false, nil
+		}
+
+		prefix = prefix + cfg.Separator
+		if strings.HasPrefix(obj.GetGenerateName(), prefix) {
+			return false, nil
+		}
+		obj.SetGenerateName(prefix + obj.GetGenerateName())
+
+		return false, nil
+	}), nil
+}
@@ -0,0 +1,109 @@
+package mutating_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	admissionv1beta1 "k8s.io/api/admission/v1beta1"
+	authenticationv1 "k8s.io/api/authentication/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	whcontext "github.com/slok/kubewebhook/pkg/webhook/context"
+	"github.com/slok/kubewebhook/pkg/webhook/mutating"
+)
+
+func TestNamePrefixMutator(t *testing.T) {
+	cfg := mutating.NamePrefixMutatorConfig{
+		UserPrefixes:      map[string]string{"alice": "tenant-x"},
+		NamespacePrefixes: map[string]string{"team-a": "tenant-a"},
+	}
+
+	tests := map[string]struct {
+		cfg     mutating.NamePrefixMutatorConfig
+		ar      *admissionv1beta1.AdmissionRequest
+		obj     metav1.Object
+		expMeta metav1.ObjectMeta
+		expErr  bool
+	}{
+		"An object with generateName should be prefixed with the namespace prefix.": {
+			cfg:     cfg,
+			ar:      &admissionv1beta1.AdmissionRequest{Namespace: "team-a"},
+			obj:     &corev1.Pod{ObjectMeta: metav1.ObjectMeta{GenerateName: "job-"}},
+			expMeta: metav1.ObjectMeta{GenerateName: "tenant-a-job-"},
+		},
+
+		"An object with generateName should be prefixed with the user prefix before the namespace prefix.": {
+			cfg: cfg,
+			ar: &admissionv1beta1.AdmissionRequest{
+				Namespace: "team-a",
+				UserInfo:  authenticationv1.UserInfo{Username: "alice"},
+			},
+			obj:     &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Namespace: "team-a", GenerateName: "job-"}},
+			expMeta: metav1.ObjectMeta{Namespace: "team-a", GenerateName: "tenant-x-job-"},
+		},
+
+		"An object with an already prefixed generateName should not be prefixed again.": {
+			cfg:     cfg,
+			ar:      &admissionv1beta1.AdmissionRequest{Namespace: "team-a"},
+			obj:     &corev1.Pod{ObjectMeta: metav1.ObjectMeta{GenerateName: "tenant-a-job-"}},
+			expMeta: metav1.ObjectMeta{GenerateName: "tenant-a-job-"},
+		},
+
+		"An object with an explicit name should not be mutated.": {
+			cfg:     cfg,
+			ar:      &admissionv1beta1.AdmissionRequest{Namespace: "team-a"},
+			obj:     &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "job-1", GenerateName: "job-"}},
+			expMeta: metav1.ObjectMeta{Name: "job-1", GenerateName: "job-"},
+		},
+
+		"An object without prefix for the user and the namespace should not be mutated.": {
+			cfg:     cfg,
+			ar:      &admissionv1beta1.AdmissionRequest{Namespace: "team-b"},
+			obj:     &corev1.Pod{ObjectMeta: metav1.ObjectMeta{GenerateName: "job-"}},
+			expMeta: metav1.ObjectMeta{GenerateName: "job-"},
+		},
+
+		"A custom separator should be used after the prefix.": {
+			cfg: mutating.NamePrefixMutatorConfig{
+				NamespacePrefixes: map[string]string{"team-a": "tenant-a"},
+				Separator:         ".",
+			},
+			ar:      &admissionv1beta1.AdmissionRequest{Namespace: "team-a"},
+			obj:     &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{GenerateName: "cfg-"}},
+			expMeta: metav1.ObjectMeta{GenerateName: "tenant-a.cfg-"},
+		},
+
+		"A configuration without prefixes should fail.": {
+			cfg:    mutating.NamePrefixMutatorConfig{},
+			expErr: true,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+			require := require.New(t)
+
+			m, err := mutating.NewNamePrefixMutator(test.cfg)
+			if test.expErr {
+				assert.Error(err)
+				return
+			}
+			require.NoError(err)
+
+			ctx := whcontext.SetAdmissionRequest(context.TODO(), test.ar)
+			_, err = m.Mutate(ctx, test.obj)
+			require.NoError(err)
+
+			gotMeta := metav1.ObjectMeta{
+				Name:         test.obj.GetName(),
+				Namespace:    test.obj.GetNamespace(),
+				GenerateName: test.obj.GetGenerateName(),
+			}
+			assert.Equal(test.expMeta, gotMeta)
+		})
+	}
+}
@@ -0,0 +1,63 @@
+package mutating
+
+import (
+	"context"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	corev1listers "k8s.io/client-go/listers/core/v1"
+
+	whcontext "github.com/slok/kubewebhook/pkg/webhook/context"
+)
+
+// NamespaceLabelsMutatorConfig is the configuration of the namespace labels mutator.
+type NamespaceLabelsMutatorConfig struct {
+	// Lister is the Namespace lister used to get the pod namespace. If missing
+	// the mutation will be skipped.
+	Lister corev1listers.NamespaceLister
+	// LabelKeys are the namespace label keys that will be copied to the pods.
+	LabelKeys []string
+}
+
+// NewNamespaceLabelsMutator returns a mutator that copies the configured labels of the pod
+// namespace onto the pods (e.g `cost-center` for billing), the namespace labels have
+// precedence over the pod ones. The webhooks don't receive the namespace object, so a
+// namespace lister is required, if the namespace is not found the pod will not be mutated.
+func NewNamespaceLabelsMutator(cfg NamespaceLabelsMutatorConfig) Mutator {
+	return MutatorFunc(func(ctx context.Context, obj metav1.Object) (bool, error) {
+		pod, ok := obj.(*corev1.Pod)
+		if !ok || cfg.Lister == nil {
+			return false, nil
+		}
+
+		// The pods created by controllers don't have the namespace set on the object.
+		nsName := pod.Namespace
+		if ar := whcontext.GetAdmissionRequest(ctx); nsName == "" && ar != nil {
+			nsName = ar.Namespace
+		}
+
+		ns, err := cfg.Lister.Get(nsName)
+		if err != nil {
+			if kerrors.IsNotFound(err) {
+				return false, nil
+			}
+			return true, fmt.Errorf("could not get %q namespace: %w", nsName, err)
+		}
+
+		for _, k := range cfg.LabelKeys {
+			v, ok := ns.Labels[k]
+			if !ok {
+				continue
+			}
+
+			if pod.Labels == nil {
+				pod.Labels = map[string]string{}
+			}
+			pod.Labels[k] = v
+		}
+
+		return false, nil
+	})
+}
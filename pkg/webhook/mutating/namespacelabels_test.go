@@ -0,0 +1,96 @@
+package mutating_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	admissionv1beta1 "k8s.io/api/admission/v1beta1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	corev1listers "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/tools/cache"
+
+	whcontext "github.com/slok/kubewebhook/pkg/webhook/context"
+	"github.com/slok/kubewebhook/pkg/webhook/mutating"
+)
+
+func newNamespaceLister(nss ...*corev1.Namespace) corev1listers.NamespaceLister {
+	indexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})
+	for _, ns := range nss {
+		_ = indexer.Add(ns)
+	}
+	return corev1listers.NewNamespaceLister(indexer)
+}
+
+func TestNamespaceLabelsMutator(t *testing.T) {
+	lister := newNamespaceLister(&corev1.Namespace{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:   "team-a",
+			Labels: map[string]string{"cost-center": "cc-1234", "team": "a", "other": "value"},
+		},
+	})
+
+	tests := map[string]struct {
+		lister       corev1listers.NamespaceLister
+		reqNamespace string
+		pod          *corev1.Pod
+		expPod       *corev1.Pod
+	}{
+		"A pod on a namespace with the labels should copy the configured labels.": {
+			lister: lister,
+			pod: &corev1.Pod{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "team-a", Labels: map[string]string{"app": "test", "team": "b"}},
+			},
+			expPod: &corev1.Pod{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "team-a", Labels: map[string]string{"app": "test", "team": "a", "cost-center": "cc-1234"}},
+			},
+		},
+
+		"A pod without namespace should use the admission request namespace.": {
+			lister:       lister,
+			reqNamespace: "team-a",
+			pod:          &corev1.Pod{},
+			expPod: &corev1.Pod{
+				ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{"team": "a", "cost-center": "cc-1234"}},
+			},
+		},
+
+		"A pod on a missing namespace should not be mutated.": {
+			lister: lister,
+			pod: &corev1.Pod{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "team-b"},
+			},
+			expPod: &corev1.Pod{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "team-b"},
+			},
+		},
+
+		"Without lister the pod should not be mutated.": {
+			pod: &corev1.Pod{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "team-a"},
+			},
+			expPod: &corev1.Pod{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "team-a"},
+			},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+			require := require.New(t)
+
+			m := mutating.NewNamespaceLabelsMutator(mutating.NamespaceLabelsMutatorConfig{
+				Lister:    test.lister,
+				LabelKeys: []string{"cost-center", "team", "missing"},
+			})
+			ctx := whcontext.SetAdmissionRequest(context.TODO(), &admissionv1beta1.AdmissionRequest{Namespace: test.reqNamespace})
+			_, err := m.Mutate(ctx, test.pod)
+			require.NoError(err)
+
+			assert.Equal(test.expPod, test.pod)
+		})
+	}
+}
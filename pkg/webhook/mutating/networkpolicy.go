@@ -0,0 +1,62 @@
+package mutating
+
+import (
+	"context"
+	"fmt"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/slok/kubewebhook/pkg/webhook/internal/helpers"
+)
+
+// NetworkPolicyAnnotationMutatorConfig is the configuration of the network policy annotation mutator.
+type NetworkPolicyAnnotationMutatorConfig struct {
+	// Key is the annotation key (e.g `network-policy`).
+	Key string
+	// Value is the annotation value (e.g `default-deny`).
+	Value string
+	// PodTemplate will set the annotation on the pod template of the workloads instead of
+	// the workload itself, the pods will have the annotation set on the object itself.
+	PodTemplate bool
+}
+
+func (c *NetworkPolicyAnnotationMutatorConfig) defaults() error {
+	if c.Key == "" {
+		return fmt.Errorf("annotation key is required")
+	}
+
+	return nil
+}
+
+// NewNetworkPolicyAnnotationMutator returns a mutator that sets the default network policy annotation
+// on the objects that don't have it, this way the new workloads opt in to the default network
+// policies (e.g default-deny on a zero-trust setup). The annotation already present on the objects
+// will never be overridden.
+func NewNetworkPolicyAnnotationMutator(cfg NetworkPolicyAnnotationMutatorConfig) (Mutator, error) {
+	if err := cfg.defaults(); err != nil {
+		return nil, fmt.Errorf("invalid configuration: %w", err)
+	}
+
+	return MutatorFunc(func(_ context.Context, obj metav1.Object) (bool, error) {
+		target := obj
+		if cfg.PodTemplate {
+			meta, _, ok := helpers.PodTemplate(obj)
+			if ok {
+				target = meta
+			}
+		}
+
+		annotations := target.GetAnnotations()
+		if _, ok := annotations[cfg.Key]; ok {
+			return false, nil
+		}
+
+		if annotations == nil {
+			annotations = map[string]string{}
+		}
+		annotations[cfg.Key] = cfg.Value
+		target.SetAnnotations(annotations)
+
+		return false, nil
+	}), nil
+}
@@ -0,0 +1,81 @@
+package mutating_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/slok/kubewebhook/pkg/webhook/mutating"
+)
+
+func TestNetworkPolicyAnnotationMutator(t *testing.T) {
+	tests := map[string]struct {
+		cfg    mutating.NetworkPolicyAnnotationMutatorConfig
+		obj    metav1.Object
+		expObj metav1.Object
+		expErr bool
+	}{
+		"A missing annotation key should fail.": {
+			cfg:    mutating.NetworkPolicyAnnotationMutatorConfig{Value: "default-deny"},
+			expErr: true,
+		},
+
+		"A bare pod without the annotation should have the annotation.": {
+			cfg:    mutating.NetworkPolicyAnnotationMutatorConfig{Key: "network-policy", Value: "default-deny", PodTemplate: true},
+			obj:    &corev1.Pod{},
+			expObj: &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Annotations: map[string]string{"network-policy": "default-deny"}}},
+		},
+
+		"A bare pod with the annotation should not be mutated.": {
+			cfg:    mutating.NetworkPolicyAnnotationMutatorConfig{Key: "network-policy", Value: "default-deny"},
+			obj:    &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Annotations: map[string]string{"network-policy": "allow-all"}}},
+			expObj: &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Annotations: map[string]string{"network-policy": "allow-all"}}},
+		},
+
+		"A workload targeting the object should have the annotation on the workload.": {
+			cfg: mutating.NetworkPolicyAnnotationMutatorConfig{Key: "network-policy", Value: "default-deny"},
+			obj: &appsv1.Deployment{},
+			expObj: &appsv1.Deployment{
+				ObjectMeta: metav1.ObjectMeta{Annotations: map[string]string{"network-policy": "default-deny"}},
+			},
+		},
+
+		"A workload targeting the pod template should have the annotation on the pod template.": {
+			cfg: mutating.NetworkPolicyAnnotationMutatorConfig{Key: "network-policy", Value: "default-deny", PodTemplate: true},
+			obj: &appsv1.Deployment{
+				ObjectMeta: metav1.ObjectMeta{Annotations: map[string]string{"other": "value"}},
+			},
+			expObj: &appsv1.Deployment{
+				ObjectMeta: metav1.ObjectMeta{Annotations: map[string]string{"other": "value"}},
+				Spec: appsv1.DeploymentSpec{
+					Template: corev1.PodTemplateSpec{
+						ObjectMeta: metav1.ObjectMeta{Annotations: map[string]string{"network-policy": "default-deny"}},
+					},
+				},
+			},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+			require := require.New(t)
+
+			m, err := mutating.NewNetworkPolicyAnnotationMutator(test.cfg)
+			if test.expErr {
+				assert.Error(err)
+				return
+			}
+			require.NoError(err)
+
+			_, err = m.Mutate(context.TODO(), test.obj)
+			require.NoError(err)
+			assert.Equal(test.expObj, test.obj)
+		})
+	}
+}
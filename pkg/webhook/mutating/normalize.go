@@ -0,0 +1,45 @@
+package mutating
+
+import (
+	"encoding/json"
+)
+
+// normalizeEmptyJSON returns the JSON data without the object members that have a null or an
+// empty array value, this way the JSON representations of the empty slices and the nil slices
+// are the same.
+func normalizeEmptyJSON(data []byte) ([]byte, error) {
+	var v interface{}
+	if err := json.Unmarshal(data, &v); err != nil {
+		return nil, err
+	}
+
+	return json.Marshal(removeEmptyJSONMembers(v))
+}
+
+func removeEmptyJSONMembers(v interface{}) interface{} {
+	switch tv := v.(type) {
+	case map[string]interface{}:
+		for k, mv := range tv {
+			if isEmptyJSONValue(mv) {
+				delete(tv, k)
+				continue
+			}
+			tv[k] = removeEmptyJSONMembers(mv)
+		}
+	case []interface{}:
+		for i, iv := range tv {
+			tv[i] = removeEmptyJSONMembers(iv)
+		}
+	}
+
+	return v
+}
+
+func isEmptyJSONValue(v interface{}) bool {
+	if v == nil {
+		return true
+	}
+
+	arr, ok := v.([]interface{})
+	return ok && len(arr) == 0
+}
@@ -0,0 +1,86 @@
+package mutating
+
+import (
+	"context"
+	"strings"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/validation"
+)
+
+// DefaultOwnerLabelKey is the default label key used by the owner label mutator.
+const DefaultOwnerLabelKey = "owner"
+
+// OwnerLabelMutatorConfig is the configuration of the owner label mutator.
+type OwnerLabelMutatorConfig struct {
+	// LabelKey is the label key that will be set with the owner workload name.
+	// By default `DefaultOwnerLabelKey`.
+	LabelKey string
+}
+
+func (c *OwnerLabelMutatorConfig) defaults() {
+	if c.LabelKey == "" {
+		c.LabelKey = DefaultOwnerLabelKey
+	}
+}
+
+// NewOwnerLabelMutator returns a mutator that sets a label on the pods with the name of the workload
+// that owns them. The owner is obtained from the controller owner reference, in case of pods owned
+// by a ReplicaSet, the Deployment name will be inferred using the `pod-template-hash` label.
+// Pods without owner will not be mutated.
+func NewOwnerLabelMutator(cfg OwnerLabelMutatorConfig) Mutator {
+	cfg.defaults()
+
+	return MutatorFunc(func(_ context.Context, obj metav1.Object) (bool, error) {
+		pod, ok := obj.(*corev1.Pod)
+		if !ok {
+			return false, nil
+		}
+
+		owner := podOwnerWorkloadName(pod)
+		if owner == "" {
+			return false, nil
+		}
+
+		if pod.Labels == nil {
+			pod.Labels = map[string]string{}
+		}
+		pod.Labels[cfg.LabelKey] = normalizeLabelValue(owner)
+
+		return false, nil
+	})
+}
+
+func podOwnerWorkloadName(pod *corev1.Pod) string {
+	ref := metav1.GetControllerOf(pod)
+	if ref == nil {
+		if len(pod.OwnerReferences) == 0 {
+			return ""
+		}
+		ref = &pod.OwnerReferences[0]
+	}
+
+	// ReplicaSets created by deployments are named `{deployment}-{pod-template-hash}`.
+	if ref.Kind == "ReplicaSet" {
+		hash := pod.Labels[appsv1.DefaultDeploymentUniqueLabelKey]
+		if hash != "" && strings.HasSuffix(ref.Name, "-"+hash) {
+			return strings.TrimSuffix(ref.Name, "-"+hash)
+		}
+	}
+
+	return ref.Name
+}
+
+// normalizeLabelValue truncates the value to the max label value length and removes
+// the not alphanumeric characters from the edges.
+func normalizeLabelValue(v string) string {
+	if len(v) > validation.LabelValueMaxLength {
+		v = v[:validation.LabelValueMaxLength]
+	}
+
+	return strings.TrimFunc(v, func(r rune) bool {
+		return !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9')
+	})
+}
@@ -0,0 +1,84 @@
+package mutating_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/slok/kubewebhook/pkg/webhook/mutating"
+)
+
+func TestOwnerLabelMutator(t *testing.T) {
+	boolPtr := func(b bool) *bool { return &b }
+
+	tests := map[string]struct {
+		cfg       mutating.OwnerLabelMutatorConfig
+		pod       *corev1.Pod
+		expLabels map[string]string
+	}{
+		"A standalone pod should not be mutated.": {
+			pod:       &corev1.Pod{},
+			expLabels: nil,
+		},
+
+		"A pod owned by a ReplicaSet of a deployment should have the deployment as the owner.": {
+			pod: &corev1.Pod{
+				ObjectMeta: metav1.ObjectMeta{
+					Labels: map[string]string{"pod-template-hash": "7d9f8c6b5"},
+					OwnerReferences: []metav1.OwnerReference{
+						{Kind: "ReplicaSet", Name: "my-app-7d9f8c6b5", Controller: boolPtr(true)},
+					},
+				},
+			},
+			expLabels: map[string]string{
+				"pod-template-hash": "7d9f8c6b5",
+				"owner":             "my-app",
+			},
+		},
+
+		"A pod owned by a standalone ReplicaSet should have the ReplicaSet as the owner.": {
+			cfg: mutating.OwnerLabelMutatorConfig{LabelKey: "app.kubernetes.io/owner"},
+			pod: &corev1.Pod{
+				ObjectMeta: metav1.ObjectMeta{
+					OwnerReferences: []metav1.OwnerReference{
+						{Kind: "ReplicaSet", Name: "my-rs", Controller: boolPtr(true)},
+					},
+				},
+			},
+			expLabels: map[string]string{
+				"app.kubernetes.io/owner": "my-rs",
+			},
+		},
+
+		"A pod with a long owner name should normalize the label value.": {
+			pod: &corev1.Pod{
+				ObjectMeta: metav1.ObjectMeta{
+					OwnerReferences: []metav1.OwnerReference{
+						{Kind: "StatefulSet", Name: strings.Repeat("a", 62) + "-b", Controller: boolPtr(true)},
+					},
+				},
+			},
+			expLabels: map[string]string{
+				"owner": strings.Repeat("a", 62),
+			},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+			require := require.New(t)
+
+			m := mutating.NewOwnerLabelMutator(test.cfg)
+			_, err := m.Mutate(context.TODO(), test.pod)
+			require.NoError(err)
+
+			assert.Equal(test.expLabels, test.pod.Labels)
+		})
+	}
+}
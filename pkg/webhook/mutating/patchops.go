@@ -0,0 +1,63 @@
+package mutating
+
+import (
+	"fmt"
+
+	"gomodules.xyz/jsonpatch/v3"
+)
+
+// DisallowedPatchOpPolicy is the policy applied to the JSON patch operations
+// that are not allowed by the webhook.
+type DisallowedPatchOpPolicy string
+
+const (
+	// DisallowedPatchOpPolicyError will make the webhook return an error if the mutation
+	// produces a not allowed operation.
+	DisallowedPatchOpPolicyError DisallowedPatchOpPolicy = "error"
+	// DisallowedPatchOpPolicyDropPatch will drop the whole patch, including the allowed operations,
+	// if the mutation produces a not allowed operation, the object is admitted without mutation.
+	// Dropping only the not allowed operations is not safe, the array index paths of the remaining
+	// operations could point to the wrong elements.
+	DisallowedPatchOpPolicyDropPatch DisallowedPatchOpPolicy = "drop-patch"
+)
+
+// validPatchOps are the JSON patch operations (RFC 6902).
+var validPatchOps = map[string]bool{
+	"add":     true,
+	"remove":  true,
+	"replace": true,
+	"move":    true,
+	"copy":    true,
+	"test":    true,
+}
+
+// filterPatchOps applies the policy to the operations that are not on the allowed operation list,
+// it returns the patch and the not allowed operations that made the policy drop the patch.
+func filterPatchOps(patch []jsonpatch.Operation, allowed []string, policy DisallowedPatchOpPolicy) ([]jsonpatch.Operation, []jsonpatch.Operation, error) {
+	if len(allowed) == 0 {
+		return patch, nil, nil
+	}
+
+	allowedOps := map[string]bool{}
+	for _, op := range allowed {
+		allowedOps[op] = true
+	}
+
+	disallowed := []jsonpatch.Operation{}
+	for _, op := range patch {
+		if allowedOps[op.Operation] {
+			continue
+		}
+
+		if policy != DisallowedPatchOpPolicyDropPatch {
+			return nil, nil, fmt.Errorf("%q patch operation on %q path is not allowed", op.Operation, op.Path)
+		}
+		disallowed = append(disallowed, op)
+	}
+
+	if len(disallowed) > 0 {
+		return []jsonpatch.Operation{}, disallowed, nil
+	}
+
+	return patch, nil, nil
+}
@@ -0,0 +1,33 @@
+package mutating
+
+import (
+	"context"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/slok/kubewebhook/pkg/webhook/internal/helpers"
+)
+
+// NewPreStopHookMutator returns a mutator that sets the handler as the `preStop` lifecycle hook of
+// the containers of the pods (or the pod templates of the workloads) that don't have a lifecycle,
+// this is useful for graceful shutdowns (e.g connection draining). The containers that define a
+// lifecycle (even with only a `postStart` hook) are not mutated.
+func NewPreStopHookMutator(handler corev1.Handler) Mutator {
+	return MutatorFunc(func(_ context.Context, obj metav1.Object) (bool, error) {
+		spec, ok := helpers.PodSpec(obj)
+		if !ok {
+			return false, nil
+		}
+
+		for i := range spec.Containers {
+			c := &spec.Containers[i]
+			if c.Lifecycle != nil {
+				continue
+			}
+			c.Lifecycle = &corev1.Lifecycle{PreStop: handler.DeepCopy()}
+		}
+
+		return false, nil
+	})
+}
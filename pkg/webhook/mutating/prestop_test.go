@@ -0,0 +1,109 @@
+package mutating_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/slok/kubewebhook/pkg/webhook/mutating"
+)
+
+func TestPreStopHookMutator(t *testing.T) {
+	sleepHandler := corev1.Handler{Exec: &corev1.ExecAction{Command: []string{"sleep", "5"}}}
+	customHandler := &corev1.Handler{Exec: &corev1.ExecAction{Command: []string{"/drain"}}}
+	postStartHandler := &corev1.Handler{Exec: &corev1.ExecAction{Command: []string{"/init"}}}
+
+	tests := map[string]struct {
+		obj    metav1.Object
+		expObj metav1.Object
+	}{
+		"Containers without lifecycle should have the preStop hook.": {
+			obj: &corev1.Pod{
+				Spec: corev1.PodSpec{
+					Containers: []corev1.Container{{Name: "app"}, {Name: "sidecar"}},
+				},
+			},
+			expObj: &corev1.Pod{
+				Spec: corev1.PodSpec{
+					Containers: []corev1.Container{
+						{Name: "app", Lifecycle: &corev1.Lifecycle{PreStop: &sleepHandler}},
+						{Name: "sidecar", Lifecycle: &corev1.Lifecycle{PreStop: &sleepHandler}},
+					},
+				},
+			},
+		},
+
+		"Containers with a preStop hook should not be mutated.": {
+			obj: &corev1.Pod{
+				Spec: corev1.PodSpec{
+					Containers: []corev1.Container{
+						{Name: "app", Lifecycle: &corev1.Lifecycle{PreStop: customHandler}},
+						{Name: "sidecar"},
+					},
+				},
+			},
+			expObj: &corev1.Pod{
+				Spec: corev1.PodSpec{
+					Containers: []corev1.Container{
+						{Name: "app", Lifecycle: &corev1.Lifecycle{PreStop: customHandler}},
+						{Name: "sidecar", Lifecycle: &corev1.Lifecycle{PreStop: &sleepHandler}},
+					},
+				},
+			},
+		},
+
+		"Containers with only a postStart hook should not be mutated.": {
+			obj: &corev1.Pod{
+				Spec: corev1.PodSpec{
+					Containers: []corev1.Container{
+						{Name: "app", Lifecycle: &corev1.Lifecycle{PostStart: postStartHandler}},
+					},
+				},
+			},
+			expObj: &corev1.Pod{
+				Spec: corev1.PodSpec{
+					Containers: []corev1.Container{
+						{Name: "app", Lifecycle: &corev1.Lifecycle{PostStart: postStartHandler}},
+					},
+				},
+			},
+		},
+
+		"Workload pod template containers without lifecycle should have the preStop hook.": {
+			obj: &appsv1.Deployment{
+				Spec: appsv1.DeploymentSpec{
+					Template: corev1.PodTemplateSpec{
+						Spec: corev1.PodSpec{Containers: []corev1.Container{{Name: "app"}}},
+					},
+				},
+			},
+			expObj: &appsv1.Deployment{
+				Spec: appsv1.DeploymentSpec{
+					Template: corev1.PodTemplateSpec{
+						Spec: corev1.PodSpec{Containers: []corev1.Container{
+							{Name: "app", Lifecycle: &corev1.Lifecycle{PreStop: &sleepHandler}},
+						}},
+					},
+				},
+			},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+			require := require.New(t)
+
+			m := mutating.NewPreStopHookMutator(sleepHandler)
+			_, err := m.Mutate(context.TODO(), test.obj)
+			require.NoError(err)
+
+			assert.Equal(test.expObj, test.obj)
+		})
+	}
+}
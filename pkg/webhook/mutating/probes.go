@@ -0,0 +1,67 @@
+package mutating
+
+import (
+	"context"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+
+	"github.com/slok/kubewebhook/pkg/webhook/internal/helpers"
+)
+
+// ProbeDefaultsMutatorConfig is the configuration of the probe defaults mutator.
+type ProbeDefaultsMutatorConfig struct {
+	// LivenessProbe is the liveness probe that will be set on the containers without
+	// liveness probe. If nil it will not be set.
+	LivenessProbe *corev1.Probe
+	// ReadinessProbe is the readiness probe that will be set on the containers without
+	// readiness probe. If nil it will not be set.
+	ReadinessProbe *corev1.Probe
+}
+
+// NewProbeDefaultsMutator returns a mutator that sets the default liveness and readiness probes on
+// the containers of the pods (or the pod templates of the workloads) that don't have them, the
+// containers probes are never overridden.
+//
+// The probes without handler (exec, HTTP get or TCP socket) will use a TCP check on the first port
+// of the container, if the container doesn't have ports, the probe will not be set.
+func NewProbeDefaultsMutator(cfg ProbeDefaultsMutatorConfig) Mutator {
+	return MutatorFunc(func(_ context.Context, obj metav1.Object) (bool, error) {
+		spec, ok := helpers.PodSpec(obj)
+		if !ok {
+			return false, nil
+		}
+
+		for i := range spec.Containers {
+			c := &spec.Containers[i]
+			if c.LivenessProbe == nil {
+				c.LivenessProbe = defaultContainerProbe(cfg.LivenessProbe, c)
+			}
+			if c.ReadinessProbe == nil {
+				c.ReadinessProbe = defaultContainerProbe(cfg.ReadinessProbe, c)
+			}
+		}
+
+		return false, nil
+	})
+}
+
+// defaultContainerProbe returns the default probe for the container, nil if it can't have one.
+func defaultContainerProbe(probe *corev1.Probe, c *corev1.Container) *corev1.Probe {
+	if probe == nil {
+		return nil
+	}
+
+	p := probe.DeepCopy()
+	if p.Exec != nil || p.HTTPGet != nil || p.TCPSocket != nil {
+		return p
+	}
+
+	if len(c.Ports) == 0 {
+		return nil
+	}
+	p.TCPSocket = &corev1.TCPSocketAction{Port: intstr.FromInt(int(c.Ports[0].ContainerPort))}
+
+	return p
+}
@@ -0,0 +1,128 @@
+package mutating_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+
+	"github.com/slok/kubewebhook/pkg/webhook/mutating"
+)
+
+func TestProbeDefaultsMutator(t *testing.T) {
+	httpProbe := &corev1.Probe{
+		Handler:       corev1.Handler{HTTPGet: &corev1.HTTPGetAction{Path: "/ready", Port: intstr.FromString("http")}},
+		PeriodSeconds: 5,
+	}
+	tcpProbe := func(port int) *corev1.Probe {
+		return &corev1.Probe{
+			Handler:       corev1.Handler{TCPSocket: &corev1.TCPSocketAction{Port: intstr.FromInt(port)}},
+			PeriodSeconds: 10,
+		}
+	}
+
+	tests := map[string]struct {
+		cfg    mutating.ProbeDefaultsMutatorConfig
+		obj    metav1.Object
+		expObj metav1.Object
+	}{
+		"Containers without probes should have the default probes on their first port.": {
+			cfg: mutating.ProbeDefaultsMutatorConfig{
+				LivenessProbe:  &corev1.Probe{PeriodSeconds: 10},
+				ReadinessProbe: httpProbe,
+			},
+			obj: &corev1.Pod{
+				Spec: corev1.PodSpec{
+					Containers: []corev1.Container{
+						{Name: "app", Ports: []corev1.ContainerPort{{ContainerPort: 8080}, {ContainerPort: 8081}}},
+						{Name: "sidecar", Ports: []corev1.ContainerPort{{ContainerPort: 9090}}},
+					},
+				},
+			},
+			expObj: &corev1.Pod{
+				Spec: corev1.PodSpec{
+					Containers: []corev1.Container{
+						{Name: "app", Ports: []corev1.ContainerPort{{ContainerPort: 8080}, {ContainerPort: 8081}}, LivenessProbe: tcpProbe(8080), ReadinessProbe: httpProbe},
+						{Name: "sidecar", Ports: []corev1.ContainerPort{{ContainerPort: 9090}}, LivenessProbe: tcpProbe(9090), ReadinessProbe: httpProbe},
+					},
+				},
+			},
+		},
+
+		"Containers with probes should not be mutated.": {
+			cfg: mutating.ProbeDefaultsMutatorConfig{
+				LivenessProbe:  &corev1.Probe{PeriodSeconds: 10},
+				ReadinessProbe: &corev1.Probe{PeriodSeconds: 10},
+			},
+			obj: &corev1.Pod{
+				Spec: corev1.PodSpec{
+					Containers: []corev1.Container{
+						{Name: "app", Ports: []corev1.ContainerPort{{ContainerPort: 8080}}, LivenessProbe: httpProbe, ReadinessProbe: httpProbe},
+					},
+				},
+			},
+			expObj: &corev1.Pod{
+				Spec: corev1.PodSpec{
+					Containers: []corev1.Container{
+						{Name: "app", Ports: []corev1.ContainerPort{{ContainerPort: 8080}}, LivenessProbe: httpProbe, ReadinessProbe: httpProbe},
+					},
+				},
+			},
+		},
+
+		"Containers with one of the probes should only set the missing probe.": {
+			cfg: mutating.ProbeDefaultsMutatorConfig{
+				LivenessProbe:  &corev1.Probe{PeriodSeconds: 10},
+				ReadinessProbe: &corev1.Probe{PeriodSeconds: 10},
+			},
+			obj: &corev1.Pod{
+				Spec: corev1.PodSpec{
+					Containers: []corev1.Container{
+						{Name: "app", Ports: []corev1.ContainerPort{{ContainerPort: 8080}}, ReadinessProbe: httpProbe},
+					},
+				},
+			},
+			expObj: &corev1.Pod{
+				Spec: corev1.PodSpec{
+					Containers: []corev1.Container{
+						{Name: "app", Ports: []corev1.ContainerPort{{ContainerPort: 8080}}, LivenessProbe: tcpProbe(8080), ReadinessProbe: httpProbe},
+					},
+				},
+			},
+		},
+
+		"Containers without ports should only set the probes with handler.": {
+			cfg: mutating.ProbeDefaultsMutatorConfig{
+				LivenessProbe:  &corev1.Probe{PeriodSeconds: 10},
+				ReadinessProbe: httpProbe,
+			},
+			obj: &corev1.Pod{
+				Spec: corev1.PodSpec{
+					Containers: []corev1.Container{{Name: "worker"}},
+				},
+			},
+			expObj: &corev1.Pod{
+				Spec: corev1.PodSpec{
+					Containers: []corev1.Container{{Name: "worker", ReadinessProbe: httpProbe}},
+				},
+			},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+			require := require.New(t)
+
+			m := mutating.NewProbeDefaultsMutator(test.cfg)
+			_, err := m.Mutate(context.TODO(), test.obj)
+			require.NoError(err)
+
+			assert.Equal(test.expObj, test.obj)
+		})
+	}
+}
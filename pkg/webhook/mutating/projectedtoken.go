@@ -0,0 +1,136 @@
+package mutating
+
+import (
+	"context"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/slok/kubewebhook/pkg/webhook/internal/helpers"
+)
+
+// ProjectedTokenMutatorConfig is the configuration of the projected service account token mutator.
+type ProjectedTokenMutatorConfig struct {
+	// VolumeName is the name of the projected volume. By default `projected-token`.
+	VolumeName string
+	// Audience is the audience of the token.
+	Audience string
+	// ExpirationSeconds is the requested duration of validity of the token. If nil
+	// Kubernetes default will be used.
+	ExpirationSeconds *int64
+	// TokenPath is the path of the token file inside the volume. By default `token`.
+	TokenPath string
+	// MountPath is the path where the volume will be mounted on the containers.
+	MountPath string
+	// Containers are the names of the containers where the volume will be mounted, if
+	// empty it will be mounted on all the containers.
+	Containers []string
+}
+
+func (c *ProjectedTokenMutatorConfig) defaults() error {
+	if c.Audience == "" {
+		return fmt.Errorf("audience is required")
+	}
+
+	if c.MountPath == "" {
+		return fmt.Errorf("mount path is required")
+	}
+
+	if c.VolumeName == "" {
+		c.VolumeName = "projected-token"
+	}
+
+	if c.TokenPath == "" {
+		c.TokenPath = "token"
+	}
+
+	return nil
+}
+
+// NewProjectedTokenMutator returns a mutator that injects a projected service account token volume
+// with an audience and mounts it on the selected containers. The mutator is idempotent, the volume
+// and the mounts will not be duplicated.
+func NewProjectedTokenMutator(cfg ProjectedTokenMutatorConfig) (Mutator, error) {
+	if err := cfg.defaults(); err != nil {
+		return nil, fmt.Errorf("invalid configuration: %w", err)
+	}
+
+	volume := corev1.Volume{
+		Name: cfg.VolumeName,
+		VolumeSource: corev1.VolumeSource{
+			Projected: &corev1.ProjectedVolumeSource{
+				Sources: []corev1.VolumeProjection{
+					{
+						ServiceAccountToken: &corev1.ServiceAccountTokenProjection{
+							Audience:          cfg.Audience,
+							ExpirationSeconds: cfg.ExpirationSeconds,
+							Path:              cfg.TokenPath,
+						},
+					},
+				},
+			},
+		},
+	}
+
+	mount := corev1.VolumeMount{
+		Name:      cfg.VolumeName,
+		MountPath: cfg.MountPath,
+		ReadOnly:  true,
+	}
+
+	return MutatorFunc(func(_ context.Context, obj metav1.Object) (bool, error) {
+		spec, ok := helpers.PodSpec(obj)
+		if !ok {
+			return false, nil
+		}
+
+		spec.Volumes = setVolume(spec.Volumes, *volume.DeepCopy())
+
+		for i, c := range spec.Containers {
+			if !containerSelected(c.Name, cfg.Containers) {
+				continue
+			}
+			spec.Containers[i].VolumeMounts = setVolumeMount(c.VolumeMounts, mount)
+		}
+
+		return false, nil
+	}), nil
+}
+
+// setVolume sets the volume, replacing the volume with the same name if already present.
+func setVolume(volumes []corev1.Volume, volume corev1.Volume) []corev1.Volume {
+	for i, v := range volumes {
+		if v.Name == volume.Name {
+			volumes[i] = volume
+			return volumes
+		}
+	}
+	return append(volumes, volume)
+}
+
+// setVolumeMount sets the volume mount, replacing the mount of the same volume if already present.
+func setVolumeMount(mounts []corev1.VolumeMount, mount corev1.VolumeMount) []corev1.VolumeMount {
+	for i, m := range mounts {
+		if m.Name == mount.Name {
+			mounts[i] = mount
+			return mounts
+		}
+	}
+	return append(mounts, mount)
+}
+
+// containerSelected returns true if the container is on the selected containers, if there
+// are no selected containers all the containers are selected.
+func containerSelected(name string, selected []string) bool {
+	if len(selected) == 0 {
+		return true
+	}
+
+	for _, s := range selected {
+		if s == name {
+			return true
+		}
+	}
+	return false
+}
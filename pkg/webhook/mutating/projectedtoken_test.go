@@ -0,0 +1,117 @@
+package mutating_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+
+	"github.com/slok/kubewebhook/pkg/webhook/mutating"
+)
+
+func TestProjectedTokenMutator(t *testing.T) {
+	int64Ptr := func(i int64) *int64 { return &i }
+	expVolume := corev1.Volume{
+		Name: "projected-token",
+		VolumeSource: corev1.VolumeSource{
+			Projected: &corev1.ProjectedVolumeSource{
+				Sources: []corev1.VolumeProjection{
+					{
+						ServiceAccountToken: &corev1.ServiceAccountTokenProjection{
+							Audience:          "vault",
+							ExpirationSeconds: int64Ptr(3600),
+							Path:              "token",
+						},
+					},
+				},
+			},
+		},
+	}
+	expMount := corev1.VolumeMount{Name: "projected-token", MountPath: "/var/run/secrets/vault", ReadOnly: true}
+
+	tests := map[string]struct {
+		containers []string
+		pod        *corev1.Pod
+		expPod     *corev1.Pod
+	}{
+		"A pod without the projected volume should inject the volume and mount it on all the containers.": {
+			pod: &corev1.Pod{
+				Spec: corev1.PodSpec{
+					Containers: []corev1.Container{{Name: "app"}, {Name: "sidecar"}},
+				},
+			},
+			expPod: &corev1.Pod{
+				Spec: corev1.PodSpec{
+					Volumes: []corev1.Volume{expVolume},
+					Containers: []corev1.Container{
+						{Name: "app", VolumeMounts: []corev1.VolumeMount{expMount}},
+						{Name: "sidecar", VolumeMounts: []corev1.VolumeMount{expMount}},
+					},
+				},
+			},
+		},
+
+		"A pod with the projected volume already injected should not duplicate the volume and mounts.": {
+			pod: &corev1.Pod{
+				Spec: corev1.PodSpec{
+					Volumes: []corev1.Volume{{Name: "data"}, expVolume},
+					Containers: []corev1.Container{
+						{Name: "app", VolumeMounts: []corev1.VolumeMount{expMount}},
+					},
+				},
+			},
+			expPod: &corev1.Pod{
+				Spec: corev1.PodSpec{
+					Volumes: []corev1.Volume{{Name: "data"}, expVolume},
+					Containers: []corev1.Container{
+						{Name: "app", VolumeMounts: []corev1.VolumeMount{expMount}},
+					},
+				},
+			},
+		},
+
+		"A pod with selected containers should only mount the volume on the selected containers.": {
+			containers: []string{"app"},
+			pod: &corev1.Pod{
+				Spec: corev1.PodSpec{
+					Containers: []corev1.Container{{Name: "app"}, {Name: "sidecar"}},
+				},
+			},
+			expPod: &corev1.Pod{
+				Spec: corev1.PodSpec{
+					Volumes: []corev1.Volume{expVolume},
+					Containers: []corev1.Container{
+						{Name: "app", VolumeMounts: []corev1.VolumeMount{expMount}},
+						{Name: "sidecar"},
+					},
+				},
+			},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+			require := require.New(t)
+
+			m, err := mutating.NewProjectedTokenMutator(mutating.ProjectedTokenMutatorConfig{
+				Audience:          "vault",
+				ExpirationSeconds: int64Ptr(3600),
+				MountPath:         "/var/run/secrets/vault",
+				Containers:        test.containers,
+			})
+			require.NoError(err)
+
+			_, err = m.Mutate(context.TODO(), test.pod)
+			require.NoError(err)
+			assert.Equal(test.expPod, test.pod)
+
+			// Reinjection should be idempotent.
+			_, err = m.Mutate(context.TODO(), test.pod)
+			require.NoError(err)
+			assert.Equal(test.expPod, test.pod)
+		})
+	}
+}
@@ -0,0 +1,106 @@
+package mutating
+
+import (
+	"context"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/slok/kubewebhook/pkg/webhook/internal/helpers"
+)
+
+// ReadOnlyRootFSMutatorConfig is the configuration of the read-only root filesystem mutator.
+type ReadOnlyRootFSMutatorConfig struct {
+	// TmpVolumeName is the name of the writable emptyDir volume. By default `tmp`.
+	TmpVolumeName string
+	// TmpMountPath is the path where the writable volume will be mounted. By default `/tmp`.
+	TmpMountPath string
+	// TmpSizeLimit is the size limit of the writable volume. If nil there will be no limit.
+	TmpSizeLimit *resource.Quantity
+	// Containers are the names of the containers that will be mutated, if empty all the
+	// containers will be mutated.
+	Containers []string
+}
+
+func (c *ReadOnlyRootFSMutatorConfig) defaults() {
+	if c.TmpVolumeName == "" {
+		c.TmpVolumeName = "tmp"
+	}
+
+	if c.TmpMountPath == "" {
+		c.TmpMountPath = "/tmp"
+	}
+}
+
+// NewReadOnlyRootFSMutator returns a mutator that sets `readOnlyRootFilesystem` on the security
+// context of the containers and injects a writable emptyDir volume mounted on the tmp path of the
+// containers, so the applications can still write temporary files.
+//
+// The mutator is idempotent, if the pod already has the volume it will not be replaced and the
+// containers that already have a mount on the tmp path will not be mounted again.
+func NewReadOnlyRootFSMutator(cfg ReadOnlyRootFSMutatorConfig) Mutator {
+	cfg.defaults()
+
+	volume := corev1.Volume{
+		Name: cfg.TmpVolumeName,
+		VolumeSource: corev1.VolumeSource{
+			EmptyDir: &corev1.EmptyDirVolumeSource{SizeLimit: cfg.TmpSizeLimit},
+		},
+	}
+
+	mount := corev1.VolumeMount{
+		Name:      cfg.TmpVolumeName,
+		MountPath: cfg.TmpMountPath,
+	}
+
+	return MutatorFunc(func(_ context.Context, obj metav1.Object) (bool, error) {
+		spec, ok := helpers.PodSpec(obj)
+		if !ok {
+			return false, nil
+		}
+
+		mutated := false
+		for i := range spec.Containers {
+			c := &spec.Containers[i]
+			if !containerSelected(c.Name, cfg.Containers) {
+				continue
+			}
+			mutated = true
+
+			if c.SecurityContext == nil {
+				c.SecurityContext = &corev1.SecurityContext{}
+			}
+			readOnly := true
+			c.SecurityContext.ReadOnlyRootFilesystem = &readOnly
+
+			if !hasMountPath(c.VolumeMounts, cfg.TmpMountPath) {
+				c.VolumeMounts = append(c.VolumeMounts, mount)
+			}
+		}
+
+		if mutated && !hasVolume(spec.Volumes, cfg.TmpVolumeName) {
+			spec.Volumes = append(spec.Volumes, *volume.DeepCopy())
+		}
+
+		return false, nil
+	})
+}
+
+func hasVolume(volumes []corev1.Volume, name string) bool {
+	for _, v := range volumes {
+		if v.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+func hasMountPath(mounts []corev1.VolumeMount, path string) bool {
+	for _, m := range mounts {
+		if m.MountPath == path {
+			return true
+		}
+	}
+	return false
+}
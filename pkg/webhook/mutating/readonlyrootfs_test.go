@@ -0,0 +1,108 @@
+package mutating_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+
+	"github.com/slok/kubewebhook/pkg/webhook/mutating"
+)
+
+func TestReadOnlyRootFSMutator(t *testing.T) {
+	boolPtr := func(b bool) *bool { return &b }
+	expVolume := corev1.Volume{
+		Name:         "tmp",
+		VolumeSource: corev1.VolumeSource{EmptyDir: &corev1.EmptyDirVolumeSource{}},
+	}
+	expMount := corev1.VolumeMount{Name: "tmp", MountPath: "/tmp"}
+	expSecCtx := &corev1.SecurityContext{ReadOnlyRootFilesystem: boolPtr(true)}
+
+	tests := map[string]struct {
+		containers []string
+		pod        *corev1.Pod
+		expPod     *corev1.Pod
+	}{
+		"A pod without the tmp volume should inject the volume and set the read-only root on all the containers.": {
+			pod: &corev1.Pod{
+				Spec: corev1.PodSpec{
+					Containers: []corev1.Container{
+						{Name: "app"},
+						{Name: "sidecar", SecurityContext: &corev1.SecurityContext{RunAsNonRoot: boolPtr(true)}},
+					},
+				},
+			},
+			expPod: &corev1.Pod{
+				Spec: corev1.PodSpec{
+					Volumes: []corev1.Volume{expVolume},
+					Containers: []corev1.Container{
+						{Name: "app", SecurityContext: expSecCtx, VolumeMounts: []corev1.VolumeMount{expMount}},
+						{Name: "sidecar", SecurityContext: &corev1.SecurityContext{RunAsNonRoot: boolPtr(true), ReadOnlyRootFilesystem: boolPtr(true)}, VolumeMounts: []corev1.VolumeMount{expMount}},
+					},
+				},
+			},
+		},
+
+		"A pod that already has the tmp volume and mounts should not duplicate them.": {
+			pod: &corev1.Pod{
+				Spec: corev1.PodSpec{
+					Volumes: []corev1.Volume{
+						{Name: "tmp", VolumeSource: corev1.VolumeSource{EmptyDir: &corev1.EmptyDirVolumeSource{Medium: corev1.StorageMediumMemory}}},
+					},
+					Containers: []corev1.Container{
+						{Name: "app", SecurityContext: expSecCtx, VolumeMounts: []corev1.VolumeMount{{Name: "tmp", MountPath: "/tmp"}}},
+						{Name: "sidecar", VolumeMounts: []corev1.VolumeMount{{Name: "other", MountPath: "/tmp"}}},
+					},
+				},
+			},
+			expPod: &corev1.Pod{
+				Spec: corev1.PodSpec{
+					Volumes: []corev1.Volume{
+						{Name: "tmp", VolumeSource: corev1.VolumeSource{EmptyDir: &corev1.EmptyDirVolumeSource{Medium: corev1.StorageMediumMemory}}},
+					},
+					Containers: []corev1.Container{
+						{Name: "app", SecurityContext: expSecCtx, VolumeMounts: []corev1.VolumeMount{{Name: "tmp", MountPath: "/tmp"}}},
+						{Name: "sidecar", SecurityContext: expSecCtx, VolumeMounts: []corev1.VolumeMount{{Name: "other", MountPath: "/tmp"}}},
+					},
+				},
+			},
+		},
+
+		"A pod with selected containers should only mutate the selected containers.": {
+			containers: []string{"app"},
+			pod: &corev1.Pod{
+				Spec: corev1.PodSpec{
+					Containers: []corev1.Container{{Name: "app"}, {Name: "sidecar"}},
+				},
+			},
+			expPod: &corev1.Pod{
+				Spec: corev1.PodSpec{
+					Volumes: []corev1.Volume{expVolume},
+					Containers: []corev1.Container{
+						{Name: "app", SecurityContext: expSecCtx, VolumeMounts: []corev1.VolumeMount{expMount}},
+						{Name: "sidecar"},
+					},
+				},
+			},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+			require := require.New(t)
+
+			m := mutating.NewReadOnlyRootFSMutator(mutating.ReadOnlyRootFSMutatorConfig{Containers: test.containers})
+			_, err := m.Mutate(context.TODO(), test.pod)
+			require.NoError(err)
+			assert.Equal(test.expPod, test.pod)
+
+			// Mutating again should be idempotent.
+			_, err = m.Mutate(context.TODO(), test.pod)
+			require.NoError(err)
+			assert.Equal(test.expPod, test.pod)
+		})
+	}
+}
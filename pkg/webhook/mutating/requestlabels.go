@@ -0,0 +1,57 @@
+package mutating
+
+import (
+	"context"
+	"fmt"
+
+	admissionv1beta1 "k8s.io/api/admission/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	whcontext "github.com/slok/kubewebhook/pkg/webhook/context"
+	"github.com/slok/kubewebhook/pkg/webhook/internal/helpers"
+)
+
+// RequestLabelsFunc returns the labels for an object based on its admission request (e.g
+// topology labels based on the namespace or the user of the request).
+type RequestLabelsFunc func(ctx context.Context, ar *admissionv1beta1.AdmissionRequest) (map[string]string, error)
+
+// NewRequestLabelsMutator returns a mutator that sets on the objects the labels computed by the
+// function from the admission request of the context. The workloads will also have the labels
+// set on their pod templates. The computed labels have precedence over the object labels.
+//
+// If the context doesn't have the admission request the object will not be mutated.
+func NewRequestLabelsMutator(labelsFunc RequestLabelsFunc) Mutator {
+	return MutatorFunc(func(ctx context.Context, obj metav1.Object) (bool, error) {
+		ar := whcontext.GetAdmissionRequest(ctx)
+		if ar == nil {
+			return false, nil
+		}
+
+		labels, err := labelsFunc(ctx, ar)
+		if err != nil {
+			return true, fmt.Errorf("could not get the request labels: %w", err)
+		}
+		if len(labels) == 0 {
+			return false, nil
+		}
+
+		// In case of pods the pod template metadata is the object metadata itself.
+		setLabels(obj, labels)
+		if meta, _, ok := helpers.PodTemplate(obj); ok {
+			setLabels(meta, labels)
+		}
+
+		return false, nil
+	})
+}
+
+func setLabels(obj metav1.Object, labels map[string]string) {
+	objLabels := obj.GetLabels()
+	if objLabels == nil {
+		objLabels = map[string]string{}
+	}
+	for k, v := range labels {
+		objLabels[k] = v
+	}
+	obj.SetLabels(objLabels)
+}
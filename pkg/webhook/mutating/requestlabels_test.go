@@ -0,0 +1,98 @@
+package mutating_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	admissionv1beta1 "k8s.io/api/admission/v1beta1"
+	appsv1 "k8s.io/api/apps/v1"
+	authenticationv1 "k8s.io/api/authentication/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	whcontext "github.com/slok/kubewebhook/pkg/webhook/context"
+	"github.com/slok/kubewebhook/pkg/webhook/mutating"
+)
+
+func TestRequestLabelsMutator(t *testing.T) {
+	zoneLabels := func(_ context.Context, ar *admissionv1beta1.AdmissionRequest) (map[string]string, error) {
+		return map[string]string{
+			"topology.slok.dev/zone": ar.Namespace + "-zone",
+			"created-by":             ar.UserInfo.Username,
+		}, nil
+	}
+
+	tests := map[string]struct {
+		labelsFunc mutating.RequestLabelsFunc
+		ar         *admissionv1beta1.AdmissionRequest
+		obj        metav1.Object
+		expObj     metav1.Object
+		expErr     bool
+	}{
+		"A pod should have the labels computed from the request.": {
+			labelsFunc: zoneLabels,
+			ar:         &admissionv1beta1.AdmissionRequest{Namespace: "eu-west", UserInfo: authenticationv1.UserInfo{Username: "slok"}},
+			obj: &corev1.Pod{
+				ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{"app": "test", "created-by": "someone"}},
+			},
+			expObj: &corev1.Pod{
+				ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{"app": "test", "created-by": "slok", "topology.slok.dev/zone": "eu-west-zone"}},
+			},
+		},
+
+		"A deployment should have the labels computed from the request on the object and the pod template.": {
+			labelsFunc: zoneLabels,
+			ar:         &admissionv1beta1.AdmissionRequest{Namespace: "eu-west", UserInfo: authenticationv1.UserInfo{Username: "slok"}},
+			obj:        &appsv1.Deployment{},
+			expObj: &appsv1.Deployment{
+				ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{"created-by": "slok", "topology.slok.dev/zone": "eu-west-zone"}},
+				Spec: appsv1.DeploymentSpec{
+					Template: corev1.PodTemplateSpec{
+						ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{"created-by": "slok", "topology.slok.dev/zone": "eu-west-zone"}},
+					},
+				},
+			},
+		},
+
+		"Without admission request the object should not be mutated.": {
+			labelsFunc: zoneLabels,
+			obj:        &corev1.Pod{},
+			expObj:     &corev1.Pod{},
+		},
+
+		"An error computing the labels should fail.": {
+			labelsFunc: func(_ context.Context, _ *admissionv1beta1.AdmissionRequest) (map[string]string, error) {
+				return nil, fmt.Errorf("wanted error")
+			},
+			ar:     &admissionv1beta1.AdmissionRequest{},
+			obj:    &corev1.Pod{},
+			expObj: &corev1.Pod{},
+			expErr: true,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+			require := require.New(t)
+
+			ctx := context.TODO()
+			if test.ar != nil {
+				ctx = whcontext.SetAdmissionRequest(ctx, test.ar)
+			}
+
+			m := mutating.NewRequestLabelsMutator(test.labelsFunc)
+			_, err := m.Mutate(ctx, test.obj)
+
+			if test.expErr {
+				assert.Error(err)
+			} else {
+				require.NoError(err)
+			}
+			assert.Equal(test.expObj, test.obj)
+		})
+	}
+}
@@ -0,0 +1,78 @@
+package mutating
+
+import (
+	"context"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/slok/kubewebhook/pkg/webhook/internal/helpers"
+)
+
+// RestartDefaultsMutatorConfig is the configuration of the restart defaults mutator.
+type RestartDefaultsMutatorConfig struct {
+	// RestartPolicy is the restart policy that will be set on the pods that don't
+	// have one. If empty it will not be set.
+	RestartPolicy corev1.RestartPolicy
+	// TerminationGracePeriodSeconds is the termination grace period that will be set on
+	// the pods that don't have one. If nil it will not be set.
+	TerminationGracePeriodSeconds *int64
+}
+
+func (c RestartDefaultsMutatorConfig) validate() error {
+	switch c.RestartPolicy {
+	case "", corev1.RestartPolicyAlways, corev1.RestartPolicyOnFailure, corev1.RestartPolicyNever:
+	default:
+		return fmt.Errorf("invalid configuration: invalid restart policy %q", c.RestartPolicy)
+	}
+
+	if c.TerminationGracePeriodSeconds != nil && *c.TerminationGracePeriodSeconds < 0 {
+		return fmt.Errorf("invalid configuration: termination grace period can't be negative")
+	}
+
+	return nil
+}
+
+// NewRestartDefaultsMutator returns a mutator that sets the default restart policy and termination grace
+// period on the pods (or the pod templates of the workloads) that don't have them set, explicit values
+// are never overridden.
+//
+// Some workloads constrain the allowed restart policies (e.g Deployments only allow `Always` and Jobs
+// only `OnFailure` or `Never`), if the configured restart policy is not allowed by the workload kind
+// it will not be set.
+//
+// The API server sets the default restart policy and termination grace period on the pods before
+// calling the mutating webhooks, so normally this mutator only takes effect on the workloads.
+func NewRestartDefaultsMutator(cfg RestartDefaultsMutatorConfig) (Mutator, error) {
+	if err := cfg.validate(); err != nil {
+		return nil, err
+	}
+
+	return MutatorFunc(func(_ context.Context, obj metav1.Object) (bool, error) {
+		spec, ok := helpers.PodSpec(obj)
+		if !ok {
+			return false, nil
+		}
+
+		if spec.RestartPolicy == "" && cfg.RestartPolicy != "" && isRestartPolicyAllowed(obj, cfg.RestartPolicy) {
+			spec.RestartPolicy = cfg.RestartPolicy
+		}
+
+		if spec.TerminationGracePeriodSeconds == nil && cfg.TerminationGracePeriodSeconds != nil {
+			tgp := *cfg.TerminationGracePeriodSeconds
+			spec.TerminationGracePeriodSeconds = &tgp
+		}
+
+		return false, nil
+	}), nil
+}
+
+func isRestartPolicyAllowed(obj metav1.Object, rp corev1.RestartPolicy) bool {
+	for _, allowed := range helpers.AllowedRestartPolicies(obj) {
+		if allowed == rp {
+			return true
+		}
+	}
+	return false
+}
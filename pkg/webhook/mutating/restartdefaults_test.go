@@ -0,0 +1,151 @@
+package mutating_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/slok/kubewebhook/pkg/webhook/mutating"
+)
+
+func TestRestartDefaultsMutator(t *testing.T) {
+	int64Ptr := func(i int64) *int64 { return &i }
+
+	tests := map[string]struct {
+		cfg    mutating.RestartDefaultsMutatorConfig
+		obj    metav1.Object
+		expObj metav1.Object
+		expErr bool
+	}{
+		"An invalid restart policy should fail.": {
+			cfg:    mutating.RestartDefaultsMutatorConfig{RestartPolicy: "Sometimes"},
+			expErr: true,
+		},
+
+		"A pod without restart policy and termination grace period should set the defaults.": {
+			cfg: mutating.RestartDefaultsMutatorConfig{
+				RestartPolicy:                 corev1.RestartPolicyOnFailure,
+				TerminationGracePeriodSeconds: int64Ptr(10),
+			},
+			obj: &corev1.Pod{},
+			expObj: &corev1.Pod{
+				Spec: corev1.PodSpec{
+					RestartPolicy:                 corev1.RestartPolicyOnFailure,
+					TerminationGracePeriodSeconds: int64Ptr(10),
+				},
+			},
+		},
+
+		"A pod with restart policy and termination grace period should not be mutated.": {
+			cfg: mutating.RestartDefaultsMutatorConfig{
+				RestartPolicy:                 corev1.RestartPolicyOnFailure,
+				TerminationGracePeriodSeconds: int64Ptr(10),
+			},
+			obj: &corev1.Pod{
+				Spec: corev1.PodSpec{
+					RestartPolicy:                 corev1.RestartPolicyNever,
+					TerminationGracePeriodSeconds: int64Ptr(0),
+				},
+			},
+			expObj: &corev1.Pod{
+				Spec: corev1.PodSpec{
+					RestartPolicy:                 corev1.RestartPolicyNever,
+					TerminationGracePeriodSeconds: int64Ptr(0),
+				},
+			},
+		},
+
+		"A job without restart policy and termination grace period should set the defaults.": {
+			cfg: mutating.RestartDefaultsMutatorConfig{
+				RestartPolicy:                 corev1.RestartPolicyNever,
+				TerminationGracePeriodSeconds: int64Ptr(30),
+			},
+			obj: &batchv1.Job{},
+			expObj: &batchv1.Job{
+				Spec: batchv1.JobSpec{
+					Template: corev1.PodTemplateSpec{
+						Spec: corev1.PodSpec{
+							RestartPolicy:                 corev1.RestartPolicyNever,
+							TerminationGracePeriodSeconds: int64Ptr(30),
+						},
+					},
+				},
+			},
+		},
+
+		"A job with restart policy and termination grace period should not be mutated.": {
+			cfg: mutating.RestartDefaultsMutatorConfig{
+				RestartPolicy:                 corev1.RestartPolicyNever,
+				TerminationGracePeriodSeconds: int64Ptr(30),
+			},
+			obj: &batchv1.Job{
+				Spec: batchv1.JobSpec{
+					Template: corev1.PodTemplateSpec{
+						Spec: corev1.PodSpec{
+							RestartPolicy:                 corev1.RestartPolicyOnFailure,
+							TerminationGracePeriodSeconds: int64Ptr(5),
+						},
+					},
+				},
+			},
+			expObj: &batchv1.Job{
+				Spec: batchv1.JobSpec{
+					Template: corev1.PodTemplateSpec{
+						Spec: corev1.PodSpec{
+							RestartPolicy:                 corev1.RestartPolicyOnFailure,
+							TerminationGracePeriodSeconds: int64Ptr(5),
+						},
+					},
+				},
+			},
+		},
+
+		"A job with a default restart policy not allowed by jobs should only set the termination grace period.": {
+			cfg: mutating.RestartDefaultsMutatorConfig{
+				RestartPolicy:                 corev1.RestartPolicyAlways,
+				TerminationGracePeriodSeconds: int64Ptr(30),
+			},
+			obj: &batchv1.Job{},
+			expObj: &batchv1.Job{
+				Spec: batchv1.JobSpec{
+					Template: corev1.PodTemplateSpec{
+						Spec: corev1.PodSpec{
+							TerminationGracePeriodSeconds: int64Ptr(30),
+						},
+					},
+				},
+			},
+		},
+
+		"A non pod object should not be mutated.": {
+			cfg: mutating.RestartDefaultsMutatorConfig{
+				RestartPolicy: corev1.RestartPolicyAlways,
+			},
+			obj:    &corev1.Service{},
+			expObj: &corev1.Service{},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+			require := require.New(t)
+
+			m, err := mutating.NewRestartDefaultsMutator(test.cfg)
+			if test.expErr {
+				assert.Error(err)
+				return
+			}
+			require.NoError(err)
+
+			_, err = m.Mutate(context.TODO(), test.obj)
+			require.NoError(err)
+			assert.Equal(test.expObj, test.obj)
+		})
+	}
+}
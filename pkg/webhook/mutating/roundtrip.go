@@ -0,0 +1,41 @@
+package mutating
+
+import (
+	"fmt"
+
+	jsonpatch "github.com/evanphx/json-patch"
+	"k8s.io/apimachinery/pkg/runtime"
+	clientsetscheme "k8s.io/client-go/kubernetes/scheme"
+
+	"github.com/slok/kubewebhook/pkg/webhook/internal/helpers"
+)
+
+// checkPatchRoundTrip applies the JSON patch to the raw object and decodes the result, returning an
+// error if the patched object is not a valid typed object (e.g a string on an integer field).
+// The result is decoded with the client-go scheme, the kinds not registered on it and the objects
+// without kind or version (e.g untyped raw objects) will only be checked if the webhook has a static
+// object type, using its object creator.
+func checkPatchRoundTrip(raw, patch []byte, staticCreator helpers.ObjectCreator) error {
+	p, err := jsonpatch.DecodePatch(patch)
+	if err != nil {
+		return fmt.Errorf("could not decode the patch: %w", err)
+	}
+
+	patched, err := p.Apply(raw)
+	if err != nil {
+		return fmt.Errorf("could not apply the patch: %w", err)
+	}
+
+	_, _, err = clientsetscheme.Codecs.UniversalDeserializer().Decode(patched, nil, nil)
+	switch {
+	case err == nil:
+		return nil
+	case !runtime.IsNotRegisteredError(err) && !runtime.IsMissingKind(err) && !runtime.IsMissingVersion(err):
+		return err
+	case staticCreator != nil:
+		_, err := staticCreator.NewObject(patched)
+		return err
+	}
+
+	return nil
+}
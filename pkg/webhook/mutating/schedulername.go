@@ -0,0 +1,64 @@
+package mutating
+
+import (
+	"context"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	whcontext "github.com/slok/kubewebhook/pkg/webhook/context"
+	"github.com/slok/kubewebhook/pkg/webhook/internal/helpers"
+)
+
+// SchedulerNameMutatorConfig is the configuration of the scheduler name mutator.
+type SchedulerNameMutatorConfig struct {
+	// SchedulerName is the scheduler name set on the pods whose namespace is not mapped
+	// by `NamespaceSchedulerNames`. If empty only the mapped namespaces will be mutated.
+	SchedulerName string
+	// NamespaceSchedulerNames are the scheduler names by the namespace of the pods.
+	NamespaceSchedulerNames map[string]string
+}
+
+func (c *SchedulerNameMutatorConfig) defaults() error {
+	if c.SchedulerName == "" && len(c.NamespaceSchedulerNames) == 0 {
+		return fmt.Errorf("scheduler name or namespace scheduler names are required")
+	}
+
+	return nil
+}
+
+// NewSchedulerNameMutator returns a mutator that sets the scheduler name of the pods (or the pod
+// templates of the workloads) that don't have one, using the namespace scheduler name or the
+// default one of the configuration. The explicit scheduler names will not be overridden.
+//
+// The API server sets the `default-scheduler` scheduler name on the pods before calling the
+// mutating webhooks, so the `default-scheduler` is handled as an unset scheduler name.
+func NewSchedulerNameMutator(cfg SchedulerNameMutatorConfig) (Mutator, error) {
+	if err := cfg.defaults(); err != nil {
+		return nil, fmt.Errorf("invalid configuration: %w", err)
+	}
+
+	return MutatorFunc(func(ctx context.Context, obj metav1.Object) (bool, error) {
+		spec, ok := helpers.PodSpec(obj)
+		if !ok || (spec.SchedulerName != "" && spec.SchedulerName != corev1.DefaultSchedulerName) {
+			return false, nil
+		}
+
+		ns := obj.GetNamespace()
+		if ar := whcontext.GetAdmissionRequest(ctx); ns == "" && ar != nil {
+			ns = ar.Namespace
+		}
+
+		name, ok := cfg.NamespaceSchedulerNames[ns]
+		if !ok {
+			name = cfg.SchedulerName
+		}
+		if name == "" {
+			return false, nil
+		}
+		spec.SchedulerName = name
+
+		return false, nil
+	}), nil
+}
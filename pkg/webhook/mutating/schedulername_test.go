@@ -0,0 +1,104 @@
+package mutating_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	admissionv1beta1 "k8s.io/api/admission/v1beta1"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	whcontext "github.com/slok/kubewebhook/pkg/webhook/context"
+	"github.com/slok/kubewebhook/pkg/webhook/mutating"
+)
+
+func TestSchedulerNameMutator(t *testing.T) {
+	tests := map[string]struct {
+		cfg            mutating.SchedulerNameMutatorConfig
+		reqNamespace   string
+		obj            metav1.Object
+		expObj         metav1.Object
+		expConfigError bool
+	}{
+		"A configuration without scheduler names should fail.": {
+			cfg:            mutating.SchedulerNameMutatorConfig{},
+			expConfigError: true,
+		},
+
+		"A pod without scheduler name should set the scheduler name.": {
+			cfg:    mutating.SchedulerNameMutatorConfig{SchedulerName: "custom"},
+			obj:    &corev1.Pod{},
+			expObj: &corev1.Pod{Spec: corev1.PodSpec{SchedulerName: "custom"}},
+		},
+
+		"A pod with the default scheduler name should set the scheduler name.": {
+			cfg:    mutating.SchedulerNameMutatorConfig{SchedulerName: "custom"},
+			obj:    &corev1.Pod{Spec: corev1.PodSpec{SchedulerName: corev1.DefaultSchedulerName}},
+			expObj: &corev1.Pod{Spec: corev1.PodSpec{SchedulerName: "custom"}},
+		},
+
+		"A pod with an explicit scheduler name should not be mutated.": {
+			cfg:    mutating.SchedulerNameMutatorConfig{SchedulerName: "custom"},
+			obj:    &corev1.Pod{Spec: corev1.PodSpec{SchedulerName: "other"}},
+			expObj: &corev1.Pod{Spec: corev1.PodSpec{SchedulerName: "other"}},
+		},
+
+		"A pod on a mapped namespace should set the namespace scheduler name.": {
+			cfg: mutating.SchedulerNameMutatorConfig{
+				SchedulerName:           "custom",
+				NamespaceSchedulerNames: map[string]string{"team-a": "team-a-scheduler"},
+			},
+			obj: &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Namespace: "team-a"}},
+			expObj: &corev1.Pod{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "team-a"},
+				Spec:       corev1.PodSpec{SchedulerName: "team-a-scheduler"},
+			},
+		},
+
+		"A pod on an unmapped namespace without default scheduler name should not be mutated.": {
+			cfg: mutating.SchedulerNameMutatorConfig{
+				NamespaceSchedulerNames: map[string]string{"team-a": "team-a-scheduler"},
+			},
+			obj:    &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Namespace: "team-b"}},
+			expObj: &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Namespace: "team-b"}},
+		},
+
+		"A deployment without namespace should use the admission request namespace.": {
+			cfg: mutating.SchedulerNameMutatorConfig{
+				NamespaceSchedulerNames: map[string]string{"team-a": "team-a-scheduler"},
+			},
+			reqNamespace: "team-a",
+			obj:          &appsv1.Deployment{},
+			expObj: &appsv1.Deployment{
+				Spec: appsv1.DeploymentSpec{
+					Template: corev1.PodTemplateSpec{
+						Spec: corev1.PodSpec{SchedulerName: "team-a-scheduler"},
+					},
+				},
+			},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+			require := require.New(t)
+
+			m, err := mutating.NewSchedulerNameMutator(test.cfg)
+			if test.expConfigError {
+				assert.Error(err)
+				return
+			}
+			require.NoError(err)
+
+			ctx := whcontext.SetAdmissionRequest(context.TODO(), &admissionv1beta1.AdmissionRequest{Namespace: test.reqNamespace})
+			_, err = m.Mutate(ctx, test.obj)
+			require.NoError(err)
+
+			assert.Equal(test.expObj, test.obj)
+		})
+	}
+}
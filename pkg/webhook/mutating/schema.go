@@ -0,0 +1,135 @@
+package mutating
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+)
+
+// Schema knows how to validate the JSON representation of an object.
+type Schema interface {
+	// Validate validates the object JSON, returns an error if the object is not valid.
+	Validate(objJSON []byte) error
+}
+
+// SchemaFunc is a helper type to create schemas from functions.
+type SchemaFunc func(objJSON []byte) error
+
+// Validate satisfies Schema interface.
+func (f SchemaFunc) Validate(objJSON []byte) error { return f(objJSON) }
+
+// Structural schema types.
+const (
+	SchemaTypeObject  = "object"
+	SchemaTypeArray   = "array"
+	SchemaTypeString  = "string"
+	SchemaTypeInteger = "integer"
+	SchemaTypeNumber  = "number"
+	SchemaTypeBoolean = "boolean"
+)
+
+// StructuralSchema is a minimal structural schema, the subset of OpenAPI v3 used by
+// the CRD structural schemas (types, properties, items and required fields). The
+// fields not declared on the properties are not validated.
+type StructuralSchema struct {
+	// Type is the type of the field, if empty any type will be valid.
+	Type string `json:"type,omitempty"`
+	// Properties are the schemas of the object fields.
+	Properties map[string]StructuralSchema `json:"properties,omitempty"`
+	// Items is the schema of the array items.
+	Items *StructuralSchema `json:"items,omitempty"`
+	// Required are the required fields of the object.
+	Required []string `json:"required,omitempty"`
+}
+
+// Validate satisfies Schema interface.
+func (s StructuralSchema) Validate(objJSON []byte) error {
+	var obj interface{}
+	if err := json.Unmarshal(objJSON, &obj); err != nil {
+		return fmt.Errorf("could not unmarshal object: %w", err)
+	}
+
+	return s.validate("", obj)
+}
+
+func (s StructuralSchema) validate(path string, v interface{}) error {
+	// Null values are valid on any type (removed fields).
+	if v == nil {
+		return nil
+	}
+
+	if err := s.validateType(path, v); err != nil {
+		return err
+	}
+
+	switch vv := v.(type) {
+	case map[string]interface{}:
+		for _, req := range s.Required {
+			if _, ok := vv[req]; !ok {
+				return fmt.Errorf("%s: required field is missing", schemaFieldPath(path, req))
+			}
+		}
+
+		// Validate in order so errors are deterministic.
+		keys := make([]string, 0, len(s.Properties))
+		for k := range s.Properties {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			fv, ok := vv[k]
+			if !ok {
+				continue
+			}
+			if err := s.Properties[k].validate(schemaFieldPath(path, k), fv); err != nil {
+				return err
+			}
+		}
+	case []interface{}:
+		if s.Items == nil {
+			return nil
+		}
+		for i, item := range vv {
+			if err := s.Items.validate(fmt.Sprintf("%s[%d]", path, i), item); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+func (s StructuralSchema) validateType(path string, v interface{}) error {
+	valid := true
+	switch s.Type {
+	case "":
+	case SchemaTypeObject:
+		_, valid = v.(map[string]interface{})
+	case SchemaTypeArray:
+		_, valid = v.([]interface{})
+	case SchemaTypeString:
+		_, valid = v.(string)
+	case SchemaTypeBoolean:
+		_, valid = v.(bool)
+	case SchemaTypeNumber:
+		_, valid = v.(float64)
+	case SchemaTypeInteger:
+		f, ok := v.(float64)
+		valid = ok && f == float64(int64(f))
+	default:
+		return fmt.Errorf("%s: unknown schema type %q", path, s.Type)
+	}
+
+	if !valid {
+		return fmt.Errorf("%s: expected %s, got %T", path, s.Type, v)
+	}
+
+	return nil
+}
+
+func schemaFieldPath(path, field string) string {
+	if path == "" {
+		return field
+	}
+	return path + "." + field
+}
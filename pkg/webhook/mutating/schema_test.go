@@ -0,0 +1,146 @@
+package mutating_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	admissionv1beta1 "k8s.io/api/admission/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	"github.com/slok/kubewebhook/pkg/log"
+	"github.com/slok/kubewebhook/pkg/webhook/internal/admissiontest"
+	"github.com/slok/kubewebhook/pkg/webhook/mutating"
+)
+
+func TestStructuralSchema(t *testing.T) {
+	schema := mutating.StructuralSchema{
+		Type:     mutating.SchemaTypeObject,
+		Required: []string{"spec"},
+		Properties: map[string]mutating.StructuralSchema{
+			"spec": {
+				Type: mutating.SchemaTypeObject,
+				Properties: map[string]mutating.StructuralSchema{
+					"replicas": {Type: mutating.SchemaTypeInteger},
+					"hosts": {
+						Type:  mutating.SchemaTypeArray,
+						Items: &mutating.StructuralSchema{Type: mutating.SchemaTypeString},
+					},
+				},
+			},
+		},
+	}
+
+	tests := map[string]struct {
+		obj    string
+		expErr bool
+	}{
+		"A valid object should be valid.": {
+			obj: `{"spec": {"replicas": 3, "hosts": ["a", "b"], "other": true}}`,
+		},
+
+		"An object missing a required field should not be valid.": {
+			obj:    `{"metadata": {}}`,
+			expErr: true,
+		},
+
+		"An object with a wrong field type should not be valid.": {
+			obj:    `{"spec": {"replicas": "3"}}`,
+			expErr: true,
+		},
+
+		"An object with a float on an integer field should not be valid.": {
+			obj:    `{"spec": {"replicas": 3.5}}`,
+			expErr: true,
+		},
+
+		"An object with a wrong array item type should not be valid.": {
+			obj:    `{"spec": {"hosts": ["a", 1]}}`,
+			expErr: true,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+
+			err := schema.Validate([]byte(test.obj))
+			if test.expErr {
+				assert.Error(err)
+			} else {
+				assert.NoError(err)
+			}
+		})
+	}
+}
+
+func TestMutationWebhookSchema(t *testing.T) {
+	schema := mutating.StructuralSchema{
+		Type: mutating.SchemaTypeObject,
+		Properties: map[string]mutating.StructuralSchema{
+			"spec": {
+				Type: mutating.SchemaTypeObject,
+				Properties: map[string]mutating.StructuralSchema{
+					"n": {Type: mutating.SchemaTypeInteger},
+				},
+			},
+		},
+	}
+
+	getMutator := func(n interface{}) mutating.Mutator {
+		return mutating.MutatorFunc(func(_ context.Context, obj metav1.Object) (bool, error) {
+			u := obj.(*unstructured.Unstructured)
+			return false, unstructured.SetNestedField(u.Object, n, "spec", "n")
+		})
+	}
+
+	tests := map[string]struct {
+		mutator  mutating.Mutator
+		expPatch string
+		expErr   bool
+	}{
+		"A mutation that satisfies the schema should return the patch.": {
+			mutator:  getMutator(int64(43)),
+			expPatch: `[{"op":"replace","path":"/spec/n","value":43}]`,
+		},
+
+		"A mutation that violates the schema should return an error.": {
+			mutator: getMutator("43"),
+			expErr:  true,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+			require := require.New(t)
+
+			cfg := mutating.WebhookConfig{Name: "test", Schema: schema}
+			wh, err := mutating.NewWebhook(cfg, test.mutator, nil, nil, log.Dummy)
+			require.NoError(err)
+
+			ar := &admissionv1beta1.AdmissionReview{
+				Request: &admissionv1beta1.AdmissionRequest{
+					UID: "test",
+					Object: runtime.RawExtension{
+						Raw: []byte(`{"kind": "whatever", "apiVersion": "v42", "metadata": {"name": "something"}, "spec": {"n": 42}}`),
+					},
+				},
+			}
+			gotResponse := wh.Review(context.TODO(), ar)
+			require.NoError(admissiontest.ValidateResponse(ar.Request, gotResponse))
+
+			if test.expErr {
+				assert.False(gotResponse.Allowed)
+				assert.Equal(metav1.StatusFailure, gotResponse.Result.Status)
+				assert.Contains(gotResponse.Result.Message, "spec.n: expected integer")
+			} else {
+				assert.True(gotResponse.Allowed)
+				assert.Equal(test.expPatch, string(gotResponse.Patch))
+			}
+		})
+	}
+}
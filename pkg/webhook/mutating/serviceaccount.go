@@ -0,0 +1,38 @@
+package mutating
+
+import (
+	"context"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	whcontext "github.com/slok/kubewebhook/pkg/webhook/context"
+	"github.com/slok/kubewebhook/pkg/webhook/internal/helpers"
+)
+
+// NewServiceAccountMutator returns a mutator that sets the service account of the pods (or the pod
+// templates of the workloads) that don't have one, based on their namespace using a namespace to
+// service account name mapping. The explicit service accounts will not be overridden.
+//
+// The API server sets the `default` service account on the pods before calling the mutating
+// webhooks, so normally this mutator is used with the workloads.
+func NewServiceAccountMutator(namespaceServiceAccounts map[string]string) Mutator {
+	return MutatorFunc(func(ctx context.Context, obj metav1.Object) (bool, error) {
+		spec, ok := helpers.PodSpec(obj)
+		if !ok || spec.ServiceAccountName != "" || spec.DeprecatedServiceAccount != "" {
+			return false, nil
+		}
+
+		ns := obj.GetNamespace()
+		if ar := whcontext.GetAdmissionRequest(ctx); ns == "" && ar != nil {
+			ns = ar.Namespace
+		}
+
+		sa, ok := namespaceServiceAccounts[ns]
+		if !ok {
+			return false, nil
+		}
+		spec.ServiceAccountName = sa
+
+		return false, nil
+	})
+}
@@ -0,0 +1,91 @@
+package mutating_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	admissionv1beta1 "k8s.io/api/admission/v1beta1"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	whcontext "github.com/slok/kubewebhook/pkg/webhook/context"
+	"github.com/slok/kubewebhook/pkg/webhook/mutating"
+)
+
+func TestServiceAccountMutator(t *testing.T) {
+	tests := map[string]struct {
+		reqNamespace string
+		obj          metav1.Object
+		expObj       metav1.Object
+	}{
+		"A pod on a mapped namespace without service account should set the namespace service account.": {
+			obj: &corev1.Pod{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "team-a"},
+			},
+			expObj: &corev1.Pod{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "team-a"},
+				Spec:       corev1.PodSpec{ServiceAccountName: "team-a-default-sa"},
+			},
+		},
+
+		"A deployment without namespace should use the admission request namespace.": {
+			reqNamespace: "team-a",
+			obj:          &appsv1.Deployment{},
+			expObj: &appsv1.Deployment{
+				Spec: appsv1.DeploymentSpec{
+					Template: corev1.PodTemplateSpec{
+						Spec: corev1.PodSpec{ServiceAccountName: "team-a-default-sa"},
+					},
+				},
+			},
+		},
+
+		"A pod on an unmapped namespace should not be mutated.": {
+			obj: &corev1.Pod{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "team-b"},
+			},
+			expObj: &corev1.Pod{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "team-b"},
+			},
+		},
+
+		"A pod with an explicit service account should not be mutated.": {
+			obj: &corev1.Pod{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "team-a"},
+				Spec:       corev1.PodSpec{ServiceAccountName: "app"},
+			},
+			expObj: &corev1.Pod{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "team-a"},
+				Spec:       corev1.PodSpec{ServiceAccountName: "app"},
+			},
+		},
+
+		"A pod with an explicit deprecated service account should not be mutated.": {
+			obj: &corev1.Pod{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "team-a"},
+				Spec:       corev1.PodSpec{DeprecatedServiceAccount: "app"},
+			},
+			expObj: &corev1.Pod{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "team-a"},
+				Spec:       corev1.PodSpec{DeprecatedServiceAccount: "app"},
+			},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+			require := require.New(t)
+
+			m := mutating.NewServiceAccountMutator(map[string]string{"team-a": "team-a-default-sa"})
+			ctx := whcontext.SetAdmissionRequest(context.TODO(), &admissionv1beta1.AdmissionRequest{Namespace: test.reqNamespace})
+			_, err := m.Mutate(ctx, test.obj)
+			require.NoError(err)
+
+			assert.Equal(test.expObj, test.obj)
+		})
+	}
+}
@@ -0,0 +1,37 @@
+package mutating
+
+import (
+	"context"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	whcontext "github.com/slok/kubewebhook/pkg/webhook/context"
+)
+
+// NewStorageClassMutator returns a mutator that sets the storage class of the PersistentVolumeClaims
+// based on their namespace using a namespace to storage class mapping. The PVCs with an explicit
+// storage class (including the empty one, that disables dynamic provisioning) or from namespaces
+// not on the mapping will not be mutated. If the PVC doesn't have namespace (e.g on creation) the
+// admission request namespace will be used.
+func NewStorageClassMutator(namespaceClasses map[string]string) Mutator {
+	return MutatorFunc(func(ctx context.Context, obj metav1.Object) (bool, error) {
+		pvc, ok := obj.(*corev1.PersistentVolumeClaim)
+		if !ok || pvc.Spec.StorageClassName != nil {
+			return false, nil
+		}
+
+		ns := pvc.Namespace
+		if ar := whcontext.GetAdmissionRequest(ctx); ns == "" && ar != nil {
+			ns = ar.Namespace
+		}
+
+		class, ok := namespaceClasses[ns]
+		if !ok {
+			return false, nil
+		}
+		pvc.Spec.StorageClassName = &class
+
+		return false, nil
+	})
+}
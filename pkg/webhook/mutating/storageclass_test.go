@@ -0,0 +1,88 @@
+package mutating_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	admissionv1beta1 "k8s.io/api/admission/v1beta1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	whcontext "github.com/slok/kubewebhook/pkg/webhook/context"
+	"github.com/slok/kubewebhook/pkg/webhook/mutating"
+)
+
+func TestStorageClassMutator(t *testing.T) {
+	strPtr := func(s string) *string { return &s }
+
+	tests := map[string]struct {
+		reqNamespace string
+		pvc          *corev1.PersistentVolumeClaim
+		expPVC       *corev1.PersistentVolumeClaim
+	}{
+		"A PVC on a mapped namespace without storage class should set the namespace storage class.": {
+			pvc: &corev1.PersistentVolumeClaim{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "team-a"},
+			},
+			expPVC: &corev1.PersistentVolumeClaim{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "team-a"},
+				Spec:       corev1.PersistentVolumeClaimSpec{StorageClassName: strPtr("fast-ssd")},
+			},
+		},
+
+		"A PVC without namespace should use the admission request namespace.": {
+			reqNamespace: "team-a",
+			pvc:          &corev1.PersistentVolumeClaim{},
+			expPVC: &corev1.PersistentVolumeClaim{
+				Spec: corev1.PersistentVolumeClaimSpec{StorageClassName: strPtr("fast-ssd")},
+			},
+		},
+
+		"A PVC on an unmapped namespace should not be mutated.": {
+			pvc: &corev1.PersistentVolumeClaim{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "team-b"},
+			},
+			expPVC: &corev1.PersistentVolumeClaim{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "team-b"},
+			},
+		},
+
+		"A PVC with an explicit storage class should not be mutated.": {
+			pvc: &corev1.PersistentVolumeClaim{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "team-a"},
+				Spec:       corev1.PersistentVolumeClaimSpec{StorageClassName: strPtr("standard")},
+			},
+			expPVC: &corev1.PersistentVolumeClaim{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "team-a"},
+				Spec:       corev1.PersistentVolumeClaimSpec{StorageClassName: strPtr("standard")},
+			},
+		},
+
+		"A PVC with an explicit empty storage class should not be mutated.": {
+			pvc: &corev1.PersistentVolumeClaim{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "team-a"},
+				Spec:       corev1.PersistentVolumeClaimSpec{StorageClassName: strPtr("")},
+			},
+			expPVC: &corev1.PersistentVolumeClaim{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "team-a"},
+				Spec:       corev1.PersistentVolumeClaimSpec{StorageClassName: strPtr("")},
+			},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+			require := require.New(t)
+
+			m := mutating.NewStorageClassMutator(map[string]string{"team-a": "fast-ssd"})
+			ctx := whcontext.SetAdmissionRequest(context.TODO(), &admissionv1beta1.AdmissionRequest{Namespace: test.reqNamespace})
+			_, err := m.Mutate(ctx, test.pvc)
+			require.NoError(err)
+
+			assert.Equal(test.expPVC, test.pvc)
+		})
+	}
+}
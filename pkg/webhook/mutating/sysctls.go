@@ -0,0 +1,86 @@
+package mutating
+
+import (
+	"context"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/slok/kubewebhook/pkg/webhook/internal/helpers"
+)
+
+// SafeSysctls are the sysctls that Kubernetes considers safe (namespaced and isolated
+// between the pods of the same node), these are allowed by default on all the clusters.
+var SafeSysctls = []string{
+	"kernel.shm_rmid_forced",
+	"net.ipv4.ip_local_port_range",
+	"net.ipv4.tcp_syncookies",
+	"net.ipv4.ping_group_range",
+}
+
+// SysctlsMutatorConfig is the configuration of the sysctls mutator.
+type SysctlsMutatorConfig struct {
+	// Sysctls are the sysctls that will be set on the pods.
+	Sysctls []corev1.Sysctl
+	// AllowUnsafe allows configuring sysctls that are not on the SafeSysctls list, the
+	// kubelets need to allow them with `--allowed-unsafe-sysctls`, otherwise the pods will
+	// fail to start.
+	AllowUnsafe bool
+}
+
+func (c SysctlsMutatorConfig) validate() error {
+	safe := map[string]bool{}
+	for _, s := range SafeSysctls {
+		safe[s] = true
+	}
+
+	for _, s := range c.Sysctls {
+		if s.Name == "" {
+			return fmt.Errorf("invalid configuration: sysctl name can't be empty")
+		}
+
+		if !c.AllowUnsafe && !safe[s.Name] {
+			return fmt.Errorf("invalid configuration: %q sysctl is not safe", s.Name)
+		}
+	}
+
+	return nil
+}
+
+// NewSysctlsMutator returns a mutator that sets the sysctls on the security context of the
+// pods (or the pod templates of the workloads). The sysctls are merged with the existing
+// ones, the pod sysctls are never overridden.
+//
+// By default only the safe sysctls can be configured, check SysctlsMutatorConfig.AllowUnsafe.
+func NewSysctlsMutator(cfg SysctlsMutatorConfig) (Mutator, error) {
+	if err := cfg.validate(); err != nil {
+		return nil, err
+	}
+
+	return MutatorFunc(func(_ context.Context, obj metav1.Object) (bool, error) {
+		spec, ok := helpers.PodSpec(obj)
+		if !ok || len(cfg.Sysctls) == 0 {
+			return false, nil
+		}
+
+		if spec.SecurityContext == nil {
+			spec.SecurityContext = &corev1.PodSecurityContext{}
+		}
+
+		present := map[string]bool{}
+		for _, s := range spec.SecurityContext.Sysctls {
+			present[s.Name] = true
+		}
+
+		for _, s := range cfg.Sysctls {
+			if present[s.Name] {
+				continue
+			}
+			spec.SecurityContext.Sysctls = append(spec.SecurityContext.Sysctls, s)
+			present[s.Name] = true
+		}
+
+		return false, nil
+	}), nil
+}
@@ -0,0 +1,118 @@
+package mutating_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/slok/kubewebhook/pkg/webhook/mutating"
+)
+
+func TestSysctlsMutator(t *testing.T) {
+	boolPtr := func(b bool) *bool { return &b }
+
+	tests := map[string]struct {
+		cfg    mutating.SysctlsMutatorConfig
+		obj    metav1.Object
+		expObj metav1.Object
+		expErr bool
+	}{
+		"An unsafe sysctl without allowing unsafe sysctls should fail.": {
+			cfg: mutating.SysctlsMutatorConfig{
+				Sysctls: []corev1.Sysctl{{Name: "net.core.somaxconn", Value: "1024"}},
+			},
+			expErr: true,
+		},
+
+		"A pod without sysctls should set the sysctls.": {
+			cfg: mutating.SysctlsMutatorConfig{
+				Sysctls: []corev1.Sysctl{
+					{Name: "net.ipv4.ip_local_port_range", Value: "1024 65000"},
+					{Name: "net.ipv4.tcp_syncookies", Value: "1"},
+				},
+			},
+			obj: &corev1.Pod{},
+			expObj: &corev1.Pod{
+				Spec: corev1.PodSpec{
+					SecurityContext: &corev1.PodSecurityContext{
+						Sysctls: []corev1.Sysctl{
+							{Name: "net.ipv4.ip_local_port_range", Value: "1024 65000"},
+							{Name: "net.ipv4.tcp_syncookies", Value: "1"},
+						},
+					},
+				},
+			},
+		},
+
+		"A pod with sysctls should merge the sysctls without overriding the existing ones.": {
+			cfg: mutating.SysctlsMutatorConfig{
+				Sysctls: []corev1.Sysctl{
+					{Name: "net.ipv4.ip_local_port_range", Value: "1024 65000"},
+					{Name: "net.ipv4.tcp_syncookies", Value: "1"},
+				},
+			},
+			obj: &corev1.Pod{
+				Spec: corev1.PodSpec{
+					SecurityContext: &corev1.PodSecurityContext{
+						RunAsNonRoot: boolPtr(true),
+						Sysctls:      []corev1.Sysctl{{Name: "net.ipv4.tcp_syncookies", Value: "0"}},
+					},
+				},
+			},
+			expObj: &corev1.Pod{
+				Spec: corev1.PodSpec{
+					SecurityContext: &corev1.PodSecurityContext{
+						RunAsNonRoot: boolPtr(true),
+						Sysctls: []corev1.Sysctl{
+							{Name: "net.ipv4.tcp_syncookies", Value: "0"},
+							{Name: "net.ipv4.ip_local_port_range", Value: "1024 65000"},
+						},
+					},
+				},
+			},
+		},
+
+		"A deployment with unsafe sysctls allowed should set the sysctls on the pod template.": {
+			cfg: mutating.SysctlsMutatorConfig{
+				Sysctls:     []corev1.Sysctl{{Name: "net.core.somaxconn", Value: "1024"}},
+				AllowUnsafe: true,
+			},
+			obj: &appsv1.Deployment{},
+			expObj: &appsv1.Deployment{
+				Spec: appsv1.DeploymentSpec{
+					Template: corev1.PodTemplateSpec{
+						Spec: corev1.PodSpec{
+							SecurityContext: &corev1.PodSecurityContext{
+								Sysctls: []corev1.Sysctl{{Name: "net.core.somaxconn", Value: "1024"}},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+			require := require.New(t)
+
+			m, err := mutating.NewSysctlsMutator(test.cfg)
+			if test.expErr {
+				assert.Error(err)
+				return
+			}
+			require.NoError(err)
+
+			_, err = m.Mutate(context.TODO(), test.obj)
+			require.NoError(err)
+
+			assert.Equal(test.expObj, test.obj)
+		})
+	}
+}
@@ -4,15 +4,20 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"strings"
+	"time"
 
 	opentracing "github.com/opentracing/opentracing-go"
 	"gomodules.xyz/jsonpatch/v3"
 	admissionv1beta1 "k8s.io/api/admission/v1beta1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
 
 	"github.com/slok/kubewebhook/pkg/log"
 	"github.com/slok/kubewebhook/pkg/observability/metrics"
 	"github.com/slok/kubewebhook/pkg/webhook"
+	whcontext "github.com/slok/kubewebhook/pkg/webhook/context"
 	"github.com/slok/kubewebhook/pkg/webhook/internal/helpers"
 	"github.com/slok/kubewebhook/pkg/webhook/internal/instrumenting"
 )
@@ -24,33 +29,174 @@ type WebhookConfig struct {
 	// Object is the object of the webhook, to use multiple types on the same webhook or
 	// type inference, don't set this field (will be `nil`).
 	Obj metav1.Object
+	// DecodeErrorAllowKinds are the kinds that will be allowed without any mutation in case
+	// the webhook can't decode them (e.g third party CRDs matched by a broad rule).
+	DecodeErrorAllowKinds []schema.GroupKind
+	// MarkMutated will mark the objects processed by the webhook with the
+	// `webhook.MutationMarkAnnotation` annotation, this way the validating
+	// webhooks can know if they are receiving an object after its mutation.
+	MarkMutated bool
+	// Schema is an optional schema that the mutated object must satisfy, if the
+	// mutated object is not valid against the schema the webhook will return an
+	// error instead of a patch that would produce an invalid object.
+	Schema Schema
+	// AllowedPatchOps are the JSON patch operations (e.g `add`, `replace`) the webhook is allowed
+	// to return, by default all the operations are allowed. Useful to forbid a mutation
+	// deleting data (`remove` operation).
+	AllowedPatchOps []string
+	// DisallowedPatchOpPolicy is the policy applied to the patches with operations not allowed
+	// by `AllowedPatchOps`, by default the webhook will return an error. Take into account that
+	// `DisallowedPatchOpPolicyDropPatch` drops the whole mutation, not only the not allowed operations.
+	DisallowedPatchOpPolicy DisallowedPatchOpPolicy
+	// KindMismatchPolicy is the policy applied when the webhook has a static object type and the
+	// admission request kind doesn't match the object kind, by default the kind is not checked.
+	// Only the object types registered on the client-go scheme can be checked.
+	KindMismatchPolicy webhook.KindMismatchPolicy
+	// InstrumentOwnerKind will add the kind of the reviewed object controller owner (e.g `ReplicaSet`)
+	// to the metrics and traces, useful to attribute the webhook load to the controllers. Only the
+	// owner kind is used (not the name) to have a bounded metrics cardinality.
+	InstrumentOwnerKind bool
+	// SlowThreshold is the review duration that once exceeded will mark the review as slow, the slow
+	// reviews still succeed but with a warning for the API client and they are measured by the
+	// slow reviews metric, useful to track the webhook SLOs. By default (0) it's disabled.
+	SlowThreshold time.Duration
+	// ExpectedTimeout is the webhook timeout configured on the API server (`timeoutSeconds`), the
+	// webhook doesn't know it, so it needs to be configured to detect the reviews close to the
+	// deadline. By default (0) it's disabled.
+	ExpectedTimeout time.Duration
+	// DeadlineWarningRatio is the ratio of the expected timeout that once exceeded will mark the
+	// review as near the deadline, these reviews still succeed but with a warning for the API
+	// client and they are measured by the near deadline reviews metric. By default 0.8.
+	DeadlineWarningRatio float64
+	// TraceBaggage will set the reviewed object group, version, kind and the operation as tracing
+	// baggage (using the `webhook.Baggage*Key` keys) on the context received by the mutators, the
+	// baggage is propagated with the trace context to the downstream services called by them.
+	TraceBaggage bool
+	// AuditMutatorsApplied will add the `mutators-applied` audit annotation to the responses with the
+	// names of the chain mutators that modified the object (e.g `a,b,c`), useful to debug the mutation
+	// pipelines. The API server records it prefixed with the webhook name. Only the named
+	// `ChainMutator`s are tracked.
+	AuditMutatorsApplied bool
+	// MutateDeletes will mutate the delete operations (using the deleted object), by default the delete
+	// operations are allowed without mutation because the patch of a deleted object has no effect, only
+	// enable it for mutators that are aware of the delete operations.
+	MutateDeletes bool
+	// NormalizeEmptyArrays will treat the empty arrays and the null values the same way when
+	// creating the JSON patch, this avoids spurious patch operations when a field is `[]` on the
+	// received object and `null` (or missing) after the mutation, or vice versa.
+	NormalizeEmptyArrays bool
+	// MaxObjectDepth is the max nesting depth of the received objects, the objects exceeding it will
+	// be rejected with an error before the mutation (the failure policy will be applied), this protects
+	// the webhook against pathological objects (e.g deeply nested CRDs) that blow up the JSON diffing.
+	// By default (0) there is no limit.
+	MaxObjectDepth int
+	// MaxObjectFields is the max number of fields of the received objects, the objects exceeding it
+	// will be rejected with an error before the mutation (the failure policy will be applied). By
+	// default (0) there is no limit.
+	MaxObjectFields int
+	// PanicFallbackMutator is the mutator that will be used when the webhook mutator panics, it
+	// receives the object as it was before the panicking mutation. Useful for critical webhooks
+	// that need a minimal safe mutation (e.g a marker annotation) instead of failing. By default
+	// the panics are not recovered.
+	PanicFallbackMutator Mutator
+	// ValidatePatchRoundTrip will apply the generated patch to the received object and decode the
+	// result, if the patched object is not a valid typed object (e.g a mutator setting a string on
+	// an integer field of an unstructured object) the webhook will return an error instead of the patch.
+	// The kinds not registered on the client-go scheme are only checked with a typed static webhook object.
+	ValidatePatchRoundTrip bool
+	// SkipIfAnnotationPresent are the annotations that once present on the received object will make
+	// the webhook allow it without mutation, useful to coordinate with other mutating webhooks (e.g
+	// an annotation set by a higher priority webhook). An empty value matches any annotation value.
+	SkipIfAnnotationPresent map[string]string
+	// LogRedactor is the redactor used to redact the sensitive data of the objects before
+	// logging them, by default `log.DefaultRedactor`.
+	LogRedactor log.Redactor
+}
+
+func (c *WebhookConfig) defaults() {
+	if c.ExpectedTimeout > 0 && c.DeadlineWarningRatio == 0 {
+		c.DeadlineWarningRatio = 0.8
+	}
+
+	if c.DisallowedPatchOpPolicy == "" {
+		c.DisallowedPatchOpPolicy = DisallowedPatchOpPolicyError
+	}
+
+	if c.KindMismatchPolicy == "" {
+		c.KindMismatchPolicy = webhook.KindMismatchPolicyIgnore
+	}
+
+	if c.LogRedactor == nil {
+		c.LogRedactor = log.DefaultRedactor
+	}
 }
 
 func (c WebhookConfig) validate() error {
-	errs := ""
+	var errs []string
 
 	if c.Name == "" {
-		errs = errs + "name can't be empty"
+		errs = append(errs, "name can't be empty")
+	}
+
+	if !c.KindMismatchPolicy.Valid() {
+		errs = append(errs, fmt.Sprintf("unknown kind mismatch policy %q", c.KindMismatchPolicy))
 	}
 
-	if errs != "" {
-		return fmt.Errorf("invalid configuration: %s", errs)
+	if c.DisallowedPatchOpPolicy != DisallowedPatchOpPolicyError && c.DisallowedPatchOpPolicy != DisallowedPatchOpPolicyDropPatch {
+		errs = append(errs, fmt.Sprintf("unknown disallowed patch operation policy %q", c.DisallowedPatchOpPolicy))
+	}
+
+	for _, op := range c.AllowedPatchOps {
+		if !validPatchOps[op] {
+			errs = append(errs, fmt.Sprintf("unknown allowed patch operation %q", op))
+		}
+	}
+
+	if c.MaxObjectDepth < 0 {
+		errs = append(errs, "max object depth can't be negative")
+	}
+
+	if c.MaxObjectFields < 0 {
+		errs = append(errs, "max object fields can't be negative")
+	}
+
+	if c.SlowThreshold < 0 {
+		errs = append(errs, "slow threshold can't be negative")
+	}
+
+	if c.ExpectedTimeout < 0 {
+		errs = append(errs, "expected timeout can't be negative")
+	}
+
+	if c.DeadlineWarningRatio < 0 {
+		errs = append(errs, "deadline warning ratio can't be negative")
+	}
+
+	if c.DeadlineWarningRatio > 1 {
+		errs = append(errs, "deadline warning ratio can't be greater than 1")
+	}
+
+	if len(errs) > 0 {
+		return fmt.Errorf("invalid configuration: %s", strings.Join(errs, ", "))
 	}
 
 	return nil
 }
 
 type mutationWebhook struct {
-	objectCreator helpers.ObjectCreator
-	mutator       Mutator
-	cfg           WebhookConfig
-	logger        log.Logger
+	objectCreator   helpers.ObjectCreator
+	mutator         Mutator
+	objGroupKind    *schema.GroupKind
+	cfg             WebhookConfig
+	logger          log.Logger
+	metricsRecorder metrics.Recorder
 }
 
 // NewWebhook is a mutating webhook and will return a webhook ready for a type of resource.
 // It will mutate the received resources.
 // This webhook will always allow the admission of the resource, only will deny in case of error.
 func NewWebhook(cfg WebhookConfig, mutator Mutator, ot opentracing.Tracer, recorder metrics.Recorder, logger log.Logger) (webhook.Webhook, error) {
+	cfg.defaults()
 	if err := cfg.validate(); err != nil {
 		return nil, err
 	}
@@ -72,8 +218,14 @@ func NewWebhook(cfg WebhookConfig, mutator Mutator, ot opentracing.Tracer, recor
 	// If we don't have the type of the object create a dynamic object creator that will
 	// infer the type.
 	var oc helpers.ObjectCreator
+	var objGroupKind *schema.GroupKind
 	if cfg.Obj != nil {
 		oc = helpers.NewStaticObjectCreator(cfg.Obj)
+		if gk, ok := helpers.ObjectGroupKind(cfg.Obj); ok {
+			objGroupKind = &gk
+		} else if cfg.KindMismatchPolicy != webhook.KindMismatchPolicyIgnore {
+			logger.Warningf("webhook object type is not registered on the scheme, the request kind will not be checked")
+		}
 	} else {
 		oc = helpers.NewDynamicObjectCreator()
 	}
@@ -81,23 +233,67 @@ func NewWebhook(cfg WebhookConfig, mutator Mutator, ot opentracing.Tracer, recor
 	// Create our webhook and wrap for instrumentation (metrics and tracing).
 	return &instrumenting.Webhook{
 		Webhook: &mutationWebhook{
-			objectCreator: oc,
-			mutator:       mutator,
-			cfg:           cfg,
-			logger:        logger,
+			objectCreator:   oc,
+			mutator:         mutator,
+			objGroupKind:    objGroupKind,
+			cfg:             cfg,
+			logger:          logger,
+			metricsRecorder: recorder,
 		},
-		ReviewKind:      metrics.MutatingReviewKind,
-		WebhookName:     cfg.Name,
-		MetricsRecorder: recorder,
-		Tracer:          ot,
+		ReviewKind:           metrics.MutatingReviewKind,
+		WebhookName:          cfg.Name,
+		MetricsRecorder:      recorder,
+		Tracer:               ot,
+		OwnerKind:            cfg.InstrumentOwnerKind,
+		SlowThreshold:        cfg.SlowThreshold,
+		ExpectedTimeout:      cfg.ExpectedTimeout,
+		DeadlineWarningRatio: cfg.DeadlineWarningRatio,
+		TraceBaggage:         cfg.TraceBaggage,
+		LogRedactor:          cfg.LogRedactor,
 	}, nil
 }
 
 func (w mutationWebhook) Review(ctx context.Context, ar *admissionv1beta1.AdmissionReview) *admissionv1beta1.AdmissionResponse {
 	auid := ar.Request.UID
 
+	// The self-test reviews are not measured.
+	if whcontext.IsSelfTest(ctx) {
+		w.metricsRecorder = metrics.Dummy
+	}
+
+	// Attach the review correlation ID to all the review logs.
+	if id := whcontext.GetCorrelationID(ctx); id != "" {
+		w.logger = log.WithValues(w.logger, "correlationID", id)
+	}
+
+	// The API server always sets the UID, an empty UID means a bad client, we respond anyway as best effort.
+	if auid == "" {
+		w.logger.Warningf("admission request %s/%s has an empty UID, the response may be rejected", ar.Request.Namespace, ar.Request.Name)
+	}
+
 	w.logger.Debugf("reviewing request %s, named: %s/%s", auid, ar.Request.Namespace, ar.Request.Name)
 
+	// Check the request is for the kind of our object type.
+	if w.objGroupKind != nil && ar.Request.Kind.Kind != "" && !helpers.GroupKindIn(ar.Request.Kind, []schema.GroupKind{*w.objGroupKind}) {
+		switch w.cfg.KindMismatchPolicy {
+		case webhook.KindMismatchPolicyError:
+			err := fmt.Errorf("request %s kind %s doesn't match the webhook object kind %s", ar.Request.UID, ar.Request.Kind.String(), w.objGroupKind.String())
+			return w.toAdmissionErrorResponse(ar, err)
+		case webhook.KindMismatchPolicyAllow:
+			w.logger.Warningf("request %s kind %s doesn't match the webhook object kind %s, allowing without mutation", ar.Request.UID, ar.Request.Kind.String(), w.objGroupKind.String())
+			if rec, ok := w.metricsRecorder.(metrics.FailOpenRecorder); ok {
+				rec.IncWebhookFailOpen(w.cfg.Name, metrics.FailOpenReasonKindMismatch)
+			}
+			return helpers.ToAdmissionAllowedNoOpResponse(ar.Request.UID)
+		}
+	}
+
+	// The API server ignores the patches of the delete operations, don't mutate them unless explicitly allowed.
+	if ar.Request.Operation == admissionv1beta1.Delete && !w.cfg.MutateDeletes {
+		w.logger.Debugf("request %s is a delete operation, allowing without mutation", ar.Request.UID)
+		return helpers.ToAdmissionAllowedNoOpResponse(ar.Request.UID)
+	}
+
 	// Delete operations don't have body because should be gone on the deletion, instead they have the body
 	// of the object we want to delete as an old object.
 	raw := ar.Request.Object.Raw
@@ -105,9 +301,22 @@ func (w mutationWebhook) Review(ctx context.Context, ar *admissionv1beta1.Admiss
 		raw = ar.Request.OldObject.Raw
 	}
 
+	if w.cfg.MaxObjectDepth > 0 || w.cfg.MaxObjectFields > 0 {
+		if err := checkJSONBounds(raw, w.cfg.MaxObjectDepth, w.cfg.MaxObjectFields); err != nil {
+			return w.toAdmissionErrorResponse(ar, fmt.Errorf("request %s object rejected: %w", ar.Request.UID, err))
+		}
+	}
+
 	// Create a new object from the raw type.
 	runtimeObj, err := w.objectCreator.NewObject(raw)
 	if err != nil {
+		if helpers.GroupKindIn(ar.Request.Kind, w.cfg.DecodeErrorAllowKinds) {
+			w.logger.Warningf("could not decode request %s object, allowing without mutation: %s", ar.Request.UID, err)
+			if rec, ok := w.metricsRecorder.(metrics.FailOpenRecorder); ok {
+				rec.IncWebhookFailOpen(w.cfg.Name, metrics.FailOpenReasonDecodeError)
+			}
+			return helpers.ToAdmissionAllowedNoOpResponse(ar.Request.UID)
+		}
 		return w.toAdmissionErrorResponse(ar, err)
 	}
 
@@ -117,48 +326,200 @@ func (w mutationWebhook) Review(ctx context.Context, ar *admissionv1beta1.Admiss
 		return w.toAdmissionErrorResponse(ar, err)
 	}
 
+	if k, ok := skipAnnotationPresent(mutatingObj, w.cfg.SkipIfAnnotationPresent); ok {
+		w.logger.Debugf("request %s object has the %q skip annotation, allowing without mutation", ar.Request.UID, k)
+		return helpers.ToAdmissionAllowedNoOpResponse(ar.Request.UID)
+	}
+
 	return w.mutatingAdmissionReview(ctx, ar, raw, mutatingObj)
 
 }
 
+// ConfigView satisfies webhook.ConfigViewer interface.
+func (w mutationWebhook) ConfigView() webhook.ConfigView {
+	return webhook.ConfigView{
+		Object: helpers.ConfigViewObject(w.cfg.Obj, w.objGroupKind),
+		Options: map[string]interface{}{
+			"decodeErrorAllowKinds":   helpers.GroupKindsStrings(w.cfg.DecodeErrorAllowKinds),
+			"markMutated":             w.cfg.MarkMutated,
+			"schemaEnabled":           w.cfg.Schema != nil,
+			"allowedPatchOps":         w.cfg.AllowedPatchOps,
+			"disallowedPatchOpPolicy": string(w.cfg.DisallowedPatchOpPolicy),
+			"kindMismatchPolicy":      string(w.cfg.KindMismatchPolicy),
+			"normalizeEmptyArrays":    w.cfg.NormalizeEmptyArrays,
+			"maxObjectDepth":          w.cfg.MaxObjectDepth,
+			"maxObjectFields":         w.cfg.MaxObjectFields,
+			"panicFallbackEnabled":    w.cfg.PanicFallbackMutator != nil,
+			"auditMutatorsApplied":    w.cfg.AuditMutatorsApplied,
+			"mutateDeletes":           w.cfg.MutateDeletes,
+			"validatePatchRoundTrip":  w.cfg.ValidatePatchRoundTrip,
+			"skipIfAnnotationPresent": w.cfg.SkipIfAnnotationPresent,
+		},
+	}
+}
+
 func (w mutationWebhook) mutatingAdmissionReview(ctx context.Context, ar *admissionv1beta1.AdmissionReview, rawObj []byte, obj metav1.Object) *admissionv1beta1.AdmissionResponse {
 	auid := ar.Request.UID
 
+	var applied *appliedMutators
+	if w.cfg.AuditMutatorsApplied {
+		ctx, applied = withAppliedMutators(ctx)
+	}
+
 	// Mutate the object.
-	_, err := w.mutator.Mutate(ctx, obj)
+	obj, err := w.mutate(ctx, obj)
 	if err != nil {
 		return w.toAdmissionErrorResponse(ar, err)
 	}
 
+	if w.cfg.MarkMutated {
+		webhook.MarkMutated(obj, w.cfg.Name)
+	}
+
 	mutatedJSON, err := json.Marshal(obj)
 	if err != nil {
 		return w.toAdmissionErrorResponse(ar, err)
 	}
 
+	if w.cfg.Schema != nil {
+		if err := w.cfg.Schema.Validate(mutatedJSON); err != nil {
+			return w.toAdmissionErrorResponse(ar, fmt.Errorf("mutated object is not valid against the schema: %w", err))
+		}
+	}
+
+	if w.cfg.NormalizeEmptyArrays {
+		rawObj, err = normalizeEmptyJSON(rawObj)
+		if err != nil {
+			return w.toAdmissionErrorResponse(ar, fmt.Errorf("could not normalize the received object: %w", err))
+		}
+		mutatedJSON, err = normalizeEmptyJSON(mutatedJSON)
+		if err != nil {
+			return w.toAdmissionErrorResponse(ar, fmt.Errorf("could not normalize the mutated object: %w", err))
+		}
+	}
+
 	patch, err := jsonpatch.CreatePatch(rawObj, mutatedJSON)
 	if err != nil {
 		return w.toAdmissionErrorResponse(ar, err)
 	}
 
+	patch, dropped, err := filterPatchOps(patch, w.cfg.AllowedPatchOps, w.cfg.DisallowedPatchOpPolicy)
+	if err != nil {
+		return w.toAdmissionErrorResponse(ar, err)
+	}
+	for _, op := range dropped {
+		w.logger.Warningf("dropped the patch due to the not allowed %q patch operation on %q path for request %s", op.Operation, op.Path, auid)
+	}
+
+	// Track the mutations that didn't mutate anything.
+	if len(patch) == 0 && len(dropped) == 0 {
+		if rec, ok := w.metricsRecorder.(metrics.MutationNoOpRecorder); ok {
+			rec.IncMutationNoOp(w.cfg.Name)
+		}
+	}
+
 	marshalledPatch, err := json.Marshal(patch)
 	if err != nil {
 		return w.toAdmissionErrorResponse(ar, err)
 	}
-	w.logger.Debugf("json patch for request %s: %s", auid, string(marshalledPatch))
+	if w.cfg.ValidatePatchRoundTrip && len(patch) > 0 {
+		var staticCreator helpers.ObjectCreator
+		if w.cfg.Obj != nil {
+			staticCreator = w.objectCreator
+		}
+		if err := checkPatchRoundTrip(rawObj, marshalledPatch, staticCreator); err != nil {
+			if rec, ok := w.metricsRecorder.(metrics.PatchRoundTripRecorder); ok {
+				rec.IncPatchRoundTripError(w.cfg.Name)
+			}
+			return w.toAdmissionErrorResponse(ar, fmt.Errorf("mutation patch doesn't produce a valid object: %w", err))
+		}
+	}
+
+	w.logger.Debugf("json patch for request %s: %s", auid, string(w.cfg.LogRedactor.RedactPatch(objectGroupKind(ar, obj), marshalledPatch)))
 
 	// Forge response.
-	return &admissionv1beta1.AdmissionResponse{
+	resp := &admissionv1beta1.AdmissionResponse{
 		UID:       auid,
 		Allowed:   true,
 		Patch:     marshalledPatch,
 		PatchType: jsonPatchType,
 	}
+
+	if applied != nil && len(applied.names) > 0 {
+		resp.AuditAnnotations = map[string]string{
+			mutatorsAppliedAuditAnnotation: strings.Join(applied.names, ","),
+		}
+	}
+
+	return resp
+}
+
+// mutate mutates the object with the mutator, if the mutator panics and the webhook has a fallback
+// mutator, the fallback mutator will mutate the object as it was before the panic.
+func (w mutationWebhook) mutate(ctx context.Context, obj metav1.Object) (mutated metav1.Object, err error) {
+	if w.cfg.PanicFallbackMutator == nil {
+		_, err := w.mutator.Mutate(ctx, obj)
+		return obj, err
+	}
+
+	robj, ok := obj.(runtime.Object)
+	if !ok {
+		return nil, fmt.Errorf("impossible to type assert the object to runtime.Object")
+	}
+	original, ok := robj.DeepCopyObject().(metav1.Object)
+	if !ok {
+		return nil, fmt.Errorf("impossible to type assert the deep copy to metav1.Object")
+	}
+
+	defer func() {
+		if r := recover(); r != nil {
+			w.logger.Errorf("mutator panicked, using the fallback mutator: %v", r)
+			if rec, ok := w.metricsRecorder.(metrics.FailOpenRecorder); ok {
+				rec.IncWebhookFailOpen(w.cfg.Name, metrics.FailOpenReasonPanic)
+			}
+			// The changes of the panicked mutation are discarded.
+			if am := getAppliedMutators(ctx); am != nil {
+				am.names = nil
+			}
+			_, err = w.cfg.PanicFallbackMutator.Mutate(ctx, original)
+			mutated = original
+		}
+	}()
+
+	_, err = w.mutator.Mutate(ctx, obj)
+	return obj, err
+}
+
+// skipAnnotationPresent returns the first skip annotation key present on the object, the skip
+// annotations with an empty value match any value.
+func skipAnnotationPresent(obj metav1.Object, skip map[string]string) (string, bool) {
+	annotations := obj.GetAnnotations()
+	for k, v := range skip {
+		if av, ok := annotations[k]; ok && (v == "" || v == av) {
+			return k, true
+		}
+	}
+
+	return "", false
 }
 
 func (w mutationWebhook) toAdmissionErrorResponse(ar *admissionv1beta1.AdmissionReview, err error) *admissionv1beta1.AdmissionResponse {
 	return helpers.ToAdmissionErrorResponse(ar.Request.UID, err, w.logger)
 }
 
+// objectGroupKind returns the group and kind of the reviewed object.
+func objectGroupKind(ar *admissionv1beta1.AdmissionReview, obj metav1.Object) schema.GroupKind {
+	if ar.Request.Kind.Kind != "" {
+		return schema.GroupKind{Group: ar.Request.Kind.Group, Kind: ar.Request.Kind.Kind}
+	}
+
+	if robj, ok := obj.(runtime.Object); ok {
+		return robj.GetObjectKind().GroupVersionKind().GroupKind()
+	}
+
+	return schema.GroupKind{}
+}
+
 // jsonPatchType is the type for Kubernetes responses type.
 var jsonPatchType = func() *admissionv1beta1.PatchType {
 	pt := admissionv1beta1.PatchTypeJSONPatch
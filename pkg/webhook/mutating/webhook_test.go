@@ -4,16 +4,32 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"strings"
 	"testing"
+	"time"
 
+	opentracing "github.com/opentracing/opentracing-go"
+	"github.com/opentracing/opentracing-go/mocktracer"
+	"github.com/prometheus/client_golang/prometheus"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
 	admissionv1beta1 "k8s.io/api/admission/v1beta1"
+	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
 
+	mmetrics "github.com/slok/kubewebhook/mocks/observability/metrics"
+	"github.com/slok/kubewebhook/pkg/internal/testutil"
 	"github.com/slok/kubewebhook/pkg/log"
+	"github.com/slok/kubewebhook/pkg/observability/metrics"
+	"github.com/slok/kubewebhook/pkg/webhook"
+	whcontext "github.com/slok/kubewebhook/pkg/webhook/context"
+	"github.com/slok/kubewebhook/pkg/webhook/internal/admissiontest"
 	"github.com/slok/kubewebhook/pkg/webhook/mutating"
 )
 
@@ -175,7 +191,7 @@ func TestPodAdmissionReviewMutation(t *testing.T) {
 		},
 
 		"A static webhook review of delete operation in a Pod should mutate the pod correctly.": {
-			cfg:     mutating.WebhookConfig{Name: "test", Obj: &corev1.Pod{}},
+			cfg:     mutating.WebhookConfig{Name: "test", Obj: &corev1.Pod{}, MutateDeletes: true},
 			mutator: getPodResourceLimitDeletorMutator(),
 			review: &admissionv1beta1.AdmissionReview{
 				Request: &admissionv1beta1.AdmissionRequest{
@@ -192,6 +208,23 @@ func TestPodAdmissionReviewMutation(t *testing.T) {
 			},
 		},
 
+		"A static webhook review of a Pod with the mutation mark enabled should mark the pod as mutated.": {
+			cfg:     mutating.WebhookConfig{Name: "test", Obj: &corev1.Pod{}, MarkMutated: true},
+			mutator: getPodNSMutator("myChangedNS"),
+			review: &admissionv1beta1.AdmissionReview{
+				Request: &admissionv1beta1.AdmissionRequest{
+					UID: "test",
+					Object: runtime.RawExtension{
+						Raw: getPodJSON(),
+					},
+				},
+			},
+			expPatch: []string{
+				`{"op":"replace","path":"/metadata/namespace","value":"myChangedNS"}`,
+				`{"op":"add","path":"/metadata/annotations/kubewebhook.slok.dev~1mutated-by","value":"test"}`,
+			},
+		},
+
 		"A dynamic webhook review of a Pod with an ns mutator should mutate the ns.": {
 			cfg:     mutating.WebhookConfig{Name: "test"},
 			mutator: getPodNSMutator("myChangedNS"),
@@ -300,7 +333,7 @@ func TestPodAdmissionReviewMutation(t *testing.T) {
 		},
 
 		"A dynamic webhook delete operation review of an unknown type should be able to mutate with the common object attributes (check unstructured object mutation).": {
-			cfg: mutating.WebhookConfig{Name: "test"},
+			cfg: mutating.WebhookConfig{Name: "test", MutateDeletes: true},
 			mutator: mutating.MutatorFunc(func(_ context.Context, obj metav1.Object) (bool, error) {
 				// Just a check to validate that is unstructured.
 				if _, ok := obj.(runtime.Unstructured); !ok {
@@ -355,11 +388,13 @@ func TestPodAdmissionReviewMutation(t *testing.T) {
 	for name, test := range tests {
 		t.Run(name, func(t *testing.T) {
 			assert := assert.New(t)
+			require := require.New(t)
 
 			wh, err := mutating.NewWebhook(test.cfg, test.mutator, nil, nil, log.Dummy)
-			assert.NoError(err)
+			require.NoError(err)
 
 			gotResponse := wh.Review(context.TODO(), test.review)
+			require.NoError(admissiontest.ValidateResponse(test.review.Request, gotResponse))
 
 			// Check uid, allowed and patch
 			assert.True(gotResponse.Allowed)
@@ -372,6 +407,86 @@ func TestPodAdmissionReviewMutation(t *testing.T) {
 	}
 }
 
+func TestMutationWebhookDecodeErrorAllowKinds(t *testing.T) {
+	tests := map[string]struct {
+		cfg        mutating.WebhookConfig
+		expAllowed bool
+		expErr     bool
+	}{
+		"A decode error of a kind not listed on the allowed kinds should return an error.": {
+			cfg: mutating.WebhookConfig{
+				Name:                  "test",
+				Obj:                   &corev1.Pod{},
+				DecodeErrorAllowKinds: []schema.GroupKind{{Group: "other.slok.dev", Kind: "House"}},
+			},
+			expAllowed: false,
+			expErr:     true,
+		},
+
+		"A decode error of a kind listed on the allowed kinds should be allowed without mutation.": {
+			cfg: mutating.WebhookConfig{
+				Name:                  "test",
+				Obj:                   &corev1.Pod{},
+				DecodeErrorAllowKinds: []schema.GroupKind{{Group: "building.slok.dev", Kind: "House"}},
+			},
+			expAllowed: true,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+			require := require.New(t)
+
+			wh, err := mutating.NewWebhook(test.cfg, getPodNSMutator("myChangedNS"), nil, nil, log.Dummy)
+			assert.NoError(err)
+
+			ar := &admissionv1beta1.AdmissionReview{
+				Request: &admissionv1beta1.AdmissionRequest{
+					UID:  "test",
+					Kind: metav1.GroupVersionKind{Group: "building.slok.dev", Version: "v1", Kind: "House"},
+					Object: runtime.RawExtension{
+						Raw: []byte(`{"kind": "House", "apiVersion": "building.slok.dev/v1", "spec": "wrong"}`),
+					},
+				},
+			}
+			gotResponse := wh.Review(context.TODO(), ar)
+			require.NoError(admissiontest.ValidateResponse(ar.Request, gotResponse))
+
+			assert.Equal(test.expAllowed, gotResponse.Allowed)
+			assert.Empty(gotResponse.Patch)
+			if test.expErr {
+				assert.Equal(metav1.StatusFailure, gotResponse.Result.Status)
+			} else {
+				assert.Nil(gotResponse.Result)
+			}
+		})
+	}
+}
+
+func TestMutationWebhookEmptyUID(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	logger := &testutil.Logger{}
+	cfg := mutating.WebhookConfig{Name: "test", Obj: &corev1.Pod{}}
+	wh, err := mutating.NewWebhook(cfg, getPodNSMutator("myChangedNS"), &opentracing.NoopTracer{}, metrics.Dummy, logger)
+	require.NoError(err)
+
+	gotResponse := wh.Review(context.TODO(), &admissionv1beta1.AdmissionReview{
+		Request: &admissionv1beta1.AdmissionRequest{
+			Namespace: "testNS",
+			Name:      "testPod",
+			Object:    runtime.RawExtension{Raw: getPodJSON()},
+		},
+	})
+
+	// Best effort response.
+	assert.True(gotResponse.Allowed)
+	assert.Contains(string(gotResponse.Patch), `{"op":"replace","path":"/metadata/namespace","value":"myChangedNS"}`)
+	assert.Equal([]string{"admission request testNS/testPod has an empty UID, the response may be rejected"}, logger.Warnings)
+}
+
 func BenchmarkPodAdmissionReviewMutation(b *testing.B) {
 	for i := 0; i < b.N; i++ {
 		mutator := getPodNSMutator("myChangedNS")
@@ -393,3 +508,1328 @@ func BenchmarkPodAdmissionReviewMutation(b *testing.B) {
 		wh.Review(context.TODO(), ar)
 	}
 }
+
+func TestMutationWebhookAllowedPatchOps(t *testing.T) {
+	tests := map[string]struct {
+		cfg         mutating.WebhookConfig
+		expAllowed  bool
+		expPatchOps []string
+		expErr      bool
+	}{
+		"Without allowed patch operations all the operations should be returned.": {
+			cfg: mutating.WebhookConfig{
+				Name: "test",
+				Obj:  &corev1.Pod{},
+			},
+			expAllowed: true,
+			expPatchOps: []string{
+				`{"op":"remove","path":"/spec/containers/0/resources/limits"}`,
+				`{"op":"remove","path":"/spec/containers/1/resources/limits"}`,
+				`{"op":"replace","path":"/metadata/namespace","value":"myChangedNS"}`,
+			},
+		},
+
+		"A not allowed operation with the drop patch policy should drop the whole patch, including the allowed operations.": {
+			cfg: mutating.WebhookConfig{
+				Name:                    "test",
+				Obj:                     &corev1.Pod{},
+				AllowedPatchOps:         []string{"add", "replace"},
+				DisallowedPatchOpPolicy: mutating.DisallowedPatchOpPolicyDropPatch,
+			},
+			expAllowed:  true,
+			expPatchOps: []string{},
+		},
+
+		"Allowed operations with the drop patch policy should not drop the patch.": {
+			cfg: mutating.WebhookConfig{
+				Name:                    "test",
+				Obj:                     &corev1.Pod{},
+				AllowedPatchOps:         []string{"remove", "replace"},
+				DisallowedPatchOpPolicy: mutating.DisallowedPatchOpPolicyDropPatch,
+			},
+			expAllowed: true,
+			expPatchOps: []string{
+				`{"op":"remove","path":"/spec/containers/0/resources/limits"}`,
+				`{"op":"remove","path":"/spec/containers/1/resources/limits"}`,
+				`{"op":"replace","path":"/metadata/namespace","value":"myChangedNS"}`,
+			},
+		},
+
+		"A not allowed operation with the error policy should return an error.": {
+			cfg: mutating.WebhookConfig{
+				Name:                    "test",
+				Obj:                     &corev1.Pod{},
+				AllowedPatchOps:         []string{"add", "replace"},
+				DisallowedPatchOpPolicy: mutating.DisallowedPatchOpPolicyError,
+			},
+			expAllowed: false,
+			expErr:     true,
+		},
+
+		"A not allowed operation with the default policy should return an error.": {
+			cfg: mutating.WebhookConfig{
+				Name:            "test",
+				Obj:             &corev1.Pod{},
+				AllowedPatchOps: []string{"add", "replace"},
+			},
+			expAllowed: false,
+			expErr:     true,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+			require := require.New(t)
+
+			mutator := mutating.NewChain(log.Dummy, getPodNSMutator("myChangedNS"), getPodResourceLimitDeletorMutator())
+			wh, err := mutating.NewWebhook(test.cfg, mutator, nil, nil, log.Dummy)
+			require.NoError(err)
+
+			ar := &admissionv1beta1.AdmissionReview{
+				Request: &admissionv1beta1.AdmissionRequest{
+					UID:    "test",
+					Object: runtime.RawExtension{Raw: getPodJSON()},
+				},
+			}
+			gotResponse := wh.Review(context.TODO(), ar)
+			require.NoError(admissiontest.ValidateResponse(ar.Request, gotResponse))
+
+			assert.Equal(test.expAllowed, gotResponse.Allowed)
+			if test.expErr {
+				assert.Equal(metav1.StatusFailure, gotResponse.Result.Status)
+				assert.Contains(gotResponse.Result.Message, `"remove" patch operation`)
+			} else {
+				var gotPatch []interface{}
+				require.NoError(json.Unmarshal(gotResponse.Patch, &gotPatch))
+				assert.Len(gotPatch, len(test.expPatchOps))
+				for _, expPatchOp := range test.expPatchOps {
+					assert.Contains(string(gotResponse.Patch), expPatchOp)
+				}
+			}
+		})
+	}
+}
+
+func TestMutationWebhookDropPatchMixedOps(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	// Adds a label and removes the resource limits.
+	labelMutator := mutating.MutatorFunc(func(_ context.Context, obj metav1.Object) (bool, error) {
+		obj.SetLabels(map[string]string{"team": "test"})
+		return false, nil
+	})
+	mutator := mutating.NewChain(log.Dummy, labelMutator, getPodResourceLimitDeletorMutator())
+
+	cfg := mutating.WebhookConfig{
+		Name:                    "test",
+		Obj:                     &corev1.Pod{},
+		AllowedPatchOps:         []string{"add"},
+		DisallowedPatchOpPolicy: mutating.DisallowedPatchOpPolicyDropPatch,
+	}
+	logger := &testutil.Logger{}
+	wh, err := mutating.NewWebhook(cfg, mutator, nil, nil, logger)
+	require.NoError(err)
+
+	ar := &admissionv1beta1.AdmissionReview{
+		Request: &admissionv1beta1.AdmissionRequest{
+			UID:    "test",
+			Object: runtime.RawExtension{Raw: getPodJSON()},
+		},
+	}
+	gotResponse := wh.Review(context.TODO(), ar)
+	require.NoError(admissiontest.ValidateResponse(ar.Request, gotResponse))
+
+	// The allowed label add operation should be dropped with the not allowed remove operations.
+	assert.True(gotResponse.Allowed)
+	assert.JSONEq(`[]`, string(gotResponse.Patch))
+	assert.Contains(logger.Warnings, `dropped the patch due to the not allowed "remove" patch operation on "/spec/containers/0/resources/limits" path for request test correlationID=test`)
+}
+
+func TestMutationWebhookInvalidAllowedPatchOpsConfig(t *testing.T) {
+	cfg := mutating.WebhookConfig{Name: "test", DisallowedPatchOpPolicy: "drop", AllowedPatchOps: []string{"add", "delete", "update"}}
+	_, err := mutating.NewWebhook(cfg, getPodNSMutator("myChangedNS"), nil, nil, log.Dummy)
+	assert.EqualError(t, err, `invalid configuration: unknown disallowed patch operation policy "drop", unknown allowed patch operation "delete", unknown allowed patch operation "update"`)
+}
+
+func TestMutationWebhookInvalidConfig(t *testing.T) {
+	cfg := mutating.WebhookConfig{KindMismatchPolicy: "wrong"}
+	_, err := mutating.NewWebhook(cfg, getPodNSMutator("myChangedNS"), nil, nil, log.Dummy)
+	assert.EqualError(t, err, `invalid configuration: name can't be empty, unknown kind mismatch policy "wrong"`)
+}
+
+func TestMutationWebhookInvalidDisallowedPatchOpPolicy(t *testing.T) {
+	cfg := mutating.WebhookConfig{Name: "test", DisallowedPatchOpPolicy: "wrong"}
+	_, err := mutating.NewWebhook(cfg, getPodNSMutator("myChangedNS"), nil, nil, log.Dummy)
+	assert.Error(t, err)
+}
+
+func TestMutationWebhookInvalidAllowedPatchOps(t *testing.T) {
+	cfg := mutating.WebhookConfig{Name: "test", AllowedPatchOps: []string{"add", "delete"}}
+	_, err := mutating.NewWebhook(cfg, getPodNSMutator("myChangedNS"), nil, nil, log.Dummy)
+	assert.Error(t, err)
+}
+
+func TestMutationWebhookRetryAfterDenial(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	// A mutator that fails closed when its dependency is unavailable.
+	m := mutating.MutatorFunc(func(_ context.Context, _ metav1.Object) (bool, error) {
+		return true, webhook.NewRetryAfterError(fmt.Errorf("registry unavailable"), 9500*time.Millisecond)
+	})
+
+	cfg := mutating.WebhookConfig{Name: "test", Obj: &corev1.Pod{}}
+	wh, err := mutating.NewWebhook(cfg, m, nil, nil, log.Dummy)
+	require.NoError(err)
+
+	ar := &admissionv1beta1.AdmissionReview{
+		Request: &admissionv1beta1.AdmissionRequest{
+			UID:    "test",
+			Object: runtime.RawExtension{Raw: getPodJSON()},
+		},
+	}
+	gotResponse := wh.Review(context.TODO(), ar)
+	require.NoError(admissiontest.ValidateResponse(ar.Request, gotResponse))
+
+	expResponse := &admissionv1beta1.AdmissionResponse{
+		UID:     "test",
+		Allowed: false,
+		Result: &metav1.Status{
+			Status:  metav1.StatusFailure,
+			Message: "registry unavailable (retry after 10s)",
+			Reason:  metav1.StatusReasonServerTimeout,
+			Code:    500,
+			Details: &metav1.StatusDetails{RetryAfterSeconds: 10},
+		},
+	}
+	assert.Equal(expResponse, gotResponse)
+}
+
+func TestMutationWebhookNoOpMetric(t *testing.T) {
+	tests := map[string]struct {
+		mutator   mutating.Mutator
+		operation admissionv1beta1.Operation
+		kind      string
+		expNoOp   bool
+		expPatch  bool
+	}{
+		"A mutation that doesn't change the object should increment the no-op counter.": {
+			mutator: getPodNSMutator("testNS"),
+			expNoOp: true,
+		},
+
+		"A mutation that changes the object should not increment the no-op counter.": {
+			mutator:  getPodNSMutator("myChangedNS"),
+			expPatch: true,
+		},
+
+		"A delete operation allowed without mutation should not increment the no-op counter.": {
+			mutator:   getPodNSMutator("testNS"),
+			operation: admissionv1beta1.Delete,
+		},
+
+		"A kind mismatch allowed without mutation should not increment the no-op counter.": {
+			mutator: getPodNSMutator("testNS"),
+			kind:    "Deployment",
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+			require := require.New(t)
+
+			mrec := &mmetrics.Recorder{}
+			testutil.IgnoreRecorderCalls(mrec, "IncAdmissionReview", "ObserveAdmissionReviewDuration", "ObserveAdmissionReviewObjectSize", "IncValidationReviewResult", "IncWebhookFailOpen")
+			if test.expNoOp {
+				mrec.On("IncMutationNoOp", "test").Once()
+			}
+
+			cfg := mutating.WebhookConfig{Name: "test", Obj: &corev1.Pod{}, KindMismatchPolicy: webhook.KindMismatchPolicyAllow}
+			wh, err := mutating.NewWebhook(cfg, test.mutator, &opentracing.NoopTracer{}, mrec, log.Dummy)
+			require.NoError(err)
+
+			ar := &admissionv1beta1.AdmissionReview{
+				Request: &admissionv1beta1.AdmissionRequest{
+					UID:       "test",
+					Operation: test.operation,
+					Kind:      metav1.GroupVersionKind{Version: "v1", Kind: test.kind},
+					Object:    runtime.RawExtension{Raw: getPodJSON()},
+					OldObject: runtime.RawExtension{Raw: getPodJSON()},
+				},
+			}
+			gotResponse := wh.Review(context.TODO(), ar)
+			require.NoError(admissiontest.ValidateResponse(ar.Request, gotResponse))
+
+			assert.True(gotResponse.Allowed)
+			gotPatch := string(gotResponse.Patch)
+			assert.Equal(test.expPatch, gotPatch != "" && gotPatch != "[]")
+			mrec.AssertExpectations(t)
+			if !test.expNoOp {
+				mrec.AssertNotCalled(t, "IncMutationNoOp", mock.Anything)
+			}
+		})
+	}
+}
+
+func TestMutationWebhookSelfTestMetrics(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	// The mock recorder will fail on any call.
+	mrec := &mmetrics.Recorder{}
+
+	cfg := mutating.WebhookConfig{Name: "test", Obj: &corev1.Pod{}}
+	wh, err := mutating.NewWebhook(cfg, getPodNSMutator("testNS"), &opentracing.NoopTracer{}, mrec, log.Dummy)
+	require.NoError(err)
+
+	ar := &admissionv1beta1.AdmissionReview{
+		Request: &admissionv1beta1.AdmissionRequest{
+			UID:    "test",
+			Object: runtime.RawExtension{Raw: getPodJSON()},
+		},
+	}
+	gotResponse := wh.Review(whcontext.SetSelfTest(context.TODO()), ar)
+	require.NoError(admissiontest.ValidateResponse(ar.Request, gotResponse))
+
+	// The self-test reviews should not be measured.
+	assert.True(gotResponse.Allowed)
+	mrec.AssertExpectations(t)
+}
+
+func TestMutationWebhookSlowThreshold(t *testing.T) {
+	slowMutator := mutating.MutatorFunc(func(_ context.Context, obj metav1.Object) (bool, error) {
+		time.Sleep(20 * time.Millisecond)
+		return false, nil
+	})
+
+	tests := map[string]struct {
+		slowThreshold time.Duration
+		expSlow       bool
+	}{
+		"A review slower than the slow threshold should be marked as slow.": {
+			slowThreshold: 5 * time.Millisecond,
+			expSlow:       true,
+		},
+
+		"A review faster than the slow threshold should not be marked as slow.": {
+			slowThreshold: time.Minute,
+			expSlow:       false,
+		},
+
+		"A review without slow threshold should not be marked as slow.": {
+			expSlow: false,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+			require := require.New(t)
+
+			mrec := &mmetrics.Recorder{}
+			testutil.IgnoreRecorderCalls(mrec, "IncAdmissionReview", "ObserveAdmissionReviewDuration", "ObserveAdmissionReviewObjectSize", "IncMutationNoOp")
+			if test.expSlow {
+				mrec.On("IncAdmissionReviewSlow", "test").Once()
+			}
+
+			cfg := mutating.WebhookConfig{Name: "test", Obj: &corev1.Pod{}, SlowThreshold: test.slowThreshold}
+			wh, err := mutating.NewWebhook(cfg, slowMutator, &opentracing.NoopTracer{}, mrec, log.Dummy)
+			require.NoError(err)
+
+			ar := &admissionv1beta1.AdmissionReview{
+				Request: &admissionv1beta1.AdmissionRequest{
+					UID:    "test",
+					Object: runtime.RawExtension{Raw: getPodJSON()},
+				},
+			}
+			gotResponse := wh.Review(context.TODO(), ar)
+			require.NoError(admissiontest.ValidateResponse(ar.Request, gotResponse))
+
+			assert.True(gotResponse.Allowed)
+			mrec.AssertExpectations(t)
+			if test.expSlow {
+				require.Len(gotResponse.Warnings, 1)
+				assert.Contains(gotResponse.Warnings[0], `webhook "test" review took`)
+				assert.Contains(gotResponse.Warnings[0], "more than the 5ms slow threshold")
+			} else {
+				assert.Empty(gotResponse.Warnings)
+				mrec.AssertNotCalled(t, "IncAdmissionReviewSlow", mock.Anything)
+			}
+		})
+	}
+}
+
+func TestMutationWebhookInvalidSlowThreshold(t *testing.T) {
+	cfg := mutating.WebhookConfig{Obj: &corev1.Pod{}, SlowThreshold: -time.Second}
+	_, err := mutating.NewWebhook(cfg, getPodNSMutator("test"), nil, nil, log.Dummy)
+	assert.EqualError(t, err, "invalid configuration: name can't be empty, slow threshold can't be negative")
+}
+
+func TestMutationWebhookDeadlineWarning(t *testing.T) {
+	slowMutator := mutating.MutatorFunc(func(_ context.Context, obj metav1.Object) (bool, error) {
+		time.Sleep(20 * time.Millisecond)
+		return false, nil
+	})
+
+	tests := map[string]struct {
+		expectedTimeout      time.Duration
+		deadlineWarningRatio float64
+		expWarning           string
+	}{
+		"A review crossing the deadline warning ratio of the expected timeout should have a warning.": {
+			expectedTimeout:      30 * time.Millisecond,
+			deadlineWarningRatio: 0.5,
+			expWarning:           "more than 50% of the 30ms expected timeout",
+		},
+
+		"A review crossing the default deadline warning ratio of the expected timeout should have a warning.": {
+			expectedTimeout: 24 * time.Millisecond,
+			expWarning:      "more than 80% of the 24ms expected timeout",
+		},
+
+		"A review not crossing the deadline warning ratio of the expected timeout should not have a warning.": {
+			expectedTimeout:      time.Minute,
+			deadlineWarningRatio: 0.5,
+		},
+
+		"A review without expected timeout should not have a warning.": {},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+			require := require.New(t)
+
+			mrec := &mmetrics.Recorder{}
+			testutil.IgnoreRecorderCalls(mrec, "IncAdmissionReview", "ObserveAdmissionReviewDuration", "ObserveAdmissionReviewObjectSize", "IncMutationNoOp")
+			if test.expWarning != "" {
+				mrec.On("IncAdmissionReviewNearDeadline", "test").Once()
+			}
+
+			cfg := mutating.WebhookConfig{
+				Name:                 "test",
+				Obj:                  &corev1.Pod{},
+				ExpectedTimeout:      test.expectedTimeout,
+				DeadlineWarningRatio: test.deadlineWarningRatio,
+			}
+			wh, err := mutating.NewWebhook(cfg, slowMutator, &opentracing.NoopTracer{}, mrec, log.Dummy)
+			require.NoError(err)
+
+			ar := &admissionv1beta1.AdmissionReview{
+				Request: &admissionv1beta1.AdmissionRequest{
+					UID:    "test",
+					Object: runtime.RawExtension{Raw: getPodJSON()},
+				},
+			}
+			gotResponse := wh.Review(context.TODO(), ar)
+			require.NoError(admissiontest.ValidateResponse(ar.Request, gotResponse))
+
+			assert.True(gotResponse.Allowed)
+			mrec.AssertExpectations(t)
+			if test.expWarning != "" {
+				require.Len(gotResponse.Warnings, 1)
+				assert.Contains(gotResponse.Warnings[0], `webhook "test" review took`)
+				assert.Contains(gotResponse.Warnings[0], test.expWarning)
+			} else {
+				assert.Empty(gotResponse.Warnings)
+				mrec.AssertNotCalled(t, "IncAdmissionReviewNearDeadline", mock.Anything)
+			}
+		})
+	}
+}
+
+func TestMutationWebhookInvalidDeadlineWarning(t *testing.T) {
+	tests := map[string]struct {
+		cfg    mutating.WebhookConfig
+		expErr string
+	}{
+		"A negative expected timeout should fail.": {
+			cfg:    mutating.WebhookConfig{Name: "test", ExpectedTimeout: -time.Second},
+			expErr: "invalid configuration: expected timeout can't be negative",
+		},
+
+		"A negative deadline warning ratio should fail.": {
+			cfg:    mutating.WebhookConfig{Name: "test", ExpectedTimeout: time.Second, DeadlineWarningRatio: -0.5},
+			expErr: "invalid configuration: deadline warning ratio can't be negative",
+		},
+
+		"A deadline warning ratio greater than 1 should fail.": {
+			cfg:    mutating.WebhookConfig{Name: "test", ExpectedTimeout: time.Second, DeadlineWarningRatio: 1.5},
+			expErr: "invalid configuration: deadline warning ratio can't be greater than 1",
+		},
+
+		"A negative expected timeout and an invalid deadline warning ratio should fail with both errors.": {
+			cfg:    mutating.WebhookConfig{Name: "test", ExpectedTimeout: -time.Second, DeadlineWarningRatio: 1.5},
+			expErr: "invalid configuration: expected timeout can't be negative, deadline warning ratio can't be greater than 1",
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			_, err := mutating.NewWebhook(test.cfg, getPodNSMutator("test"), nil, nil, log.Dummy)
+			assert.EqualError(t, err, test.expErr)
+		})
+	}
+}
+
+func TestMutationWebhookAuditMutatorsApplied(t *testing.T) {
+	setLabel := func(k, v string) mutating.Mutator {
+		return mutating.MutatorFunc(func(_ context.Context, obj metav1.Object) (bool, error) {
+			labels := obj.GetLabels()
+			if labels == nil {
+				labels = map[string]string{}
+			}
+			labels[k] = v
+			obj.SetLabels(labels)
+			return false, nil
+		})
+	}
+	noop := mutating.MutatorFunc(func(_ context.Context, obj metav1.Object) (bool, error) { return false, nil })
+
+	tests := map[string]struct {
+		audit              bool
+		mutators           []mutating.Mutator
+		expAuditAnnotation map[string]string
+	}{
+		"Only the mutators that modified the object should be on the audit annotation.": {
+			audit: true,
+			mutators: []mutating.Mutator{
+				mutating.ChainMutator{Name: "label-a", Mutator: setLabel("a", "1")},
+				mutating.ChainMutator{Name: "noop", Mutator: noop},
+				mutating.ChainMutator{Name: "label-b", Mutator: setLabel("b", "2")},
+				mutating.ChainMutator{Name: "label-a-again", Mutator: setLabel("a", "1")},
+			},
+			expAuditAnnotation: map[string]string{"mutators-applied": "label-a,label-b"},
+		},
+
+		"Unnamed mutators should not be on the audit annotation.": {
+			audit: true,
+			mutators: []mutating.Mutator{
+				setLabel("a", "1"),
+				mutating.ChainMutator{Name: "label-b", Mutator: setLabel("b", "2")},
+			},
+			expAuditAnnotation: map[string]string{"mutators-applied": "label-b"},
+		},
+
+		"If no mutator modified the object it should not have the audit annotation.": {
+			audit: true,
+			mutators: []mutating.Mutator{
+				mutating.ChainMutator{Name: "noop", Mutator: noop},
+			},
+		},
+
+		"If disabled it should not have the audit annotation.": {
+			audit: false,
+			mutators: []mutating.Mutator{
+				mutating.ChainMutator{Name: "label-a", Mutator: setLabel("a", "1")},
+			},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+			require := require.New(t)
+
+			cfg := mutating.WebhookConfig{Name: "test", Obj: &corev1.Pod{}, AuditMutatorsApplied: test.audit}
+			wh, err := mutating.NewWebhook(cfg, mutating.NewChain(log.Dummy, test.mutators...), nil, nil, log.Dummy)
+			require.NoError(err)
+
+			ar := &admissionv1beta1.AdmissionReview{
+				Request: &admissionv1beta1.AdmissionRequest{
+					UID:    "test",
+					Object: runtime.RawExtension{Raw: getPodJSON()},
+				},
+			}
+			gotResponse := wh.Review(context.TODO(), ar)
+			require.NoError(admissiontest.ValidateResponse(ar.Request, gotResponse))
+
+			assert.True(gotResponse.Allowed)
+			assert.Equal(test.expAuditAnnotation, gotResponse.AuditAnnotations)
+		})
+	}
+}
+
+func TestMutationWebhookFailOpenMetric(t *testing.T) {
+	panicMutator := mutating.MutatorFunc(func(_ context.Context, obj metav1.Object) (bool, error) {
+		panic("wanted panic")
+	})
+
+	tests := map[string]struct {
+		cfg       mutating.WebhookConfig
+		mutator   mutating.Mutator
+		kind      metav1.GroupVersionKind
+		raw       []byte
+		expReason string
+	}{
+		"A kind mismatch allowed by the policy should increment the fail-open counter.": {
+			cfg:       mutating.WebhookConfig{Name: "test", Obj: &corev1.Pod{}, KindMismatchPolicy: webhook.KindMismatchPolicyAllow},
+			mutator:   getPodNSMutator("myChangedNS"),
+			kind:      metav1.GroupVersionKind{Group: "apps", Version: "v1", Kind: "Deployment"},
+			raw:       getPodJSON(),
+			expReason: metrics.FailOpenReasonKindMismatch,
+		},
+
+		"A decode error allowed by the kind should increment the fail-open counter.": {
+			cfg: mutating.WebhookConfig{
+				Name:                  "test",
+				Obj:                   &corev1.Pod{},
+				DecodeErrorAllowKinds: []schema.GroupKind{{Group: "building.slok.dev", Kind: "House"}},
+			},
+			mutator:   getPodNSMutator("myChangedNS"),
+			kind:      metav1.GroupVersionKind{Group: "building.slok.dev", Version: "v1", Kind: "House"},
+			raw:       []byte(`{"kind": "House", "apiVersion": "building.slok.dev/v1", "spec": "wrong"}`),
+			expReason: metrics.FailOpenReasonDecodeError,
+		},
+
+		"A panicking mutator with fallback should increment the fail-open counter.": {
+			cfg:       mutating.WebhookConfig{Name: "test", Obj: &corev1.Pod{}, PanicFallbackMutator: getPodNSMutator("testNS")},
+			mutator:   panicMutator,
+			raw:       getPodJSON(),
+			expReason: metrics.FailOpenReasonPanic,
+		},
+
+		"A regular mutation should not increment the fail-open counter.": {
+			cfg:     mutating.WebhookConfig{Name: "test", Obj: &corev1.Pod{}, KindMismatchPolicy: webhook.KindMismatchPolicyAllow},
+			mutator: getPodNSMutator("myChangedNS"),
+			kind:    metav1.GroupVersionKind{Version: "v1", Kind: "Pod"},
+			raw:     getPodJSON(),
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+			require := require.New(t)
+
+			mrec := &mmetrics.Recorder{}
+			testutil.IgnoreRecorderCalls(mrec, "IncAdmissionReview", "ObserveAdmissionReviewDuration", "ObserveAdmissionReviewObjectSize", "IncMutationNoOp", "IncValidationReviewResult")
+			if test.expReason != "" {
+				mrec.On("IncWebhookFailOpen", "test", test.expReason).Once()
+			}
+
+			wh, err := mutating.NewWebhook(test.cfg, test.mutator, nil, mrec, log.Dummy)
+			require.NoError(err)
+
+			ar := &admissionv1beta1.AdmissionReview{
+				Request: &admissionv1beta1.AdmissionRequest{
+					UID:    "test",
+					Kind:   test.kind,
+					Object: runtime.RawExtension{Raw: test.raw},
+				},
+			}
+			gotResponse := wh.Review(context.TODO(), ar)
+			require.NoError(admissiontest.ValidateResponse(ar.Request, gotResponse))
+
+			assert.True(gotResponse.Allowed)
+			mrec.AssertExpectations(t)
+			if test.expReason == "" {
+				mrec.AssertNotCalled(t, "IncWebhookFailOpen", mock.Anything, mock.Anything)
+			}
+		})
+	}
+}
+
+func TestMutationWebhookDeleteOperation(t *testing.T) {
+	tests := map[string]struct {
+		mutateDeletes bool
+		operation     admissionv1beta1.Operation
+		expPatch      bool
+	}{
+		"A delete operation should be allowed without patch by default.": {
+			operation: admissionv1beta1.Delete,
+			expPatch:  false,
+		},
+
+		"A delete operation should be mutated if explicitly allowed.": {
+			mutateDeletes: true,
+			operation:     admissionv1beta1.Delete,
+			expPatch:      true,
+		},
+
+		"A create operation should be mutated.": {
+			operation: admissionv1beta1.Create,
+			expPatch:  true,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+			require := require.New(t)
+
+			cfg := mutating.WebhookConfig{Name: "test", Obj: &corev1.Pod{}, MutateDeletes: test.mutateDeletes}
+			wh, err := mutating.NewWebhook(cfg, getPodNSMutator("myChangedNS"), nil, nil, log.Dummy)
+			require.NoError(err)
+
+			ar := &admissionv1beta1.AdmissionReview{
+				Request: &admissionv1beta1.AdmissionRequest{
+					UID:       "test",
+					Operation: test.operation,
+				},
+			}
+			if test.operation == admissionv1beta1.Delete {
+				ar.Request.OldObject = runtime.RawExtension{Raw: getPodJSON()}
+			} else {
+				ar.Request.Object = runtime.RawExtension{Raw: getPodJSON()}
+			}
+			gotResponse := wh.Review(context.TODO(), ar)
+			require.NoError(admissiontest.ValidateResponse(ar.Request, gotResponse))
+
+			assert.True(gotResponse.Allowed)
+			if test.expPatch {
+				assert.Contains(string(gotResponse.Patch), `{"op":"replace","path":"/metadata/namespace","value":"myChangedNS"}`)
+			} else {
+				assert.Empty(gotResponse.Patch)
+				assert.Nil(gotResponse.PatchType)
+			}
+		})
+	}
+}
+
+func TestMutationWebhookLogRedaction(t *testing.T) {
+	secretMutator := mutating.MutatorFunc(func(_ context.Context, obj metav1.Object) (bool, error) {
+		secret := obj.(*corev1.Secret)
+		secret.StringData = map[string]string{"password": "sup3rs3cr3t"}
+		secret.Labels = map[string]string{"mutated": "true"}
+		return false, nil
+	})
+
+	tests := map[string]struct {
+		cfg          mutating.WebhookConfig
+		expInLog     string
+		expNotInLogs string
+	}{
+		"The secret data should be redacted on the logs by default.": {
+			cfg:          mutating.WebhookConfig{Name: "test", Obj: &corev1.Secret{}},
+			expInLog:     `{"op":"add","path":"/stringData","value":"REDACTED"}`,
+			expNotInLogs: "sup3rs3cr3t",
+		},
+
+		"The custom redactor should be used if set.": {
+			cfg: mutating.WebhookConfig{
+				Name:        "test",
+				Obj:         &corev1.Secret{},
+				LogRedactor: log.NewRedactor(log.RedactRule{GroupKind: schema.GroupKind{Kind: "Secret"}, Fields: []string{"metadata.labels"}}),
+			},
+			expInLog:     `{"op":"add","path":"/metadata/labels","value":"REDACTED"}`,
+			expNotInLogs: "sup3rs3cr3t",
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+			require := require.New(t)
+
+			logger := &testutil.Logger{}
+			wh, err := mutating.NewWebhook(test.cfg, secretMutator, &opentracing.NoopTracer{}, metrics.Dummy, logger)
+			require.NoError(err)
+
+			ar := &admissionv1beta1.AdmissionReview{
+				Request: &admissionv1beta1.AdmissionRequest{
+					UID:    "test",
+					Kind:   metav1.GroupVersionKind{Version: "v1", Kind: "Secret"},
+					Object: runtime.RawExtension{Raw: []byte(`{"kind":"Secret","apiVersion":"v1","metadata":{"name":"test"}}`)},
+				},
+			}
+			gotResponse := wh.Review(context.TODO(), ar)
+			require.NoError(admissiontest.ValidateResponse(ar.Request, gotResponse))
+
+			// The response patch should not be redacted.
+			assert.Contains(string(gotResponse.Patch), "sup3rs3cr3t")
+
+			logs := strings.Join(logger.Debugs, "\n")
+			assert.Contains(logs, test.expInLog)
+			assert.NotContains(logs, test.expNotInLogs)
+		})
+	}
+}
+
+func TestMutationWebhookKindMismatch(t *testing.T) {
+	podKind := metav1.GroupVersionKind{Version: "v1", Kind: "Pod"}
+	deployKind := metav1.GroupVersionKind{Group: "apps", Version: "v1", Kind: "Deployment"}
+
+	tests := map[string]struct {
+		policy     webhook.KindMismatchPolicy
+		kind       metav1.GroupVersionKind
+		expAllowed bool
+		expPatch   bool
+		expErr     bool
+	}{
+		"A request kind mismatch with the default policy should not be checked.": {
+			kind:       deployKind,
+			expAllowed: true,
+			expPatch:   true,
+		},
+
+		"A request kind mismatch with the error policy should return an error.": {
+			policy:     webhook.KindMismatchPolicyError,
+			kind:       deployKind,
+			expAllowed: false,
+			expErr:     true,
+		},
+
+		"A request kind mismatch with the allow policy should be allowed without mutation.": {
+			policy:     webhook.KindMismatchPolicyAllow,
+			kind:       deployKind,
+			expAllowed: true,
+		},
+
+		"A request kind match with the error policy should be mutated.": {
+			policy:     webhook.KindMismatchPolicyError,
+			kind:       podKind,
+			expAllowed: true,
+			expPatch:   true,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+			require := require.New(t)
+
+			cfg := mutating.WebhookConfig{Name: "test", Obj: &corev1.Pod{}, KindMismatchPolicy: test.policy}
+			wh, err := mutating.NewWebhook(cfg, getPodNSMutator("myChangedNS"), nil, nil, log.Dummy)
+			require.NoError(err)
+
+			ar := &admissionv1beta1.AdmissionReview{
+				Request: &admissionv1beta1.AdmissionRequest{
+					UID:    "test",
+					Kind:   test.kind,
+					Object: runtime.RawExtension{Raw: getPodJSON()},
+				},
+			}
+			gotResponse := wh.Review(context.TODO(), ar)
+			require.NoError(admissiontest.ValidateResponse(ar.Request, gotResponse))
+
+			assert.Equal(test.expAllowed, gotResponse.Allowed)
+			assert.Equal(test.expPatch, len(gotResponse.Patch) > 0)
+			if test.expErr {
+				assert.Equal(metav1.StatusFailure, gotResponse.Result.Status)
+				assert.Contains(gotResponse.Result.Message, "doesn't match the webhook object kind Pod")
+			}
+		})
+	}
+}
+
+func TestMutationWebhookNormalizeEmptyArrays(t *testing.T) {
+	tests := map[string]struct {
+		normalize   bool
+		mutator     mutating.Mutator
+		expPatchOps []string
+	}{
+		"Without normalization, the empty arrays and nulls should produce spurious patch operations.": {
+			normalize: false,
+			mutator:   mutating.NewChain(log.Dummy),
+			expPatchOps: []string{
+				`{"op":"add","path":"/metadata/creationTimestamp","value":null}`,
+				`{"op":"remove","path":"/spec/containers/0/args"}`,
+			},
+		},
+
+		"With normalization, the empty arrays and nulls should not produce patch operations.": {
+			normalize:   true,
+			mutator:     mutating.NewChain(log.Dummy),
+			expPatchOps: []string{},
+		},
+
+		"With normalization, the real mutations should produce patch operations.": {
+			normalize: true,
+			mutator:   getPodNSMutator("myChangedNS"),
+			expPatchOps: []string{
+				`{"op":"add","path":"/metadata/namespace","value":"myChangedNS"}`,
+			},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+			require := require.New(t)
+
+			cfg := mutating.WebhookConfig{Name: "test", Obj: &corev1.Pod{}, NormalizeEmptyArrays: test.normalize}
+			wh, err := mutating.NewWebhook(cfg, test.mutator, nil, nil, log.Dummy)
+			require.NoError(err)
+
+			ar := &admissionv1beta1.AdmissionReview{
+				Request: &admissionv1beta1.AdmissionRequest{
+					UID: "test",
+					Object: runtime.RawExtension{
+						Raw: []byte(`{"kind":"Pod","apiVersion":"v1","metadata":{"name":"test"},"spec":{"containers":[{"name":"app","args":[],"resources":{}}]},"status":{}}`),
+					},
+				},
+			}
+			gotResponse := wh.Review(context.TODO(), ar)
+			require.NoError(admissiontest.ValidateResponse(ar.Request, gotResponse))
+
+			require.True(gotResponse.Allowed)
+			var gotPatch []interface{}
+			require.NoError(json.Unmarshal(gotResponse.Patch, &gotPatch))
+			assert.Len(gotPatch, len(test.expPatchOps))
+			for _, expPatchOp := range test.expPatchOps {
+				assert.Contains(string(gotResponse.Patch), expPatchOp)
+			}
+		})
+	}
+}
+
+func TestMutationWebhookPanicFallbackMutator(t *testing.T) {
+	panicMutator := mutating.MutatorFunc(func(_ context.Context, obj metav1.Object) (bool, error) {
+		obj.SetNamespace("partiallyMutated")
+		panic("wanted panic")
+	})
+	fallbackMutator := mutating.MutatorFunc(func(_ context.Context, obj metav1.Object) (bool, error) {
+		annotations := obj.GetAnnotations()
+		annotations["slok.dev/fallback"] = "true"
+		obj.SetAnnotations(annotations)
+		return false, nil
+	})
+
+	tests := map[string]struct {
+		mutator     mutating.Mutator
+		expPatchOps []string
+	}{
+		"A panicking mutator should use the fallback mutator on the object before the panic.": {
+			mutator: panicMutator,
+			expPatchOps: []string{
+				`{"op":"add","path":"/metadata/annotations/slok.dev~1fallback","value":"true"}`,
+			},
+		},
+
+		"A regular mutator should not use the fallback mutator.": {
+			mutator: getPodNSMutator("myChangedNS"),
+			expPatchOps: []string{
+				`{"op":"replace","path":"/metadata/namespace","value":"myChangedNS"}`,
+			},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+			require := require.New(t)
+
+			cfg := mutating.WebhookConfig{Name: "test", Obj: &corev1.Pod{}, PanicFallbackMutator: fallbackMutator}
+			wh, err := mutating.NewWebhook(cfg, test.mutator, nil, nil, log.Dummy)
+			require.NoError(err)
+
+			ar := &admissionv1beta1.AdmissionReview{
+				Request: &admissionv1beta1.AdmissionRequest{
+					UID:    "test",
+					Object: runtime.RawExtension{Raw: getPodJSON()},
+				},
+			}
+			gotResponse := wh.Review(context.TODO(), ar)
+			require.NoError(admissiontest.ValidateResponse(ar.Request, gotResponse))
+
+			require.True(gotResponse.Allowed)
+			var gotPatch []interface{}
+			require.NoError(json.Unmarshal(gotResponse.Patch, &gotPatch))
+			assert.Len(gotPatch, len(test.expPatchOps))
+			for _, expPatchOp := range test.expPatchOps {
+				assert.Contains(string(gotResponse.Patch), expPatchOp)
+			}
+		})
+	}
+}
+
+func TestMutationWebhookObjectBounds(t *testing.T) {
+	nestedObj := func(depth int) []byte {
+		return []byte(`{"kind":"Pathological","apiVersion":"slok.dev/v1","metadata":{"name":"test"},"spec":` +
+			strings.Repeat(`{"a":`, depth) + `"value"` + strings.Repeat(`}`, depth) + `}`)
+	}
+	manyFieldsObj := func(fields int) []byte {
+		fs := make([]string, 0, fields)
+		for i := 0; i < fields; i++ {
+			fs = append(fs, fmt.Sprintf(`"f%d":[%d,{"x":true}]`, i, i))
+		}
+		return []byte(`{"kind":"Pathological","apiVersion":"slok.dev/v1","metadata":{"name":"test"},"spec":{` + strings.Join(fs, ",") + `}}`)
+	}
+
+	tests := map[string]struct {
+		cfg        mutating.WebhookConfig
+		raw        []byte
+		expAllowed bool
+		expMessage string
+	}{
+		"Without bounds a pathologically nested object should be allowed.": {
+			cfg:        mutating.WebhookConfig{Name: "test"},
+			raw:        nestedObj(200),
+			expAllowed: true,
+		},
+
+		"A pathologically nested object should be rejected by the depth guard.": {
+			cfg:        mutating.WebhookConfig{Name: "test", MaxObjectDepth: 32},
+			raw:        nestedObj(200),
+			expAllowed: false,
+			expMessage: "object exceeds the max nesting depth of 32",
+		},
+
+		"An object inside the depth bound should be allowed.": {
+			cfg:        mutating.WebhookConfig{Name: "test", MaxObjectDepth: 32},
+			raw:        nestedObj(10),
+			expAllowed: true,
+		},
+
+		"An object with too many fields should be rejected by the fields guard.": {
+			cfg:        mutating.WebhookConfig{Name: "test", MaxObjectFields: 100},
+			raw:        manyFieldsObj(100),
+			expAllowed: false,
+			expMessage: "object exceeds the max number of fields of 100",
+		},
+
+		"An object inside the fields bound should be allowed.": {
+			cfg:        mutating.WebhookConfig{Name: "test", MaxObjectFields: 100},
+			raw:        manyFieldsObj(40),
+			expAllowed: true,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+			require := require.New(t)
+
+			wh, err := mutating.NewWebhook(test.cfg, mutating.NewChain(log.Dummy), nil, nil, log.Dummy)
+			require.NoError(err)
+
+			ar := &admissionv1beta1.AdmissionReview{
+				Request: &admissionv1beta1.AdmissionRequest{
+					UID:    "test",
+					Object: runtime.RawExtension{Raw: test.raw},
+				},
+			}
+			gotResponse := wh.Review(context.TODO(), ar)
+			require.NoError(admissiontest.ValidateResponse(ar.Request, gotResponse))
+
+			assert.Equal(test.expAllowed, gotResponse.Allowed)
+			if !test.expAllowed {
+				require.NotNil(gotResponse.Result)
+				assert.Contains(gotResponse.Result.Message, test.expMessage)
+			}
+		})
+	}
+}
+
+func TestMutationWebhookInvalidObjectGuard(t *testing.T) {
+	tests := map[string]struct {
+		cfg    mutating.WebhookConfig
+		expErr string
+	}{
+		"A negative max object depth should fail.": {
+			cfg:    mutating.WebhookConfig{Name: "test", MaxObjectDepth: -1},
+			expErr: "invalid configuration: max object depth can't be negative",
+		},
+
+		"A negative max object fields should fail.": {
+			cfg:    mutating.WebhookConfig{Name: "test", MaxObjectFields: -1},
+			expErr: "invalid configuration: max object fields can't be negative",
+		},
+
+		"A negative max object depth and fields should fail with both errors.": {
+			cfg:    mutating.WebhookConfig{Name: "test", MaxObjectDepth: -1, MaxObjectFields: -1},
+			expErr: "invalid configuration: max object depth can't be negative, max object fields can't be negative",
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			_, err := mutating.NewWebhook(test.cfg, mutating.NewChain(log.Dummy), nil, nil, log.Dummy)
+			assert.EqualError(t, err, test.expErr)
+		})
+	}
+}
+
+func TestMutationWebhookValidatePatchRoundTrip(t *testing.T) {
+	setReplicas := func(replicas interface{}) mutating.Mutator {
+		return mutating.MutatorFunc(func(_ context.Context, obj metav1.Object) (bool, error) {
+			u := obj.(*unstructured.Unstructured)
+			return false, unstructured.SetNestedField(u.Object, replicas, "spec", "replicas")
+		})
+	}
+
+	tests := map[string]struct {
+		obj        metav1.Object
+		validate   bool
+		mutator    mutating.Mutator
+		raw        []byte
+		expAllowed bool
+		expPatch   string
+	}{
+		"A type-invalid mutation without round-trip validation should return the patch.": {
+			mutator:    setReplicas("three"),
+			raw:        []byte(`{"kind":"Deployment","apiVersion":"apps/v1","metadata":{"name":"test"},"spec":{"replicas":1}}`),
+			expAllowed: true,
+			expPatch:   `[{"op":"replace","path":"/spec/replicas","value":"three"}]`,
+		},
+
+		"A type-invalid mutation with round-trip validation should return an error.": {
+			validate:   true,
+			mutator:    setReplicas("three"),
+			raw:        []byte(`{"kind":"Deployment","apiVersion":"apps/v1","metadata":{"name":"test"},"spec":{"replicas":1}}`),
+			expAllowed: false,
+		},
+
+		"A valid mutation with round-trip validation should return the patch.": {
+			validate:   true,
+			mutator:    setReplicas(int64(3)),
+			raw:        []byte(`{"kind":"Deployment","apiVersion":"apps/v1","metadata":{"name":"test"},"spec":{"replicas":1}}`),
+			expAllowed: true,
+			expPatch:   `[{"op":"replace","path":"/spec/replicas","value":3}]`,
+		},
+
+		"A mutation of a kind not registered on the scheme with round-trip validation should return the patch.": {
+			validate:   true,
+			mutator:    setReplicas("three"),
+			raw:        []byte(`{"kind":"House","apiVersion":"building.slok.dev/v1","metadata":{"name":"test"},"spec":{"replicas":1}}`),
+			expAllowed: true,
+			expPatch:   `[{"op":"replace","path":"/spec/replicas","value":"three"}]`,
+		},
+
+		"A mutation of an object without kind and version with round-trip validation should return the patch.": {
+			obj:      &corev1.Pod{},
+			validate: true,
+			mutator: mutating.MutatorFunc(func(_ context.Context, obj metav1.Object) (bool, error) {
+				obj.SetLabels(map[string]string{"team": "test"})
+				return false, nil
+			}),
+			raw:        []byte(`{"metadata":{"name":"test","creationTimestamp":null},"spec":{"containers":null},"status":{}}`),
+			expAllowed: true,
+			expPatch:   `[{"op":"add","path":"/metadata/labels","value":{"team":"test"}}]`,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+			require := require.New(t)
+
+			mrec := &mmetrics.Recorder{}
+			testutil.IgnoreRecorderCalls(mrec, "IncAdmissionReview", "ObserveAdmissionReviewDuration", "ObserveAdmissionReviewObjectSize", "IncMutationNoOp", "IncValidationReviewResult")
+			if !test.expAllowed {
+				mrec.On("IncAdmissionReviewError", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).Once()
+				mrec.On("IncPatchRoundTripError", "test").Once()
+			}
+
+			obj := test.obj
+			if obj == nil {
+				obj = &unstructured.Unstructured{}
+			}
+			cfg := mutating.WebhookConfig{Name: "test", Obj: obj, ValidatePatchRoundTrip: test.validate}
+			wh, err := mutating.NewWebhook(cfg, test.mutator, nil, mrec, log.Dummy)
+			require.NoError(err)
+
+			ar := &admissionv1beta1.AdmissionReview{
+				Request: &admissionv1beta1.AdmissionRequest{
+					UID:    "test",
+					Object: runtime.RawExtension{Raw: test.raw},
+				},
+			}
+			gotResponse := wh.Review(context.TODO(), ar)
+			require.NoError(admissiontest.ValidateResponse(ar.Request, gotResponse))
+
+			assert.Equal(test.expAllowed, gotResponse.Allowed)
+			if test.expAllowed {
+				assert.Equal(test.expPatch, string(gotResponse.Patch))
+			} else {
+				assert.Contains(gotResponse.Result.Message, "mutation patch doesn't produce a valid object")
+			}
+			mrec.AssertExpectations(t)
+		})
+	}
+}
+
+func TestMutationWebhookSkipIfAnnotationPresent(t *testing.T) {
+	tests := map[string]struct {
+		skip        map[string]string
+		annotations map[string]string
+		expPatch    bool
+	}{
+		"An object without the skip annotation should be mutated.": {
+			skip:        map[string]string{"mutated-by": "other"},
+			annotations: map[string]string{"app": "test"},
+			expPatch:    true,
+		},
+
+		"An object with the skip annotation should not be mutated.": {
+			skip:        map[string]string{"mutated-by": "other"},
+			annotations: map[string]string{"mutated-by": "other"},
+			expPatch:    false,
+		},
+
+		"An object with the skip annotation key and a different value should be mutated.": {
+			skip:        map[string]string{"mutated-by": "other"},
+			annotations: map[string]string{"mutated-by": "another"},
+			expPatch:    true,
+		},
+
+		"An object with the skip annotation key and any value should not be mutated if the skip value is empty.": {
+			skip:        map[string]string{"mutated-by": ""},
+			annotations: map[string]string{"mutated-by": "another"},
+			expPatch:    false,
+		},
+
+		"An object with any of the skip annotations should not be mutated.": {
+			skip:        map[string]string{"mutated-by": "other", "managed-by": "other"},
+			annotations: map[string]string{"managed-by": "other"},
+			expPatch:    false,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+			require := require.New(t)
+
+			cfg := mutating.WebhookConfig{Name: "test", Obj: &corev1.Pod{}, SkipIfAnnotationPresent: test.skip}
+			wh, err := mutating.NewWebhook(cfg, getPodNSMutator("myChangedNS"), nil, nil, log.Dummy)
+			require.NoError(err)
+
+			pod := &corev1.Pod{
+				ObjectMeta: metav1.ObjectMeta{Name: "test", Namespace: "test", Annotations: test.annotations},
+			}
+			raw, err := json.Marshal(pod)
+			require.NoError(err)
+
+			ar := &admissionv1beta1.AdmissionReview{
+				Request: &admissionv1beta1.AdmissionRequest{
+					UID:    "test",
+					Object: runtime.RawExtension{Raw: raw},
+				},
+			}
+			gotResponse := wh.Review(context.TODO(), ar)
+			require.NoError(admissiontest.ValidateResponse(ar.Request, gotResponse))
+
+			assert.True(gotResponse.Allowed)
+			if test.expPatch {
+				assert.Contains(string(gotResponse.Patch), `{"op":"replace","path":"/metadata/namespace","value":"myChangedNS"}`)
+			} else {
+				assert.Empty(gotResponse.Patch)
+			}
+		})
+	}
+}
+
+func TestMutationWebhookTraceBaggage(t *testing.T) {
+	tests := map[string]struct {
+		traceBaggage bool
+		expBaggage   map[string]string
+	}{
+		"Without trace baggage the mutator context should not have the review baggage.": {
+			traceBaggage: false,
+			expBaggage: map[string]string{
+				webhook.BaggageGroupKey:     "",
+				webhook.BaggageVersionKey:   "",
+				webhook.BaggageKindKey:      "",
+				webhook.BaggageOperationKey: "",
+			},
+		},
+
+		"With trace baggage the mutator context should have the review baggage.": {
+			traceBaggage: true,
+			expBaggage: map[string]string{
+				webhook.BaggageGroupKey:     "apps",
+				webhook.BaggageVersionKey:   "v1",
+				webhook.BaggageKindKey:      "Deployment",
+				webhook.BaggageOperationKey: "CREATE",
+			},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+			require := require.New(t)
+
+			gotBaggage := map[string]string{}
+			m := mutating.MutatorFunc(func(ctx context.Context, obj metav1.Object) (bool, error) {
+				span := opentracing.SpanFromContext(ctx)
+				require.NotNil(span)
+				for k := range test.expBaggage {
+					gotBaggage[k] = span.BaggageItem(k)
+				}
+				return false, nil
+			})
+
+			cfg := mutating.WebhookConfig{Name: "test", Obj: &appsv1.Deployment{}, TraceBaggage: test.traceBaggage}
+			wh, err := mutating.NewWebhook(cfg, m, mocktracer.New(), nil, log.Dummy)
+			require.NoError(err)
+
+			ar := &admissionv1beta1.AdmissionReview{
+				Request: &admissionv1beta1.AdmissionRequest{
+					UID:       "test",
+					Kind:      metav1.GroupVersionKind{Group: "apps", Version: "v1", Kind: "Deployment"},
+					Operation: admissionv1beta1.Create,
+					Object:    runtime.RawExtension{Raw: []byte(`{"kind":"Deployment","apiVersion":"apps/v1","metadata":{"name":"test"}}`)},
+				},
+			}
+			gotResponse := wh.Review(context.TODO(), ar)
+			require.NoError(admissiontest.ValidateResponse(ar.Request, gotResponse))
+
+			assert.True(gotResponse.Allowed)
+			assert.Equal(test.expBaggage, gotBaggage)
+		})
+	}
+}
+
+func TestMutationWebhookConfigView(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	cfg := mutating.WebhookConfig{
+		Name:                    "test",
+		Obj:                     &corev1.Pod{},
+		DecodeErrorAllowKinds:   []schema.GroupKind{{Group: "slok.dev", Kind: "Custom"}},
+		MarkMutated:             true,
+		Schema:                  mutating.SchemaFunc(func(_ []byte) error { return nil }),
+		AllowedPatchOps:         []string{"add"},
+		MaxObjectDepth:          32,
+		SkipIfAnnotationPresent: map[string]string{"mutated-by": "other"},
+		LogRedactor:             log.NewRedactor(log.RedactRule{GroupKind: schema.GroupKind{Kind: "Secret"}, Fields: []string{"data.password"}}),
+	}
+	wh, err := mutating.NewWebhook(cfg, mutating.NewChain(log.Dummy), &opentracing.NoopTracer{}, metrics.NewPrometheus(prometheus.NewRegistry()), log.Dummy)
+	require.NoError(err)
+
+	gotView, ok := webhook.GetConfigView(wh)
+	require.True(ok)
+
+	expView := webhook.ConfigView{
+		Name:           "test",
+		Kind:           "mutating",
+		Object:         "Pod",
+		MetricsEnabled: true,
+		TracingEnabled: false,
+		Options: map[string]interface{}{
+			"decodeErrorAllowKinds":   []string{"Custom.slok.dev"},
+			"markMutated":             true,
+			"schemaEnabled":           true,
+			"slowThreshold":           "0s",
+			"expectedTimeout":         "0s",
+			"deadlineWarningRatio":    float64(0),
+			"allowedPatchOps":         []string{"add"},
+			"disallowedPatchOpPolicy": "error",
+			"kindMismatchPolicy":      "ignore",
+			"normalizeEmptyArrays":    false,
+			"maxObjectDepth":          32,
+			"maxObjectFields":         0,
+			"panicFallbackEnabled":    false,
+			"auditMutatorsApplied":    false,
+			"mutateDeletes":           false,
+			"validatePatchRoundTrip":  false,
+			"skipIfAnnotationPresent": map[string]string{"mutated-by": "other"},
+			"instrumentOwnerKind":     false,
+			"traceBaggage":            false,
+		},
+	}
+	assert.Equal(expView, gotView)
+
+	// The view should be serializable and not have sensitive data.
+	data, err := json.Marshal(gotView)
+	require.NoError(err)
+	assert.NotContains(string(data), "password")
+	assert.NotContains(string(data), "Redactor")
+}
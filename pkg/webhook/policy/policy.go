@@ -0,0 +1,81 @@
+// Package policy has the policies, the policies return the changes an object needs and can
+// be materialized as a mutator (apply the changes) or as a validator (deny if the object needs
+// changes), this way the same policy can auto fix the objects on development environments and
+// block them on production environments.
+package policy
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/slok/kubewebhook/pkg/webhook/mutating"
+	"github.com/slok/kubewebhook/pkg/webhook/validating"
+)
+
+// Change is a change that an object needs to satisfy a policy.
+type Change struct {
+	// Description is the description of the change (e.g `set container "app" runAsNonRoot`).
+	Description string
+	// Apply applies the change on the object.
+	Apply func(obj metav1.Object) error
+}
+
+// Policy knows how to return the changes that an object needs to satisfy the policy, the
+// policies must not change the received object.
+type Policy interface {
+	Changes(ctx context.Context, obj metav1.Object) ([]Change, error)
+}
+
+// Func is a helper type to create policies from functions.
+type Func func(ctx context.Context, obj metav1.Object) ([]Change, error)
+
+// Changes satisfies Policy interface.
+func (f Func) Changes(ctx context.Context, obj metav1.Object) ([]Change, error) {
+	return f(ctx, obj)
+}
+
+// NewMutator returns a mutator that applies the changes of the policy on the objects.
+func NewMutator(p Policy) mutating.Mutator {
+	return mutating.MutatorFunc(func(ctx context.Context, obj metav1.Object) (bool, error) {
+		changes, err := p.Changes(ctx, obj)
+		if err != nil {
+			return true, fmt.Errorf("could not get policy changes: %w", err)
+		}
+
+		for _, c := range changes {
+			if err := c.Apply(obj); err != nil {
+				return true, fmt.Errorf("could not apply %q policy change: %w", c.Description, err)
+			}
+		}
+
+		return false, nil
+	})
+}
+
+// NewValidator returns a validator that denies the objects that need changes to satisfy the
+// policy, listing the needed changes.
+func NewValidator(p Policy) validating.Validator {
+	return validating.ValidatorFunc(func(ctx context.Context, obj metav1.Object) (bool, validating.ValidatorResult, error) {
+		changes, err := p.Changes(ctx, obj)
+		if err != nil {
+			return true, validating.ValidatorResult{}, fmt.Errorf("could not get policy changes: %w", err)
+		}
+
+		if len(changes) == 0 {
+			return false, validating.ValidatorResult{Valid: true}, nil
+		}
+
+		descs := make([]string, 0, len(changes))
+		for _, c := range changes {
+			descs = append(descs, c.Description)
+		}
+
+		return true, validating.ValidatorResult{
+			Valid:   false,
+			Message: fmt.Sprintf("object doesn't satisfy the policy, needed changes: %s", strings.Join(descs, "; ")),
+		}, nil
+	})
+}
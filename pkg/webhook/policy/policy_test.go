@@ -0,0 +1,105 @@
+package policy_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/slok/kubewebhook/pkg/webhook/policy"
+)
+
+// runAsNonRootPolicy is a policy that requires the pod containers to run as non root.
+var runAsNonRootPolicy = policy.Func(func(_ context.Context, obj metav1.Object) ([]policy.Change, error) {
+	pod, ok := obj.(*corev1.Pod)
+	if !ok {
+		return nil, nil
+	}
+
+	var changes []policy.Change
+	for i, c := range pod.Spec.Containers {
+		if c.SecurityContext != nil && c.SecurityContext.RunAsNonRoot != nil && *c.SecurityContext.RunAsNonRoot {
+			continue
+		}
+
+		i := i
+		changes = append(changes, policy.Change{
+			Description: fmt.Sprintf("set container %q runAsNonRoot", c.Name),
+			Apply: func(obj metav1.Object) error {
+				c := &obj.(*corev1.Pod).Spec.Containers[i]
+				if c.SecurityContext == nil {
+					c.SecurityContext = &corev1.SecurityContext{}
+				}
+				runAsNonRoot := true
+				c.SecurityContext.RunAsNonRoot = &runAsNonRoot
+				return nil
+			},
+		})
+	}
+
+	return changes, nil
+})
+
+func TestPolicy(t *testing.T) {
+	boolPtr := func(b bool) *bool { return &b }
+	nonRootSecCtx := &corev1.SecurityContext{RunAsNonRoot: boolPtr(true)}
+
+	tests := map[string]struct {
+		pod        *corev1.Pod
+		expPod     *corev1.Pod
+		expValid   bool
+		expMessage string
+	}{
+		"A pod that satisfies the policy should not be mutated and be valid.": {
+			pod: &corev1.Pod{Spec: corev1.PodSpec{Containers: []corev1.Container{
+				{Name: "app", SecurityContext: nonRootSecCtx},
+			}}},
+			expPod: &corev1.Pod{Spec: corev1.PodSpec{Containers: []corev1.Container{
+				{Name: "app", SecurityContext: nonRootSecCtx},
+			}}},
+			expValid: true,
+		},
+
+		"A pod that doesn't satisfy the policy should be mutated and be invalid listing the changes.": {
+			pod: &corev1.Pod{Spec: corev1.PodSpec{Containers: []corev1.Container{
+				{Name: "app"},
+				{Name: "sidecar", SecurityContext: nonRootSecCtx},
+				{Name: "proxy", SecurityContext: &corev1.SecurityContext{RunAsNonRoot: boolPtr(false)}},
+			}}},
+			expPod: &corev1.Pod{Spec: corev1.PodSpec{Containers: []corev1.Container{
+				{Name: "app", SecurityContext: nonRootSecCtx},
+				{Name: "sidecar", SecurityContext: nonRootSecCtx},
+				{Name: "proxy", SecurityContext: nonRootSecCtx},
+			}}},
+			expValid:   false,
+			expMessage: `object doesn't satisfy the policy, needed changes: set container "app" runAsNonRoot; set container "proxy" runAsNonRoot`,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+			require := require.New(t)
+
+			// Validation mode.
+			_, res, err := policy.NewValidator(runAsNonRootPolicy).Validate(context.TODO(), test.pod)
+			require.NoError(err)
+			assert.Equal(test.expValid, res.Valid)
+			assert.Equal(test.expMessage, res.Message)
+
+			// Mutation mode.
+			_, err = policy.NewMutator(runAsNonRootPolicy).Mutate(context.TODO(), test.pod)
+			require.NoError(err)
+			assert.Equal(test.expPod, test.pod)
+
+			// After the mutation the object should be valid.
+			_, res, err = policy.NewValidator(runAsNonRootPolicy).Validate(context.TODO(), test.pod)
+			require.NoError(err)
+			assert.True(res.Valid)
+		})
+	}
+}
@@ -0,0 +1,199 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+
+	admissionv1beta1 "k8s.io/api/admission/v1beta1"
+	"k8s.io/apimachinery/pkg/types"
+
+	"github.com/slok/kubewebhook/pkg/log"
+	"github.com/slok/kubewebhook/pkg/observability/metrics"
+	whcontext "github.com/slok/kubewebhook/pkg/webhook/context"
+)
+
+// SelfTestCase is a sample admission request and the expected review outcome of the webhook.
+type SelfTestCase struct {
+	// Name is the name of the case used on the logs, errors and metrics.
+	Name string
+	// Request is the sample admission request. If the request doesn't have UID a self-test UID will be used.
+	Request *admissionv1beta1.AdmissionRequest
+	// ExpAllowed is the expected allowed result of the review.
+	ExpAllowed bool
+	// ExpPatch is the expected JSON patch of the review (e.g `[]` for no mutation), the patches are
+	// compared as JSON documents. If nil the patch will not be checked.
+	ExpPatch []byte
+}
+
+// SelfTesterConfig is the configuration of the self-tester.
+type SelfTesterConfig struct {
+	// Webhook is the self-tested webhook.
+	Webhook Webhook
+	// Name is the name of the webhook used on the logs and metrics.
+	Name string
+	// MetricsRecorder is the recorder used to measure the self-test results, by default `metrics.Dummy`.
+	MetricsRecorder metrics.Recorder
+	// Logger is the logger used to log the self-test results, by default `log.Dummy`.
+	Logger log.Logger
+}
+
+func (c *SelfTesterConfig) defaults() error {
+	if c.Webhook == nil {
+		return fmt.Errorf("webhook is required")
+	}
+
+	if c.Name == "" {
+		return fmt.Errorf("name is required")
+	}
+
+	if c.MetricsRecorder == nil {
+		c.MetricsRecorder = metrics.Dummy
+	}
+
+	if c.Logger == nil {
+		c.Logger = log.Dummy
+	}
+
+	return nil
+}
+
+// SelfTester runs sample admission requests through a webhook and checks the reviews have the expected
+// outcome, normally at startup to catch the misconfigurations early (e.g a wrong object type or
+// a mutator not applied), before the webhook receives real traffic.
+//
+// The samples are reviewed by the real webhook, so the reviews are marked as self-test reviews on
+// the context (check `whcontext.IsSelfTest`): the library webhooks don't measure them and the circuit
+// breaker ignores them, but the mutators and validators side effects (e.g calls to external services)
+// will happen unless they check the context, and the metrics recorded by the mutators and validators
+// (e.g the chain mutators skipped errors) will be recorded.
+type SelfTester struct {
+	cfg SelfTesterConfig
+
+	mu  sync.Mutex
+	ran bool
+	err error
+}
+
+// NewSelfTester returns a new self-tester for the webhook.
+func NewSelfTester(cfg SelfTesterConfig) (*SelfTester, error) {
+	if err := cfg.defaults(); err != nil {
+		return nil, fmt.Errorf("invalid configuration: %w", err)
+	}
+
+	return &SelfTester{cfg: cfg}, nil
+}
+
+// SelfTest reviews all the self-test cases with the webhook, logging and measuring each case
+// result. It returns an error with all the failed cases, the result will be used by `Ready`.
+func (s *SelfTester) SelfTest(samples []SelfTestCase) error {
+	var errs []string
+	for i, c := range samples {
+		if c.Name == "" {
+			c.Name = fmt.Sprintf("#%d", i)
+		}
+
+		err := s.runCase(c)
+		if rec, ok := s.cfg.MetricsRecorder.(metrics.SelfTestRecorder); ok {
+			rec.IncSelfTestResult(s.cfg.Name, c.Name, err == nil)
+		}
+		if err != nil {
+			s.cfg.Logger.Errorf("webhook %q self-test %q failed: %s", s.cfg.Name, c.Name, err)
+			errs = append(errs, fmt.Sprintf("%q: %s", c.Name, err))
+			continue
+		}
+		s.cfg.Logger.Infof("webhook %q self-test %q passed", s.cfg.Name, c.Name)
+	}
+
+	var err error
+	if len(errs) > 0 {
+		err = fmt.Errorf("webhook %q self-tests failed: %s", s.cfg.Name, strings.Join(errs, "; "))
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.ran = true
+	s.err = err
+
+	return err
+}
+
+// Ready returns an error if the self-tests have not been run or the last self-test failed,
+// useful to fail the readiness checks of the webhook server.
+func (s *SelfTester) Ready() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !s.ran {
+		return fmt.Errorf("webhook %q self-tests have not been run", s.cfg.Name)
+	}
+
+	return s.err
+}
+
+func (s *SelfTester) runCase(c SelfTestCase) error {
+	if c.Request == nil {
+		return fmt.Errorf("request is required")
+	}
+
+	req := *c.Request
+	if req.UID == "" {
+		req.UID = types.UID("self-test")
+	}
+
+	ctx := whcontext.SetSelfTest(context.Background())
+	resp := s.cfg.Webhook.Review(ctx, &admissionv1beta1.AdmissionReview{Request: &req})
+	if resp == nil {
+		return fmt.Errorf("webhook returned an empty response")
+	}
+
+	if resp.Allowed != c.ExpAllowed {
+		msg := ""
+		if resp.Result != nil {
+			msg = resp.Result.Message
+		}
+		return fmt.Errorf("expected allowed %t, got %t: %q", c.ExpAllowed, resp.Allowed, msg)
+	}
+
+	if c.ExpPatch != nil {
+		equal, err := jsonPatchEqual(c.ExpPatch, resp.Patch)
+		if err != nil {
+			return err
+		}
+		if !equal {
+			return fmt.Errorf("expected patch %s, got %s", c.ExpPatch, resp.Patch)
+		}
+	}
+
+	return nil
+}
+
+// jsonPatchEqual returns true if the JSON patches are the same JSON documents, the empty
+// patches are the same as an empty list of operations.
+func jsonPatchEqual(a, b []byte) (bool, error) {
+	decode := func(p []byte) (interface{}, error) {
+		if len(bytes.TrimSpace(p)) == 0 {
+			p = []byte("[]")
+		}
+		var v interface{}
+		if err := json.Unmarshal(p, &v); err != nil {
+			return nil, fmt.Errorf("invalid JSON patch: %w", err)
+		}
+		return v, nil
+	}
+
+	va, err := decode(a)
+	if err != nil {
+		return false, err
+	}
+	vb, err := decode(b)
+	if err != nil {
+		return false, err
+	}
+
+	return reflect.DeepEqual(va, vb), nil
+}
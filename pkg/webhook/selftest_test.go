@@ -0,0 +1,123 @@
+package webhook_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	admissionv1beta1 "k8s.io/api/admission/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	mmetrics "github.com/slok/kubewebhook/mocks/observability/metrics"
+	"github.com/slok/kubewebhook/pkg/webhook"
+	whcontext "github.com/slok/kubewebhook/pkg/webhook/context"
+)
+
+func TestSelfTester(t *testing.T) {
+	// The webhook denies the `kube-system` namespace and mutates the rest.
+	wh := reviewFunc(func(ctx context.Context, ar *admissionv1beta1.AdmissionReview) *admissionv1beta1.AdmissionResponse {
+		if !whcontext.IsSelfTest(ctx) {
+			return &admissionv1beta1.AdmissionResponse{
+				UID:    ar.Request.UID,
+				Result: &metav1.Status{Status: metav1.StatusFailure, Message: "not a self-test review"},
+			}
+		}
+		if ar.Request.Namespace == "kube-system" {
+			return &admissionv1beta1.AdmissionResponse{
+				UID:    ar.Request.UID,
+				Result: &metav1.Status{Status: metav1.StatusFailure, Message: "namespace not allowed"},
+			}
+		}
+		return &admissionv1beta1.AdmissionResponse{
+			UID:     ar.Request.UID,
+			Allowed: true,
+			Patch:   []byte(`[{"op":"add","path":"/metadata/labels","value":{"app":"test"}}]`),
+		}
+	})
+
+	tests := map[string]struct {
+		cases        []webhook.SelfTestCase
+		expErr       bool
+		expCaseTests map[string]bool
+	}{
+		"Self-test cases with the expected outcomes should pass.": {
+			cases: []webhook.SelfTestCase{
+				{
+					Name:       "mutated",
+					Request:    &admissionv1beta1.AdmissionRequest{Namespace: "default"},
+					ExpAllowed: true,
+					ExpPatch:   []byte(`[{"path": "/metadata/labels", "op": "add", "value": {"app": "test"}}]`),
+				},
+				{
+					Name:       "denied",
+					Request:    &admissionv1beta1.AdmissionRequest{Namespace: "kube-system"},
+					ExpAllowed: false,
+				},
+			},
+			expCaseTests: map[string]bool{"mutated": true, "denied": true},
+		},
+
+		"Self-test cases with unexpected outcomes should fail.": {
+			cases: []webhook.SelfTestCase{
+				{
+					Name:       "mutated",
+					Request:    &admissionv1beta1.AdmissionRequest{Namespace: "default"},
+					ExpAllowed: true,
+					ExpPatch:   []byte(`[]`),
+				},
+				{
+					Name:       "denied",
+					Request:    &admissionv1beta1.AdmissionRequest{Namespace: "kube-system"},
+					ExpAllowed: true,
+				},
+				{
+					Name:       "allowed",
+					Request:    &admissionv1beta1.AdmissionRequest{Namespace: "default"},
+					ExpAllowed: true,
+				},
+			},
+			expErr:       true,
+			expCaseTests: map[string]bool{"mutated": false, "denied": false, "allowed": true},
+		},
+
+		"A self-test case without request should fail.": {
+			cases:        []webhook.SelfTestCase{{Name: "empty"}},
+			expErr:       true,
+			expCaseTests: map[string]bool{"empty": false},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+			require := require.New(t)
+
+			mrec := &mmetrics.Recorder{}
+			for c, passed := range test.expCaseTests {
+				mrec.On("IncSelfTestResult", "test", c, passed).Once()
+			}
+
+			st, err := webhook.NewSelfTester(webhook.SelfTesterConfig{Webhook: wh, Name: "test", MetricsRecorder: mrec})
+			require.NoError(err)
+
+			// Not ready until the self-tests are run.
+			assert.Error(st.Ready())
+
+			err = st.SelfTest(test.cases)
+			if test.expErr {
+				assert.Error(err)
+				assert.Error(st.Ready())
+			} else {
+				assert.NoError(err)
+				assert.NoError(st.Ready())
+			}
+			mrec.AssertExpectations(t)
+		})
+	}
+}
+
+func TestSelfTesterInvalidConfig(t *testing.T) {
+	_, err := webhook.NewSelfTester(webhook.SelfTesterConfig{Name: "test"})
+	assert.Error(t, err)
+}
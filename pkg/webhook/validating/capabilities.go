@@ -0,0 +1,58 @@
+package validating
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/slok/kubewebhook/pkg/webhook/internal/helpers"
+)
+
+// NewCapabilitiesValidator returns a validator that denies the pods (or the pod templates of the
+// workloads) whose containers, including the init containers, add Linux capabilities (security
+// context `capabilities.add`) that are not on the allowed list, naming the offending containers
+// and capabilities. Like the container runtimes, the capabilities are compared case insensitive
+// and without the `CAP_` prefix (e.g `cap_net_admin` is `NET_ADMIN`).
+func NewCapabilitiesValidator(allowed []corev1.Capability) Validator {
+	allowedCaps := map[string]bool{}
+	for _, c := range allowed {
+		allowedCaps[normalizeCapability(c)] = true
+	}
+
+	return ValidatorFunc(func(_ context.Context, obj metav1.Object) (bool, ValidatorResult, error) {
+		spec, ok := helpers.PodSpec(obj)
+		if !ok {
+			return false, ValidatorResult{Valid: true}, nil
+		}
+
+		var msgs []string
+		for _, cs := range [][]corev1.Container{spec.InitContainers, spec.Containers} {
+			for _, c := range cs {
+				if c.SecurityContext == nil || c.SecurityContext.Capabilities == nil {
+					continue
+				}
+				for _, cp := range c.SecurityContext.Capabilities.Add {
+					if !allowedCaps[normalizeCapability(cp)] {
+						msgs = append(msgs, fmt.Sprintf("container %q adds the not allowed %q capability", c.Name, cp))
+					}
+				}
+			}
+		}
+
+		if len(msgs) > 0 {
+			return true, ValidatorResult{
+				Valid:   false,
+				Message: strings.Join(msgs, "; "),
+			}, nil
+		}
+
+		return false, ValidatorResult{Valid: true}, nil
+	})
+}
+
+func normalizeCapability(c corev1.Capability) string {
+	return strings.TrimPrefix(strings.ToUpper(string(c)), "CAP_")
+}
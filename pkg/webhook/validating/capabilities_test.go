@@ -0,0 +1,92 @@
+package validating_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/slok/kubewebhook/pkg/webhook/validating"
+)
+
+func TestCapabilitiesValidator(t *testing.T) {
+	container := func(name string, caps ...corev1.Capability) corev1.Container {
+		return corev1.Container{
+			Name: name,
+			SecurityContext: &corev1.SecurityContext{
+				Capabilities: &corev1.Capabilities{Add: caps, Drop: []corev1.Capability{"ALL"}},
+			},
+		}
+	}
+
+	tests := map[string]struct {
+		obj         metav1.Object
+		expValid    bool
+		expMessages []string
+	}{
+		"A pod without security context should be valid.": {
+			obj:      &corev1.Pod{Spec: corev1.PodSpec{Containers: []corev1.Container{{Name: "app"}}}},
+			expValid: true,
+		},
+
+		"A pod adding allowed capabilities should be valid.": {
+			obj: &corev1.Pod{Spec: corev1.PodSpec{Containers: []corev1.Container{
+				container("app", "NET_BIND_SERVICE"),
+				container("sidecar", "CAP_chown"),
+			}}},
+			expValid: true,
+		},
+
+		"A pod adding not allowed capabilities should be invalid.": {
+			obj: &corev1.Pod{Spec: corev1.PodSpec{Containers: []corev1.Container{
+				container("app", "NET_BIND_SERVICE", "SYS_ADMIN"),
+				container("sidecar", "CAP_NET_ADMIN"),
+			}}},
+			expValid: false,
+			expMessages: []string{
+				`container "app" adds the not allowed "SYS_ADMIN" capability`,
+				`container "sidecar" adds the not allowed "CAP_NET_ADMIN" capability`,
+			},
+		},
+
+		"A workload adding not allowed capabilities on the init containers should be invalid.": {
+			obj: &appsv1.Deployment{
+				Spec: appsv1.DeploymentSpec{
+					Template: corev1.PodTemplateSpec{
+						Spec: corev1.PodSpec{
+							InitContainers: []corev1.Container{container("init", "NET_ADMIN")},
+							Containers:     []corev1.Container{container("app", "NET_BIND_SERVICE")},
+						},
+					},
+				},
+			},
+			expValid:    false,
+			expMessages: []string{`container "init" adds the not allowed "NET_ADMIN" capability`},
+		},
+
+		"A non pod object should be valid.": {
+			obj:      &corev1.Service{},
+			expValid: true,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+			require := require.New(t)
+
+			v := validating.NewCapabilitiesValidator([]corev1.Capability{"NET_BIND_SERVICE", "CHOWN"})
+			_, res, err := v.Validate(context.TODO(), test.obj)
+			require.NoError(err)
+
+			assert.Equal(test.expValid, res.Valid)
+			for _, msg := range test.expMessages {
+				assert.Contains(res.Message, msg)
+			}
+		})
+	}
+}
@@ -0,0 +1,250 @@
+package validating
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	batchv1beta1 "k8s.io/api/batch/v1beta1"
+	batchv2alpha1 "k8s.io/api/batch/v2alpha1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// CronJobRules are the rules the CronJobs need to satisfy.
+type CronJobRules struct {
+	// MinInterval is the minimum time allowed between two runs of the schedule.
+	// If 0 the schedule frequency will not be checked.
+	MinInterval time.Duration
+	// DisallowedConcurrencyPolicies are the concurrency policies that are not allowed. A missing
+	// concurrency policy is treated as `Allow` because is the Kubernetes default.
+	DisallowedConcurrencyPolicies []string
+}
+
+// NewCronJobValidator returns a validator that denies the CronJobs (batch/v1beta1 and batch/v2alpha1)
+// with an unparsable schedule, a schedule that runs more frequently than the rules minimum interval
+// or a disallowed concurrency policy.
+func NewCronJobValidator(rules CronJobRules) Validator {
+	disallowed := map[string]bool{}
+	for _, p := range rules.DisallowedConcurrencyPolicies {
+		disallowed[p] = true
+	}
+
+	return ValidatorFunc(func(_ context.Context, obj metav1.Object) (bool, ValidatorResult, error) {
+		var schedule, policy string
+		switch cj := obj.(type) {
+		case *batchv1beta1.CronJob:
+			schedule, policy = cj.Spec.Schedule, string(cj.Spec.ConcurrencyPolicy)
+		case *batchv2alpha1.CronJob:
+			schedule, policy = cj.Spec.Schedule, string(cj.Spec.ConcurrencyPolicy)
+		default:
+			return false, ValidatorResult{Valid: true}, nil
+		}
+
+		if policy == "" {
+			policy = string(batchv1beta1.AllowConcurrent)
+		}
+
+		var msgs []string
+		if disallowed[policy] {
+			msgs = append(msgs, fmt.Sprintf("concurrency policy %q is not allowed", policy))
+		}
+
+		interval, err := cronScheduleMinInterval(schedule)
+		switch {
+		case err != nil:
+			msgs = append(msgs, fmt.Sprintf("invalid schedule %q: %s", schedule, err))
+		case rules.MinInterval > 0 && interval < rules.MinInterval:
+			msgs = append(msgs, fmt.Sprintf("schedule %q runs every %s, the min interval allowed is %s", schedule, interval, rules.MinInterval))
+		}
+
+		if len(msgs) > 0 {
+			return true, ValidatorResult{
+				Valid:   false,
+				Message: strings.Join(msgs, "; "),
+			}, nil
+		}
+
+		return false, ValidatorResult{Valid: true}, nil
+	})
+}
+
+var cronDescriptors = map[string]string{
+	"@yearly":   "0 0 1 1 *",
+	"@annually": "0 0 1 1 *",
+	"@monthly":  "0 0 1 * *",
+	"@weekly":   "0 0 * * 0",
+	"@daily":    "0 0 * * *",
+	"@midnight": "0 0 * * *",
+	"@hourly":   "0 * * * *",
+}
+
+var (
+	cronMonthNames = map[string]int{"jan": 1, "feb": 2, "mar": 3, "apr": 4, "may": 5, "jun": 6, "jul": 7, "aug": 8, "sep": 9, "oct": 10, "nov": 11, "dec": 12}
+	cronDayNames   = map[string]int{"sun": 0, "mon": 1, "tue": 2, "wed": 3, "thu": 4, "fri": 5, "sat": 6}
+)
+
+// cronScheduleMinInterval parses a standard cron schedule (5 fields or descriptor) and returns the
+// minimum time between two consecutive runs.
+func cronScheduleMinInterval(schedule string) (time.Duration, error) {
+	schedule = strings.TrimSpace(schedule)
+	if strings.HasPrefix(schedule, "@every ") {
+		d, err := time.ParseDuration(strings.TrimSpace(strings.TrimPrefix(schedule, "@every ")))
+		if err != nil {
+			return 0, err
+		}
+		if d <= 0 {
+			return 0, fmt.Errorf("interval must be positive")
+		}
+		return d, nil
+	}
+	if d, ok := cronDescriptors[schedule]; ok {
+		schedule = d
+	}
+
+	fields := strings.Fields(schedule)
+	if len(fields) != 5 {
+		return 0, fmt.Errorf("expected 5 fields, got %d", len(fields))
+	}
+
+	minutes, err := parseCronField(fields[0], 0, 59, nil)
+	if err != nil {
+		return 0, fmt.Errorf("minute: %w", err)
+	}
+	hours, err := parseCronField(fields[1], 0, 23, nil)
+	if err != nil {
+		return 0, fmt.Errorf("hour: %w", err)
+	}
+	doms, err := parseCronField(fields[2], 1, 31, nil)
+	if err != nil {
+		return 0, fmt.Errorf("day of month: %w", err)
+	}
+	months, err := parseCronField(fields[3], 1, 12, cronMonthNames)
+	if err != nil {
+		return 0, fmt.Errorf("month: %w", err)
+	}
+	dows, err := parseCronField(fields[4], 0, 7, cronDayNames)
+	if err != nil {
+		return 0, fmt.Errorf("day of week: %w", err)
+	}
+	if dows[7] {
+		dows[0] = true
+	}
+
+	// Run times inside a day, in minutes.
+	var dayMinutes []int
+	for h := range hours {
+		for m := range minutes {
+			dayMinutes = append(dayMinutes, h*60+m)
+		}
+	}
+	sort.Ints(dayMinutes)
+
+	// Like cron, if any of the day fields is a wildcard both need to match, if not, any of them.
+	anyDay := strings.HasPrefix(fields[2], "*") || strings.HasPrefix(fields[4], "*")
+
+	// Get the days that run, 8 years are enough to repeat every month and day combination.
+	const minutesInDay = 24 * 60
+	start := time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC)
+	var runDays []int
+	for i := 0; i < 8*366; i++ {
+		t := start.AddDate(0, 0, i)
+		if !months[int(t.Month())] {
+			continue
+		}
+		domMatch, dowMatch := doms[t.Day()], dows[int(t.Weekday())]
+		if (anyDay && domMatch && dowMatch) || (!anyDay && (domMatch || dowMatch)) {
+			runDays = append(runDays, i)
+		}
+	}
+	if len(runDays) == 0 {
+		return 0, fmt.Errorf("schedule never runs")
+	}
+
+	minInterval := -1
+	for i := 1; i < len(dayMinutes); i++ {
+		if d := dayMinutes[i] - dayMinutes[i-1]; minInterval < 0 || d < minInterval {
+			minInterval = d
+		}
+	}
+	first, last := dayMinutes[0], dayMinutes[len(dayMinutes)-1]
+	for i := 1; i < len(runDays); i++ {
+		d := (runDays[i]-runDays[i-1])*minutesInDay - last + first
+		if minInterval < 0 || d < minInterval {
+			minInterval = d
+		}
+	}
+	if minInterval < 0 {
+		return 0, fmt.Errorf("schedule doesn't repeat")
+	}
+
+	return time.Duration(minInterval) * time.Minute, nil
+}
+
+// parseCronField parses a cron schedule field (e.g `*/5`, `1-10/2`, `mon,wed`) returning the
+// matched values.
+func parseCronField(field string, min, max int, names map[string]int) (map[int]bool, error) {
+	parseValue := func(s string) (int, error) {
+		if v, ok := names[strings.ToLower(s)]; ok {
+			return v, nil
+		}
+		v, err := strconv.Atoi(s)
+		if err != nil {
+			return 0, fmt.Errorf("invalid value %q", s)
+		}
+		if v < min || v > max {
+			return 0, fmt.Errorf("value %d out of range [%d, %d]", v, min, max)
+		}
+		return v, nil
+	}
+
+	values := map[int]bool{}
+	for _, part := range strings.Split(field, ",") {
+		rangePart, step := part, 1
+		if i := strings.Index(part, "/"); i >= 0 {
+			s, err := strconv.Atoi(part[i+1:])
+			if err != nil || s <= 0 {
+				return nil, fmt.Errorf("invalid step %q", part[i+1:])
+			}
+			rangePart, step = part[:i], s
+		}
+
+		var from, to int
+		switch {
+		case rangePart == "*" || rangePart == "?":
+			from, to = min, max
+		case strings.Contains(rangePart, "-"):
+			bounds := strings.SplitN(rangePart, "-", 2)
+			f, err := parseValue(bounds[0])
+			if err != nil {
+				return nil, err
+			}
+			t, err := parseValue(bounds[1])
+			if err != nil {
+				return nil, err
+			}
+			if f > t {
+				return nil, fmt.Errorf("invalid range %q", rangePart)
+			}
+			from, to = f, t
+		default:
+			v, err := parseValue(rangePart)
+			if err != nil {
+				return nil, err
+			}
+			// Like cron, a single value with step means from the value to the max.
+			from, to = v, v
+			if strings.Contains(part, "/") {
+				to = max
+			}
+		}
+
+		for v := from; v <= to; v += step {
+			values[v] = true
+		}
+	}
+
+	return values, nil
+}
@@ -0,0 +1,145 @@
+package validating_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	batchv1beta1 "k8s.io/api/batch/v1beta1"
+	batchv2alpha1 "k8s.io/api/batch/v2alpha1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/slok/kubewebhook/pkg/webhook/validating"
+)
+
+func TestCronJobValidator(t *testing.T) {
+	cronJob := func(schedule string, policy batchv1beta1.ConcurrencyPolicy) *batchv1beta1.CronJob {
+		return &batchv1beta1.CronJob{
+			Spec: batchv1beta1.CronJobSpec{
+				Schedule:          schedule,
+				ConcurrencyPolicy: policy,
+			},
+		}
+	}
+
+	rules := validating.CronJobRules{
+		MinInterval:                   time.Hour,
+		DisallowedConcurrencyPolicies: []string{"Allow"},
+	}
+
+	tests := map[string]struct {
+		rules      validating.CronJobRules
+		obj        metav1.Object
+		expValid   bool
+		expMessage string
+	}{
+		"A compliant CronJob should be valid.": {
+			rules:    rules,
+			obj:      cronJob("0 */2 * * *", batchv1beta1.ForbidConcurrent),
+			expValid: true,
+		},
+
+		"A CronJob with a schedule at the min interval should be valid.": {
+			rules:    rules,
+			obj:      cronJob("@hourly", batchv1beta1.ReplaceConcurrent),
+			expValid: true,
+		},
+
+		"A CronJob with a weekly schedule should be valid.": {
+			rules:    validating.CronJobRules{MinInterval: 72 * time.Hour},
+			obj:      cronJob("30 3 * * sun", batchv1beta1.ForbidConcurrent),
+			expValid: true,
+		},
+
+		"A CronJob with a too frequent schedule should be invalid.": {
+			rules:      rules,
+			obj:        cronJob("*/15 * * * *", batchv1beta1.ForbidConcurrent),
+			expValid:   false,
+			expMessage: `schedule "*/15 * * * *" runs every 15m0s, the min interval allowed is 1h0m0s`,
+		},
+
+		"A CronJob with a too frequent schedule around midnight should be invalid.": {
+			rules:      rules,
+			obj:        cronJob("10,50 0,23 * * *", batchv1beta1.ForbidConcurrent),
+			expValid:   false,
+			expMessage: `schedule "10,50 0,23 * * *" runs every 20m0s, the min interval allowed is 1h0m0s`,
+		},
+
+		"A CronJob with a disallowed concurrency policy should be invalid.": {
+			rules:      rules,
+			obj:        cronJob("0 0 * * *", batchv1beta1.AllowConcurrent),
+			expValid:   false,
+			expMessage: `concurrency policy "Allow" is not allowed`,
+		},
+
+		"A CronJob without concurrency policy should use the default policy.": {
+			rules:      rules,
+			obj:        cronJob("0 0 * * *", ""),
+			expValid:   false,
+			expMessage: `concurrency policy "Allow" is not allowed`,
+		},
+
+		"A CronJob with an unparsable schedule should be invalid.": {
+			rules:      rules,
+			obj:        cronJob("every hour", batchv1beta1.ForbidConcurrent),
+			expValid:   false,
+			expMessage: `invalid schedule "every hour": expected 5 fields, got 2`,
+		},
+
+		"A CronJob with an out of range schedule should be invalid.": {
+			rules:      rules,
+			obj:        cronJob("0 25 * * *", batchv1beta1.ForbidConcurrent),
+			expValid:   false,
+			expMessage: `invalid schedule "0 25 * * *": hour: value 25 out of range [0, 23]`,
+		},
+
+		"A CronJob with a schedule that never runs should be invalid.": {
+			rules:      rules,
+			obj:        cronJob("0 0 30 2 *", batchv1beta1.ForbidConcurrent),
+			expValid:   false,
+			expMessage: `invalid schedule "0 0 30 2 *": schedule never runs`,
+		},
+
+		"A CronJob with multiple violations should be invalid with all the violations.": {
+			rules:      rules,
+			obj:        cronJob("* * * * *", batchv1beta1.AllowConcurrent),
+			expValid:   false,
+			expMessage: `concurrency policy "Allow" is not allowed; schedule "* * * * *" runs every 1m0s, the min interval allowed is 1h0m0s`,
+		},
+
+		"A batch/v2alpha1 CronJob with a too frequent schedule should be invalid.": {
+			rules: rules,
+			obj: &batchv2alpha1.CronJob{
+				Spec: batchv2alpha1.CronJobSpec{
+					Schedule:          "@every 10m",
+					ConcurrencyPolicy: batchv2alpha1.ForbidConcurrent,
+				},
+			},
+			expValid:   false,
+			expMessage: `schedule "@every 10m" runs every 10m0s, the min interval allowed is 1h0m0s`,
+		},
+
+		"A non CronJob object should be valid.": {
+			rules:    rules,
+			obj:      &corev1.Pod{},
+			expValid: true,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+			require := require.New(t)
+
+			v := validating.NewCronJobValidator(test.rules)
+			_, res, err := v.Validate(context.TODO(), test.obj)
+			require.NoError(err)
+
+			assert.Equal(test.expValid, res.Valid)
+			assert.Equal(test.expMessage, res.Message)
+		})
+	}
+}
@@ -0,0 +1,125 @@
+package validating
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/slok/kubewebhook/pkg/webhook/internal/helpers"
+)
+
+// Verifier knows how to verify the signature of an image (e.g using a signature
+// verification service), returning an error if the image is not verified.
+type Verifier interface {
+	Verify(ctx context.Context, image string) error
+}
+
+// VerifierFunc is a helper type to create verifiers from functions.
+type VerifierFunc func(ctx context.Context, image string) error
+
+// Verify satisfies Verifier interface.
+func (f VerifierFunc) Verify(ctx context.Context, image string) error { return f(ctx, image) }
+
+// NewTTLCacheVerifier returns a verifier that caches the images verified by the verifier
+// during the TTL, the not verified images are never cached. The expired images are evicted
+// from the cache so it only grows with the images verified during the TTL.
+func NewTTLCacheVerifier(verifier Verifier, ttl time.Duration) Verifier {
+	return &ttlCacheVerifier{
+		verifier: verifier,
+		ttl:      ttl,
+		verified: map[string]time.Time{},
+	}
+}
+
+type ttlCacheVerifier struct {
+	verifier     Verifier
+	ttl          time.Duration
+	verified     map[string]time.Time
+	lastEviction time.Time
+	mu           sync.Mutex
+}
+
+func (t *ttlCacheVerifier) Verify(ctx context.Context, image string) error {
+	t.mu.Lock()
+	expiration, ok := t.verified[image]
+	if ok && !time.Now().Before(expiration) {
+		delete(t.verified, image)
+		ok = false
+	}
+	t.mu.Unlock()
+	if ok {
+		return nil
+	}
+
+	if err := t.verifier.Verify(ctx, image); err != nil {
+		return err
+	}
+
+	t.mu.Lock()
+	now := time.Now()
+	t.verified[image] = now.Add(t.ttl)
+	t.evictExpired(now)
+	t.mu.Unlock()
+
+	return nil
+}
+
+// evictExpired removes the expired images from the cache, the cache is swept at most once
+// per TTL to amortize the cost. Must be called with the lock held.
+func (t *ttlCacheVerifier) evictExpired(now time.Time) {
+	if now.Sub(t.lastEviction) < t.ttl {
+		return
+	}
+	t.lastEviction = now
+
+	for image, expiration := range t.verified {
+		if !now.Before(expiration) {
+			delete(t.verified, image)
+		}
+	}
+}
+
+// NewImageSignatureValidator returns a validator that denies the pods (or the pod templates of the
+// workloads) that use images that the verifier can't verify, listing all the failures. To cache
+// the verified images use `NewTTLCacheVerifier`.
+func NewImageSignatureValidator(verifier Verifier) Validator {
+	return ValidatorFunc(func(ctx context.Context, obj metav1.Object) (bool, ValidatorResult, error) {
+		spec, ok := helpers.PodSpec(obj)
+		if !ok {
+			return false, ValidatorResult{Valid: true}, nil
+		}
+
+		var images []string
+		seen := map[string]bool{}
+		for _, cs := range [][]corev1.Container{spec.InitContainers, spec.Containers} {
+			for _, c := range cs {
+				if seen[c.Image] {
+					continue
+				}
+				seen[c.Image] = true
+				images = append(images, c.Image)
+			}
+		}
+
+		var msgs []string
+		for _, image := range images {
+			if err := verifier.Verify(ctx, image); err != nil {
+				msgs = append(msgs, fmt.Sprintf("image %q is not verified: %s", image, err))
+			}
+		}
+
+		if len(msgs) > 0 {
+			return true, ValidatorResult{
+				Valid:   false,
+				Message: strings.Join(msgs, "; "),
+			}, nil
+		}
+
+		return false, ValidatorResult{Valid: true}, nil
+	})
+}
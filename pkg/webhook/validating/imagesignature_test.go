@@ -0,0 +1,111 @@
+package validating_test
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/slok/kubewebhook/pkg/webhook/validating"
+)
+
+// fakeVerifier verifies the images of the `verified.io` registry and counts the verifications.
+type fakeVerifier struct {
+	calls map[string]int
+}
+
+func (f *fakeVerifier) Verify(_ context.Context, image string) error {
+	if f.calls == nil {
+		f.calls = map[string]int{}
+	}
+	f.calls[image]++
+
+	if !strings.HasPrefix(image, "verified.io/") {
+		return fmt.Errorf("signature not found")
+	}
+	return nil
+}
+
+func TestImageSignatureValidator(t *testing.T) {
+	tests := map[string]struct {
+		obj         metav1.Object
+		expValid    bool
+		expMessages []string
+	}{
+		"A pod with verified images should be valid.": {
+			obj: &corev1.Pod{
+				Spec: corev1.PodSpec{
+					InitContainers: []corev1.Container{{Name: "init", Image: "verified.io/init:v1"}},
+					Containers:     []corev1.Container{{Name: "app", Image: "verified.io/app:v1"}},
+				},
+			},
+			expValid: true,
+		},
+
+		"A pod with unverified images should be invalid listing all the failures.": {
+			obj: &corev1.Pod{
+				Spec: corev1.PodSpec{
+					InitContainers: []corev1.Container{{Name: "init", Image: "evil.io/init:v1"}},
+					Containers: []corev1.Container{
+						{Name: "app", Image: "verified.io/app:v1"},
+						{Name: "sidecar", Image: "docker.io/sidecar:v1"},
+					},
+				},
+			},
+			expValid: false,
+			expMessages: []string{
+				`image "evil.io/init:v1" is not verified: signature not found`,
+				`image "docker.io/sidecar:v1" is not verified: signature not found`,
+			},
+		},
+
+		"A non pod object should be valid.": {
+			obj:      &corev1.Service{},
+			expValid: true,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+			require := require.New(t)
+
+			v := validating.NewImageSignatureValidator(&fakeVerifier{})
+			_, res, err := v.Validate(context.TODO(), test.obj)
+			require.NoError(err)
+
+			assert.Equal(test.expValid, res.Valid)
+			for _, msg := range test.expMessages {
+				assert.Contains(res.Message, msg)
+			}
+		})
+	}
+}
+
+func TestTTLCacheVerifier(t *testing.T) {
+	assert := assert.New(t)
+
+	fv := &fakeVerifier{}
+	v := validating.NewTTLCacheVerifier(fv, 50*time.Millisecond)
+
+	// The verified images should be cached.
+	assert.NoError(v.Verify(context.TODO(), "verified.io/app:v1"))
+	assert.NoError(v.Verify(context.TODO(), "verified.io/app:v1"))
+	assert.Equal(1, fv.calls["verified.io/app:v1"])
+
+	// The unverified images should not be cached.
+	assert.Error(v.Verify(context.TODO(), "evil.io/app:v1"))
+	assert.Error(v.Verify(context.TODO(), "evil.io/app:v1"))
+	assert.Equal(2, fv.calls["evil.io/app:v1"])
+
+	// After the TTL the verified images should be verified again.
+	time.Sleep(100 * time.Millisecond)
+	assert.NoError(v.Verify(context.TODO(), "verified.io/app:v1"))
+	assert.Equal(2, fv.calls["verified.io/app:v1"])
+}
@@ -0,0 +1,84 @@
+package validating
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	extensionsv1beta1 "k8s.io/api/extensions/v1beta1"
+	networkingv1 "k8s.io/api/networking/v1"
+	networkingv1beta1 "k8s.io/api/networking/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// NewIngressHostValidator returns a validator that denies the Ingresses that have rule hosts
+// that are not under one of the allowed domain suffixes (e.g `example.com` allows `example.com`,
+// `app.example.com` and `*.example.com`). The rules without host (e.g default backend) will be
+// allowed.
+func NewIngressHostValidator(allowedSuffixes []string) Validator {
+	suffixes := make([]string, 0, len(allowedSuffixes))
+	for _, s := range allowedSuffixes {
+		s = strings.ToLower(strings.Trim(s, "."))
+		if s != "" {
+			suffixes = append(suffixes, s)
+		}
+	}
+
+	return ValidatorFunc(func(_ context.Context, obj metav1.Object) (bool, ValidatorResult, error) {
+		hosts, ok := ingressHosts(obj)
+		if !ok {
+			return false, ValidatorResult{Valid: true}, nil
+		}
+
+		var msgs []string
+		for _, host := range hosts {
+			if host == "" || hostAllowed(host, suffixes) {
+				continue
+			}
+			msgs = append(msgs, fmt.Sprintf("host %q is not under the allowed domains", host))
+		}
+
+		if len(msgs) > 0 {
+			return true, ValidatorResult{
+				Valid:   false,
+				Message: strings.Join(msgs, "; "),
+			}, nil
+		}
+
+		return false, ValidatorResult{Valid: true}, nil
+	})
+}
+
+func hostAllowed(host string, suffixes []string) bool {
+	host = strings.ToLower(host)
+	for _, s := range suffixes {
+		if host == s || strings.HasSuffix(host, "."+s) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// ingressHosts returns the rule hosts of the supported Ingress versions.
+func ingressHosts(obj metav1.Object) ([]string, bool) {
+	var hosts []string
+	switch ing := obj.(type) {
+	case *networkingv1.Ingress:
+		for _, r := range ing.Spec.Rules {
+			hosts = append(hosts, r.Host)
+		}
+	case *networkingv1beta1.Ingress:
+		for _, r := range ing.Spec.Rules {
+			hosts = append(hosts, r.Host)
+		}
+	case *extensionsv1beta1.Ingress:
+		for _, r := range ing.Spec.Rules {
+			hosts = append(hosts, r.Host)
+		}
+	default:
+		return nil, false
+	}
+
+	return hosts, true
+}
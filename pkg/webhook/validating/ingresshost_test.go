@@ -0,0 +1,104 @@
+package validating_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	extensionsv1beta1 "k8s.io/api/extensions/v1beta1"
+	networkingv1 "k8s.io/api/networking/v1"
+	networkingv1beta1 "k8s.io/api/networking/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/slok/kubewebhook/pkg/webhook/validating"
+)
+
+func TestIngressHostValidator(t *testing.T) {
+	tests := map[string]struct {
+		obj         metav1.Object
+		expValid    bool
+		expMessages []string
+	}{
+		"An ingress with allowed hosts should be valid.": {
+			obj: &networkingv1.Ingress{
+				Spec: networkingv1.IngressSpec{
+					Rules: []networkingv1.IngressRule{
+						{Host: "example.com"},
+						{Host: "app.example.com"},
+						{Host: "*.apps.example.org"},
+					},
+				},
+			},
+			expValid: true,
+		},
+
+		"An ingress with disallowed hosts should be invalid with a message per host.": {
+			obj: &networkingv1.Ingress{
+				Spec: networkingv1.IngressSpec{
+					Rules: []networkingv1.IngressRule{
+						{Host: "app.example.com"},
+						{Host: "evil.com"},
+						{Host: "notexample.com"},
+					},
+				},
+			},
+			expValid: false,
+			expMessages: []string{
+				`host "evil.com" is not under the allowed domains`,
+				`host "notexample.com" is not under the allowed domains`,
+			},
+		},
+
+		"An ingress without hosts should be valid.": {
+			obj: &networkingv1.Ingress{
+				Spec: networkingv1.IngressSpec{
+					Rules: []networkingv1.IngressRule{{}},
+				},
+			},
+			expValid: true,
+		},
+
+		"A networking v1beta1 ingress with disallowed hosts should be invalid.": {
+			obj: &networkingv1beta1.Ingress{
+				Spec: networkingv1beta1.IngressSpec{
+					Rules: []networkingv1beta1.IngressRule{{Host: "evil.com"}},
+				},
+			},
+			expValid:    false,
+			expMessages: []string{`host "evil.com" is not under the allowed domains`},
+		},
+
+		"An extensions v1beta1 ingress with disallowed hosts should be invalid.": {
+			obj: &extensionsv1beta1.Ingress{
+				Spec: extensionsv1beta1.IngressSpec{
+					Rules: []extensionsv1beta1.IngressRule{{Host: "evil.com"}},
+				},
+			},
+			expValid:    false,
+			expMessages: []string{`host "evil.com" is not under the allowed domains`},
+		},
+
+		"A non ingress object should be valid.": {
+			obj:      &corev1.Pod{},
+			expValid: true,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+			require := require.New(t)
+
+			v := validating.NewIngressHostValidator([]string{"example.com", ".apps.example.org"})
+			_, res, err := v.Validate(context.TODO(), test.obj)
+			require.NoError(err)
+
+			assert.Equal(test.expValid, res.Valid)
+			for _, msg := range test.expMessages {
+				assert.Contains(res.Message, msg)
+			}
+		})
+	}
+}
@@ -0,0 +1,38 @@
+package validating
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// NewLoadBalancerServiceValidator returns a validator that denies the `LoadBalancer` Services
+// missing any of the required annotations (e.g the cloud load balancer subnet or scheme),
+// listing the missing annotation keys. The rest of the Services will be allowed.
+func NewLoadBalancerServiceValidator(requiredAnnotations []string) Validator {
+	return ValidatorFunc(func(_ context.Context, obj metav1.Object) (bool, ValidatorResult, error) {
+		svc, ok := obj.(*corev1.Service)
+		if !ok || svc.Spec.Type != corev1.ServiceTypeLoadBalancer {
+			return false, ValidatorResult{Valid: true}, nil
+		}
+
+		var missing []string
+		for _, a := range requiredAnnotations {
+			if _, ok := svc.Annotations[a]; !ok {
+				missing = append(missing, a)
+			}
+		}
+
+		if len(missing) > 0 {
+			return true, ValidatorResult{
+				Valid:   false,
+				Message: fmt.Sprintf("load balancer service is missing the required annotations: %s", strings.Join(missing, ", ")),
+			}, nil
+		}
+
+		return false, ValidatorResult{Valid: true}, nil
+	})
+}
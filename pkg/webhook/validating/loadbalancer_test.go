@@ -0,0 +1,81 @@
+package validating_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/slok/kubewebhook/pkg/webhook/validating"
+)
+
+func TestLoadBalancerServiceValidator(t *testing.T) {
+	const (
+		subnetAnnotation = "service.beta.kubernetes.io/aws-load-balancer-subnets"
+		schemeAnnotation = "service.beta.kubernetes.io/aws-load-balancer-scheme"
+	)
+
+	tests := map[string]struct {
+		obj        metav1.Object
+		expValid   bool
+		expMessage string
+	}{
+		"A LoadBalancer service with all the required annotations should be valid.": {
+			obj: &corev1.Service{
+				ObjectMeta: metav1.ObjectMeta{
+					Annotations: map[string]string{subnetAnnotation: "subnet-a,subnet-b", schemeAnnotation: "internal"},
+				},
+				Spec: corev1.ServiceSpec{Type: corev1.ServiceTypeLoadBalancer},
+			},
+			expValid: true,
+		},
+
+		"A LoadBalancer service missing a required annotation should be invalid.": {
+			obj: &corev1.Service{
+				ObjectMeta: metav1.ObjectMeta{
+					Annotations: map[string]string{schemeAnnotation: "internal"},
+				},
+				Spec: corev1.ServiceSpec{Type: corev1.ServiceTypeLoadBalancer},
+			},
+			expValid:   false,
+			expMessage: "load balancer service is missing the required annotations: service.beta.kubernetes.io/aws-load-balancer-subnets",
+		},
+
+		"A LoadBalancer service without annotations should be invalid.": {
+			obj: &corev1.Service{
+				Spec: corev1.ServiceSpec{Type: corev1.ServiceTypeLoadBalancer},
+			},
+			expValid:   false,
+			expMessage: "load balancer service is missing the required annotations: service.beta.kubernetes.io/aws-load-balancer-subnets, service.beta.kubernetes.io/aws-load-balancer-scheme",
+		},
+
+		"A ClusterIP service without annotations should be valid.": {
+			obj: &corev1.Service{
+				Spec: corev1.ServiceSpec{Type: corev1.ServiceTypeClusterIP},
+			},
+			expValid: true,
+		},
+
+		"A non service object should be valid.": {
+			obj:      &corev1.Pod{},
+			expValid: true,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+			require := require.New(t)
+
+			v := validating.NewLoadBalancerServiceValidator([]string{subnetAnnotation, schemeAnnotation})
+			_, res, err := v.Validate(context.TODO(), test.obj)
+			require.NoError(err)
+
+			assert.Equal(test.expValid, res.Valid)
+			assert.Equal(test.expMessage, res.Message)
+		})
+	}
+}
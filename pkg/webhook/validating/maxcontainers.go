@@ -0,0 +1,32 @@
+package validating
+
+import (
+	"context"
+	"fmt"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/slok/kubewebhook/pkg/webhook/internal/helpers"
+)
+
+// NewMaxContainersValidator returns a validator that denies the pods (or the pod templates of
+// the workloads) that have more containers than the max, counting the init, the regular and
+// the ephemeral containers.
+func NewMaxContainersValidator(max int) Validator {
+	return ValidatorFunc(func(_ context.Context, obj metav1.Object) (bool, ValidatorResult, error) {
+		spec, ok := helpers.PodSpec(obj)
+		if !ok {
+			return false, ValidatorResult{Valid: true}, nil
+		}
+
+		count := len(spec.InitContainers) + len(spec.Containers) + len(spec.EphemeralContainers)
+		if count > max {
+			return true, ValidatorResult{
+				Valid:   false,
+				Message: fmt.Sprintf("pod has %d containers, the max allowed is %d", count, max),
+			}, nil
+		}
+
+		return false, ValidatorResult{Valid: true}, nil
+	})
+}
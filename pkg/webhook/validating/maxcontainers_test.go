@@ -0,0 +1,87 @@
+package validating_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/slok/kubewebhook/pkg/webhook/validating"
+)
+
+func TestMaxContainersValidator(t *testing.T) {
+	tests := map[string]struct {
+		obj        metav1.Object
+		expValid   bool
+		expMessage string
+	}{
+		"A pod below the limit should be valid.": {
+			obj: &corev1.Pod{
+				Spec: corev1.PodSpec{
+					Containers: []corev1.Container{{Name: "app"}},
+				},
+			},
+			expValid: true,
+		},
+
+		"A pod at the limit should be valid.": {
+			obj: &corev1.Pod{
+				Spec: corev1.PodSpec{
+					InitContainers:      []corev1.Container{{Name: "init"}},
+					Containers:          []corev1.Container{{Name: "app"}},
+					EphemeralContainers: []corev1.EphemeralContainer{{EphemeralContainerCommon: corev1.EphemeralContainerCommon{Name: "debug"}}},
+				},
+			},
+			expValid: true,
+		},
+
+		"A pod above the limit should be invalid.": {
+			obj: &corev1.Pod{
+				Spec: corev1.PodSpec{
+					InitContainers:      []corev1.Container{{Name: "init"}},
+					Containers:          []corev1.Container{{Name: "app"}, {Name: "sidecar"}},
+					EphemeralContainers: []corev1.EphemeralContainer{{EphemeralContainerCommon: corev1.EphemeralContainerCommon{Name: "debug"}}},
+				},
+			},
+			expValid:   false,
+			expMessage: "pod has 4 containers, the max allowed is 3",
+		},
+
+		"A workload pod template above the limit should be invalid.": {
+			obj: &appsv1.Deployment{
+				Spec: appsv1.DeploymentSpec{
+					Template: corev1.PodTemplateSpec{
+						Spec: corev1.PodSpec{
+							Containers: []corev1.Container{{Name: "app"}, {Name: "sidecar1"}, {Name: "sidecar2"}, {Name: "sidecar3"}},
+						},
+					},
+				},
+			},
+			expValid:   false,
+			expMessage: "pod has 4 containers, the max allowed is 3",
+		},
+
+		"A non pod object should be valid.": {
+			obj:      &corev1.Service{},
+			expValid: true,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+			require := require.New(t)
+
+			v := validating.NewMaxContainersValidator(3)
+			_, res, err := v.Validate(context.TODO(), test.obj)
+			require.NoError(err)
+
+			assert.Equal(test.expValid, res.Valid)
+			assert.Equal(test.expMessage, res.Message)
+		})
+	}
+}
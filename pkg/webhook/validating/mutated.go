@@ -0,0 +1,40 @@
+package validating
+
+import (
+	"context"
+	"fmt"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	"github.com/slok/kubewebhook/pkg/webhook/mutating"
+)
+
+// NewMutatedObjectValidator returns a validator that validates the object that the reference
+// mutator would produce. The mutation is made in memory on a copy of the received object,
+// the received object is never modified and the mutation is never applied.
+//
+// Ordering caveat: Kubernetes always calls the validating webhooks after all the mutating
+// webhooks, so on a cluster where the mutating webhook is registered the validator will
+// already receive the mutated object and the reference mutator will be applied twice (the
+// mutators should be idempotent). This validator is useful to check what a mutator would do
+// (e.g when the mutating webhook is not registered yet, or to validate a pipeline on tests).
+func NewMutatedObjectValidator(mutator mutating.Mutator, validator Validator) Validator {
+	return ValidatorFunc(func(ctx context.Context, obj metav1.Object) (bool, ValidatorResult, error) {
+		robj, ok := obj.(runtime.Object)
+		if !ok {
+			return true, ValidatorResult{}, fmt.Errorf("object can't be copied, is not a runtime.Object")
+		}
+
+		mutatedObj, ok := robj.DeepCopyObject().(metav1.Object)
+		if !ok {
+			return true, ValidatorResult{}, fmt.Errorf("impossible to type assert the deep copy to metav1.Object")
+		}
+
+		if _, err := mutator.Mutate(ctx, mutatedObj); err != nil {
+			return true, ValidatorResult{}, fmt.Errorf("reference mutator failed: %w", err)
+		}
+
+		return validator.Validate(ctx, mutatedObj)
+	})
+}
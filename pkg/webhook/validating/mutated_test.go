@@ -0,0 +1,78 @@
+package validating_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/slok/kubewebhook/pkg/webhook/mutating"
+	"github.com/slok/kubewebhook/pkg/webhook/validating"
+)
+
+func TestMutatedObjectValidator(t *testing.T) {
+	teamLabelMutator := mutating.MutatorFunc(func(_ context.Context, obj metav1.Object) (bool, error) {
+		labels := obj.GetLabels()
+		if labels == nil {
+			labels = map[string]string{}
+		}
+		labels["team"] = "team-a"
+		obj.SetLabels(labels)
+		return false, nil
+	})
+
+	teamLabelValidator := validating.ValidatorFunc(func(_ context.Context, obj metav1.Object) (bool, validating.ValidatorResult, error) {
+		if obj.GetLabels()["team"] == "" {
+			return true, validating.ValidatorResult{Valid: false, Message: "team label is required"}, nil
+		}
+		return false, validating.ValidatorResult{Valid: true}, nil
+	})
+
+	tests := map[string]struct {
+		mutator  mutating.Mutator
+		expValid bool
+		expErr   bool
+	}{
+		"The validator should see the fields of the mutated object.": {
+			mutator:  teamLabelMutator,
+			expValid: true,
+		},
+
+		"Without the mutation the validator should not see the mutated fields.": {
+			mutator:  mutating.NewChain(nil),
+			expValid: false,
+		},
+
+		"A reference mutator error should fail.": {
+			mutator: mutating.MutatorFunc(func(_ context.Context, obj metav1.Object) (bool, error) {
+				return true, fmt.Errorf("wanted error")
+			}),
+			expErr: true,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+			require := require.New(t)
+
+			pod := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "test", Labels: map[string]string{"app": "test"}}}
+
+			v := validating.NewMutatedObjectValidator(test.mutator, teamLabelValidator)
+			_, res, err := v.Validate(context.TODO(), pod)
+
+			if test.expErr {
+				assert.Error(err)
+			} else if assert.NoError(err) {
+				assert.Equal(test.expValid, res.Valid)
+			}
+
+			// The received object should not be mutated.
+			require.Equal(map[string]string{"app": "test"}, pod.Labels)
+		})
+	}
+}
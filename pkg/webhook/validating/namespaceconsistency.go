@@ -0,0 +1,35 @@
+package validating
+
+import (
+	"context"
+	"fmt"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	whcontext "github.com/slok/kubewebhook/pkg/webhook/context"
+)
+
+// NewNamespaceConsistencyValidator returns a validator that denies the objects whose metadata
+// namespace doesn't match the admission request namespace, a defense against namespace spoofing.
+//
+// The objects without namespace are allowed, these are the cluster scoped resources (both
+// namespaces are empty) and the namespaced objects that will get the namespace from the
+// request. If there isn't an admission request on the context the object will be allowed.
+func NewNamespaceConsistencyValidator() Validator {
+	return ValidatorFunc(func(ctx context.Context, obj metav1.Object) (bool, ValidatorResult, error) {
+		ar := whcontext.GetAdmissionRequest(ctx)
+		if ar == nil {
+			return false, ValidatorResult{Valid: true}, nil
+		}
+
+		ns := obj.GetNamespace()
+		if ns != "" && ns != ar.Namespace {
+			return true, ValidatorResult{
+				Valid:   false,
+				Message: fmt.Sprintf("object namespace %q doesn't match the request namespace %q", ns, ar.Namespace),
+			}, nil
+		}
+
+		return false, ValidatorResult{Valid: true}, nil
+	})
+}
@@ -0,0 +1,80 @@
+package validating_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	admissionv1beta1 "k8s.io/api/admission/v1beta1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	whcontext "github.com/slok/kubewebhook/pkg/webhook/context"
+	"github.com/slok/kubewebhook/pkg/webhook/validating"
+)
+
+func TestNamespaceConsistencyValidator(t *testing.T) {
+	tests := map[string]struct {
+		ar         *admissionv1beta1.AdmissionRequest
+		obj        metav1.Object
+		expValid   bool
+		expMessage string
+	}{
+		"An object with the same namespace as the request should be valid.": {
+			ar:       &admissionv1beta1.AdmissionRequest{Namespace: "team-a"},
+			obj:      &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Namespace: "team-a"}},
+			expValid: true,
+		},
+
+		"An object without namespace on a namespaced request should be valid.": {
+			ar:       &admissionv1beta1.AdmissionRequest{Namespace: "team-a"},
+			obj:      &corev1.Pod{},
+			expValid: true,
+		},
+
+		"A cluster scoped object should be valid.": {
+			ar:       &admissionv1beta1.AdmissionRequest{},
+			obj:      &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "team-a"}},
+			expValid: true,
+		},
+
+		"An object with a different namespace than the request should be invalid.": {
+			ar:         &admissionv1beta1.AdmissionRequest{Namespace: "team-a"},
+			obj:        &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Namespace: "kube-system"}},
+			expValid:   false,
+			expMessage: `object namespace "kube-system" doesn't match the request namespace "team-a"`,
+		},
+
+		"An object with namespace on a cluster scoped request should be invalid.": {
+			ar:         &admissionv1beta1.AdmissionRequest{},
+			obj:        &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Namespace: "kube-system"}},
+			expValid:   false,
+			expMessage: `object namespace "kube-system" doesn't match the request namespace ""`,
+		},
+
+		"An object without admission request should be valid.": {
+			obj:      &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Namespace: "kube-system"}},
+			expValid: true,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+			require := require.New(t)
+
+			ctx := context.TODO()
+			if test.ar != nil {
+				ctx = whcontext.SetAdmissionRequest(ctx, test.ar)
+			}
+
+			v := validating.NewNamespaceConsistencyValidator()
+			_, res, err := v.Validate(ctx, test.obj)
+			require.NoError(err)
+
+			assert.Equal(test.expValid, res.Valid)
+			assert.Equal(test.expMessage, res.Message)
+		})
+	}
+}
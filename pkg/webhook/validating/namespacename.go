@@ -0,0 +1,46 @@
+package validating
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+
+	admissionv1beta1 "k8s.io/api/admission/v1beta1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	whcontext "github.com/slok/kubewebhook/pkg/webhook/context"
+)
+
+// NewNamespaceNameValidator returns a validator that denies the creation of the Namespaces
+// whose name doesn't match the regex pattern (e.g `team-[a-z0-9]+`). The pattern needs to
+// match the full name.
+//
+// Only Namespace creations are validated, the rest of the operations (known using the
+// admission request on the context) will be allowed.
+func NewNamespaceNameValidator(pattern string) (Validator, error) {
+	rgx, err := regexp.Compile(fmt.Sprintf("^(?:%s)$", pattern))
+	if err != nil {
+		return nil, fmt.Errorf("invalid namespace name pattern: %w", err)
+	}
+
+	return ValidatorFunc(func(ctx context.Context, obj metav1.Object) (bool, ValidatorResult, error) {
+		ns, ok := obj.(*corev1.Namespace)
+		if !ok {
+			return false, ValidatorResult{Valid: true}, nil
+		}
+
+		if ar := whcontext.GetAdmissionRequest(ctx); ar != nil && ar.Operation != admissionv1beta1.Create {
+			return false, ValidatorResult{Valid: true}, nil
+		}
+
+		if !rgx.MatchString(ns.Name) {
+			return true, ValidatorResult{
+				Valid:   false,
+				Message: fmt.Sprintf("namespace name %q doesn't follow the naming convention, it must match %q", ns.Name, pattern),
+			}, nil
+		}
+
+		return false, ValidatorResult{Valid: true}, nil
+	}), nil
+}
@@ -0,0 +1,88 @@
+package validating_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	admissionv1beta1 "k8s.io/api/admission/v1beta1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	whcontext "github.com/slok/kubewebhook/pkg/webhook/context"
+	"github.com/slok/kubewebhook/pkg/webhook/validating"
+)
+
+func TestNamespaceNameValidator(t *testing.T) {
+	tests := map[string]struct {
+		pattern  string
+		obj      metav1.Object
+		op       admissionv1beta1.Operation
+		expValid bool
+		expErr   bool
+	}{
+		"An invalid pattern should fail.": {
+			pattern: "team-[a-z",
+			expErr:  true,
+		},
+
+		"A namespace creation with a conforming name should be valid.": {
+			pattern:  "team-[a-z0-9]+",
+			obj:      &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "team-payments1"}},
+			op:       admissionv1beta1.Create,
+			expValid: true,
+		},
+
+		"A namespace creation with a non conforming name should be invalid.": {
+			pattern:  "team-[a-z0-9]+",
+			obj:      &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "payments"}},
+			op:       admissionv1beta1.Create,
+			expValid: false,
+		},
+
+		"A namespace creation with a partially conforming name should be invalid.": {
+			pattern:  "team-[a-z0-9]+",
+			obj:      &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "my-team-payments"}},
+			op:       admissionv1beta1.Create,
+			expValid: false,
+		},
+
+		"A namespace update with a non conforming name should be valid.": {
+			pattern:  "team-[a-z0-9]+",
+			obj:      &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "payments"}},
+			op:       admissionv1beta1.Update,
+			expValid: true,
+		},
+
+		"A non namespace object should be valid.": {
+			pattern:  "team-[a-z0-9]+",
+			obj:      &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "payments"}},
+			op:       admissionv1beta1.Create,
+			expValid: true,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+			require := require.New(t)
+
+			v, err := validating.NewNamespaceNameValidator(test.pattern)
+			if test.expErr {
+				assert.Error(err)
+				return
+			}
+			require.NoError(err)
+
+			ctx := whcontext.SetAdmissionRequest(context.TODO(), &admissionv1beta1.AdmissionRequest{Operation: test.op})
+			_, res, err := v.Validate(ctx, test.obj)
+			require.NoError(err)
+
+			assert.Equal(test.expValid, res.Valid)
+			if !test.expValid {
+				assert.Contains(res.Message, "team-[a-z0-9]+")
+			}
+		})
+	}
+}
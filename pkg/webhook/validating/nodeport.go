@@ -0,0 +1,37 @@
+package validating
+
+import (
+	"context"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// NewNodePortValidator returns a validator that denies the Services that request NodePorts
+// outside of the allowed range (both included). The Services without NodePorts (e.g `ClusterIP`)
+// or with the NodePorts assigned automatically by Kubernetes will be allowed.
+func NewNodePortValidator(min, max int32) Validator {
+	return ValidatorFunc(func(_ context.Context, obj metav1.Object) (bool, ValidatorResult, error) {
+		svc, ok := obj.(*corev1.Service)
+		if !ok {
+			return false, ValidatorResult{Valid: true}, nil
+		}
+
+		for _, p := range svc.Spec.Ports {
+			// Not set NodePorts are assigned by Kubernetes.
+			if p.NodePort == 0 {
+				continue
+			}
+
+			if p.NodePort < min || p.NodePort > max {
+				return true, ValidatorResult{
+					Valid:   false,
+					Message: fmt.Sprintf("port %q node port %d is not allowed, it must be in the %d-%d range", p.Name, p.NodePort, min, max),
+				}, nil
+			}
+		}
+
+		return false, ValidatorResult{Valid: true}, nil
+	})
+}
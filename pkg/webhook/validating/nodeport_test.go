@@ -0,0 +1,97 @@
+package validating_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/slok/kubewebhook/pkg/webhook/validating"
+)
+
+func TestNodePortValidator(t *testing.T) {
+	tests := map[string]struct {
+		obj      metav1.Object
+		expValid bool
+	}{
+		"A NodePort service with the node ports in range should be valid.": {
+			obj: &corev1.Service{
+				Spec: corev1.ServiceSpec{
+					Type: corev1.ServiceTypeNodePort,
+					Ports: []corev1.ServicePort{
+						{Name: "http", Port: 80, NodePort: 30000},
+						{Name: "https", Port: 443, NodePort: 30100},
+					},
+				},
+			},
+			expValid: true,
+		},
+
+		"A NodePort service with a node port out of range should be invalid.": {
+			obj: &corev1.Service{
+				Spec: corev1.ServiceSpec{
+					Type: corev1.ServiceTypeNodePort,
+					Ports: []corev1.ServicePort{
+						{Name: "http", Port: 80, NodePort: 30000},
+						{Name: "https", Port: 443, NodePort: 31000},
+					},
+				},
+			},
+			expValid: false,
+		},
+
+		"A NodePort service with automatic node ports should be valid.": {
+			obj: &corev1.Service{
+				Spec: corev1.ServiceSpec{
+					Type:  corev1.ServiceTypeNodePort,
+					Ports: []corev1.ServicePort{{Name: "http", Port: 80}},
+				},
+			},
+			expValid: true,
+		},
+
+		"A LoadBalancer service with a node port out of range should be invalid.": {
+			obj: &corev1.Service{
+				Spec: corev1.ServiceSpec{
+					Type:  corev1.ServiceTypeLoadBalancer,
+					Ports: []corev1.ServicePort{{Name: "http", Port: 80, NodePort: 29999}},
+				},
+			},
+			expValid: false,
+		},
+
+		"A ClusterIP service should be valid.": {
+			obj: &corev1.Service{
+				Spec: corev1.ServiceSpec{
+					Type:  corev1.ServiceTypeClusterIP,
+					Ports: []corev1.ServicePort{{Name: "http", Port: 80}},
+				},
+			},
+			expValid: true,
+		},
+
+		"A non service object should be valid.": {
+			obj:      &corev1.Pod{},
+			expValid: true,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+			require := require.New(t)
+
+			v := validating.NewNodePortValidator(30000, 30999)
+			_, res, err := v.Validate(context.TODO(), test.obj)
+			require.NoError(err)
+
+			assert.Equal(test.expValid, res.Valid)
+			if !test.expValid {
+				assert.Contains(res.Message, "30000-30999")
+			}
+		})
+	}
+}
@@ -0,0 +1,64 @@
+package validating
+
+import (
+	"context"
+	"fmt"
+
+	appsv1 "k8s.io/api/apps/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	policyv1beta1listers "k8s.io/client-go/listers/policy/v1beta1"
+)
+
+// PDBCoverageValidatorConfig is the configuration of the PodDisruptionBudget coverage validator.
+type PDBCoverageValidatorConfig struct {
+	// Lister is the PodDisruptionBudget lister used to get the namespace PDBs. If
+	// missing the check will be skipped.
+	Lister policyv1beta1listers.PodDisruptionBudgetLister
+	// Deny will deny the workloads without PDB, by default the workloads will be
+	// allowed with a warning.
+	Deny bool
+}
+
+// NewPDBCoverageValidator returns a validator that checks the Deployments and StatefulSets are
+// covered by a PodDisruptionBudget of the same namespace that selects the workload pods.
+func NewPDBCoverageValidator(cfg PDBCoverageValidatorConfig) Validator {
+	return ValidatorFunc(func(_ context.Context, obj metav1.Object) (bool, ValidatorResult, error) {
+		if cfg.Lister == nil {
+			return false, ValidatorResult{Valid: true}, nil
+		}
+
+		var podLabels map[string]string
+		switch o := obj.(type) {
+		case *appsv1.Deployment:
+			podLabels = o.Spec.Template.Labels
+		case *appsv1.StatefulSet:
+			podLabels = o.Spec.Template.Labels
+		default:
+			return false, ValidatorResult{Valid: true}, nil
+		}
+
+		pdbs, err := cfg.Lister.PodDisruptionBudgets(obj.GetNamespace()).List(labels.Everything())
+		if err != nil {
+			return true, ValidatorResult{}, fmt.Errorf("could not list PodDisruptionBudgets: %w", err)
+		}
+
+		for _, pdb := range pdbs {
+			selector, err := metav1.LabelSelectorAsSelector(pdb.Spec.Selector)
+			if err != nil {
+				return true, ValidatorResult{}, fmt.Errorf("invalid %s PodDisruptionBudget selector: %w", pdb.Name, err)
+			}
+
+			if !selector.Empty() && selector.Matches(labels.Set(podLabels)) {
+				return false, ValidatorResult{Valid: true}, nil
+			}
+		}
+
+		msg := fmt.Sprintf("%s/%s is not covered by any PodDisruptionBudget", obj.GetNamespace(), obj.GetName())
+		if cfg.Deny {
+			return true, ValidatorResult{Valid: false, Message: msg}, nil
+		}
+
+		return false, ValidatorResult{Valid: true, Warnings: []string{msg}}, nil
+	})
+}
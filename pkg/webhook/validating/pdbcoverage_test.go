@@ -0,0 +1,110 @@
+package validating_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	policyv1beta1 "k8s.io/api/policy/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	policyv1beta1listers "k8s.io/client-go/listers/policy/v1beta1"
+	"k8s.io/client-go/tools/cache"
+
+	"github.com/slok/kubewebhook/pkg/webhook/validating"
+)
+
+func newPDBLister(pdbs ...*policyv1beta1.PodDisruptionBudget) policyv1beta1listers.PodDisruptionBudgetLister {
+	indexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc})
+	for _, pdb := range pdbs {
+		_ = indexer.Add(pdb)
+	}
+	return policyv1beta1listers.NewPodDisruptionBudgetLister(indexer)
+}
+
+func TestPDBCoverageValidator(t *testing.T) {
+	deploy := &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{Name: "app", Namespace: "test"},
+		Spec: appsv1.DeploymentSpec{
+			Template: corev1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{"app": "app"}},
+			},
+		},
+	}
+	pdb := func(ns string, selector map[string]string) *policyv1beta1.PodDisruptionBudget {
+		return &policyv1beta1.PodDisruptionBudget{
+			ObjectMeta: metav1.ObjectMeta{Name: "pdb", Namespace: ns},
+			Spec: policyv1beta1.PodDisruptionBudgetSpec{
+				Selector: &metav1.LabelSelector{MatchLabels: selector},
+			},
+		}
+	}
+
+	tests := map[string]struct {
+		cfg    validating.PDBCoverageValidatorConfig
+		obj    metav1.Object
+		expRes validating.ValidatorResult
+	}{
+		"Without lister the check should be skipped.": {
+			cfg:    validating.PDBCoverageValidatorConfig{},
+			obj:    deploy,
+			expRes: validating.ValidatorResult{Valid: true},
+		},
+
+		"A deployment with a matching PDB should be valid.": {
+			cfg: validating.PDBCoverageValidatorConfig{
+				Lister: newPDBLister(pdb("test", map[string]string{"app": "app"})),
+				Deny:   true,
+			},
+			obj:    deploy,
+			expRes: validating.ValidatorResult{Valid: true},
+		},
+
+		"A deployment without a matching PDB should be denied.": {
+			cfg: validating.PDBCoverageValidatorConfig{
+				Lister: newPDBLister(
+					pdb("test", map[string]string{"app": "other"}),
+					pdb("other", map[string]string{"app": "app"}),
+				),
+				Deny: true,
+			},
+			obj:    deploy,
+			expRes: validating.ValidatorResult{Valid: false, Message: "test/app is not covered by any PodDisruptionBudget"},
+		},
+
+		"A deployment without a matching PDB should be allowed with a warning by default.": {
+			cfg: validating.PDBCoverageValidatorConfig{
+				Lister: newPDBLister(),
+			},
+			obj: deploy,
+			expRes: validating.ValidatorResult{
+				Valid:    true,
+				Warnings: []string{"test/app is not covered by any PodDisruptionBudget"},
+			},
+		},
+
+		"Not workload objects should be skipped.": {
+			cfg: validating.PDBCoverageValidatorConfig{
+				Lister: newPDBLister(),
+				Deny:   true,
+			},
+			obj:    &corev1.Pod{},
+			expRes: validating.ValidatorResult{Valid: true},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+			require := require.New(t)
+
+			v := validating.NewPDBCoverageValidator(test.cfg)
+			_, res, err := v.Validate(context.TODO(), test.obj)
+			require.NoError(err)
+
+			assert.Equal(test.expRes, res)
+		})
+	}
+}
@@ -0,0 +1,49 @@
+package validating
+
+import (
+	"context"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	whcontext "github.com/slok/kubewebhook/pkg/webhook/context"
+)
+
+// NewPVCStorageQuotaValidator returns a validator that denies the PersistentVolumeClaims whose
+// storage request exceeds the storage cap of their namespace. The PersistentVolumeClaims of the
+// namespaces without cap will be allowed.
+func NewPVCStorageQuotaValidator(namespaceCaps map[string]resource.Quantity) Validator {
+	return ValidatorFunc(func(ctx context.Context, obj metav1.Object) (bool, ValidatorResult, error) {
+		pvc, ok := obj.(*corev1.PersistentVolumeClaim)
+		if !ok {
+			return false, ValidatorResult{Valid: true}, nil
+		}
+
+		// The objects created by controllers (e.g StatefulSet volume claims) don't have the namespace set.
+		ns := pvc.Namespace
+		if ar := whcontext.GetAdmissionRequest(ctx); ns == "" && ar != nil {
+			ns = ar.Namespace
+		}
+
+		storageCap, ok := namespaceCaps[ns]
+		if !ok {
+			return false, ValidatorResult{Valid: true}, nil
+		}
+
+		requested, ok := pvc.Spec.Resources.Requests[corev1.ResourceStorage]
+		if !ok {
+			return false, ValidatorResult{Valid: true}, nil
+		}
+
+		if requested.Cmp(storageCap) > 0 {
+			return true, ValidatorResult{
+				Valid:   false,
+				Message: fmt.Sprintf("requested storage %s exceeds the %q namespace allowed storage of %s", requested.String(), ns, storageCap.String()),
+			}, nil
+		}
+
+		return false, ValidatorResult{Valid: true}, nil
+	})
+}
@@ -0,0 +1,80 @@
+package validating_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/slok/kubewebhook/pkg/webhook/validating"
+)
+
+func TestPVCStorageQuotaValidator(t *testing.T) {
+	pvc := func(ns, storage string) *corev1.PersistentVolumeClaim {
+		return &corev1.PersistentVolumeClaim{
+			ObjectMeta: metav1.ObjectMeta{Namespace: ns},
+			Spec: corev1.PersistentVolumeClaimSpec{
+				Resources: corev1.ResourceRequirements{
+					Requests: corev1.ResourceList{corev1.ResourceStorage: resource.MustParse(storage)},
+				},
+			},
+		}
+	}
+
+	tests := map[string]struct {
+		obj        metav1.Object
+		expValid   bool
+		expMessage string
+	}{
+		"A PVC under the cap should be valid.": {
+			obj:      pvc("team-a", "5Gi"),
+			expValid: true,
+		},
+
+		"A PVC at the cap should be valid.": {
+			obj:      pvc("team-a", "10Gi"),
+			expValid: true,
+		},
+
+		"A PVC at the cap with a different unit should be valid.": {
+			obj:      pvc("team-a", "10240Mi"),
+			expValid: true,
+		},
+
+		"A PVC over the cap should be invalid.": {
+			obj:        pvc("team-a", "11Gi"),
+			expValid:   false,
+			expMessage: `requested storage 11Gi exceeds the "team-a" namespace allowed storage of 10Gi`,
+		},
+
+		"A PVC on a namespace without cap should be valid.": {
+			obj:      pvc("team-b", "1Ti"),
+			expValid: true,
+		},
+
+		"A non PVC object should be valid.": {
+			obj:      &corev1.Pod{},
+			expValid: true,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+			require := require.New(t)
+
+			v := validating.NewPVCStorageQuotaValidator(map[string]resource.Quantity{
+				"team-a": resource.MustParse("10Gi"),
+			})
+			_, res, err := v.Validate(context.TODO(), test.obj)
+			require.NoError(err)
+
+			assert.Equal(test.expValid, res.Valid)
+			assert.Equal(test.expMessage, res.Message)
+		})
+	}
+}
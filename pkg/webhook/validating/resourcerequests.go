@@ -0,0 +1,44 @@
+package validating
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/slok/kubewebhook/pkg/webhook/internal/helpers"
+)
+
+// NewRequireResourceRequests returns a validator that denies the pods (or the pod templates of
+// the workloads) whose containers, including the init containers, don't have a request of
+// the required resources (e.g `cpu` and `memory`), naming the offending containers and resources.
+func NewRequireResourceRequests(required []corev1.ResourceName) Validator {
+	return ValidatorFunc(func(_ context.Context, obj metav1.Object) (bool, ValidatorResult, error) {
+		spec, ok := helpers.PodSpec(obj)
+		if !ok {
+			return false, ValidatorResult{Valid: true}, nil
+		}
+
+		var msgs []string
+		for _, cs := range [][]corev1.Container{spec.InitContainers, spec.Containers} {
+			for _, c := range cs {
+				for _, r := range required {
+					if _, ok := c.Resources.Requests[r]; !ok {
+						msgs = append(msgs, fmt.Sprintf("container %q is missing the %q resource request", c.Name, r))
+					}
+				}
+			}
+		}
+
+		if len(msgs) > 0 {
+			return true, ValidatorResult{
+				Valid:   false,
+				Message: strings.Join(msgs, "; "),
+			}, nil
+		}
+
+		return false, ValidatorResult{Valid: true}, nil
+	})
+}
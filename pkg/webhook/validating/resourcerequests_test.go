@@ -0,0 +1,98 @@
+package validating_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/slok/kubewebhook/pkg/webhook/validating"
+)
+
+func TestRequireResourceRequests(t *testing.T) {
+	requests := func(rs ...corev1.ResourceName) corev1.ResourceRequirements {
+		rl := corev1.ResourceList{}
+		for _, r := range rs {
+			rl[r] = resource.MustParse("1")
+		}
+		return corev1.ResourceRequirements{Requests: rl}
+	}
+	deployment := func(initContainers, containers []corev1.Container) *appsv1.Deployment {
+		return &appsv1.Deployment{
+			Spec: appsv1.DeploymentSpec{
+				Template: corev1.PodTemplateSpec{
+					Spec: corev1.PodSpec{InitContainers: initContainers, Containers: containers},
+				},
+			},
+		}
+	}
+
+	tests := map[string]struct {
+		obj         metav1.Object
+		expValid    bool
+		expMessages []string
+	}{
+		"A workload with all the resource requests should be valid.": {
+			obj: deployment(
+				[]corev1.Container{{Name: "init", Resources: requests(corev1.ResourceCPU, corev1.ResourceMemory)}},
+				[]corev1.Container{{Name: "app", Resources: requests(corev1.ResourceCPU, corev1.ResourceMemory)}},
+			),
+			expValid: true,
+		},
+
+		"A workload with partial resource requests should be invalid.": {
+			obj: deployment(nil, []corev1.Container{
+				{Name: "app", Resources: requests(corev1.ResourceCPU, corev1.ResourceMemory)},
+				{Name: "sidecar", Resources: requests(corev1.ResourceCPU)},
+			}),
+			expValid:    false,
+			expMessages: []string{`container "sidecar" is missing the "memory" resource request`},
+		},
+
+		"A workload without resource requests should be invalid, including the init containers.": {
+			obj: deployment(
+				[]corev1.Container{{Name: "init"}},
+				[]corev1.Container{{Name: "app"}},
+			),
+			expValid: false,
+			expMessages: []string{
+				`container "init" is missing the "cpu" resource request`,
+				`container "init" is missing the "memory" resource request`,
+				`container "app" is missing the "cpu" resource request`,
+				`container "app" is missing the "memory" resource request`,
+			},
+		},
+
+		"A pod without resource requests should be invalid.": {
+			obj:         &corev1.Pod{Spec: corev1.PodSpec{Containers: []corev1.Container{{Name: "app", Resources: requests(corev1.ResourceMemory)}}}},
+			expValid:    false,
+			expMessages: []string{`container "app" is missing the "cpu" resource request`},
+		},
+
+		"A non pod object should be valid.": {
+			obj:      &corev1.Service{},
+			expValid: true,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+			require := require.New(t)
+
+			v := validating.NewRequireResourceRequests([]corev1.ResourceName{corev1.ResourceCPU, corev1.ResourceMemory})
+			_, res, err := v.Validate(context.TODO(), test.obj)
+			require.NoError(err)
+
+			assert.Equal(test.expValid, res.Valid)
+			for _, msg := range test.expMessages {
+				assert.Contains(res.Message, msg)
+			}
+		})
+	}
+}
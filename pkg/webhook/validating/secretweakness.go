@@ -0,0 +1,75 @@
+package validating
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// NewSecretWeaknessValidator returns a validator that denies the Secrets that have well-known
+// weak values (e.g `password`, `changeme`). The patterns are case insensitive regexes that need
+// to match the full value (e.g `admin[0-9]*`).
+//
+// The Secret data is received base64 encoded, it's decoded before checking it and the values of
+// `stringData` are also checked. The denial message only has the offending keys, the values are
+// never returned nor logged.
+func NewSecretWeaknessValidator(patterns []string) (Validator, error) {
+	rgxs := make([]*regexp.Regexp, 0, len(patterns))
+	for _, p := range patterns {
+		rgx, err := regexp.Compile(fmt.Sprintf("^(?i:%s)$", p))
+		if err != nil {
+			return nil, fmt.Errorf("invalid weak secret pattern: %w", err)
+		}
+		rgxs = append(rgxs, rgx)
+	}
+
+	isWeak := func(value string) bool {
+		value = strings.TrimSpace(value)
+		for _, rgx := range rgxs {
+			if rgx.MatchString(value) {
+				return true
+			}
+		}
+		return false
+	}
+
+	return ValidatorFunc(func(_ context.Context, obj metav1.Object) (bool, ValidatorResult, error) {
+		secret, ok := obj.(*corev1.Secret)
+		if !ok {
+			return false, ValidatorResult{Valid: true}, nil
+		}
+
+		// The Secret data is decoded from base64 when the object is unmarshaled.
+		weakKeys := map[string]bool{}
+		for k, v := range secret.Data {
+			if isWeak(string(v)) {
+				weakKeys[k] = true
+			}
+		}
+		for k, v := range secret.StringData {
+			if isWeak(v) {
+				weakKeys[k] = true
+			}
+		}
+
+		if len(weakKeys) > 0 {
+			keys := make([]string, 0, len(weakKeys))
+			for k := range weakKeys {
+				keys = append(keys, k)
+			}
+			sort.Strings(keys)
+
+			return true, ValidatorResult{
+				Valid:   false,
+				Message: fmt.Sprintf("secret has weak values on keys: %s", strings.Join(keys, ", ")),
+			}, nil
+		}
+
+		return false, ValidatorResult{Valid: true}, nil
+	}), nil
+}
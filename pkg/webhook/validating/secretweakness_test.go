@@ -0,0 +1,98 @@
+package validating_test
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/slok/kubewebhook/pkg/webhook/validating"
+)
+
+func TestSecretWeaknessValidator(t *testing.T) {
+	patterns := []string{"password", "changeme", "admin[0-9]*"}
+
+	// Get the secrets like the webhook receives them, with the data base64 encoded.
+	secretFromJSON := func(data string) *corev1.Secret {
+		s := &corev1.Secret{}
+		err := json.Unmarshal([]byte(data), s)
+		require.NoError(t, err)
+		return s
+	}
+
+	tests := map[string]struct {
+		patterns   []string
+		obj        metav1.Object
+		expErr     bool
+		expValid   bool
+		expMessage string
+	}{
+		"A secret with strong values should be valid.": {
+			patterns: patterns,
+			// {"user": "app", "password": "x8#kP2!qZ9"}
+			obj:      secretFromJSON(`{"data": {"user": "YXBw", "password": "eDgja1AyIXFaOQ=="}}`),
+			expValid: true,
+		},
+
+		"A secret with weak values should be invalid with the offending keys.": {
+			patterns: patterns,
+			// {"user": "app", "password": "ChangeMe", "root": "admin123"}
+			obj:        secretFromJSON(`{"data": {"user": "YXBw", "password": "Q2hhbmdlTWU=", "root": "YWRtaW4xMjM="}}`),
+			expValid:   false,
+			expMessage: "secret has weak values on keys: password, root",
+		},
+
+		"A secret with weak string data values should be invalid.": {
+			patterns: patterns,
+			obj: &corev1.Secret{
+				StringData: map[string]string{"token": "password\n"},
+			},
+			expValid:   false,
+			expMessage: "secret has weak values on keys: token",
+		},
+
+		"A secret with values that contain a weak value should be valid.": {
+			patterns: patterns,
+			obj: &corev1.Secret{
+				Data: map[string][]byte{"password": []byte("my-password-is-strong-9f8a7")},
+			},
+			expValid: true,
+		},
+
+		"A non secret object should be valid.": {
+			patterns: patterns,
+			obj:      &corev1.ConfigMap{Data: map[string]string{"password": "password"}},
+			expValid: true,
+		},
+
+		"An invalid pattern should fail.": {
+			patterns: []string{"[password"},
+			expErr:   true,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+			require := require.New(t)
+
+			v, err := validating.NewSecretWeaknessValidator(test.patterns)
+			if test.expErr {
+				assert.Error(err)
+				return
+			}
+			require.NoError(err)
+
+			_, res, err := v.Validate(context.TODO(), test.obj)
+			require.NoError(err)
+
+			assert.Equal(test.expValid, res.Valid)
+			assert.Equal(test.expMessage, res.Message)
+			assert.NotContains(res.Message, "ChangeMe")
+		})
+	}
+}
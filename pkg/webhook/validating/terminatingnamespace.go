@@ -0,0 +1,60 @@
+package validating
+
+import (
+	"context"
+	"fmt"
+
+	admissionv1beta1 "k8s.io/api/admission/v1beta1"
+	corev1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	corev1listers "k8s.io/client-go/listers/core/v1"
+
+	whcontext "github.com/slok/kubewebhook/pkg/webhook/context"
+)
+
+// NewTerminatingNamespaceValidator returns a validator that denies the creation of objects on
+// terminating namespaces with a clear message, instead of the generic API server error. The
+// webhooks don't receive the namespace object, so a namespace lister is required, if the lister
+// is missing or the namespace is not found the validation will be skipped.
+//
+// Only creations are validated, the rest of the operations (known using the admission request
+// on the context) will be allowed.
+func NewTerminatingNamespaceValidator(lister corev1listers.NamespaceLister) Validator {
+	return ValidatorFunc(func(ctx context.Context, obj metav1.Object) (bool, ValidatorResult, error) {
+		if lister == nil {
+			return false, ValidatorResult{Valid: true}, nil
+		}
+
+		ar := whcontext.GetAdmissionRequest(ctx)
+		if ar != nil && ar.Operation != admissionv1beta1.Create {
+			return false, ValidatorResult{Valid: true}, nil
+		}
+
+		// The objects created by controllers don't have the namespace set on the object.
+		nsName := obj.GetNamespace()
+		if nsName == "" && ar != nil {
+			nsName = ar.Namespace
+		}
+		if nsName == "" {
+			return false, ValidatorResult{Valid: true}, nil
+		}
+
+		ns, err := lister.Get(nsName)
+		if err != nil {
+			if kerrors.IsNotFound(err) {
+				return false, ValidatorResult{Valid: true}, nil
+			}
+			return true, ValidatorResult{}, fmt.Errorf("could not get %q namespace: %w", nsName, err)
+		}
+
+		if ns.Status.Phase == corev1.NamespaceTerminating {
+			return true, ValidatorResult{
+				Valid:   false,
+				Message: fmt.Sprintf("namespace %q is terminating, new objects can't be created on it", nsName),
+			}, nil
+		}
+
+		return false, ValidatorResult{Valid: true}, nil
+	})
+}
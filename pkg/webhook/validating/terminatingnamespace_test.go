@@ -0,0 +1,104 @@
+package validating_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	admissionv1beta1 "k8s.io/api/admission/v1beta1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	corev1listers "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/tools/cache"
+
+	whcontext "github.com/slok/kubewebhook/pkg/webhook/context"
+	"github.com/slok/kubewebhook/pkg/webhook/validating"
+)
+
+func newNamespaceLister(nss ...*corev1.Namespace) corev1listers.NamespaceLister {
+	indexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})
+	for _, ns := range nss {
+		_ = indexer.Add(ns)
+	}
+	return corev1listers.NewNamespaceLister(indexer)
+}
+
+func TestTerminatingNamespaceValidator(t *testing.T) {
+	lister := newNamespaceLister(
+		&corev1.Namespace{
+			ObjectMeta: metav1.ObjectMeta{Name: "active"},
+			Status:     corev1.NamespaceStatus{Phase: corev1.NamespaceActive},
+		},
+		&corev1.Namespace{
+			ObjectMeta: metav1.ObjectMeta{Name: "terminating"},
+			Status:     corev1.NamespaceStatus{Phase: corev1.NamespaceTerminating},
+		},
+	)
+
+	tests := map[string]struct {
+		lister     corev1listers.NamespaceLister
+		obj        metav1.Object
+		ar         *admissionv1beta1.AdmissionRequest
+		expValid   bool
+		expMessage string
+	}{
+		"Creating an object on an active namespace should be valid.": {
+			lister:   lister,
+			obj:      &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Namespace: "active"}},
+			ar:       &admissionv1beta1.AdmissionRequest{Operation: admissionv1beta1.Create},
+			expValid: true,
+		},
+
+		"Creating an object on a terminating namespace should be invalid.": {
+			lister:     lister,
+			obj:        &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Namespace: "terminating"}},
+			ar:         &admissionv1beta1.AdmissionRequest{Operation: admissionv1beta1.Create},
+			expValid:   false,
+			expMessage: `namespace "terminating" is terminating, new objects can't be created on it`,
+		},
+
+		"Creating an object without namespace on a terminating request namespace should be invalid.": {
+			lister:     lister,
+			obj:        &corev1.Pod{},
+			ar:         &admissionv1beta1.AdmissionRequest{Operation: admissionv1beta1.Create, Namespace: "terminating"},
+			expValid:   false,
+			expMessage: `namespace "terminating" is terminating, new objects can't be created on it`,
+		},
+
+		"Updating an object on a terminating namespace should be valid.": {
+			lister:   lister,
+			obj:      &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Namespace: "terminating"}},
+			ar:       &admissionv1beta1.AdmissionRequest{Operation: admissionv1beta1.Update},
+			expValid: true,
+		},
+
+		"Creating an object on a missing namespace should be valid.": {
+			lister:   lister,
+			obj:      &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Namespace: "missing"}},
+			ar:       &admissionv1beta1.AdmissionRequest{Operation: admissionv1beta1.Create},
+			expValid: true,
+		},
+
+		"Without lister the validation should be skipped.": {
+			obj:      &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Namespace: "terminating"}},
+			ar:       &admissionv1beta1.AdmissionRequest{Operation: admissionv1beta1.Create},
+			expValid: true,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+			require := require.New(t)
+
+			ctx := whcontext.SetAdmissionRequest(context.TODO(), test.ar)
+			v := validating.NewTerminatingNamespaceValidator(test.lister)
+			_, res, err := v.Validate(ctx, test.obj)
+			require.NoError(err)
+
+			assert.Equal(test.expValid, res.Valid)
+			assert.Equal(test.expMessage, res.Message)
+		})
+	}
+}
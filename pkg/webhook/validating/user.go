@@ -0,0 +1,25 @@
+package validating
+
+import (
+	"context"
+
+	whcontext "github.com/slok/kubewebhook/pkg/webhook/context"
+)
+
+// UserInGroup returns true if the user that made the admission request stored on the context
+// belongs to the group. Useful for validators that branch on the user groups (e.g stricter
+// policies for the non admin groups). If the request is missing it will return false.
+func UserInGroup(ctx context.Context, group string) bool {
+	ui, ok := whcontext.GetUserInfo(ctx)
+	if !ok {
+		return false
+	}
+
+	for _, g := range ui.Groups {
+		if g == group {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,68 @@
+package validating_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	admissionv1beta1 "k8s.io/api/admission/v1beta1"
+	authenticationv1 "k8s.io/api/authentication/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	whcontext "github.com/slok/kubewebhook/pkg/webhook/context"
+	"github.com/slok/kubewebhook/pkg/webhook/validating"
+)
+
+func TestUserInGroup(t *testing.T) {
+	// Validator that only allows privileged pods to the admins.
+	v := validating.ValidatorFunc(func(ctx context.Context, obj metav1.Object) (bool, validating.ValidatorResult, error) {
+		pod := obj.(*corev1.Pod)
+		if pod.Labels["privileged"] == "true" && !validating.UserInGroup(ctx, "admins") {
+			return true, validating.ValidatorResult{Valid: false, Message: "only admins can create privileged pods"}, nil
+		}
+		return false, validating.ValidatorResult{Valid: true}, nil
+	})
+
+	tests := map[string]struct {
+		ar       *admissionv1beta1.AdmissionRequest
+		expValid bool
+	}{
+		"A user in the group should branch to the group policy.": {
+			ar: &admissionv1beta1.AdmissionRequest{
+				UserInfo: authenticationv1.UserInfo{Username: "john", Groups: []string{"system:authenticated", "admins"}},
+			},
+			expValid: true,
+		},
+
+		"A user not in the group should not branch to the group policy.": {
+			ar: &admissionv1beta1.AdmissionRequest{
+				UserInfo: authenticationv1.UserInfo{Username: "jane", Groups: []string{"system:authenticated", "developers"}},
+			},
+			expValid: false,
+		},
+
+		"A missing admission request should not be in any group.": {
+			expValid: false,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+			require := require.New(t)
+
+			ctx := context.TODO()
+			if test.ar != nil {
+				ctx = whcontext.SetAdmissionRequest(ctx, test.ar)
+			}
+
+			pod := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{"privileged": "true"}}}
+			_, res, err := v.Validate(ctx, pod)
+			require.NoError(err)
+
+			assert.Equal(test.expValid, res.Valid)
+		})
+	}
+}
@@ -13,6 +13,8 @@ import (
 type ValidatorResult struct {
 	Valid   bool
 	Message string
+	// Warnings are warning messages that will be returned to the API client.
+	Warnings []string
 }
 
 // Validator knows how to validate the received kubernetes object.
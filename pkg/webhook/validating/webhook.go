@@ -3,14 +3,18 @@ package validating
 import (
 	"context"
 	"fmt"
+	"strings"
+	"time"
 
 	opentracing "github.com/opentracing/opentracing-go"
 	admissionv1beta1 "k8s.io/api/admission/v1beta1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
 
 	"github.com/slok/kubewebhook/pkg/log"
 	"github.com/slok/kubewebhook/pkg/observability/metrics"
 	"github.com/slok/kubewebhook/pkg/webhook"
+	whcontext "github.com/slok/kubewebhook/pkg/webhook/context"
 	"github.com/slok/kubewebhook/pkg/webhook/internal/helpers"
 	"github.com/slok/kubewebhook/pkg/webhook/internal/instrumenting"
 )
@@ -22,17 +26,74 @@ type WebhookConfig struct {
 	// Object is the object of the webhook, to use multiple types on the same webhook or
 	// type inference, don't set this field (will be `nil`).
 	Obj metav1.Object
+	// DecodeErrorAllowKinds are the kinds that will be allowed without any validation in case
+	// the webhook can't decode them (e.g third party CRDs matched by a broad rule).
+	DecodeErrorAllowKinds []schema.GroupKind
+	// KindMismatchPolicy is the policy applied when the webhook has a static object type and the
+	// admission request kind doesn't match the object kind, by default the kind is not checked.
+	// Only the object types registered on the client-go scheme can be checked.
+	KindMismatchPolicy webhook.KindMismatchPolicy
+	// InstrumentOwnerKind will add the kind of the reviewed object controller owner (e.g `ReplicaSet`)
+	// to the metrics and traces, useful to attribute the webhook load to the controllers. Only the
+	// owner kind is used (not the name) to have a bounded metrics cardinality.
+	InstrumentOwnerKind bool
+	// SlowThreshold is the review duration that once exceeded will mark the review as slow, the slow
+	// reviews still succeed but with a warning for the API client and they are measured by the
+	// slow reviews metric, useful to track the webhook SLOs. By default (0) it's disabled.
+	SlowThreshold time.Duration
+	// ExpectedTimeout is the webhook timeout configured on the API server (`timeoutSeconds`), the
+	// webhook doesn't know it, so it needs to be configured to detect the reviews close to the
+	// deadline. By default (0) it's disabled.
+	ExpectedTimeout time.Duration
+	// DeadlineWarningRatio is the ratio of the expected timeout that once exceeded will mark the
+	// review as near the deadline, these reviews still succeed but with a warning for the API
+	// client and they are measured by the near deadline reviews metric. By default 0.8.
+	DeadlineWarningRatio float64
+	// TraceBaggage will set the reviewed object group, version, kind and the operation as tracing
+	// baggage (using the `webhook.Baggage*Key` keys) on the context received by the validators, the
+	// baggage is propagated with the trace context to the downstream services called by them.
+	TraceBaggage bool
+}
+
+func (c *WebhookConfig) defaults() {
+	if c.ExpectedTimeout > 0 && c.DeadlineWarningRatio == 0 {
+		c.DeadlineWarningRatio = 0.8
+	}
+
+	if c.KindMismatchPolicy == "" {
+		c.KindMismatchPolicy = webhook.KindMismatchPolicyIgnore
+	}
 }
 
 func (c *WebhookConfig) validate() error {
-	errs := ""
+	var errs []string
 
 	if c.Name == "" {
-		errs = errs + "name can't be empty"
+		errs = append(errs, "name can't be empty")
+	}
+
+	if !c.KindMismatchPolicy.Valid() {
+		errs = append(errs, fmt.Sprintf("unknown kind mismatch policy %q", c.KindMismatchPolicy))
 	}
 
-	if errs != "" {
-		return fmt.Errorf("invalid configuration: %s", errs)
+	if c.SlowThreshold < 0 {
+		errs = append(errs, "slow threshold can't be negative")
+	}
+
+	if c.ExpectedTimeout < 0 {
+		errs = append(errs, "expected timeout can't be negative")
+	}
+
+	if c.DeadlineWarningRatio < 0 {
+		errs = append(errs, "deadline warning ratio can't be negative")
+	}
+
+	if c.DeadlineWarningRatio > 1 {
+		errs = append(errs, "deadline warning ratio can't be greater than 1")
+	}
+
+	if len(errs) > 0 {
+		return fmt.Errorf("invalid configuration: %s", strings.Join(errs, ", "))
 	}
 
 	return nil
@@ -41,6 +102,7 @@ func (c *WebhookConfig) validate() error {
 // NewWebhook is a validating webhook and will return a webhook ready for a type of resource
 // it will validate the received resources.
 func NewWebhook(cfg WebhookConfig, validator Validator, ot opentracing.Tracer, recorder metrics.Recorder, logger log.Logger) (webhook.Webhook, error) {
+	cfg.defaults()
 	if err := cfg.validate(); err != nil {
 		return nil, err
 	}
@@ -62,8 +124,14 @@ func NewWebhook(cfg WebhookConfig, validator Validator, ot opentracing.Tracer, r
 	// If we don't have the type of the object create a dynamic object creator that will
 	// infer the type.
 	var oc helpers.ObjectCreator
+	var objGroupKind *schema.GroupKind
 	if cfg.Obj != nil {
 		oc = helpers.NewStaticObjectCreator(cfg.Obj)
+		if gk, ok := helpers.ObjectGroupKind(cfg.Obj); ok {
+			objGroupKind = &gk
+		} else if cfg.KindMismatchPolicy != webhook.KindMismatchPolicyIgnore {
+			logger.Warningf("webhook object type is not registered on the scheme, the request kind will not be checked")
+		}
 	} else {
 		oc = helpers.NewDynamicObjectCreator()
 	}
@@ -71,28 +139,67 @@ func NewWebhook(cfg WebhookConfig, validator Validator, ot opentracing.Tracer, r
 	// Create our webhook and wrap for instrumentation (metrics and tracing).
 	return &instrumenting.Webhook{
 		Webhook: &validateWebhook{
-			objectCreator: oc,
-			validator:     validator,
-			cfg:           cfg,
-			logger:        logger,
+			objectCreator:   oc,
+			validator:       validator,
+			objGroupKind:    objGroupKind,
+			cfg:             cfg,
+			logger:          logger,
+			metricsRecorder: recorder,
 		},
-		ReviewKind:      metrics.ValidatingReviewKind,
-		WebhookName:     cfg.Name,
-		MetricsRecorder: recorder,
-		Tracer:          ot,
+		ReviewKind:           metrics.ValidatingReviewKind,
+		WebhookName:          cfg.Name,
+		MetricsRecorder:      recorder,
+		Tracer:               ot,
+		OwnerKind:            cfg.InstrumentOwnerKind,
+		SlowThreshold:        cfg.SlowThreshold,
+		ExpectedTimeout:      cfg.ExpectedTimeout,
+		DeadlineWarningRatio: cfg.DeadlineWarningRatio,
+		TraceBaggage:         cfg.TraceBaggage,
 	}, nil
 }
 
 type validateWebhook struct {
-	objectCreator helpers.ObjectCreator
-	validator     Validator
-	cfg           WebhookConfig
-	logger        log.Logger
+	objectCreator   helpers.ObjectCreator
+	validator       Validator
+	objGroupKind    *schema.GroupKind
+	cfg             WebhookConfig
+	logger          log.Logger
+	metricsRecorder metrics.Recorder
 }
 
 func (w validateWebhook) Review(ctx context.Context, ar *admissionv1beta1.AdmissionReview) *admissionv1beta1.AdmissionResponse {
+	// The self-test reviews are not measured.
+	if whcontext.IsSelfTest(ctx) {
+		w.metricsRecorder = metrics.Dummy
+	}
+
+	// Attach the review correlation ID to all the review logs.
+	if id := whcontext.GetCorrelationID(ctx); id != "" {
+		w.logger = log.WithValues(w.logger, "correlationID", id)
+	}
+
+	// The API server always sets the UID, an empty UID means a bad client, we respond anyway as best effort.
+	if ar.Request.UID == "" {
+		w.logger.Warningf("admission request %s/%s has an empty UID, the response may be rejected", ar.Request.Namespace, ar.Request.Name)
+	}
+
 	w.logger.Debugf("reviewing request %s, named: %s/%s", ar.Request.UID, ar.Request.Namespace, ar.Request.Name)
 
+	// Check the request is for the kind of our object type.
+	if w.objGroupKind != nil && ar.Request.Kind.Kind != "" && !helpers.GroupKindIn(ar.Request.Kind, []schema.GroupKind{*w.objGroupKind}) {
+		switch w.cfg.KindMismatchPolicy {
+		case webhook.KindMismatchPolicyError:
+			err := fmt.Errorf("request %s kind %s doesn't match the webhook object kind %s", ar.Request.UID, ar.Request.Kind.String(), w.objGroupKind.String())
+			return w.toAdmissionErrorResponse(ar, err)
+		case webhook.KindMismatchPolicyAllow:
+			w.logger.Warningf("request %s kind %s doesn't match the webhook object kind %s, allowing without validation", ar.Request.UID, ar.Request.Kind.String(), w.objGroupKind.String())
+			if rec, ok := w.metricsRecorder.(metrics.FailOpenRecorder); ok {
+				rec.IncWebhookFailOpen(w.cfg.Name, metrics.FailOpenReasonKindMismatch)
+			}
+			return helpers.ToAdmissionAllowedNoOpResponse(ar.Request.UID)
+		}
+	}
+
 	// Delete operations don't have body because should be gone on the deletion, instead they have the body
 	// of the object we want to delete as an old object.
 	raw := ar.Request.Object.Raw
@@ -103,6 +210,13 @@ func (w validateWebhook) Review(ctx context.Context, ar *admissionv1beta1.Admiss
 	// Create a new object from the raw type.
 	runtimeObj, err := w.objectCreator.NewObject(raw)
 	if err != nil {
+		if helpers.GroupKindIn(ar.Request.Kind, w.cfg.DecodeErrorAllowKinds) {
+			w.logger.Warningf("could not decode request %s object, allowing without validation: %s", ar.Request.UID, err)
+			if rec, ok := w.metricsRecorder.(metrics.FailOpenRecorder); ok {
+				rec.IncWebhookFailOpen(w.cfg.Name, metrics.FailOpenReasonDecodeError)
+			}
+			return helpers.ToAdmissionAllowedNoOpResponse(ar.Request.UID)
+		}
 		return w.toAdmissionErrorResponse(ar, err)
 	}
 
@@ -131,6 +245,18 @@ func (w validateWebhook) Review(ctx context.Context, ar *admissionv1beta1.Admiss
 			Status:  status,
 			Message: res.Message,
 		},
+		Warnings: res.Warnings,
+	}
+}
+
+// ConfigView satisfies webhook.ConfigViewer interface.
+func (w validateWebhook) ConfigView() webhook.ConfigView {
+	return webhook.ConfigView{
+		Object: helpers.ConfigViewObject(w.cfg.Obj, w.objGroupKind),
+		Options: map[string]interface{}{
+			"decodeErrorAllowKinds": helpers.GroupKindsStrings(w.cfg.DecodeErrorAllowKinds),
+			"kindMismatchPolicy":    string(w.cfg.KindMismatchPolicy),
+		},
 	}
 }
 
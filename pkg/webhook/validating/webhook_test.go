@@ -7,15 +7,23 @@ import (
 	"testing"
 	"time"
 
+	opentracing "github.com/opentracing/opentracing-go"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
 	admissionv1beta1 "k8s.io/api/admission/v1beta1"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
 
+	mmetrics "github.com/slok/kubewebhook/mocks/observability/metrics"
+	"github.com/slok/kubewebhook/pkg/internal/testutil"
 	"github.com/slok/kubewebhook/pkg/log"
 	"github.com/slok/kubewebhook/pkg/observability/metrics"
+	"github.com/slok/kubewebhook/pkg/webhook"
+	whcontext "github.com/slok/kubewebhook/pkg/webhook/context"
+	"github.com/slok/kubewebhook/pkg/webhook/internal/admissiontest"
 	"github.com/slok/kubewebhook/pkg/webhook/validating"
 )
 
@@ -261,6 +269,28 @@ func TestPodAdmissionReviewValidation(t *testing.T) {
 				},
 			},
 		},
+
+		"A static webhook review of an undecodable kind listed on the decode error allowed kinds should return allowed.": {
+			cfg: validating.WebhookConfig{
+				Name:                  "test",
+				Obj:                   &corev1.Pod{},
+				DecodeErrorAllowKinds: []schema.GroupKind{{Group: "building.slok.dev", Kind: "House"}},
+			},
+			validator: getFakeValidator(false, "invalid test chain"),
+			review: &admissionv1beta1.AdmissionReview{
+				Request: &admissionv1beta1.AdmissionRequest{
+					UID:  "test",
+					Kind: metav1.GroupVersionKind{Group: "building.slok.dev", Version: "v1", Kind: "House"},
+					Object: runtime.RawExtension{
+						Raw: []byte(`{"kind": "House", "apiVersion": "building.slok.dev/v1", "spec": "wrong"}`),
+					},
+				},
+			},
+			expResponse: &admissionv1beta1.AdmissionResponse{
+				UID:     "test",
+				Allowed: true,
+			},
+		},
 	}
 
 	for name, test := range tests {
@@ -271,12 +301,258 @@ func TestPodAdmissionReviewValidation(t *testing.T) {
 			wh, err := validating.NewWebhook(test.cfg, test.validator, nil, nil, log.Dummy)
 			require.NoError(err)
 			gotResponse := wh.Review(context.TODO(), test.review)
+			require.NoError(admissiontest.ValidateResponse(test.review.Request, gotResponse))
 
 			assert.Equal(test.expResponse, gotResponse)
 		})
 	}
 }
 
+func TestValidatingWebhookPostMutationDetection(t *testing.T) {
+	getPodJSON := func(annotations map[string]string) []byte {
+		pod := &corev1.Pod{
+			TypeMeta: metav1.TypeMeta{
+				APIVersion: "v1",
+				Kind:       "Pod",
+			},
+			ObjectMeta: metav1.ObjectMeta{
+				Name:        "testPod",
+				Namespace:   "testNS",
+				Annotations: annotations,
+			},
+		}
+		bs, _ := json.Marshal(pod)
+		return bs
+	}
+
+	tests := map[string]struct {
+		podJSON    []byte
+		expAllowed bool
+	}{
+		"A pod marked as mutated by the mutating webhook should be detected as post-mutation.": {
+			podJSON:    getPodJSON(map[string]string{webhook.MutationMarkAnnotation: "other,pod-mutator"}),
+			expAllowed: true,
+		},
+
+		"A pod marked as mutated by other mutating webhooks should not be detected as post-mutation.": {
+			podJSON:    getPodJSON(map[string]string{webhook.MutationMarkAnnotation: "other"}),
+			expAllowed: false,
+		},
+
+		"A pod without mutation mark should not be detected as post-mutation.": {
+			podJSON:    getPodJSON(nil),
+			expAllowed: false,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+			require := require.New(t)
+
+			// Only allow mutated pods.
+			v := validating.ValidatorFunc(func(_ context.Context, obj metav1.Object) (bool, validating.ValidatorResult, error) {
+				valid := webhook.IsMutationMarked(obj, "pod-mutator")
+				return false, validating.ValidatorResult{Valid: valid}, nil
+			})
+
+			cfg := validating.WebhookConfig{Name: "test", Obj: &corev1.Pod{}}
+			wh, err := validating.NewWebhook(cfg, v, nil, nil, log.Dummy)
+			require.NoError(err)
+
+			ar := &admissionv1beta1.AdmissionReview{
+				Request: &admissionv1beta1.AdmissionRequest{
+					UID:    "test",
+					Object: runtime.RawExtension{Raw: test.podJSON},
+				},
+			}
+			gotResponse := wh.Review(context.TODO(), ar)
+			require.NoError(admissiontest.ValidateResponse(ar.Request, gotResponse))
+
+			assert.Equal(test.expAllowed, gotResponse.Allowed)
+		})
+	}
+}
+
+func TestValidatingWebhookEmptyUID(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	logger := &testutil.Logger{}
+	cfg := validating.WebhookConfig{Name: "test", Obj: &corev1.Pod{}}
+	wh, err := validating.NewWebhook(cfg, getFakeValidator(true, "valid"), &opentracing.NoopTracer{}, metrics.Dummy, logger)
+	require.NoError(err)
+
+	gotResponse := wh.Review(context.TODO(), &admissionv1beta1.AdmissionReview{
+		Request: &admissionv1beta1.AdmissionRequest{
+			Namespace: "testNS",
+			Name:      "testPod",
+			Object:    runtime.RawExtension{Raw: getPodJSON()},
+		},
+	})
+
+	// Best effort response.
+	assert.True(gotResponse.Allowed)
+	assert.Equal([]string{"admission request testNS/testPod has an empty UID, the response may be rejected"}, logger.Warnings)
+}
+
+func TestValidatingWebhookCorrelationIDLogs(t *testing.T) {
+	tests := map[string]struct {
+		ctx      context.Context
+		expDebug string
+	}{
+		"By default the admission review UID should be logged as the correlation ID.": {
+			ctx:      context.TODO(),
+			expDebug: "reviewing request test-uid, named: testNS/testPod correlationID=test-uid",
+		},
+
+		"A custom correlation ID should be logged as the correlation ID.": {
+			ctx:      whcontext.SetCorrelationID(context.TODO(), "test-request-id"),
+			expDebug: "reviewing request test-uid, named: testNS/testPod correlationID=test-request-id",
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+			require := require.New(t)
+
+			logger := &testutil.Logger{}
+			cfg := validating.WebhookConfig{Name: "test", Obj: &corev1.Pod{}}
+			wh, err := validating.NewWebhook(cfg, getFakeValidator(true, "valid"), &opentracing.NoopTracer{}, metrics.Dummy, logger)
+			require.NoError(err)
+
+			wh.Review(test.ctx, &admissionv1beta1.AdmissionReview{
+				Request: &admissionv1beta1.AdmissionRequest{
+					UID:       "test-uid",
+					Namespace: "testNS",
+					Name:      "testPod",
+					Object:    runtime.RawExtension{Raw: getPodJSON()},
+				},
+			})
+
+			assert.Contains(logger.Debugs, test.expDebug)
+		})
+	}
+}
+
+func TestValidatingWebhookInvalidConfig(t *testing.T) {
+	cfg := validating.WebhookConfig{KindMismatchPolicy: "wrong"}
+	_, err := validating.NewWebhook(cfg, getFakeValidator(true, "valid"), nil, nil, log.Dummy)
+	assert.EqualError(t, err, `invalid configuration: name can't be empty, unknown kind mismatch policy "wrong"`)
+}
+
+func TestValidatingWebhookInvalidSlowThreshold(t *testing.T) {
+	cfg := validating.WebhookConfig{Obj: &corev1.Pod{}, SlowThreshold: -time.Second}
+	_, err := validating.NewWebhook(cfg, getFakeValidator(true, "valid"), nil, nil, log.Dummy)
+	assert.EqualError(t, err, "invalid configuration: name can't be empty, slow threshold can't be negative")
+}
+
+func TestValidatingWebhookInvalidDeadlineWarning(t *testing.T) {
+	cfg := validating.WebhookConfig{Name: "test", Obj: &corev1.Pod{}, ExpectedTimeout: -time.Second, DeadlineWarningRatio: -0.5}
+	_, err := validating.NewWebhook(cfg, getFakeValidator(true, "valid"), nil, nil, log.Dummy)
+	assert.EqualError(t, err, "invalid configuration: expected timeout can't be negative, deadline warning ratio can't be negative")
+}
+
+func TestValidatingWebhookKindMismatch(t *testing.T) {
+	podKind := metav1.GroupVersionKind{Version: "v1", Kind: "Pod"}
+	deployKind := metav1.GroupVersionKind{Group: "apps", Version: "v1", Kind: "Deployment"}
+
+	tests := map[string]struct {
+		policy     webhook.KindMismatchPolicy
+		kind       metav1.GroupVersionKind
+		expAllowed bool
+		expErr     bool
+	}{
+		"A request kind mismatch with the default policy should not be checked.": {
+			kind:       deployKind,
+			expAllowed: false,
+		},
+
+		"A request kind mismatch with the error policy should return an error.": {
+			policy:     webhook.KindMismatchPolicyError,
+			kind:       deployKind,
+			expAllowed: false,
+			expErr:     true,
+		},
+
+		"A request kind mismatch with the allow policy should be allowed without validation.": {
+			policy:     webhook.KindMismatchPolicyAllow,
+			kind:       deployKind,
+			expAllowed: true,
+		},
+
+		"A request kind match with the error policy should be validated.": {
+			policy:     webhook.KindMismatchPolicyError,
+			kind:       podKind,
+			expAllowed: false,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+			require := require.New(t)
+
+			cfg := validating.WebhookConfig{Name: "test", Obj: &corev1.Pod{}, KindMismatchPolicy: test.policy}
+			wh, err := validating.NewWebhook(cfg, getFakeValidator(false, "invalid"), nil, nil, log.Dummy)
+			require.NoError(err)
+
+			ar := &admissionv1beta1.AdmissionReview{
+				Request: &admissionv1beta1.AdmissionRequest{
+					UID:    "test",
+					Kind:   test.kind,
+					Object: runtime.RawExtension{Raw: getPodJSON()},
+				},
+			}
+			gotResponse := wh.Review(context.TODO(), ar)
+			require.NoError(admissiontest.ValidateResponse(ar.Request, gotResponse))
+
+			assert.Equal(test.expAllowed, gotResponse.Allowed)
+			if test.expErr {
+				assert.Equal(metav1.StatusFailure, gotResponse.Result.Status)
+				assert.Contains(gotResponse.Result.Message, "doesn't match the webhook object kind Pod")
+			}
+		})
+	}
+}
+
+func TestValidatingWebhookRetryAfterDenial(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	// A validator that fails closed when its dependency is unavailable.
+	v := validating.ValidatorFunc(func(_ context.Context, _ metav1.Object) (bool, validating.ValidatorResult, error) {
+		return true, validating.ValidatorResult{}, webhook.NewRetryAfterError(fmt.Errorf("registry unavailable"), 9500*time.Millisecond)
+	})
+
+	cfg := validating.WebhookConfig{Name: "test", Obj: &corev1.Pod{}}
+	wh, err := validating.NewWebhook(cfg, v, nil, nil, log.Dummy)
+	require.NoError(err)
+
+	ar := &admissionv1beta1.AdmissionReview{
+		Request: &admissionv1beta1.AdmissionRequest{
+			UID:    "test",
+			Object: runtime.RawExtension{Raw: getPodJSON()},
+		},
+	}
+	gotResponse := wh.Review(context.TODO(), ar)
+	require.NoError(admissiontest.ValidateResponse(ar.Request, gotResponse))
+
+	expResponse := &admissionv1beta1.AdmissionResponse{
+		UID:     "test",
+		Allowed: false,
+		Result: &metav1.Status{
+			Status:  metav1.StatusFailure,
+			Message: "registry unavailable (retry after 10s)",
+			Reason:  metav1.StatusReasonServerTimeout,
+			Code:    500,
+			Details: &metav1.StatusDetails{RetryAfterSeconds: 10},
+		},
+	}
+	assert.Equal(expResponse, gotResponse)
+}
+
 func getRandomValidator() validating.Validator {
 	return validating.ValidatorFunc(func(_ context.Context, _ metav1.Object) (bool, validating.ValidatorResult, error) {
 		valid := time.Now().Nanosecond()%2 == 0
@@ -304,3 +580,68 @@ func BenchmarkPodAdmissionReviewValidation(b *testing.B) {
 		wh.Review(context.TODO(), ar)
 	}
 }
+
+func TestValidatingWebhookFailOpenMetric(t *testing.T) {
+	tests := map[string]struct {
+		cfg       validating.WebhookConfig
+		kind      metav1.GroupVersionKind
+		raw       []byte
+		expReason string
+	}{
+		"A kind mismatch allowed by the policy should increment the fail-open counter.": {
+			cfg:       validating.WebhookConfig{Name: "test", Obj: &corev1.Pod{}, KindMismatchPolicy: webhook.KindMismatchPolicyAllow},
+			kind:      metav1.GroupVersionKind{Group: "apps", Version: "v1", Kind: "Deployment"},
+			raw:       getPodJSON(),
+			expReason: metrics.FailOpenReasonKindMismatch,
+		},
+
+		"A decode error allowed by the kind should increment the fail-open counter.": {
+			cfg: validating.WebhookConfig{
+				Name:                  "test",
+				Obj:                   &corev1.Pod{},
+				DecodeErrorAllowKinds: []schema.GroupKind{{Group: "building.slok.dev", Kind: "House"}},
+			},
+			kind:      metav1.GroupVersionKind{Group: "building.slok.dev", Version: "v1", Kind: "House"},
+			raw:       []byte(`{"kind": "House", "apiVersion": "building.slok.dev/v1", "spec": "wrong"}`),
+			expReason: metrics.FailOpenReasonDecodeError,
+		},
+
+		"A regular validation should not increment the fail-open counter.": {
+			cfg:  validating.WebhookConfig{Name: "test", Obj: &corev1.Pod{}, KindMismatchPolicy: webhook.KindMismatchPolicyAllow},
+			kind: metav1.GroupVersionKind{Version: "v1", Kind: "Pod"},
+			raw:  getPodJSON(),
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+			require := require.New(t)
+
+			mrec := &mmetrics.Recorder{}
+			testutil.IgnoreRecorderCalls(mrec, "IncAdmissionReview", "ObserveAdmissionReviewDuration", "ObserveAdmissionReviewObjectSize", "IncValidationReviewResult")
+			if test.expReason != "" {
+				mrec.On("IncWebhookFailOpen", "test", test.expReason).Once()
+			}
+
+			wh, err := validating.NewWebhook(test.cfg, getFakeValidator(true, ""), nil, mrec, log.Dummy)
+			require.NoError(err)
+
+			ar := &admissionv1beta1.AdmissionReview{
+				Request: &admissionv1beta1.AdmissionRequest{
+					UID:    "test",
+					Kind:   test.kind,
+					Object: runtime.RawExtension{Raw: test.raw},
+				},
+			}
+			gotResponse := wh.Review(context.TODO(), ar)
+			require.NoError(admissiontest.ValidateResponse(ar.Request, gotResponse))
+
+			assert.True(gotResponse.Allowed)
+			mrec.AssertExpectations(t)
+			if test.expReason == "" {
+				mrec.AssertNotCalled(t, "IncWebhookFailOpen", mock.Anything, mock.Anything)
+			}
+		})
+	}
+}